@@ -0,0 +1,153 @@
+// simulate_escalation runs the escalation engine as a what-if: current candidates, a proposed rule set and a
+// simulated "now". Nothing is written; it prints which complaints would get reminders or escalate, and to whom.
+// Usage: from project root, run: go run ./cmd/simulate_escalation [-rules rules.json] [-now 2026-03-02T10:00:00+05:30] [-json]
+// rules.json is a JSON array of rules in the admin API format; without -rules the current active rules are used.
+// Requires .env (or env) with DB_*.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"finalneta/config"
+	"finalneta/models"
+	"finalneta/repository"
+	"finalneta/schema"
+	"finalneta/service"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	rulesFile := flag.String("rules", "", "JSON file with the proposed rule set (default: current active rules)")
+	nowFlag := flag.String("now", "", "simulated time, RFC3339 (default: current time)")
+	asJSON := flag.Bool("json", false, "print the result as JSON")
+	flag.Parse()
+
+	now := time.Now()
+	if *nowFlag != "" {
+		t, err := time.Parse(time.RFC3339, *nowFlag)
+		if err != nil {
+			log.Fatalf("Invalid -now: %v", err)
+		}
+		now = t
+	}
+
+	var proposed []models.EscalationRule
+	if *rulesFile != "" {
+		rules, err := loadRules(*rulesFile)
+		if err != nil {
+			log.Fatalf("Rules: %v", err)
+		}
+		proposed = rules
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env not found")
+	}
+	cfg := config.LoadConfig()
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&charset=utf8mb4&loc=UTC",
+		cfg.Database.User, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.DBName)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		log.Fatalf("DB open: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatalf("DB ping: %v", err)
+	}
+	schema.ValidateRequiredColumns(db, nil)
+
+	// No email or metrics services: the simulation never reaches them
+	escalationService := service.NewEscalationService(
+		repository.NewComplaintRepository(db),
+		repository.NewEscalationRepository(db),
+		repository.NewVerificationRepository(db),
+		nil, nil,
+		service.NewSLACalendarService(repository.NewSLACalendarRepository(db)),
+		service.NewSLAPolicyService(repository.NewSLAPolicyRepository(db)),
+		nil, // transfers are not simulated or verified
+		nil, // no delegation: escalations name the officer looked up, on leave or not
+		cfg.Pilot.DryRun, cfg.Pilot.DryRunSLAOverrideMinutes, cfg.Pilot.TestEscalationOverrideMinutes,
+	)
+
+	sim, err := escalationService.SimulateEscalations(context.Background(), proposed, now)
+	if err != nil {
+		log.Fatalf("Simulation: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(sim); err != nil {
+			log.Fatalf("Encode: %v", err)
+		}
+		return
+	}
+	printTable(sim)
+}
+
+// loadRules reads a JSON array of rules in the admin API format (unknown fields rejected)
+func loadRules(path string) ([]models.EscalationRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var reqs []models.EscalationRuleRequest
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&reqs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	rules := make([]models.EscalationRule, 0, len(reqs))
+	for i := range reqs {
+		rule, err := reqs[i].ToRule()
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, nil
+}
+
+func printTable(sim *models.EscalationSimulation) {
+	fmt.Printf("Simulated now: %s\n", sim.SimulatedNow.Format(time.RFC3339))
+	fmt.Printf("Rule set: %s (%d active rules), %d candidates\n\n", sim.RuleSet, sim.Rules, sim.Candidates)
+
+	if len(sim.Actions) == 0 {
+		fmt.Println("No reminders or escalations would fire.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMPLAINT\tACTION\tRULE\tLEVEL\tTO DEPT\tTO OFFICER\tREASON")
+		for _, a := range sim.Actions {
+			level := fmt.Sprintf("L%d", a.FromLevel+1)
+			if a.ToLevel != nil {
+				level = fmt.Sprintf("L%d -> L%d", a.FromLevel+1, *a.ToLevel+1)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				a.ComplaintNumber, a.Action, a.Rule, level, optionalID(a.ToDepartmentID), optionalID(a.ToOfficerID), a.Reason)
+		}
+		w.Flush()
+	}
+
+	for _, s := range sim.Skipped {
+		fmt.Printf("Skipped complaint %d: %s\n", s.ComplaintID, s.Error)
+	}
+}
+
+func optionalID(id *int64) string {
+	if id == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *id)
+}
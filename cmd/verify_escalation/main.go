@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"finalneta/config"
 	"finalneta/models"
@@ -20,6 +21,7 @@ import (
 )
 
 func main() {
+	ctx := context.Background()
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env not found")
 	}
@@ -79,10 +81,14 @@ func main() {
 	escalationService := service.NewEscalationService(
 		complaintRepo, escalationRepo, verificationRepo,
 		nil, service.NewPilotMetricsService(pilotMetricsRepo),
+		service.NewSLACalendarService(repository.NewSLACalendarRepository(db)),
+		service.NewSLAPolicyService(repository.NewSLAPolicyRepository(db)),
+		nil, // transfers are not simulated or verified
+		nil, // no delegation: escalations name the officer looked up, on leave or not
 		cfg.Pilot.DryRun, cfg.Pilot.DryRunSLAOverrideMinutes, cfg.Pilot.TestEscalationOverrideMinutes,
 	)
 
-	candidates, err := escalationRepo.GetEscalationCandidates(
+	candidates, err := escalationRepo.GetEscalationCandidates(ctx,
 		[]models.ComplaintStatus{models.StatusVerified, models.StatusUnderReview, models.StatusInProgress},
 		24*time.Hour,
 	)
@@ -113,7 +119,7 @@ func main() {
 		log.Printf("[VERIFY] Normalizing complaint %d to under_review and backdating so SLA is met", complaintID)
 		normalizeToUnderReview(db, complaintID)
 		// Re-fetch candidates to confirm
-		candidates, _ = escalationRepo.GetEscalationCandidates(
+		candidates, _ = escalationRepo.GetEscalationCandidates(ctx,
 			[]models.ComplaintStatus{models.StatusVerified, models.StatusUnderReview, models.StatusInProgress},
 			24*time.Hour,
 		)
@@ -131,7 +137,7 @@ func main() {
 
 	// --- 3) One escalation cycle ---
 	log.Printf("[VERIFY] Running ProcessEscalations once ...")
-	results, err := escalationService.ProcessEscalations()
+	results, err := escalationService.ProcessEscalations(ctx)
 	if err != nil {
 		log.Fatalf("[VERIFY] ProcessEscalations: %v", err)
 	}
@@ -1,15 +1,20 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds application configuration
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Pilot    PilotConfig
+	Database   DatabaseConfig
+	Server     ServerConfig
+	Pilot      PilotConfig
+	Storage    StorageConfig
+	Jobs       JobsConfig
+	Assignment AssignmentConfig
 }
 
 // DatabaseConfig holds database configuration
@@ -24,16 +29,50 @@ type DatabaseConfig struct {
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Host string
+	Port       string
+	Host       string
+	InstanceID string // INSTANCE_ID: Identity of this server process for worker leases (default: hostname-pid)
+
+	ShutdownTimeoutSeconds int // SHUTDOWN_TIMEOUT_SECONDS: On SIGTERM, how long in-flight requests get to finish
+}
+
+// StorageConfig holds file upload configuration
+type StorageConfig struct {
+	Backend          string // STORAGE_BACKEND: "local" (default) or "s3"
+	UploadBasePath   string // UPLOAD_BASE_PATH: Root directory for uploaded files (local backend, default: uploads)
+	PublicBaseURL    string // PUBLIC_BASE_URL: Prefix for local signed file URLs (empty = relative URLs)
+	SigningSecret    string // STORAGE_SIGNING_SECRET: HMAC key for local signed file URLs (default: JWT_SECRET)
+	S3Endpoint       string // S3_ENDPOINT: e.g. https://s3.ap-south-1.amazonaws.com or http://localhost:9000 (MinIO)
+	S3Region         string // S3_REGION (default: us-east-1)
+	S3Bucket         string // S3_BUCKET
+	S3AccessKeyID    string // S3_ACCESS_KEY_ID
+	S3SecretKey      string // S3_SECRET_ACCESS_KEY
+	S3ForcePathStyle bool   // S3_FORCE_PATH_STYLE: Use endpoint/bucket/key URLs (required for MinIO)
 }
 
 // PilotConfig holds pilot-specific configuration
 type PilotConfig struct {
-	DryRun                        bool // PILOT_DRY_RUN: Enable dry-run/testing mode
-	DryRunSLAOverrideMinutes      int  // PILOT_DRY_RUN_SLA_OVERRIDE_MINUTES: Override SLA hours with minutes (0 = disabled)
-	TestEscalationOverrideMinutes int  // TEST_ESCALATION_OVERRIDE_MINUTES: Safe test-only SLA override in minutes (0 = disabled)
-	EscalationWorkerIntervalSeconds int // ESCALATION_WORKER_INTERVAL_SECONDS: Worker run interval in seconds (0 = use default: 1h or pilot 30s)
+	DryRun                          bool // PILOT_DRY_RUN: Enable dry-run/testing mode
+	DryRunSLAOverrideMinutes        int  // PILOT_DRY_RUN_SLA_OVERRIDE_MINUTES: Override SLA hours with minutes (0 = disabled)
+	TestEscalationOverrideMinutes   int  // TEST_ESCALATION_OVERRIDE_MINUTES: Safe test-only SLA override in minutes (0 = disabled)
+	EscalationWorkerIntervalSeconds int  // ESCALATION_WORKER_INTERVAL_SECONDS: Worker run interval in seconds (0 = use default: 1h or pilot 30s)
+	AutoCloseResolvedDays           int  // AUTO_CLOSE_RESOLVED_DAYS: Close resolved complaints after N days without citizen response (0 = disabled)
+	AwaitingCitizenAutoCloseDays    int  // AWAITING_CITIZEN_AUTO_CLOSE_DAYS: Close awaiting_citizen complaints after N days without a reply (0 = disabled)
+	AutoCloseWorkerIntervalSeconds  int  // AUTO_CLOSE_WORKER_INTERVAL_SECONDS: Auto-close worker run interval in seconds
+	EvidenceSweepIntervalSeconds    int  // EVIDENCE_SWEEP_INTERVAL_SECONDS: Evidence integrity sweep interval in seconds (0 = disabled)
+}
+
+// JobsConfig holds background job scheduling configuration
+type JobsConfig struct {
+	Schedules        map[string]string // JOB_SCHEDULE_<NAME>: Interval ("30s") or cron ("0 2 * * *") overriding a job's default schedule
+	JitterSeconds    int               // JOB_JITTER_SECONDS: Random delay of up to N seconds added to every scheduled run
+	RunRetentionDays int               // JOB_RUN_RETENTION_DAYS: Days of job_runs history to keep
+}
+
+// AssignmentConfig holds officer assignment configuration
+type AssignmentConfig struct {
+	DefaultStrategy      string            // ASSIGNMENT_STRATEGY: round_robin, least_open (default), category or nearest
+	DepartmentStrategies map[string]string // ASSIGNMENT_STRATEGY_<DEPARTMENT_ID>: Strategy for one department, keyed by department ID
 }
 
 // LoadConfig loads configuration from environment variables.
@@ -49,14 +88,42 @@ func LoadConfig() *Config {
 			DBName:      os.Getenv("DB_NAME"),
 		},
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: getEnv("PORT", getEnv("SERVER_PORT", "8080")), // PORT for Render/fly.io; SERVER_PORT for custom
+			Host:       getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:       getEnv("PORT", getEnv("SERVER_PORT", "8080")), // PORT for Render/fly.io; SERVER_PORT for custom
+			InstanceID: getEnv("INSTANCE_ID", defaultInstanceID()),
+
+			ShutdownTimeoutSeconds: getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
 		},
 		Pilot: PilotConfig{
-			DryRun:                         getEnvBool("PILOT_DRY_RUN", false),
-			DryRunSLAOverrideMinutes:       getEnvInt("PILOT_DRY_RUN_SLA_OVERRIDE_MINUTES", 0),
-			TestEscalationOverrideMinutes:  getEnvInt("TEST_ESCALATION_OVERRIDE_MINUTES", 0),
+			DryRun:                          getEnvBool("PILOT_DRY_RUN", false),
+			DryRunSLAOverrideMinutes:        getEnvInt("PILOT_DRY_RUN_SLA_OVERRIDE_MINUTES", 0),
+			TestEscalationOverrideMinutes:   getEnvInt("TEST_ESCALATION_OVERRIDE_MINUTES", 0),
 			EscalationWorkerIntervalSeconds: getEnvInt("ESCALATION_WORKER_INTERVAL_SECONDS", 0),
+			AutoCloseResolvedDays:           getEnvInt("AUTO_CLOSE_RESOLVED_DAYS", 7),
+			AwaitingCitizenAutoCloseDays:    getEnvInt("AWAITING_CITIZEN_AUTO_CLOSE_DAYS", 14),
+			AutoCloseWorkerIntervalSeconds:  getEnvInt("AUTO_CLOSE_WORKER_INTERVAL_SECONDS", 3600),
+			EvidenceSweepIntervalSeconds:    getEnvInt("EVIDENCE_SWEEP_INTERVAL_SECONDS", 86400),
+		},
+		Jobs: JobsConfig{
+			Schedules:        jobScheduleOverrides(),
+			JitterSeconds:    getEnvInt("JOB_JITTER_SECONDS", 5),
+			RunRetentionDays: getEnvInt("JOB_RUN_RETENTION_DAYS", 30),
+		},
+		Assignment: AssignmentConfig{
+			DefaultStrategy:      getEnv("ASSIGNMENT_STRATEGY", "least_open"),
+			DepartmentStrategies: assignmentStrategyOverrides(),
+		},
+		Storage: StorageConfig{
+			Backend:          getEnv("STORAGE_BACKEND", "local"),
+			UploadBasePath:   getEnv("UPLOAD_BASE_PATH", "uploads"),
+			PublicBaseURL:    os.Getenv("PUBLIC_BASE_URL"),
+			SigningSecret:    getEnv("STORAGE_SIGNING_SECRET", getEnv("JWT_SECRET", "pilot-secret-key-change-in-production")),
+			S3Endpoint:       os.Getenv("S3_ENDPOINT"),
+			S3Region:         getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:         os.Getenv("S3_BUCKET"),
+			S3AccessKeyID:    os.Getenv("S3_ACCESS_KEY_ID"),
+			S3SecretKey:      os.Getenv("S3_SECRET_ACCESS_KEY"),
+			S3ForcePathStyle: getEnvBool("S3_FORCE_PATH_STYLE", false),
 		},
 	}
 }
@@ -69,6 +136,40 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// jobScheduleOverrides collects JOB_SCHEDULE_<NAME> variables, keyed by lower-case job name
+// (JOB_SCHEDULE_EVIDENCE_INTEGRITY="0 2 * * *" -> "evidence_integrity")
+func jobScheduleOverrides() map[string]string {
+	return envPrefixOverrides("JOB_SCHEDULE_")
+}
+
+// assignmentStrategyOverrides collects ASSIGNMENT_STRATEGY_<DEPARTMENT_ID> variables, keyed by department ID
+// (ASSIGNMENT_STRATEGY_3=nearest -> "3"); main rejects keys that are not numbers
+func assignmentStrategyOverrides() map[string]string {
+	return envPrefixOverrides("ASSIGNMENT_STRATEGY_")
+}
+
+// envPrefixOverrides collects non-empty <prefix><NAME> variables, keyed by lower-case NAME
+func envPrefixOverrides(prefix string) map[string]string {
+	overrides := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, prefix) || value == "" {
+			continue
+		}
+		overrides[strings.ToLower(strings.TrimPrefix(key, prefix))] = value
+	}
+	return overrides
+}
+
+// defaultInstanceID identifies this process among replicas: hostname plus PID
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "server"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
 // getEnvBool gets a boolean environment variable or returns a default value
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
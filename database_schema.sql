@@ -35,11 +35,12 @@ CREATE TABLE IF NOT EXISTS complaints (
     longitude DECIMAL(11, 8) NULL COMMENT 'Specific coordinates',
     assigned_department_id BIGINT NULL COMMENT 'Initially assigned department',
     assigned_officer_id BIGINT NULL COMMENT 'Currently assigned officer',
-    current_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated') NOT NULL DEFAULT 'draft' COMMENT 'Current status',
+    current_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NOT NULL DEFAULT 'draft' COMMENT 'Current status',
     priority ENUM('low', 'medium', 'high', 'urgent') NOT NULL DEFAULT 'medium' COMMENT 'Priority level',
     is_public BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Public visibility flag',
     public_consent_given BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'User consent for public disclosure',
     supporter_count INT NOT NULL DEFAULT 0 COMMENT 'Count of supporting users',
+    version INT NOT NULL DEFAULT 0 COMMENT 'Optimistic concurrency version',
     resolved_at TIMESTAMP NULL COMMENT 'Resolution timestamp',
     closed_at TIMESTAMP NULL COMMENT 'Closure timestamp',
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Complaint creation',
@@ -61,8 +62,8 @@ CREATE TABLE IF NOT EXISTS complaints (
 CREATE TABLE IF NOT EXISTS complaint_status_history (
     history_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     complaint_id BIGINT NOT NULL COMMENT 'Related complaint',
-    old_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated') NULL COMMENT 'Previous status',
-    new_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated') NOT NULL COMMENT 'New status',
+    old_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NULL COMMENT 'Previous status',
+    new_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NOT NULL COMMENT 'New status',
     changed_by_type ENUM('user', 'officer', 'system', 'admin') NOT NULL COMMENT 'Who made the change',
     changed_by_user_id BIGINT NULL COMMENT 'User who changed (if applicable)',
     changed_by_officer_id BIGINT NULL COMMENT 'Officer who changed (if applicable)',
@@ -71,6 +72,7 @@ CREATE TABLE IF NOT EXISTS complaint_status_history (
     notes TEXT NULL COMMENT 'Status change notes/comments',
     actor_type ENUM('system','authority','user') NULL COMMENT 'Audit: who made the change',
     actor_id BIGINT NULL COMMENT 'Audit: user_id or officer_id; NULL for system',
+    on_behalf_of_officer_id BIGINT NULL COMMENT 'Absent officer the acting delegate stood in for',
     reason TEXT NULL COMMENT 'Audit: reason for change when available',
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Change timestamp',
     
@@ -94,12 +96,19 @@ CREATE TABLE IF NOT EXISTS complaint_attachments (
     file_size BIGINT NULL COMMENT 'Size in bytes',
     uploaded_by_user_id BIGINT NULL COMMENT 'Uploader',
     is_public BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Public visibility',
+    exif_captured_at DATETIME NULL COMMENT 'EXIF DateTimeOriginal (UTC)',
+    exif_time_zoned BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'EXIF time had OffsetTimeOriginal; FALSE = camera-local time stored as UTC',
+    exif_latitude DECIMAL(10, 8) NULL COMMENT 'EXIF GPS latitude',
+    exif_longitude DECIMAL(11, 8) NULL COMMENT 'EXIF GPS longitude',
+    exif_camera_model VARCHAR(255) NULL COMMENT 'EXIF camera make/model',
+    perceptual_hash BIGINT NULL COMMENT '64-bit dHash (JPEG/PNG); compare with BIT_COUNT(a ^ b)',
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Upload timestamp',
     
     FOREIGN KEY (complaint_id) REFERENCES complaints(complaint_id) ON DELETE CASCADE,
     FOREIGN KEY (uploaded_by_user_id) REFERENCES users(user_id) ON DELETE SET NULL,
     INDEX idx_complaint_id (complaint_id),
     INDEX idx_uploaded_by (uploaded_by_user_id),
+    INDEX idx_perceptual_hash (perceptual_hash),
     INDEX idx_created_at (created_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -146,11 +155,12 @@ CREATE TABLE IF NOT EXISTS escalation_rules (
     rule_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     from_department_id BIGINT NULL COMMENT 'Source department (NULL = any)',
     from_location_id BIGINT NULL COMMENT 'Source location (NULL = any)',
-    to_department_id BIGINT NOT NULL COMMENT 'Target department',
+    to_department_id BIGINT NULL COMMENT 'Target department (NULL = same department hierarchy)',
     to_location_id BIGINT NULL COMMENT 'Target location (NULL = same)',
     escalation_level INT NOT NULL COMMENT 'Level in hierarchy',
     conditions JSON NULL COMMENT 'Conditions for escalation (time-based, status-based, etc.)',
     is_active BOOLEAN NOT NULL DEFAULT TRUE COMMENT 'Active status',
+    version INT NOT NULL DEFAULT 1 COMMENT 'Rule version; bumped on every admin change',
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Rule creation',
     updated_at TIMESTAMP NULL COMMENT 'Last update',
     
@@ -102,6 +102,7 @@ function ComplaintDetailScreen() {
       'verified': '#0EA5A4',
       'under_review': '#F59E0B',
       'in_progress': '#1E3A8A',
+      'awaiting_citizen': '#F59E0B',
       'resolved': '#0EA5A4',
       'rejected': '#DC2626',
       'closed': '#64748B',
@@ -3,11 +3,13 @@ import { useParams, useNavigate, useLocation } from 'react-router-dom';
 import { authorityApi } from '../../services/authorityApi';
 
 // Allowed transitions (Authority only): submitted→under_review, under_review→in_progress, in_progress→resolved, escalated→under_review|in_progress
+// awaiting_citizen asks the citizen a question (the reason); the SLA clock stops until they reply
 const NEXT_STATUS = {
   submitted: ['under_review'],
-  under_review: ['in_progress'],
-  in_progress: ['resolved'],
-  escalated: ['under_review', 'in_progress'],
+  under_review: ['in_progress', 'awaiting_citizen'],
+  in_progress: ['resolved', 'awaiting_citizen'],
+  escalated: ['under_review', 'in_progress', 'awaiting_citizen'],
+  awaiting_citizen: ['under_review', 'in_progress'],
 };
 
 export default function AuthorityComplaintDetailScreen() {
@@ -64,6 +66,7 @@ export default function AuthorityComplaintDetailScreen() {
         <p><strong>Status:</strong> {complaint.current_status}</p>
         <p><strong>Priority:</strong> {complaint.priority}</p>
         <p><strong>Created:</strong> {complaint.created_at}</p>
+        {complaint.sla_due_at && <p><strong>SLA due (working hours):</strong> {complaint.sla_due_at}</p>}
         {complaint.supporter_count != null && <p><strong>Supporters:</strong> {complaint.supporter_count}</p>}
       </div>
 
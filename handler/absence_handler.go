@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"finalneta/models"
+	"finalneta/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// AbsenceHandler serves officer leave windows and their delegates (officers for themselves, admins for anyone)
+type AbsenceHandler struct {
+	service *service.OfficerAbsenceService
+}
+
+// NewAbsenceHandler creates a new absence handler
+func NewAbsenceHandler(svc *service.OfficerAbsenceService) *AbsenceHandler {
+	return &AbsenceHandler{service: svc}
+}
+
+// RecordMyAbsence handles POST /api/v1/authority/absences
+// Body: {"delegate_officer_id": 7, "starts_at": "2026-11-02T00:00:00Z", "ends_at": "2026-11-09T00:00:00Z", "reason": "..."}
+// starts_at defaults to now.
+func (h *AbsenceHandler) RecordMyAbsence(w http.ResponseWriter, r *http.Request) {
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	h.recordAbsence(w, r, officerID, models.ActorOfficer)
+}
+
+// ListMyAbsences handles GET /api/v1/authority/absences (own absences and the ones covered as delegate)
+func (h *AbsenceHandler) ListMyAbsences(w http.ResponseWriter, r *http.Request) {
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	h.listAbsences(w, r, officerID)
+}
+
+// CancelMyAbsence handles DELETE /api/v1/authority/absences/{absence_id} (own absences only)
+func (h *AbsenceHandler) CancelMyAbsence(w http.ResponseWriter, r *http.Request) {
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	h.cancelAbsence(w, r, officerID)
+}
+
+// RecordAbsence handles POST /api/v1/admin/authorities/{officer_id}/absences (same body as RecordMyAbsence)
+func (h *AbsenceHandler) RecordAbsence(w http.ResponseWriter, r *http.Request) {
+	officerID, ok := parseAbsenceOfficerID(w, r)
+	if !ok {
+		return
+	}
+	h.recordAbsence(w, r, officerID, models.ActorAdmin)
+}
+
+// ListAbsences handles GET /api/v1/admin/authorities/{officer_id}/absences
+func (h *AbsenceHandler) ListAbsences(w http.ResponseWriter, r *http.Request) {
+	officerID, ok := parseAbsenceOfficerID(w, r)
+	if !ok {
+		return
+	}
+	h.listAbsences(w, r, officerID)
+}
+
+// CancelAbsence handles DELETE /api/v1/admin/absences/{absence_id} (any officer's absence)
+func (h *AbsenceHandler) CancelAbsence(w http.ResponseWriter, r *http.Request) {
+	h.cancelAbsence(w, r, 0)
+}
+
+func (h *AbsenceHandler) recordAbsence(w http.ResponseWriter, r *http.Request, officerID int64, createdBy models.ActorType) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	var req models.CreateOfficerAbsenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body (times are RFC 3339)")
+		return
+	}
+	absence, err := h.service.RecordAbsence(ctx, officerID, &req, createdBy, getClientIP(r), r.UserAgent())
+	if err != nil {
+		respondWithAbsenceError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, absence)
+}
+
+func (h *AbsenceHandler) listAbsences(w http.ResponseWriter, r *http.Request, officerID int64) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	absences, err := h.service.ListAbsences(ctx, officerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to list absences")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"officer_id": officerID, "absences": absences})
+}
+
+// cancelAbsence cancels {absence_id}; officerID 0 = admin
+func (h *AbsenceHandler) cancelAbsence(w http.ResponseWriter, r *http.Request, officerID int64) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	absenceID, err := strconv.ParseInt(mux.Vars(r)["absence_id"], 10, 64)
+	if err != nil || absenceID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid absence ID")
+		return
+	}
+	absence, err := h.service.CancelAbsence(ctx, absenceID, officerID, getClientIP(r), r.UserAgent())
+	if err != nil {
+		respondWithAbsenceError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, absence)
+}
+
+func parseAbsenceOfficerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	officerID, err := strconv.ParseInt(mux.Vars(r)["officer_id"], 10, 64)
+	if err != nil || officerID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid officer ID")
+		return 0, false
+	}
+	return officerID, true
+}
+
+// respondWithAbsenceError maps absence service errors
+func respondWithAbsenceError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid absence"), strings.HasPrefix(err.Error(), "invalid delegate"):
+		respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+	case strings.HasPrefix(err.Error(), "absence conflict"):
+		respondWithError(w, http.StatusConflict, "Conflict", err.Error())
+	case err.Error() == "absence not found", err.Error() == "officer not found":
+		respondWithError(w, http.StatusNotFound, "Not found", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to update absence")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"finalneta/repository"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -24,7 +25,9 @@ func NewAdminHandler(authorityRepo *repository.AuthorityRepository, complaintRep
 
 // GetAuthorities returns all authorities (officers) for admin. GET /api/v1/admin/authorities
 func (h *AdminHandler) GetAuthorities(w http.ResponseWriter, r *http.Request) {
-	list, err := h.authorityRepo.ListOfficers()
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	list, err := h.authorityRepo.ListOfficers(ctx)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
 		return
@@ -38,7 +41,7 @@ func (h *AdminHandler) GetAuthorities(w http.ResponseWriter, r *http.Request) {
 		} else if row.AuthorityLevel == 3 {
 			levelStr = "L3"
 		}
-		out = append(out, adminAuthorityResponse{
+		item := adminAuthorityResponse{
 			OfficerID:      row.OfficerID,
 			FullName:       row.FullName,
 			DepartmentID:   row.DepartmentID,
@@ -46,13 +49,27 @@ func (h *AdminHandler) GetAuthorities(w http.ResponseWriter, r *http.Request) {
 			AuthorityLevel: levelStr,
 			IsActive:       row.IsActive,
 			Email:          row.Email,
-		})
+			Categories:     []string{},
+		}
+		if row.SupervisorOfficerID.Valid {
+			item.SupervisorOfficerID = &row.SupervisorOfficerID.Int64
+		}
+		if row.Latitude.Valid && row.Longitude.Valid {
+			item.Latitude = &row.Latitude.Float64
+			item.Longitude = &row.Longitude.Float64
+		}
+		if row.Categories != nil {
+			item.Categories = row.Categories
+		}
+		out = append(out, item)
 	}
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{"authorities": out})
 }
 
 // CreateAuthority creates an officer and credential. POST /api/v1/admin/authorities. Audited.
 func (h *AdminHandler) CreateAuthority(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	var req adminCreateAuthorityRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Bad Request", "Invalid JSON body")
@@ -71,11 +88,15 @@ func (h *AdminHandler) CreateAuthority(w http.ResponseWriter, r *http.Request) {
 	if req.IsActive != nil {
 		isActive = *req.IsActive
 	}
-	officerID, err := h.authorityRepo.CreateOfficer(
+	officerID, err := h.authorityRepo.CreateOfficer(ctx,
 		req.FullName, req.Designation, req.Email, req.Password,
-		req.DepartmentID, req.LocationID, level, isActive,
+		req.DepartmentID, req.LocationID, level, req.SupervisorOfficerID, isActive,
 	)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid supervisor") {
+			respondWithError(w, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
 		return
 	}
@@ -83,7 +104,7 @@ func (h *AdminHandler) CreateAuthority(w http.ResponseWriter, r *http.Request) {
 	auditData := map[string]interface{}{
 		"officer_id": officerID, "full_name": req.FullName,
 		"department_id": req.DepartmentID, "location_id": req.LocationID,
-		"authority_level": level, "is_active": isActive,
+		"authority_level": level, "supervisor_officer_id": req.SupervisorOfficerID, "is_active": isActive,
 	}
 	newVal, _ := json.Marshal(auditData)
 	auditLog := &models.AuditLog{
@@ -95,7 +116,7 @@ func (h *AdminHandler) CreateAuthority(w http.ResponseWriter, r *http.Request) {
 		IPAddress:      sql.NullString{String: r.RemoteAddr, Valid: true},
 		UserAgent:      sql.NullString{String: r.UserAgent(), Valid: true},
 	}
-	_ = h.complaintRepo.CreateAuditLog(auditLog)
+	_ = h.complaintRepo.CreateAuditLog(ctx, auditLog)
 
 	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
 		"officer_id": officerID,
@@ -103,8 +124,11 @@ func (h *AdminHandler) CreateAuthority(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// UpdateAuthority updates department_id, location_id, authority_level, is_active. PUT /api/v1/admin/authorities/{officer_id}. Audited.
+// UpdateAuthority updates department_id, location_id, authority_level, supervisor_officer_id (0 clears), is_active,
+// and the assignment inputs categories (replaces the list) and latitude/longitude (together). PUT /api/v1/admin/authorities/{officer_id}. Audited.
 func (h *AdminHandler) UpdateAuthority(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	vars := mux.Vars(r)
 	officerIDStr, ok := vars["officer_id"]
 	if !ok {
@@ -117,7 +141,7 @@ func (h *AdminHandler) UpdateAuthority(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Load current for audit old_values
-	oldName, oldDept, oldLoc, oldLevel, oldActive, err := h.authorityRepo.GetOfficerByID(officerID)
+	oldName, oldDept, oldLoc, oldLevel, oldSupervisor, oldActive, err := h.authorityRepo.GetOfficerByID(ctx, officerID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Not Found", "Officer not found")
 		return
@@ -143,24 +167,61 @@ func (h *AdminHandler) UpdateAuthority(w http.ResponseWriter, r *http.Request) {
 	if req.IsActive != nil {
 		active = req.IsActive
 	}
-	if dept == nil && loc == nil && level == nil && active == nil {
-		respondWithError(w, http.StatusBadRequest, "Bad Request", "Provide at least one of department_id, location_id, authority_level, is_active")
+	hasCoordinates := req.Latitude != nil || req.Longitude != nil
+	if dept == nil && loc == nil && level == nil && req.SupervisorOfficerID == nil && active == nil && req.Categories == nil && !hasCoordinates {
+		respondWithError(w, http.StatusBadRequest, "Bad Request", "Provide at least one of department_id, location_id, authority_level, supervisor_officer_id, is_active, categories, latitude/longitude")
 		return
 	}
-	if err := h.authorityRepo.UpdateOfficer(officerID, dept, loc, level, active); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
-		return
+	if hasCoordinates {
+		if req.Latitude == nil || req.Longitude == nil {
+			respondWithError(w, http.StatusBadRequest, "Bad Request", "latitude and longitude must be provided together")
+			return
+		}
+		if *req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180 {
+			respondWithError(w, http.StatusBadRequest, "Bad Request", "Invalid latitude or longitude")
+			return
+		}
+	}
+	if dept != nil || loc != nil || level != nil || req.SupervisorOfficerID != nil || active != nil {
+		if err := h.authorityRepo.UpdateOfficer(ctx, officerID, dept, loc, level, req.SupervisorOfficerID, active); err != nil {
+			if strings.HasPrefix(err.Error(), "invalid supervisor") {
+				respondWithError(w, http.StatusBadRequest, "Bad Request", err.Error())
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
+			return
+		}
+	}
+	if req.Categories != nil {
+		if err := h.authorityRepo.SetOfficerCategories(ctx, officerID, *req.Categories); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
+			return
+		}
+	}
+	if hasCoordinates {
+		if err := h.authorityRepo.SetOfficerCoordinates(ctx, officerID, *req.Latitude, *req.Longitude); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
+			return
+		}
 	}
 	// Audit: admin update
 	oldVal, _ := json.Marshal(map[string]interface{}{
 		"full_name": oldName, "department_id": oldDept, "location_id": oldLoc,
-		"authority_level": oldLevel, "is_active": oldActive,
+		"authority_level": oldLevel, "supervisor_officer_id": nullableOfficerID(oldSupervisor), "is_active": oldActive,
 	})
-	_, newDept, newLoc, newLevel, newActive, _ := h.authorityRepo.GetOfficerByID(officerID)
-	newVal, _ := json.Marshal(map[string]interface{}{
+	_, newDept, newLoc, newLevel, newSupervisor, newActive, _ := h.authorityRepo.GetOfficerByID(ctx, officerID)
+	newValues := map[string]interface{}{
 		"full_name": oldName, "department_id": newDept, "location_id": newLoc,
-		"authority_level": newLevel, "is_active": newActive,
-	})
+		"authority_level": newLevel, "supervisor_officer_id": nullableOfficerID(newSupervisor), "is_active": newActive,
+	}
+	if req.Categories != nil {
+		newValues["categories"] = *req.Categories
+	}
+	if hasCoordinates {
+		newValues["latitude"] = *req.Latitude
+		newValues["longitude"] = *req.Longitude
+	}
+	newVal, _ := json.Marshal(newValues)
 	auditLog := &models.AuditLog{
 		EntityType:   "officer",
 		EntityID:     officerID,
@@ -171,11 +232,19 @@ func (h *AdminHandler) UpdateAuthority(w http.ResponseWriter, r *http.Request) {
 		IPAddress:    sql.NullString{String: r.RemoteAddr, Valid: true},
 		UserAgent:    sql.NullString{String: r.UserAgent(), Valid: true},
 	}
-	_ = h.complaintRepo.CreateAuditLog(auditLog)
+	_ = h.complaintRepo.CreateAuditLog(ctx, auditLog)
 
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{"message": "Authority updated"})
 }
 
+// nullableOfficerID returns the ID, or nil (JSON null) when unset
+func nullableOfficerID(v sql.NullInt64) interface{} {
+	if !v.Valid {
+		return nil
+	}
+	return v.Int64
+}
+
 func levelStringToInt(s string) int {
 	switch s {
 	case "L2":
@@ -188,29 +257,38 @@ func levelStringToInt(s string) int {
 }
 
 type adminAuthorityResponse struct {
-	OfficerID      int64  `json:"officer_id"`
-	FullName       string `json:"full_name"`
-	DepartmentID   int64  `json:"department_id"`
-	LocationID     int64  `json:"location_id"`
-	AuthorityLevel string `json:"authority_level"`
-	IsActive       bool   `json:"is_active"`
-	Email          string `json:"email,omitempty"`
+	OfficerID           int64    `json:"officer_id"`
+	FullName            string   `json:"full_name"`
+	DepartmentID        int64    `json:"department_id"`
+	LocationID          int64    `json:"location_id"`
+	AuthorityLevel      string   `json:"authority_level"`
+	SupervisorOfficerID *int64   `json:"supervisor_officer_id"` // reporting line; null = none
+	IsActive            bool     `json:"is_active"`
+	Email               string   `json:"email,omitempty"`
+	Categories          []string `json:"categories"`         // complaint categories handled (category assignment)
+	Latitude            *float64 `json:"latitude,omitempty"` // office coordinates (nearest assignment)
+	Longitude           *float64 `json:"longitude,omitempty"`
 }
 
 type adminCreateAuthorityRequest struct {
-	FullName       string  `json:"full_name"`
-	Designation    string  `json:"designation"`
-	Email          string  `json:"email"`
-	Password       string  `json:"password"`
-	DepartmentID   int64   `json:"department_id"`
-	LocationID     int64   `json:"location_id"`
-	AuthorityLevel string  `json:"authority_level"`
-	IsActive       *bool   `json:"is_active"`
+	FullName            string `json:"full_name"`
+	Designation         string `json:"designation"`
+	Email               string `json:"email"`
+	Password            string `json:"password"`
+	DepartmentID        int64  `json:"department_id"`
+	LocationID          int64  `json:"location_id"`
+	AuthorityLevel      string `json:"authority_level"`
+	SupervisorOfficerID *int64 `json:"supervisor_officer_id"` // officer this one reports to (higher level)
+	IsActive            *bool  `json:"is_active"`
 }
 
 type adminUpdateAuthorityRequest struct {
-	DepartmentID   *int64  `json:"department_id"`
-	LocationID     *int64  `json:"location_id"`
-	AuthorityLevel *string `json:"authority_level"`
-	IsActive       *bool   `json:"is_active"`
+	DepartmentID        *int64    `json:"department_id"`
+	LocationID          *int64    `json:"location_id"`
+	AuthorityLevel      *string   `json:"authority_level"`
+	SupervisorOfficerID *int64    `json:"supervisor_officer_id"` // 0 clears the reporting line
+	IsActive            *bool     `json:"is_active"`
+	Categories          *[]string `json:"categories"` // replaces the officer's categories; [] clears
+	Latitude            *float64  `json:"latitude"`   // office coordinates; set with longitude
+	Longitude           *float64  `json:"longitude"`
 }
\ No newline at end of file
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"finalneta/service"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// attachmentFormMemory is how much of a multipart body is kept in memory before spilling to temp files
+const attachmentFormMemory = 32 << 20
+
+// AttachmentHandler handles citizen photo uploads for complaints
+type AttachmentHandler struct {
+	service *service.AttachmentService
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(svc *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: svc}
+}
+
+// UploadAttachments handles POST /api/v1/complaints/{id}/attachments
+// Multipart form: one or more image parts named "files" (or "file"), optional "latitude"/"longitude".
+// Each image gets an attachment row and a write-once evidence hash (raw bytes + GPS + server time).
+func (h *AttachmentHandler) UploadAttachments(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+
+	// Body cap: max files at max size, plus room for multipart headers and form fields
+	r.Body = http.MaxBytesReader(w, r.Body, int64(service.MaxAttachmentsPerUpload*service.MaxAttachmentSize+(1<<20)))
+	if err := r.ParseMultipartForm(attachmentFormMemory); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse multipart form (images up to 10 MB each, at most 5 per upload)")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	ctx, cancel := context.WithTimeout(r.Context(), uploadRequestTimeout)
+	defer cancel()
+
+	latitude, err := parseOptionalFloat(r.FormValue("latitude"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Invalid latitude")
+		return
+	}
+	longitude, err := parseOptionalFloat(r.FormValue("longitude"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Invalid longitude")
+		return
+	}
+	if (latitude != nil && (*latitude < -90 || *latitude > 90)) || (longitude != nil && (*longitude < -180 || *longitude > 180)) {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "GPS coordinates out of range")
+		return
+	}
+
+	headers := append(r.MultipartForm.File["files"], r.MultipartForm.File["file"]...)
+	if len(headers) > service.MaxAttachmentsPerUpload {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Too many files in one upload")
+		return
+	}
+	uploads := make([]service.AttachmentUpload, 0, len(headers))
+	for _, fh := range headers {
+		data, err := readMultipartFile(fh)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to read uploaded file")
+			return
+		}
+		uploads = append(uploads, service.AttachmentUpload{FileName: fh.Filename, Data: data})
+	}
+
+	response, err := h.service.UploadAttachments(ctx, complaintID, userID, uploads, latitude, longitude, getClientIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "complaint not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", "Complaint not found")
+		case strings.Contains(err.Error(), "only the complaint owner"):
+			respondWithError(w, http.StatusForbidden, "Forbidden", "Only the complaint owner can upload attachments")
+		case strings.Contains(err.Error(), "complaint is closed"):
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint is closed")
+		case strings.Contains(err.Error(), "invalid attachment"):
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to save attachments")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// readMultipartFile reads one uploaded part fully into memory
+func readMultipartFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// parseOptionalFloat parses a form value; empty means not provided
+func parseOptionalFloat(value string) (*float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("not a finite number: %s", value)
+	}
+	return &v, nil
+}
@@ -40,6 +40,8 @@ type AuthorityLoginResponse struct {
 // Login handles POST /authority/login
 // PILOT: Supports email+password OR static token
 func (h *AuthorityAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	var req AuthorityLoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
@@ -54,20 +56,20 @@ func (h *AuthorityAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var officerID int64
 	var err error
 	if req.StaticToken != "" {
-		officerID, err = h.authorityService.ValidateStaticToken(req.StaticToken)
+		officerID, err = h.authorityService.ValidateStaticToken(ctx, req.StaticToken)
 	} else {
 		if req.Email == "" || req.Password == "" {
 			respondWithError(w, http.StatusBadRequest, "Validation error", "Email and password are required")
 			return
 		}
-		officerID, err = h.authorityService.ValidateCredentials(req.Email, req.Password)
+		officerID, err = h.authorityService.ValidateCredentials(ctx, req.Email, req.Password)
 	}
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Invalid credentials")
 		return
 	}
 
-	_, _, authorityLevel, err := h.authorityService.GetOfficerProfile(officerID)
+	_, _, authorityLevel, err := h.authorityService.GetOfficerProfile(ctx, officerID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to load profile")
 		return
@@ -104,6 +106,8 @@ type MeResponse struct {
 
 // Me handles GET /authority/me (requires authority auth; returns officer profile).
 func (h *AuthorityAuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	officerIDVal := r.Context().Value("officer_id")
 	if officerIDVal == nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found")
@@ -114,7 +118,7 @@ func (h *AuthorityAuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Invalid officer context")
 		return
 	}
-	deptID, locID, level, err := h.authorityService.GetOfficerProfile(officerID)
+	deptID, locID, level, err := h.authorityService.GetOfficerProfile(ctx, officerID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
 		return
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"finalneta/models"
+	"finalneta/repository"
 	"finalneta/service"
 	"fmt"
 	"net/http"
@@ -26,6 +28,8 @@ func NewAuthorityHandler(authorityService *service.AuthorityService) *AuthorityH
 
 // GetMyComplaints handles GET /authority/complaints?status=&page=1&page_size=20 (read-only; only complaints assigned to this authority).
 func (h *AuthorityHandler) GetMyComplaints(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	officerID, err := getOfficerIDFromContext(r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
@@ -43,7 +47,7 @@ func (h *AuthorityHandler) GetMyComplaints(w http.ResponseWriter, r *http.Reques
 	if pageSize > 100 {
 		pageSize = 100
 	}
-	complaints, total, err := h.authorityService.GetComplaintsByOfficerIDPaginated(officerID, status, page, pageSize)
+	complaints, total, err := h.authorityService.GetComplaintsByOfficerIDPaginated(ctx, officerID, status, page, pageSize)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
 		return
@@ -57,7 +61,11 @@ func (h *AuthorityHandler) GetMyComplaints(w http.ResponseWriter, r *http.Reques
 }
 
 // UpdateComplaintStatus handles POST /api/v1/authority/complaints/{complaint_id}/status (body: new_status, reason). Assignment and transition validated in service.
+// Optional If-Match header carries the complaint version (ETag) the officer saw; a stale version returns 409 Conflict.
+// The response ETag is the new version.
 func (h *AuthorityHandler) UpdateComplaintStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	officerID, err := getOfficerIDFromContext(r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
@@ -82,10 +90,78 @@ func (h *AuthorityHandler) UpdateComplaintStatus(w http.ResponseWriter, r *http.
 		respondWithError(w, http.StatusBadRequest, "Validation error", "Reason is required for status change")
 		return
 	}
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
 	ipAddress := getClientIP(r)
 	userAgent := r.UserAgent()
-	response, err := h.authorityService.UpdateComplaintStatus(complaintID, officerID, &req, ipAddress, userAgent)
+	response, err := h.authorityService.UpdateComplaintStatus(ctx, complaintID, officerID, &req, expectedVersion, ipAddress, userAgent)
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint was modified by someone else; reload and try again")
+			return
+		}
+		if err.Error() == "complaint not found" {
+			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		if err.Error() == "complaint not assigned to this authority" {
+			respondWithError(w, http.StatusForbidden, "Forbidden", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid status transition") || strings.Contains(err.Error(), "reason is required") {
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
+		return
+	}
+	w.Header().Set("ETag", complaintETag(response.Version))
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// UpdateComplaintPriority handles POST /authority/complaints/{id}/priority
+// Body: {"priority": "urgent", "reason": "..."}; optional If-Match. Returns the re-evaluated sla_due_at.
+func (h *AuthorityHandler) UpdateComplaintPriority(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+	var req models.AuthorityUpdatePriorityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+	if req.Priority == "" {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Priority is required")
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Reason is required for priority change")
+		return
+	}
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+	response, err := h.authorityService.UpdateComplaintPriority(ctx, complaintID, officerID, &req, expectedVersion, getClientIP(r), r.UserAgent())
 	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint was modified by someone else; reload and try again")
+			return
+		}
 		if err.Error() == "complaint not found" {
 			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
 			return
@@ -94,19 +170,23 @@ func (h *AuthorityHandler) UpdateComplaintStatus(w http.ResponseWriter, r *http.
 			respondWithError(w, http.StatusForbidden, "Forbidden", err.Error())
 			return
 		}
-		if strings.Contains(err.Error(), "invalid status transition") || err.Error() == "invalid status transition: closed is system-only" {
+		if strings.Contains(err.Error(), "invalid priority") {
 			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
 			return
 		}
 		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
 		return
 	}
+	w.Header().Set("ETag", complaintETag(response.Version))
 	respondWithJSON(w, http.StatusOK, response)
 }
 
 // AddNote handles POST /authority/complaints/{id}/note
 // Adds an internal note (not visible to citizen)
 func (h *AuthorityHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// Extract officer_id from context
 	officerID, err := getOfficerIDFromContext(r)
 	if err != nil {
@@ -137,7 +217,7 @@ func (h *AuthorityHandler) AddNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Add note via service
-	response, err := h.authorityService.AddNote(complaintID, officerID, req.NoteText)
+	response, err := h.authorityService.AddNote(ctx, complaintID, officerID, req.NoteText)
 	if err != nil {
 		if err.Error() == "complaint not found" {
 			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
@@ -168,3 +248,24 @@ func getOfficerIDFromContext(r *http.Request) (int64, error) {
 
 	return officerID, nil
 }
+
+// complaintETag formats a complaint version as a strong ETag
+func complaintETag(version int64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// parseIfMatch reads the complaint version from the If-Match header.
+// Returns nil when the header is absent or "*" (no precondition). Accepts "3", W/"3" or 3.
+func parseIfMatch(r *http.Request) (*int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	value = strings.Trim(value, "\"")
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header: expected complaint version")
+	}
+	return &version, nil
+}
@@ -22,6 +22,9 @@ func NewChatHandler(pilotMetricsService *service.PilotMetricsService) *ChatHandl
 // Auth (JWT, phone_verified) is unchanged. If chat_state/draft storage is added later, clear it here.
 // Emits chat_abandoned metric event if user had a draft but never submitted.
 func (h *ChatHandler) ResetChatDraft(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// User is already authenticated by middleware; no body required.
 	// Future: clear user_draft / chat_state by user_id from context.
 	
@@ -34,7 +37,7 @@ func (h *ChatHandler) ResetChatDraft(w http.ResponseWriter, r *http.Request) {
 			metadata := map[string]interface{}{
 				"action": "reset",
 			}
-			h.pilotMetricsService.EmitChatAbandoned(userID, metadata)
+			h.pilotMetricsService.EmitChatAbandoned(ctx, userID, metadata)
 		}
 	}
 	
@@ -1,18 +1,18 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"finalneta/models"
 	"finalneta/repository"
 	"finalneta/service"
-	"io"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -23,8 +23,6 @@ type ComplaintHandler struct {
 	userService           *service.UserService
 	abusePreventionService *service.AbusePreventionService
 	complaintRepo          *repository.ComplaintRepository
-	voiceNoteRepo          *repository.VoiceNoteRepository
-	uploadBasePath         string
 }
 
 // NewComplaintHandler creates a new complaint handler
@@ -33,25 +31,21 @@ func NewComplaintHandler(
 	userService *service.UserService,
 	abusePreventionService *service.AbusePreventionService,
 	complaintRepo *repository.ComplaintRepository,
-	voiceNoteRepo *repository.VoiceNoteRepository,
 ) *ComplaintHandler {
-	basePath := os.Getenv("UPLOAD_BASE_PATH")
-	if basePath == "" {
-		basePath = "uploads"
-	}
 	return &ComplaintHandler{
 		service:               svc,
 		userService:           userService,
 		abusePreventionService: abusePreventionService,
 		complaintRepo:          complaintRepo,
-		voiceNoteRepo:          voiceNoteRepo,
-		uploadBasePath:         basePath,
 	}
 }
 
 // CreateComplaint handles POST /api/complaints
 // Creates a new complaint with proper lifecycle initialization
 func (h *ComplaintHandler) CreateComplaint(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// Extract user_id from context (assumed to be set by auth middleware)
 	userID, err := getUserIDFromContext(r)
 	if err != nil {
@@ -116,7 +110,7 @@ func (h *ComplaintHandler) CreateComplaint(w http.ResponseWriter, r *http.Reques
 		}
 		
 		// Perform abuse prevention checks
-		abuseCheck, err := h.abusePreventionService.ValidateComplaintSubmission(
+		abuseCheck, err := h.abusePreventionService.ValidateComplaintSubmission(ctx,
 			userID,
 			req.Title, // issue_summary (title)
 			pincode,
@@ -139,7 +133,7 @@ func (h *ComplaintHandler) CreateComplaint(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Create complaint
-	response, err := h.service.CreateComplaint(&req, userID, ipAddress, userAgent)
+	response, err := h.service.CreateComplaint(ctx, &req, userID, ipAddress, userAgent)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
 		return
@@ -151,6 +145,9 @@ func (h *ComplaintHandler) CreateComplaint(w http.ResponseWriter, r *http.Reques
 // GetComplaintByID handles GET /api/complaints/{id}
 // Retrieves complaint details (citizen view)
 func (h *ComplaintHandler) GetComplaintByID(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// Extract user_id from context
 	userID, err := getUserIDFromContext(r)
 	if err != nil {
@@ -168,7 +165,7 @@ func (h *ComplaintHandler) GetComplaintByID(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Get complaint
-	response, err := h.service.GetComplaintByID(complaintID, userID)
+	response, err := h.service.GetComplaintByID(ctx, complaintID, userID)
 	if err != nil {
 		if err.Error() == "complaint not found" || err.Error() == "complaint not found or access denied" {
 			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
@@ -184,6 +181,9 @@ func (h *ComplaintHandler) GetComplaintByID(w http.ResponseWriter, r *http.Reque
 // GetStatusTimeline handles GET /api/complaints/{id}/timeline
 // Retrieves the complete status timeline for a complaint
 func (h *ComplaintHandler) GetStatusTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// Extract user_id from context
 	userID, err := getUserIDFromContext(r)
 	if err != nil {
@@ -201,7 +201,7 @@ func (h *ComplaintHandler) GetStatusTimeline(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Get timeline
-	response, err := h.service.GetStatusTimeline(complaintID, userID)
+	response, err := h.service.GetStatusTimeline(ctx, complaintID, userID)
 	if err != nil {
 		if err.Error() == "complaint not found" || err.Error() == "complaint not found or access denied" {
 			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
@@ -214,90 +214,12 @@ func (h *ComplaintHandler) GetStatusTimeline(w http.ResponseWriter, r *http.Requ
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-// UploadVoice handles POST /api/v1/complaints/{id}/voice
-// Citizen JWT only; only complaint owner can upload. One voice per complaint (overwrite allowed). Voice is not public.
-func (h *ComplaintHandler) UploadVoice(w http.ResponseWriter, r *http.Request) {
-	userID, err := getUserIDFromContext(r)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
-		return
-	}
-	vars := mux.Vars(r)
-	complaintIDStr := vars["id"]
-	complaintID, err := strconv.ParseInt(complaintIDStr, 10, 64)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
-		return
-	}
-	ownerID, err := h.complaintRepo.GetComplaintOwnerID(complaintID)
-	if err != nil {
-		if err.Error() == "complaint not found" {
-			respondWithError(w, http.StatusNotFound, "Not found", "Complaint not found")
-			return
-		}
-		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
-		return
-	}
-	if ownerID != userID {
-		respondWithError(w, http.StatusForbidden, "Forbidden", "Only the complaint owner can upload a voice note")
-		return
-	}
-	contentType := r.Header.Get("Content-Type")
-	var ext string
-	switch {
-	case strings.Contains(contentType, "audio/webm"):
-		ext = "webm"
-	case strings.Contains(contentType, "audio/wav"):
-		ext = "wav"
-	default:
-		ext = "webm"
-	}
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to read request body")
-		return
-	}
-	if len(body) == 0 {
-		respondWithError(w, http.StatusBadRequest, "Validation error", "Empty voice data")
-		return
-	}
-	voiceDir := filepath.Join(h.uploadBasePath, "voices")
-	if err := os.MkdirAll(voiceDir, 0755); err != nil {
-		log.Printf("[voice] failed to create directory %s: %v", voiceDir, err)
-		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to save voice note")
-		return
-	}
-	filename := fmt.Sprintf("%d.%s", complaintID, ext)
-	filePath := filepath.Join(voiceDir, filename)
-	if err := os.WriteFile(filePath, body, 0644); err != nil {
-		log.Printf("[voice] failed to write file %s: %v", filePath, err)
-		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to save voice note")
-		return
-	}
-	relativePath := filepath.Join("voices", filename)
-	mimeType := "audio/webm"
-	if ext == "wav" {
-		mimeType = "audio/wav"
-	}
-	note := &models.ComplaintVoiceNote{
-		ComplaintID: complaintID,
-		FilePath:    relativePath,
-		MimeType:    mimeType,
-	}
-	if err := h.voiceNoteRepo.CreateOrUpdate(note); err != nil {
-		log.Printf("[voice] failed to save voice note record: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to save voice note")
-		return
-	}
-	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
-		"message":      "Voice note attached",
-		"complaint_id": complaintID,
-	})
-}
-
 // GetUserComplaints handles GET /api/v1/complaints
 // Retrieves all complaints for the authenticated user
 func (h *ComplaintHandler) GetUserComplaints(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// Extract user_id from context (set by auth middleware)
 	userID, err := getUserIDFromContext(r)
 	if err != nil {
@@ -306,7 +228,7 @@ func (h *ComplaintHandler) GetUserComplaints(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Get user's complaints
-	complaints, err := h.service.GetUserComplaints(userID)
+	complaints, err := h.service.GetUserComplaints(ctx, userID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
 		return
@@ -318,6 +240,9 @@ func (h *ComplaintHandler) GetUserComplaints(w http.ResponseWriter, r *http.Requ
 // UpdateComplaintStatus handles PATCH /api/complaints/{id}/status
 // Updates complaint status (internal use only - requires officer/admin authentication)
 func (h *ComplaintHandler) UpdateComplaintStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// Extract actor information from context
 	// In production, this would come from authentication middleware
 	actorType, actorUserID, actorOfficerID, err := getActorFromContext(r)
@@ -353,7 +278,7 @@ func (h *ComplaintHandler) UpdateComplaintStatus(w http.ResponseWriter, r *http.
 	userAgent := r.UserAgent()
 
 	// Update status
-	response, err := h.service.UpdateComplaintStatus(
+	response, err := h.service.UpdateComplaintStatus(ctx,
 		complaintID,
 		&req,
 		actorType,
@@ -367,7 +292,11 @@ func (h *ComplaintHandler) UpdateComplaintStatus(w http.ResponseWriter, r *http.
 			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
 			return
 		}
-		if err.Error() == "invalid status transition" {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint was modified concurrently; reload and try again")
+			return
+		}
+		if strings.Contains(err.Error(), "invalid status transition") || strings.Contains(err.Error(), "reason is required") {
 			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
 			return
 		}
@@ -378,6 +307,103 @@ func (h *ComplaintHandler) UpdateComplaintStatus(w http.ResponseWriter, r *http.
 	respondWithJSON(w, http.StatusOK, response)
 }
 
+// AnswerClarification handles POST /api/v1/complaints/{id}/clarification
+// Citizen owner answers the officer's question; the complaint returns to its previous status
+func (h *ComplaintHandler) AnswerClarification(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "User authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+
+	var req models.ClarificationAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+	if strings.TrimSpace(req.Answer) == "" {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Answer is required")
+		return
+	}
+
+	response, err := h.service.AnswerClarification(ctx, complaintID, userID, &req, getClientIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint was modified concurrently; reload and try again")
+		case strings.Contains(err.Error(), "not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", "Complaint not found")
+		case strings.Contains(err.Error(), "invalid status transition"):
+			respondWithError(w, http.StatusConflict, "Conflict", err.Error())
+		case strings.Contains(err.Error(), "reason is required"):
+			respondWithError(w, http.StatusBadRequest, "Validation error", "Answer is required")
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to record answer")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// RespondToResolution handles POST /api/v1/complaints/{id}/resolution
+// Citizen owner confirms (closes) or disputes (reopens) a resolved complaint
+func (h *ComplaintHandler) RespondToResolution(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "User authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+
+	var req models.ResolutionResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+	if req.Action == "" {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Action is required (confirm or dispute)")
+		return
+	}
+
+	response, err := h.service.RespondToResolution(ctx, complaintID, userID, &req, getClientIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint was modified concurrently; reload and try again")
+		case strings.Contains(err.Error(), "not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", "Complaint not found")
+		case strings.Contains(err.Error(), "invalid status transition"):
+			respondWithError(w, http.StatusConflict, "Conflict", err.Error())
+		case strings.Contains(err.Error(), "invalid action"), strings.Contains(err.Error(), "reason is required"),
+			strings.Contains(err.Error(), "invalid attachment_id"):
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to record resolution response")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
 // Helper functions
 
 // getUserIDFromContext extracts user_id from request context
@@ -446,6 +472,19 @@ func getClientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
+// requestTimeout bounds the database and storage work behind one API request
+const requestTimeout = 15 * time.Second
+
+// uploadRequestTimeout replaces requestTimeout for handlers that store uploaded files;
+// it starts once the request body has been read
+const uploadRequestTimeout = time.Minute
+
+// requestContext returns the context for a handler's service calls: cancelled when the client
+// disconnects or after requestTimeout. Call the returned cancel when the handler returns.
+func requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(r.Context(), requestTimeout)
+}
+
 // respondWithJSON sends a JSON response
 func respondWithJSON(w http.ResponseWriter, statusCode int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
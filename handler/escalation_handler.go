@@ -1,40 +1,206 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"finalneta/models"
+	"finalneta/repository"
 	"finalneta/service"
 	"finalneta/worker"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 // EscalationHandler handles HTTP requests for escalation operations
 type EscalationHandler struct {
 	escalationService *service.EscalationService
-	worker            *worker.EscalationWorker
+	jobs              *worker.Manager
 }
 
 // NewEscalationHandler creates a new escalation handler
 func NewEscalationHandler(
 	escalationService *service.EscalationService,
-	worker *worker.EscalationWorker,
+	jobs *worker.Manager,
 ) *EscalationHandler {
 	return &EscalationHandler{
 		escalationService: escalationService,
-		worker:            worker,
+		jobs:              jobs,
 	}
 }
 
 // ProcessEscalations handles POST /api/v1/escalations/process
 // Manually triggers escalation processing (useful for testing or manual runs)
+// Same as POST /api/v1/admin/jobs/escalation/run: recorded in job_runs, never overlaps a scheduled run
+// on any instance (409 if one is in progress)
 func (h *EscalationHandler) ProcessEscalations(w http.ResponseWriter, r *http.Request) {
-	_ = h.worker // Worker field reserved for future use
-	results, err := h.escalationService.ProcessEscalations()
+	run, result, err := h.jobs.RunNow(worker.EscalationJobName)
+	if run == nil {
+		respondWithJobError(w, err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
 		return
 	}
 
+	results, _ := result.Detail.([]models.EscalationResult)
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"processed": len(results),
 		"results":   results,
+		"run":       run,
 	})
 }
+
+// RequestEscalation handles POST /api/v1/complaints/{id}/escalate
+// Citizen owner asks for escalation once the SLA of the current level is breached (once per level).
+// Body is optional: {"note": "..."}
+func (h *EscalationHandler) RequestEscalation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "User authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+
+	var req models.CitizenEscalationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+
+	result, err := h.escalationService.RequestEscalation(ctx, complaintID, userID, req.Note, getClientIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint was modified concurrently; reload and try again")
+		case strings.Contains(err.Error(), "not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", "Complaint not found")
+		case strings.Contains(err.Error(), "invalid request"):
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+		case strings.Contains(err.Error(), "escalation not available"),
+			strings.Contains(err.Error(), "already requested"),
+			strings.Contains(err.Error(), "invalid status transition"):
+			respondWithError(w, http.StatusConflict, "Conflict", err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to escalate complaint")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// EscalateByOfficer handles POST /api/v1/authority/complaints/{id}/escalate
+// Body: {"reason": "..."}; optional If-Match. The assigned officer sends the complaint to the next level.
+func (h *EscalationHandler) EscalateByOfficer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+	var req models.AuthorityEscalateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Reason is required for escalation")
+		return
+	}
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	result, err := h.escalationService.EscalateByOfficer(ctx, complaintID, officerID, req.Reason, expectedVersion, getClientIP(r), r.UserAgent())
+	if err != nil {
+		respondWithOfficerEscalationError(w, err)
+		return
+	}
+	w.Header().Set("ETag", complaintETag(result.Version))
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// TransferComplaint handles POST /api/v1/authority/complaints/{id}/transfer
+// Body: {"to_department_id": 3, "to_location_id": 2, "reason": "..."}; optional If-Match.
+// The assigned officer hands the complaint to another department and/or location at the same level.
+func (h *EscalationHandler) TransferComplaint(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+	var req models.AuthorityTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+	if req.ToDepartmentID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "to_department_id is required")
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		respondWithError(w, http.StatusBadRequest, "Validation error", "Reason is required for transfer")
+		return
+	}
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	response, err := h.escalationService.TransferComplaint(ctx, complaintID, officerID, &req, expectedVersion, getClientIP(r), r.UserAgent())
+	if err != nil {
+		respondWithOfficerEscalationError(w, err)
+		return
+	}
+	w.Header().Set("ETag", complaintETag(response.Version))
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// respondWithOfficerEscalationError maps escalation and transfer errors for the authority endpoints
+func respondWithOfficerEscalationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		respondWithError(w, http.StatusConflict, "Conflict", "Complaint was modified by someone else; reload and try again")
+	case err.Error() == "complaint not found":
+		respondWithError(w, http.StatusNotFound, "Not found", err.Error())
+	case err.Error() == "complaint not assigned to this authority":
+		respondWithError(w, http.StatusForbidden, "Forbidden", err.Error())
+	case strings.Contains(err.Error(), "not available"), strings.Contains(err.Error(), "invalid status transition"):
+		respondWithError(w, http.StatusConflict, "Conflict", err.Error())
+	case strings.Contains(err.Error(), "invalid transfer"), strings.Contains(err.Error(), "reason is required"):
+		respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
+	}
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"finalneta/models"
+	"finalneta/repository"
+	"finalneta/service"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// EscalationRuleHandler serves the admin escalation rules API and the what-if simulator
+type EscalationRuleHandler struct {
+	service           *service.EscalationRuleService
+	escalationService *service.EscalationService
+}
+
+// NewEscalationRuleHandler creates a new escalation rule handler
+func NewEscalationRuleHandler(svc *service.EscalationRuleService, escalationService *service.EscalationService) *EscalationRuleHandler {
+	return &EscalationRuleHandler{service: svc, escalationService: escalationService}
+}
+
+// escalationRuleResponse is one rule with its timestamps
+type escalationRuleResponse struct {
+	models.EscalationRuleView
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// escalationRuleHistoryEntry is one version of a rule from audit_log
+type escalationRuleHistoryEntry struct {
+	AuditID   int64           `json:"audit_id"`
+	Action    string          `json:"action"`
+	OldValues json.RawMessage `json:"old_values"`
+	NewValues json.RawMessage `json:"new_values"`
+	Metadata  json.RawMessage `json:"metadata"`
+	IPAddress string          `json:"ip_address,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ListRules handles GET /api/v1/admin/escalation-rules?include_inactive=true
+func (h *EscalationRuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	includeInactive := r.URL.Query().Get("include_inactive") == "true"
+	rules, err := h.service.ListRules(ctx, includeInactive)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to list escalation rules")
+		return
+	}
+	out := make([]escalationRuleResponse, 0, len(rules))
+	for i := range rules {
+		out = append(out, toEscalationRuleResponse(&rules[i]))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"rules": out})
+}
+
+// GetRule handles GET /api/v1/admin/escalation-rules/{rule_id}. The ETag is the rule version.
+func (h *EscalationRuleHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	ruleID, ok := parseRuleID(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.service.GetRule(ctx, ruleID)
+	if err != nil {
+		h.respondWithRuleError(w, err)
+		return
+	}
+	w.Header().Set("ETag", complaintETag(int64(rule.Version)))
+	respondWithJSON(w, http.StatusOK, toEscalationRuleResponse(rule))
+}
+
+// GetRuleHistory handles GET /api/v1/admin/escalation-rules/{rule_id}/history (every version, oldest first)
+func (h *EscalationRuleHandler) GetRuleHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	ruleID, ok := parseRuleID(w, r)
+	if !ok {
+		return
+	}
+	history, err := h.service.GetRuleHistory(ctx, ruleID)
+	if err != nil {
+		h.respondWithRuleError(w, err)
+		return
+	}
+	out := make([]escalationRuleHistoryEntry, 0, len(history))
+	for _, a := range history {
+		out = append(out, escalationRuleHistoryEntry{
+			AuditID:   a.AuditID,
+			Action:    a.Action,
+			OldValues: rawJSON(a.OldValues),
+			NewValues: rawJSON(a.NewValues),
+			Metadata:  rawJSON(a.Metadata),
+			IPAddress: a.IPAddress.String,
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"rule_id": ruleID, "history": out})
+}
+
+// CreateRule handles POST /api/v1/admin/escalation-rules. Audited; overlapping rules are returned as warnings.
+func (h *EscalationRuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	rule, ok := decodeEscalationRuleRequest(w, r)
+	if !ok {
+		return
+	}
+	created, warnings, err := h.service.CreateRule(ctx, rule, getClientIP(r), r.UserAgent())
+	if err != nil {
+		h.respondWithRuleError(w, err)
+		return
+	}
+	w.Header().Set("ETag", complaintETag(int64(created.Version)))
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"rule":     toEscalationRuleResponse(created),
+		"warnings": nonNilStrings(warnings),
+	})
+}
+
+// UpdateRule handles PUT /api/v1/admin/escalation-rules/{rule_id} (full replacement). Audited.
+// Optional If-Match carries the rule version the admin saw; a stale version returns 409 Conflict.
+func (h *EscalationRuleHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	ruleID, ok := parseRuleID(w, r)
+	if !ok {
+		return
+	}
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "invalid If-Match header: expected rule version")
+		return
+	}
+	rule, ok := decodeEscalationRuleRequest(w, r)
+	if !ok {
+		return
+	}
+	updated, warnings, err := h.service.UpdateRule(ctx, ruleID, rule, expectedVersion, getClientIP(r), r.UserAgent())
+	if err != nil {
+		h.respondWithRuleError(w, err)
+		return
+	}
+	w.Header().Set("ETag", complaintETag(int64(updated.Version)))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"rule":     toEscalationRuleResponse(updated),
+		"warnings": nonNilStrings(warnings),
+	})
+}
+
+// DeactivateRule handles DELETE /api/v1/admin/escalation-rules/{rule_id} (sets is_active = false; optional If-Match). Audited.
+func (h *EscalationRuleHandler) DeactivateRule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	ruleID, ok := parseRuleID(w, r)
+	if !ok {
+		return
+	}
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "invalid If-Match header: expected rule version")
+		return
+	}
+	rule, err := h.service.DeactivateRule(ctx, ruleID, expectedVersion, getClientIP(r), r.UserAgent())
+	if err != nil {
+		h.respondWithRuleError(w, err)
+		return
+	}
+	w.Header().Set("ETag", complaintETag(int64(rule.Version)))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"rule": toEscalationRuleResponse(rule)})
+}
+
+// SimulateEscalations handles POST /api/v1/admin/escalation-rules/simulate
+// Body (optional): {"now": RFC3339, "rules": [rule, ...]}. Without rules the current active rules are used;
+// without now the current time. Nothing is written.
+func (h *EscalationRuleHandler) SimulateEscalations(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	var req models.EscalationSimulationRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", fmt.Sprintf("Failed to parse request body: %v", err))
+		return
+	}
+
+	now := time.Now()
+	if req.Now != nil {
+		now = *req.Now
+	}
+	var proposed []models.EscalationRule
+	if req.Rules != nil {
+		proposed = make([]models.EscalationRule, 0, len(req.Rules))
+		for i := range req.Rules {
+			rule, err := req.Rules[i].ToRule()
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Validation error", fmt.Sprintf("rules[%d]: %v", i, err))
+				return
+			}
+			proposed = append(proposed, *rule)
+		}
+	}
+
+	sim, err := h.escalationService.SimulateEscalations(ctx, proposed, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid escalation rule") {
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to simulate escalations")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, sim)
+}
+
+// respondWithRuleError maps escalation rule service errors to HTTP status codes
+func (h *EscalationRuleHandler) respondWithRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRuleVersionConflict):
+		respondWithError(w, http.StatusConflict, "Conflict", "Escalation rule was modified by someone else; reload and try again")
+	case strings.Contains(err.Error(), "not found"):
+		respondWithError(w, http.StatusNotFound, "Not found", err.Error())
+	case strings.Contains(err.Error(), "conflicts with"):
+		respondWithError(w, http.StatusConflict, "Conflict", err.Error())
+	case strings.Contains(err.Error(), "invalid escalation rule"):
+		respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to save escalation rule")
+	}
+}
+
+// parseRuleID reads {rule_id}; writes 400 and returns false if invalid
+func parseRuleID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	ruleID, err := strconv.ParseInt(mux.Vars(r)["rule_id"], 10, 64)
+	if err != nil || ruleID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid rule ID")
+		return 0, false
+	}
+	return ruleID, true
+}
+
+// decodeEscalationRuleRequest decodes the body strictly (unknown fields rejected) into a rule;
+// writes 400 and returns false on error. Conditions are validated by the service.
+func decodeEscalationRuleRequest(w http.ResponseWriter, r *http.Request) (*models.EscalationRule, bool) {
+	var req models.EscalationRuleRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", fmt.Sprintf("Failed to parse request body: %v", err))
+		return nil, false
+	}
+	rule, err := req.ToRule()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+		return nil, false
+	}
+	return rule, true
+}
+
+func toEscalationRuleResponse(rule *models.EscalationRule) escalationRuleResponse {
+	resp := escalationRuleResponse{
+		EscalationRuleView: service.EscalationRuleSnapshot(rule),
+		CreatedAt:          rule.CreatedAt,
+	}
+	if rule.UpdatedAt.Valid {
+		resp.UpdatedAt = &rule.UpdatedAt.Time
+	}
+	return resp
+}
+
+// rawJSON returns a JSON column as raw JSON (null when empty)
+func rawJSON(v sql.NullString) json.RawMessage {
+	if !v.Valid || v.String == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(v.String)
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
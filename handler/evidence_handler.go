@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"finalneta/models"
+	"finalneta/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// EvidenceHandler handles evidence integrity re-verification
+type EvidenceHandler struct {
+	service *service.EvidenceService
+}
+
+// NewEvidenceHandler creates a new evidence handler
+func NewEvidenceHandler(svc *service.EvidenceService) *EvidenceHandler {
+	return &EvidenceHandler{service: svc}
+}
+
+// VerifyForAuthority handles POST /api/v1/authority/complaints/{id}/attachments/{attachment_id}/verify
+// Officer must be assigned to the complaint.
+func (h *EvidenceHandler) VerifyForAuthority(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	h.verify(ctx, w, r, models.ActorOfficer, &officerID)
+}
+
+// VerifyForAdmin handles POST /api/v1/admin/complaints/{id}/attachments/{attachment_id}/verify
+func (h *EvidenceHandler) VerifyForAdmin(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	h.verify(ctx, w, r, models.ActorAdmin, nil)
+}
+
+// verify recomputes the evidence hash and returns match / mismatch / file_missing (200 in all three cases)
+func (h *EvidenceHandler) verify(ctx context.Context, w http.ResponseWriter, r *http.Request, actorType models.ActorType, officerID *int64) {
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+	attachmentID, err := strconv.ParseInt(vars["attachment_id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid attachment ID")
+		return
+	}
+
+	result, err := h.service.VerifyAttachment(ctx, complaintID, attachmentID, actorType, officerID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not assigned"):
+			respondWithError(w, http.StatusForbidden, "Forbidden", err.Error())
+		case strings.Contains(err.Error(), "evidence not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", "No evidence record for this attachment")
+		case strings.Contains(err.Error(), "not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to verify evidence")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
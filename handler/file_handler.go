@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"finalneta/storage"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// FileHandler serves signed URLs issued by the local storage backend.
+// S3 signed URLs go straight to the bucket and never reach this handler.
+type FileHandler struct {
+	local *storage.Local
+}
+
+// NewFileHandler creates a new file handler
+func NewFileHandler(local *storage.Local) *FileHandler {
+	return &FileHandler{local: local}
+}
+
+// ServeFile handles GET /api/v1/files/{key}?expires=...&signature=...
+// No auth header: the signature is the credential. Supports Range requests.
+func (h *FileHandler) ServeFile(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if err := storage.ValidateKey(key); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid file key")
+		return
+	}
+	q := r.URL.Query()
+	if err := h.local.VerifySignature(key, q.Get("expires"), q.Get("signature")); err != nil {
+		respondWithError(w, http.StatusForbidden, "Forbidden", err.Error())
+		return
+	}
+
+	body, info, err := h.local.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Not found", "File not found")
+			return
+		}
+		log.Printf("[files] failed to open %s: %v", key, err)
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to read file")
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	name := key[strings.LastIndex(key, "/")+1:]
+	if rs, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, info.LastModified, rs)
+		return
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("[files] failed to send %s: %v", key, err)
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"finalneta/models"
+	"finalneta/worker"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultJobRunsLimit and maxJobRunsLimit bound GET /admin/jobs/{name}/runs
+const (
+	defaultJobRunsLimit = 50
+	maxJobRunsLimit     = 500
+)
+
+// JobHandler serves the admin background jobs API (list, pause, resume, run now, history)
+type JobHandler struct {
+	manager *worker.Manager
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(manager *worker.Manager) *JobHandler {
+	return &JobHandler{manager: manager}
+}
+
+// pauseJobRequest is the optional body of POST /admin/jobs/{name}/pause
+type pauseJobRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ListJobs handles GET /api/v1/admin/jobs
+// Pause state and last run are shared by all instances; running and next_run_at are for the instance answering.
+func (h *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	jobs, err := h.manager.Jobs(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to list jobs")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"jobs": jobs})
+}
+
+// GetJobRuns handles GET /api/v1/admin/jobs/{name}/runs?limit=50 (newest first, every instance)
+func (h *JobHandler) GetJobRuns(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	name := mux.Vars(r)["name"]
+	limit := defaultJobRunsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxJobRunsLimit {
+			respondWithError(w, http.StatusBadRequest, "Invalid request", fmt.Sprintf("limit must be between 1 and %d", maxJobRunsLimit))
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.manager.History(ctx, name, limit)
+	if err != nil {
+		respondWithJobError(w, err)
+		return
+	}
+	if runs == nil {
+		runs = []models.JobRun{}
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"job": name, "runs": runs})
+}
+
+// PauseJob handles POST /api/v1/admin/jobs/{name}/pause
+// Body (optional): {"reason": "..."}. Scheduled runs stop on every instance; a run in progress finishes.
+func (h *JobHandler) PauseJob(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	name := mux.Vars(r)["name"]
+	var req pauseJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+	if err := h.manager.Pause(ctx, name, req.Reason); err != nil {
+		respondWithJobError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"job": name, "paused": true})
+}
+
+// ResumeJob handles POST /api/v1/admin/jobs/{name}/resume
+func (h *JobHandler) ResumeJob(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	name := mux.Vars(r)["name"]
+	if err := h.manager.Resume(ctx, name); err != nil {
+		respondWithJobError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"job": name, "paused": false})
+}
+
+// RunJob handles POST /api/v1/admin/jobs/{name}/run
+// Runs the job now on this instance and waits for it (also while paused). 409 if a run is in progress.
+// A failed run still returns 200 with the recorded run (status "failed" and the error).
+func (h *JobHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	run, result, err := h.manager.RunNow(name)
+	if run == nil {
+		respondWithJobError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"run": run, "detail": result.Detail})
+}
+
+// respondWithJobError maps job manager errors to HTTP status codes
+func respondWithJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, worker.ErrJobNotFound):
+		respondWithError(w, http.StatusNotFound, "Not found", "Job not found")
+	case errors.Is(err, worker.ErrJobRunInProgress):
+		respondWithError(w, http.StatusConflict, "Conflict", "A run of this job is already in progress; try again shortly")
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
+	}
+}
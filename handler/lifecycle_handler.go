@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"finalneta/lifecycle"
+	"finalneta/models"
+	"net/http"
+)
+
+// LifecycleHandler serves the complaint state machine so clients can show only valid actions
+type LifecycleHandler struct{}
+
+// NewLifecycleHandler creates a new lifecycle handler
+func NewLifecycleHandler() *LifecycleHandler {
+	return &LifecycleHandler{}
+}
+
+// GetGraph handles GET /api/v1/lifecycle
+// Returns all states and transitions. Optional query filters:
+//   - from: only transitions out of this status
+//   - actor: only transitions this actor may take (user, officer, admin, system)
+func (h *LifecycleHandler) GetGraph(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	actor := r.URL.Query().Get("actor")
+
+	graph := lifecycle.GetGraph()
+	if from != "" {
+		graph.Transitions = lifecycle.AllowedFrom(models.ComplaintStatus(from), models.ActorType(actor))
+	} else if actor != "" {
+		var filtered []lifecycle.Transition
+		for _, t := range graph.Transitions {
+			if t.AllowsActor(models.ActorType(actor)) {
+				filtered = append(filtered, t)
+			}
+		}
+		graph.Transitions = filtered
+	}
+	if graph.Transitions == nil {
+		graph.Transitions = []lifecycle.Transition{}
+	}
+
+	respondWithJSON(w, http.StatusOK, graph)
+}
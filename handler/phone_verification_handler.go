@@ -132,6 +132,8 @@ func (h *PhoneVerificationHandler) SendOTP(w http.ResponseWriter, r *http.Reques
 // VerifyOTP handles POST /api/v1/users/otp/verify
 // Verifies OTP and creates/updates user
 func (h *PhoneVerificationHandler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	var req VerifyOTPRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
@@ -185,14 +187,14 @@ func (h *PhoneVerificationHandler) VerifyOTP(w http.ResponseWriter, r *http.Requ
 	fmt.Printf("[SUCCESS] OTP matched! Proceeding with verification...\n\n")
 
 	// OTP verified - get or create user
-	userID, _, err := h.userService.GetOrCreateUserByPhone(cleanPhone)
+	userID, _, err := h.userService.GetOrCreateUserByPhone(ctx, cleanPhone)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", fmt.Sprintf("Failed to create/get user: %v", err))
 		return
 	}
 
 	// Mark phone as verified
-	err = h.userService.MarkPhoneVerified(userID)
+	err = h.userService.MarkPhoneVerified(ctx, userID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", fmt.Sprintf("Failed to verify phone: %v", err))
 		return
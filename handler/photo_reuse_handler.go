@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"finalneta/models"
+	"finalneta/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// PhotoReuseHandler serves the admin recycled-photo review list
+type PhotoReuseHandler struct {
+	service *service.PhotoReuseService
+}
+
+// NewPhotoReuseHandler creates a new photo reuse handler
+func NewPhotoReuseHandler(svc *service.PhotoReuseService) *PhotoReuseHandler {
+	return &PhotoReuseHandler{service: svc}
+}
+
+// photoReuseReviewRequest is the body of POST /api/v1/admin/photo-reuse/{flag_id}/review
+type photoReuseReviewRequest struct {
+	Decision string `json:"decision"` // confirmed | dismissed
+	Note     string `json:"note,omitempty"`
+}
+
+// ListFlags handles GET /api/v1/admin/photo-reuse?status=pending&page=1&page_size=20
+// Cross-user and cross-device matches are listed first.
+func (h *PhotoReuseHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	status := r.URL.Query().Get("status")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	flags, total, err := h.service.ListFlags(ctx, status, page, pageSize)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid status") {
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"flags":     flags,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// ReviewFlag handles POST /api/v1/admin/photo-reuse/{flag_id}/review (body: decision, note)
+func (h *PhotoReuseHandler) ReviewFlag(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	flagID, err := strconv.ParseInt(mux.Vars(r)["flag_id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid flag ID")
+		return
+	}
+	var req photoReuseReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to parse request body")
+		return
+	}
+
+	flag, err := h.service.ReviewFlag(ctx, flagID, models.PhotoReuseStatus(req.Decision), req.Note)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", err.Error())
+		case strings.Contains(err.Error(), "already reviewed"):
+			respondWithError(w, http.StatusConflict, "Conflict", err.Error())
+		case strings.Contains(err.Error(), "invalid decision"):
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to review flag")
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, flag)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"finalneta/models"
 	"finalneta/repository"
 	"net/http"
 	"time"
@@ -10,16 +11,19 @@ import (
 
 // PublicHandler serves read-only public case data. No auth; whitelisted fields only; no PII.
 type PublicHandler struct {
-	complaintRepo *repository.ComplaintRepository
+	complaintRepo  *repository.ComplaintRepository
+	escalationRepo *repository.EscalationRepository // read-only: escalation level on timeline entries
 }
 
-// NewPublicHandler creates a public handler. Does not touch escalation or existing APIs.
-func NewPublicHandler(complaintRepo *repository.ComplaintRepository) *PublicHandler {
-	return &PublicHandler{complaintRepo: complaintRepo}
+// NewPublicHandler creates a public handler. Does not touch existing APIs.
+func NewPublicHandler(complaintRepo *repository.ComplaintRepository, escalationRepo *repository.EscalationRepository) *PublicHandler {
+	return &PublicHandler{complaintRepo: complaintRepo, escalationRepo: escalationRepo}
 }
 
 // GetPublicComplaintByNumber returns public-safe complaint + timeline. GET /api/v1/public/complaints/by-number/{complaint_number}. No auth; complaint_id never exposed.
 func (h *PublicHandler) GetPublicComplaintByNumber(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
 	vars := mux.Vars(r)
 	complaintNumber, ok := vars["complaint_number"]
 	if !ok || complaintNumber == "" {
@@ -27,7 +31,7 @@ func (h *PublicHandler) GetPublicComplaintByNumber(w http.ResponseWriter, r *htt
 		return
 	}
 
-	data, complaintID, err := h.complaintRepo.GetPublicComplaintByNumber(complaintNumber)
+	data, complaintID, err := h.complaintRepo.GetPublicComplaintByNumber(ctx, complaintNumber)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
 		return
@@ -38,11 +42,23 @@ func (h *PublicHandler) GetPublicComplaintByNumber(w http.ResponseWriter, r *htt
 	}
 
 	// Timeline from complaint_status_history; complaint_id used only internally (never in response)
-	history, err := h.complaintRepo.GetStatusHistory(complaintID)
+	history, err := h.complaintRepo.GetStatusHistory(ctx, complaintID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
 		return
 	}
+	escalations, err := h.escalationRepo.GetEscalationsByComplaint(ctx, complaintID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		return
+	}
+	// Level reached by each escalation, keyed by the status history row it wrote (transfers keep the level)
+	escalationLevels := make(map[int64]int, len(escalations))
+	for _, e := range escalations {
+		if e.StatusHistoryID.Valid && e.EscalationType != models.EscalationTypeTransfer {
+			escalationLevels[e.StatusHistoryID.Int64] = e.EscalationLevel + 1
+		}
+	}
 	timeline := make([]publicTimelineEntry, 0, len(history))
 	for _, h := range history {
 		actorType := ""
@@ -61,6 +77,9 @@ func (h *PublicHandler) GetPublicComplaintByNumber(w http.ResponseWriter, r *htt
 			NewStatus:  string(h.NewStatus),
 			ActorType:  actorType,
 		})
+		if level, ok := escalationLevels[h.HistoryID]; ok {
+			timeline[len(timeline)-1].EscalationLevel = &level
+		}
 	}
 
 	respondWithJSON(w, http.StatusOK, publicComplaintResponse{
@@ -88,4 +107,6 @@ type publicTimelineEntry struct {
 	OldStatus string `json:"old_status"`
 	NewStatus string `json:"new_status"`
 	ActorType string `json:"actor_type"`
+	// EscalationLevel is the level reached, on escalation entries only (actor_type user = citizen request)
+	EscalationLevel *int `json:"escalation_level,omitempty"`
 }
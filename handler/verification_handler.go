@@ -23,6 +23,9 @@ func NewVerificationHandler(svc *service.VerificationService) *VerificationHandl
 // VerifyComplaint handles POST /api/v1/complaints/{id}/verify
 // Verifies a complaint according to all verification rules
 func (h *VerificationHandler) VerifyComplaint(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
 	// Extract complaint ID from URL
 	vars := mux.Vars(r)
 	complaintIDStr := vars["id"]
@@ -49,7 +52,7 @@ func (h *VerificationHandler) VerifyComplaint(w http.ResponseWriter, r *http.Req
 	userAgent := r.UserAgent()
 
 	// Verify complaint
-	result, err := h.service.VerifyComplaint(&req, ipAddress, userAgent)
+	result, err := h.service.VerifyComplaint(ctx, &req, ipAddress, userAgent)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Internal error", err.Error())
 		return
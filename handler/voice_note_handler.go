@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"finalneta/repository"
+	"finalneta/service"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// VoiceNoteHandler handles citizen voice clips and authority playback
+type VoiceNoteHandler struct {
+	service *service.VoiceNoteService
+}
+
+// NewVoiceNoteHandler creates a new voice note handler
+func NewVoiceNoteHandler(svc *service.VoiceNoteService) *VoiceNoteHandler {
+	return &VoiceNoteHandler{service: svc}
+}
+
+// UploadVoice handles POST /api/v1/complaints/{id}/voice
+// Citizen JWT only; only complaint owner can upload. Body: raw WAV or WebM/Opus audio (Content-Type is not trusted;
+// the container is sniffed). Each upload adds a clip; earlier clips are kept. Voice is not public.
+func (h *VoiceNoteHandler) UploadVoice(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return
+	}
+	complaintID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, service.MaxVoiceClipSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Validation error", fmt.Sprintf("Voice note exceeds %d MB", service.MaxVoiceClipSize>>20))
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Failed to read request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), uploadRequestTimeout)
+	defer cancel()
+	note, err := h.service.UploadClip(ctx, complaintID, userID, body, getClientIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrVoiceClipLimit):
+			respondWithError(w, http.StatusConflict, "Conflict", fmt.Sprintf("A complaint can have at most %d voice notes", service.MaxVoiceClipsPerComplaint))
+		case strings.Contains(err.Error(), "complaint not found"):
+			respondWithError(w, http.StatusNotFound, "Not found", "Complaint not found")
+		case strings.Contains(err.Error(), "only the complaint owner"):
+			respondWithError(w, http.StatusForbidden, "Forbidden", "Only the complaint owner can upload a voice note")
+		case strings.Contains(err.Error(), "complaint is closed"):
+			respondWithError(w, http.StatusConflict, "Conflict", "Complaint is closed")
+		case strings.Contains(err.Error(), "invalid voice note"):
+			respondWithError(w, http.StatusBadRequest, "Validation error", err.Error())
+		default:
+			log.Printf("[voice] complaint_id=%d upload failed: %v", complaintID, err)
+			respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to save voice note")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"message":      "Voice note attached",
+		"complaint_id": complaintID,
+		"voice_note":   note,
+	})
+}
+
+// ListForAuthority handles GET /api/v1/authority/complaints/{id}/voice/clips
+// Assigned officer or their supervisor only. Clips oldest first (metadata only; stream each via .../voice/clips/{clip_id}).
+func (h *VoiceNoteHandler) ListForAuthority(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	complaintID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+
+	clips, err := h.service.ListForAuthority(ctx, complaintID, officerID)
+	if err != nil {
+		respondWithVoiceAccessError(w, complaintID, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"complaint_id": complaintID,
+		"clips":        clips,
+	})
+}
+
+// StreamForAuthority handles GET /api/v1/authority/complaints/{id}/voice (latest clip)
+// and GET /api/v1/authority/complaints/{id}/voice/clips/{clip_id}.
+// Assigned officer or their supervisor only. Streams the audio with its stored MIME type and
+// supports Range / If-Range (206 Partial Content) so phones can seek without downloading the whole file.
+func (h *VoiceNoteHandler) StreamForAuthority(w http.ResponseWriter, r *http.Request) {
+	officerID, err := getOfficerIDFromContext(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer ID not found in context")
+		return
+	}
+	vars := mux.Vars(r)
+	complaintID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid complaint ID")
+		return
+	}
+	var clipID *int64
+	if v, ok := vars["clip_id"]; ok {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request", "Invalid clip ID")
+			return
+		}
+		clipID = &id
+	}
+
+	stream, err := h.service.OpenForAuthority(r.Context(), complaintID, clipID, officerID, r.Header.Get("Range"), getClientIP(r), r.UserAgent())
+	if err != nil {
+		respondWithVoiceAccessError(w, complaintID, err)
+		return
+	}
+	defer stream.Body.Close()
+
+	w.Header().Set("Content-Type", stream.Info.ContentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="complaint-%d-voice-%d%s"`, complaintID, stream.Note.ID, voiceFileExt(stream.Note.FilePath)))
+	http.ServeContent(w, r, "", stream.Info.LastModified, stream.Body)
+}
+
+// respondWithVoiceAccessError maps authority voice access errors to HTTP status codes
+func respondWithVoiceAccessError(w http.ResponseWriter, complaintID int64, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not assigned"):
+		respondWithError(w, http.StatusForbidden, "Forbidden", err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		respondWithError(w, http.StatusNotFound, "Not found", err.Error())
+	default:
+		log.Printf("[voice] complaint_id=%d access failed: %v", complaintID, err)
+		respondWithError(w, http.StatusInternalServerError, "Internal error", "Failed to load voice note")
+	}
+}
+
+// voiceFileExt returns the extension of a storage key (".webm"), or ""
+func voiceFileExt(key string) string {
+	slash := strings.LastIndex(key, "/")
+	if dot := strings.LastIndex(key, "."); dot > slash {
+		return key[dot:]
+	}
+	return ""
+}
@@ -0,0 +1,217 @@
+// Package lifecycle is the single source of truth for the complaint state machine.
+//
+// Every service that changes complaints.current_status validates the move here first.
+// The table below declares, per transition, which actors may take it, whether a reason
+// is mandatory and how resolved_at / closed_at change. The same table is served to the
+// frontend (GET /api/v1/lifecycle) so it can offer only valid actions.
+package lifecycle
+
+import (
+	"database/sql"
+	"finalneta/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimestampEffect describes what a transition does to resolved_at or closed_at
+type TimestampEffect string
+
+const (
+	TimestampKeep  TimestampEffect = "keep"  // leave as is
+	TimestampSet   TimestampEffect = "set"   // set to now
+	TimestampClear TimestampEffect = "clear" // set to NULL
+)
+
+// Transition is one allowed edge of the complaint state machine
+type Transition struct {
+	From           models.ComplaintStatus `json:"from"`
+	To             models.ComplaintStatus `json:"to"`
+	Actors         []models.ActorType     `json:"actors"`
+	RequiresReason bool                   `json:"requires_reason"`
+	ResolvedAt     TimestampEffect        `json:"resolved_at"`
+	ClosedAt       TimestampEffect        `json:"closed_at"`
+	Description    string                 `json:"description"`
+}
+
+// State describes one complaint status
+type State struct {
+	Status   models.ComplaintStatus `json:"status"`
+	Terminal bool                   `json:"terminal"`
+}
+
+// Graph is the full state machine as served to clients
+type Graph struct {
+	States      []State      `json:"states"`
+	Transitions []Transition `json:"transitions"`
+}
+
+var (
+	citizen   = []models.ActorType{models.ActorUser}
+	authority = []models.ActorType{models.ActorOfficer, models.ActorAdmin}
+	system    = []models.ActorType{models.ActorSystem}
+	admin     = []models.ActorType{models.ActorAdmin}
+)
+
+// states lists every complaint status in display order
+var states = []State{
+	{Status: models.StatusDraft},
+	{Status: models.StatusSubmitted},
+	{Status: models.StatusVerified},
+	{Status: models.StatusUnderReview},
+	{Status: models.StatusInProgress},
+	{Status: models.StatusEscalated},
+	{Status: models.StatusAwaitingCitizen},
+	{Status: models.StatusResolved},
+	{Status: models.StatusRejected},
+	{Status: models.StatusClosed, Terminal: true},
+}
+
+// transitions is the complaint state machine. Unlisted moves are invalid.
+var transitions = []Transition{
+	// Citizen drafting
+	{From: models.StatusDraft, To: models.StatusDraft, Actors: citizen, Description: "Save draft"},
+	{From: models.StatusDraft, To: models.StatusSubmitted, Actors: citizen, Description: "Submit complaint"},
+	{From: models.StatusSubmitted, To: models.StatusDraft, Actors: citizen, Description: "Return to draft"},
+
+	// Verification (rule-based, system only)
+	{From: models.StatusSubmitted, To: models.StatusVerified, Actors: system, Description: "Complaint verified automatically"},
+
+	// Authority work
+	{From: models.StatusSubmitted, To: models.StatusUnderReview, Actors: authority, RequiresReason: true, Description: "Start review"},
+	{From: models.StatusVerified, To: models.StatusUnderReview, Actors: authority, RequiresReason: true, Description: "Start review"},
+	{From: models.StatusVerified, To: models.StatusInProgress, Actors: admin, RequiresReason: true, Description: "Start work"},
+	{From: models.StatusUnderReview, To: models.StatusInProgress, Actors: authority, RequiresReason: true, Description: "Start work"},
+	{From: models.StatusInProgress, To: models.StatusResolved, Actors: authority, RequiresReason: true, ResolvedAt: TimestampSet, Description: "Mark resolved"},
+	{From: models.StatusEscalated, To: models.StatusUnderReview, Actors: authority, RequiresReason: true, Description: "Pick up escalated complaint"},
+	{From: models.StatusEscalated, To: models.StatusInProgress, Actors: authority, RequiresReason: true, Description: "Start work on escalated complaint"},
+	{From: models.StatusResolved, To: models.StatusInProgress, Actors: authority, RequiresReason: true, ResolvedAt: TimestampClear, Description: "Withdraw resolution before citizen confirms"},
+
+	// Question to the citizen: the SLA clock stops until the citizen replies (back to the status the
+	// complaint had), the officer moves on without a reply, or the complaint auto-closes unanswered
+	{From: models.StatusVerified, To: models.StatusAwaitingCitizen, Actors: authority, RequiresReason: true, Description: "Ask the citizen for information"},
+	{From: models.StatusUnderReview, To: models.StatusAwaitingCitizen, Actors: authority, RequiresReason: true, Description: "Ask the citizen for information"},
+	{From: models.StatusInProgress, To: models.StatusAwaitingCitizen, Actors: authority, RequiresReason: true, Description: "Ask the citizen for information"},
+	{From: models.StatusEscalated, To: models.StatusAwaitingCitizen, Actors: authority, RequiresReason: true, Description: "Ask the citizen for information"},
+	{From: models.StatusAwaitingCitizen, To: models.StatusVerified, Actors: citizen, RequiresReason: true, Description: "Citizen replies"},
+	{From: models.StatusAwaitingCitizen, To: models.StatusUnderReview, Actors: []models.ActorType{models.ActorUser, models.ActorOfficer, models.ActorAdmin}, RequiresReason: true, Description: "Citizen replies / continue without reply"},
+	{From: models.StatusAwaitingCitizen, To: models.StatusInProgress, Actors: []models.ActorType{models.ActorUser, models.ActorOfficer, models.ActorAdmin}, RequiresReason: true, Description: "Citizen replies / continue without reply"},
+	{From: models.StatusAwaitingCitizen, To: models.StatusEscalated, Actors: citizen, RequiresReason: true, Description: "Citizen replies"},
+	{From: models.StatusAwaitingCitizen, To: models.StatusClosed, Actors: []models.ActorType{models.ActorSystem, models.ActorAdmin}, RequiresReason: true, ClosedAt: TimestampSet, Description: "Auto-close: no reply from citizen"},
+
+	// Rejection and reopen (admin only)
+	{From: models.StatusSubmitted, To: models.StatusRejected, Actors: admin, RequiresReason: true, Description: "Reject complaint"},
+	{From: models.StatusVerified, To: models.StatusRejected, Actors: admin, RequiresReason: true, Description: "Reject complaint"},
+	{From: models.StatusUnderReview, To: models.StatusRejected, Actors: admin, RequiresReason: true, Description: "Reject complaint"},
+	{From: models.StatusInProgress, To: models.StatusRejected, Actors: admin, RequiresReason: true, Description: "Reject complaint"},
+	{From: models.StatusRejected, To: models.StatusUnderReview, Actors: admin, RequiresReason: true, Description: "Reopen rejected complaint"},
+
+	// Escalation: system after an SLA breach, user = citizen request once the SLA is breached, officer = manual
+	// escalation of an assigned complaint (escalation service only; the status endpoint does not escalate)
+	{From: models.StatusVerified, To: models.StatusEscalated, Actors: []models.ActorType{models.ActorSystem, models.ActorAdmin, models.ActorUser, models.ActorOfficer}, RequiresReason: true, Description: "Escalate to the next level"},
+	{From: models.StatusUnderReview, To: models.StatusEscalated, Actors: []models.ActorType{models.ActorSystem, models.ActorAdmin, models.ActorUser, models.ActorOfficer}, RequiresReason: true, Description: "Escalate to the next level"},
+	{From: models.StatusInProgress, To: models.StatusEscalated, Actors: []models.ActorType{models.ActorSystem, models.ActorAdmin, models.ActorUser, models.ActorOfficer}, RequiresReason: true, Description: "Escalate to the next level"},
+	{From: models.StatusEscalated, To: models.StatusEscalated, Actors: authority, RequiresReason: true, Description: "Escalate further"},
+
+	// Citizen response to resolution, auto-close and closure
+	{From: models.StatusResolved, To: models.StatusClosed, Actors: []models.ActorType{models.ActorUser, models.ActorSystem, models.ActorAdmin}, ClosedAt: TimestampSet, Description: "Confirm resolution / auto-close"},
+	{From: models.StatusResolved, To: models.StatusUnderReview, Actors: citizen, RequiresReason: true, ResolvedAt: TimestampClear, Description: "Dispute resolution (reopen)"},
+	{From: models.StatusRejected, To: models.StatusClosed, Actors: []models.ActorType{models.ActorSystem, models.ActorAdmin}, ClosedAt: TimestampSet, Description: "Close rejected complaint"},
+}
+
+func init() {
+	for i := range transitions {
+		if transitions[i].ResolvedAt == "" {
+			transitions[i].ResolvedAt = TimestampKeep
+		}
+		if transitions[i].ClosedAt == "" {
+			transitions[i].ClosedAt = TimestampKeep
+		}
+	}
+}
+
+// Find returns the transition from → to, or nil if the move is not in the state machine
+func Find(from, to models.ComplaintStatus) *Transition {
+	for i := range transitions {
+		if transitions[i].From == from && transitions[i].To == to {
+			t := transitions[i]
+			return &t
+		}
+	}
+	return nil
+}
+
+// Validate checks that actor may move a complaint from → to with the given reason.
+// Error messages start with "invalid status transition" (handlers match on that prefix)
+// or "reason is required".
+func Validate(from, to models.ComplaintStatus, actor models.ActorType, reason string) (*Transition, error) {
+	t := Find(from, to)
+	if t == nil {
+		return nil, fmt.Errorf("invalid status transition from %s to %s", from, to)
+	}
+	if !t.AllowsActor(actor) {
+		return nil, fmt.Errorf("invalid status transition from %s to %s: not allowed for %s", from, to, actor)
+	}
+	if t.RequiresReason && strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("reason is required for transition from %s to %s", from, to)
+	}
+	return t, nil
+}
+
+// AllowsActor reports whether actor may take this transition
+func (t *Transition) AllowsActor(actor models.ActorType) bool {
+	for _, a := range t.Actors {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// Timestamps applies the transition's timestamp effects to the current values and returns
+// the resolved_at / closed_at to store (nil = NULL)
+func (t *Transition) Timestamps(resolvedAt, closedAt sql.NullTime, now time.Time) (*time.Time, *time.Time) {
+	return applyEffect(t.ResolvedAt, resolvedAt, now), applyEffect(t.ClosedAt, closedAt, now)
+}
+
+func applyEffect(effect TimestampEffect, current sql.NullTime, now time.Time) *time.Time {
+	switch effect {
+	case TimestampSet:
+		return &now
+	case TimestampClear:
+		return nil
+	default:
+		if current.Valid {
+			v := current.Time
+			return &v
+		}
+		return nil
+	}
+}
+
+// AllowedFrom returns the transitions out of from that actor may take.
+// An empty actor returns transitions for every actor.
+func AllowedFrom(from models.ComplaintStatus, actor models.ActorType) []Transition {
+	var out []Transition
+	for _, t := range transitions {
+		if t.From != from {
+			continue
+		}
+		if actor != "" && !t.AllowsActor(actor) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// GetGraph returns a copy of the full state machine
+func GetGraph() Graph {
+	g := Graph{
+		States:      make([]State, len(states)),
+		Transitions: make([]Transition, len(transitions)),
+	}
+	copy(g.States, states)
+	copy(g.Transitions, transitions)
+	return g
+}
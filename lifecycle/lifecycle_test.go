@@ -0,0 +1,221 @@
+package lifecycle
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"finalneta/models"
+)
+
+const (
+	user    = models.ActorUser
+	officer = models.ActorOfficer
+	adm     = models.ActorAdmin
+	sys     = models.ActorSystem
+)
+
+var allActors = []models.ActorType{user, officer, adm, sys}
+
+// want restates the state machine so any change to it has to be made here too
+var want = []struct {
+	from, to   models.ComplaintStatus
+	actors     []models.ActorType
+	reason     bool
+	resolvedAt TimestampEffect
+	closedAt   TimestampEffect
+}{
+	{models.StatusDraft, models.StatusDraft, []models.ActorType{user}, false, TimestampKeep, TimestampKeep},
+	{models.StatusDraft, models.StatusSubmitted, []models.ActorType{user}, false, TimestampKeep, TimestampKeep},
+	{models.StatusSubmitted, models.StatusDraft, []models.ActorType{user}, false, TimestampKeep, TimestampKeep},
+	{models.StatusSubmitted, models.StatusVerified, []models.ActorType{sys}, false, TimestampKeep, TimestampKeep},
+
+	{models.StatusSubmitted, models.StatusUnderReview, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusVerified, models.StatusUnderReview, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusVerified, models.StatusInProgress, []models.ActorType{adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusUnderReview, models.StatusInProgress, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusInProgress, models.StatusResolved, []models.ActorType{officer, adm}, true, TimestampSet, TimestampKeep},
+	{models.StatusEscalated, models.StatusUnderReview, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusEscalated, models.StatusInProgress, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusResolved, models.StatusInProgress, []models.ActorType{officer, adm}, true, TimestampClear, TimestampKeep},
+
+	{models.StatusVerified, models.StatusAwaitingCitizen, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusUnderReview, models.StatusAwaitingCitizen, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusInProgress, models.StatusAwaitingCitizen, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusEscalated, models.StatusAwaitingCitizen, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusAwaitingCitizen, models.StatusVerified, []models.ActorType{user}, true, TimestampKeep, TimestampKeep},
+	{models.StatusAwaitingCitizen, models.StatusUnderReview, []models.ActorType{user, officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusAwaitingCitizen, models.StatusInProgress, []models.ActorType{user, officer, adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusAwaitingCitizen, models.StatusEscalated, []models.ActorType{user}, true, TimestampKeep, TimestampKeep},
+	{models.StatusAwaitingCitizen, models.StatusClosed, []models.ActorType{sys, adm}, true, TimestampKeep, TimestampSet},
+
+	{models.StatusSubmitted, models.StatusRejected, []models.ActorType{adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusVerified, models.StatusRejected, []models.ActorType{adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusUnderReview, models.StatusRejected, []models.ActorType{adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusInProgress, models.StatusRejected, []models.ActorType{adm}, true, TimestampKeep, TimestampKeep},
+	{models.StatusRejected, models.StatusUnderReview, []models.ActorType{adm}, true, TimestampKeep, TimestampKeep},
+
+	{models.StatusVerified, models.StatusEscalated, []models.ActorType{sys, adm, user, officer}, true, TimestampKeep, TimestampKeep},
+	{models.StatusUnderReview, models.StatusEscalated, []models.ActorType{sys, adm, user, officer}, true, TimestampKeep, TimestampKeep},
+	{models.StatusInProgress, models.StatusEscalated, []models.ActorType{sys, adm, user, officer}, true, TimestampKeep, TimestampKeep},
+	{models.StatusEscalated, models.StatusEscalated, []models.ActorType{officer, adm}, true, TimestampKeep, TimestampKeep},
+
+	{models.StatusResolved, models.StatusClosed, []models.ActorType{user, sys, adm}, false, TimestampKeep, TimestampSet},
+	{models.StatusResolved, models.StatusUnderReview, []models.ActorType{user}, true, TimestampClear, TimestampKeep},
+	{models.StatusRejected, models.StatusClosed, []models.ActorType{sys, adm}, false, TimestampKeep, TimestampSet},
+}
+
+func allows(actors []models.ActorType, actor models.ActorType) bool {
+	for _, a := range actors {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range want {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			for _, actor := range allActors {
+				tr, err := Validate(tt.from, tt.to, actor, "because")
+				if !allows(tt.actors, actor) {
+					if err == nil || !strings.HasPrefix(err.Error(), "invalid status transition") {
+						t.Errorf("Validate as %s: err = %v, want invalid status transition", actor, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Validate as %s: %v", actor, err)
+				}
+				if tr.RequiresReason != tt.reason || tr.ResolvedAt != tt.resolvedAt || tr.ClosedAt != tt.closedAt {
+					t.Errorf("transition = reason %v, resolved_at %s, closed_at %s; want %v, %s, %s",
+						tr.RequiresReason, tr.ResolvedAt, tr.ClosedAt, tt.reason, tt.resolvedAt, tt.closedAt)
+				}
+
+				for _, reason := range []string{"", "  \n"} {
+					_, err := Validate(tt.from, tt.to, actor, reason)
+					if tt.reason {
+						if err == nil || !strings.HasPrefix(err.Error(), "reason is required") {
+							t.Errorf("Validate as %s with reason %q: err = %v, want reason is required", actor, reason, err)
+						}
+					} else if err != nil {
+						t.Errorf("Validate as %s without reason: %v", actor, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRejectsUnlistedMoves(t *testing.T) {
+	listed := make(map[[2]models.ComplaintStatus]bool)
+	for _, tt := range want {
+		listed[[2]models.ComplaintStatus{tt.from, tt.to}] = true
+	}
+	if len(transitions) != len(want) {
+		t.Errorf("state machine has %d transitions, test lists %d", len(transitions), len(want))
+	}
+
+	for _, from := range states {
+		for _, to := range states {
+			if listed[[2]models.ComplaintStatus{from.Status, to.Status}] {
+				continue
+			}
+			for _, actor := range allActors {
+				if _, err := Validate(from.Status, to.Status, actor, "because"); err == nil || !strings.HasPrefix(err.Error(), "invalid status transition") {
+					t.Errorf("Validate(%s -> %s as %s): err = %v, want invalid status transition", from.Status, to.Status, actor, err)
+				}
+			}
+		}
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-48 * time.Hour)
+	set := sql.NullTime{Time: earlier, Valid: true}
+
+	check := func(t *testing.T, name string, effect TimestampEffect, current sql.NullTime, got *time.Time) {
+		t.Helper()
+		var want *time.Time
+		switch effect {
+		case TimestampSet:
+			want = &now
+		case TimestampKeep:
+			if current.Valid {
+				want = &current.Time
+			}
+		}
+		if (got == nil) != (want == nil) || (got != nil && !got.Equal(*want)) {
+			t.Errorf("%s (%s, current %v) = %v, want %v", name, effect, current, got, want)
+		}
+	}
+
+	for _, tt := range want {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			tr := Find(tt.from, tt.to)
+			if tr == nil {
+				t.Fatal("transition not found")
+			}
+			for _, current := range []sql.NullTime{{}, set} {
+				resolvedAt, closedAt := tr.Timestamps(current, current, now)
+				check(t, "resolved_at", tt.resolvedAt, current, resolvedAt)
+				check(t, "closed_at", tt.closedAt, current, closedAt)
+			}
+		})
+	}
+}
+
+func TestAllowedFrom(t *testing.T) {
+	targets := func(ts []Transition) []models.ComplaintStatus {
+		out := make([]models.ComplaintStatus, len(ts))
+		for i, t := range ts {
+			out[i] = t.To
+		}
+		return out
+	}
+
+	tests := []struct {
+		from  models.ComplaintStatus
+		actor models.ActorType
+		want  []models.ComplaintStatus
+	}{
+		{models.StatusResolved, user, []models.ComplaintStatus{models.StatusClosed, models.StatusUnderReview}},
+		{models.StatusResolved, officer, []models.ComplaintStatus{models.StatusInProgress}},
+		{models.StatusResolved, sys, []models.ComplaintStatus{models.StatusClosed}},
+		{models.StatusResolved, "", []models.ComplaintStatus{models.StatusInProgress, models.StatusClosed, models.StatusUnderReview}},
+		{models.StatusSubmitted, sys, []models.ComplaintStatus{models.StatusVerified}},
+		{models.StatusAwaitingCitizen, officer, []models.ComplaintStatus{models.StatusUnderReview, models.StatusInProgress}},
+		{models.StatusEscalated, user, nil},
+		{models.StatusClosed, "", nil},
+	}
+	for _, tt := range tests {
+		got := targets(AllowedFrom(tt.from, tt.actor))
+		if len(got) != len(tt.want) {
+			t.Errorf("AllowedFrom(%s, %q) = %v, want %v", tt.from, tt.actor, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("AllowedFrom(%s, %q) = %v, want %v", tt.from, tt.actor, got, tt.want)
+				break
+			}
+		}
+	}
+
+	// Every outgoing transition is listed for its actors, and terminal states have none
+	for _, s := range states {
+		for _, actor := range allActors {
+			for _, tr := range AllowedFrom(s.Status, actor) {
+				if _, err := Validate(tr.From, tr.To, actor, "because"); err != nil {
+					t.Errorf("AllowedFrom(%s, %s) offers %s, but Validate: %v", s.Status, actor, tr.To, err)
+				}
+			}
+		}
+		if s.Terminal && len(AllowedFrom(s.Status, "")) != 0 {
+			t.Errorf("terminal state %s has outgoing transitions", s.Status)
+		}
+	}
+}
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"finalneta/config"
 	"finalneta/repository"
 	"finalneta/routes"
 	"finalneta/schema"
 	"finalneta/service"
+	"finalneta/storage"
 	"finalneta/worker"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -20,6 +26,10 @@ import (
 )
 
 func main() {
+	// SIGINT/SIGTERM (Ctrl-C, container stop) start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found, using environment variables")
@@ -129,6 +139,36 @@ func main() {
 
 	// Safe DB init: create only missing tables (users, complaints, complaint_status_history), then ensure columns
 	schema.InitializeDatabase(db)
+	// Everything else comes from migrations/; refuse to start if one has not been applied
+	schema.ValidateRequiredColumns(db, nil)
+
+	// File storage: attachments, voice notes and exports (STORAGE_BACKEND=local|s3)
+	var blob storage.Blob
+	switch cfg.Storage.Backend {
+	case "s3":
+		s3Blob, err := storage.NewS3(storage.S3Config{
+			Endpoint:        cfg.Storage.S3Endpoint,
+			Region:          cfg.Storage.S3Region,
+			Bucket:          cfg.Storage.S3Bucket,
+			AccessKeyID:     cfg.Storage.S3AccessKeyID,
+			SecretAccessKey: cfg.Storage.S3SecretKey,
+			ForcePathStyle:  cfg.Storage.S3ForcePathStyle,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure S3 storage: %v", err)
+		}
+		blob = s3Blob
+		log.Printf("Storage: s3 bucket %s at %s", cfg.Storage.S3Bucket, cfg.Storage.S3Endpoint)
+	case "local", "":
+		localBlob, err := storage.NewLocal(cfg.Storage.UploadBasePath, cfg.Storage.PublicBaseURL, cfg.Storage.SigningSecret)
+		if err != nil {
+			log.Fatalf("Failed to configure local storage: %v", err)
+		}
+		blob = localBlob
+		log.Printf("Storage: local directory %s", cfg.Storage.UploadBasePath)
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND %q (use local or s3)", cfg.Storage.Backend)
+	}
 
 	// Initialize repositories
 	complaintRepo := repository.NewComplaintRepository(db)
@@ -141,27 +181,55 @@ func main() {
 	emailLogRepo := repository.NewEmailLogRepository(db)
 	pilotMetricsRepo := repository.NewPilotMetricsRepository(db)
 	voiceNoteRepo := repository.NewVoiceNoteRepository(db)
+	evidenceRepo := repository.NewEvidenceRepository(db)
+	photoReuseRepo := repository.NewPhotoReuseRepository(db)
+	slaCalendarRepo := repository.NewSLACalendarRepository(db)
+	slaPolicyRepo := repository.NewSLAPolicyRepository(db)
+	workerLeaseRepo := repository.NewWorkerLeaseRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	assignmentRepo := repository.NewAssignmentRepository(db)
+	officerAbsenceRepo := repository.NewOfficerAbsenceRepository(db)
 
 	// Initialize services
 	userService := service.NewUserService(userRepo) // ISSUE 1 & 2: User service
 	emailShadowService := service.NewEmailShadowService(emailLogRepo)
 	pilotMetricsService := service.NewPilotMetricsService(pilotMetricsRepo)
-	complaintService := service.NewComplaintService(complaintRepo, departmentRepo, emailShadowService, pilotMetricsService)
 	verificationService := service.NewVerificationService(
 		complaintRepo,
 		verificationRepo,
 		nil, // Use default config
 	)
+	slaCalendarService := service.NewSLACalendarService(slaCalendarRepo)
+	slaPolicyService := service.NewSLAPolicyService(slaPolicyRepo)
+	departmentStrategies := make(map[int64]string)
+	for key, strategy := range cfg.Assignment.DepartmentStrategies {
+		departmentID, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || departmentID <= 0 {
+			log.Fatalf("ASSIGNMENT_STRATEGY_%s: suffix must be a department ID", strings.ToUpper(key))
+		}
+		departmentStrategies[departmentID] = strategy
+	}
+	// Officer leave: new assignments and escalations for an absent officer go to their delegate
+	officerAbsenceService := service.NewOfficerAbsenceService(officerAbsenceRepo, authorityRepo, complaintRepo)
+	assignmentService, err := service.NewAssignmentService(assignmentRepo, officerAbsenceService, cfg.Assignment.DefaultStrategy, departmentStrategies)
+	if err != nil {
+		log.Fatalf("Invalid assignment configuration: %v", err)
+	}
 	escalationService := service.NewEscalationService(
 		complaintRepo,
 		escalationRepo,
 		verificationRepo,
 		emailShadowService,
 		pilotMetricsService,
+		slaCalendarService,
+		slaPolicyService,
+		assignmentService,
+		officerAbsenceService,
 		cfg.Pilot.DryRun,
 		cfg.Pilot.DryRunSLAOverrideMinutes,
 		cfg.Pilot.TestEscalationOverrideMinutes,
 	)
+	complaintService := service.NewComplaintService(complaintRepo, departmentRepo, evidenceRepo, emailShadowService, pilotMetricsService, escalationService, assignmentService, blob)
 	notificationService := service.NewNotificationService(
 		notificationRepo,
 		complaintRepo,
@@ -182,19 +250,64 @@ func main() {
 	} else if cfg.Pilot.EscalationWorkerIntervalSeconds > 0 {
 		intervalSeconds = cfg.Pilot.EscalationWorkerIntervalSeconds
 	}
-	escalationWorker := worker.NewEscalationWorker(
-		escalationService,
-		time.Duration(intervalSeconds)*time.Second,
-	)
+	// Background jobs start in every instance: exclusive jobs hold a worker_leases row per run and
+	// notifications are claimed per batch, so replicas never double-escalate or double-send.
+	// Runs are recorded in job_runs; /api/v1/admin/jobs pauses, resumes and triggers them.
+	workerLeaseService := service.NewWorkerLeaseService(workerLeaseRepo, cfg.Server.InstanceID)
+	log.Printf("Worker instance ID: %s", workerLeaseService.InstanceID())
+	jobManager := worker.NewManager(jobRepo, workerLeaseService)
+	jitter := time.Duration(cfg.Jobs.JitterSeconds) * time.Second
+	registerJob := func(job *worker.Job) {
+		// JOB_SCHEDULE_<NAME> overrides the default schedule
+		if override, ok := cfg.Jobs.Schedules[job.Name]; ok {
+			schedule, err := worker.ParseSchedule(override)
+			if err != nil {
+				log.Fatalf("JOB_SCHEDULE_%s: %v", strings.ToUpper(job.Name), err)
+			}
+			job.Schedule = schedule
+		}
+		job.Jitter = jitter
+		if err := jobManager.Register(job); err != nil {
+			log.Fatalf("Failed to register job: %v", err)
+		}
+	}
+
 	log.Printf("Escalation worker interval: %d seconds", intervalSeconds)
 	log.Printf("Reason: %s", intervalReason)
-	escalationWorker.Start()
+	registerJob(worker.NewEscalationJob(escalationService, worker.Every(time.Duration(intervalSeconds)*time.Second)))
+	registerJob(worker.NewNotificationJob(notificationService, worker.Every(30*time.Second)))
 
-	notificationWorker := worker.NewNotificationWorker(
-		notificationService,
-		30*time.Second, // Process every 30 seconds
-	)
-	notificationWorker.Start()
+	// Auto-close: resolved → closed after the citizen confirmation window,
+	// awaiting_citizen → closed when the officer's question goes unanswered
+	autoCloseService := service.NewAutoCloseService(complaintRepo, pilotMetricsService, cfg.Pilot.AutoCloseResolvedDays, cfg.Pilot.AwaitingCitizenAutoCloseDays)
+	autoCloseIntervalSeconds := cfg.Pilot.AutoCloseWorkerIntervalSeconds
+	if autoCloseIntervalSeconds <= 0 {
+		autoCloseIntervalSeconds = 3600
+	}
+	if autoCloseService.Enabled() {
+		registerJob(worker.NewAutoCloseJob(autoCloseService, worker.Every(time.Duration(autoCloseIntervalSeconds)*time.Second)))
+	} else {
+		log.Println("Auto-close job disabled (AUTO_CLOSE_RESOLVED_DAYS=0 and AWAITING_CITIZEN_AUTO_CLOSE_DAYS=0)")
+	}
+
+	// Evidence: photo uploads write the hash; the sweep re-verifies stored files
+	attachmentService := service.NewAttachmentService(complaintRepo, evidenceRepo, photoReuseRepo, blob)
+	photoReuseService := service.NewPhotoReuseService(photoReuseRepo, complaintRepo)
+	voiceNoteService := service.NewVoiceNoteService(voiceNoteRepo, complaintRepo, authorityRepo, officerAbsenceService, blob)
+	escalationRuleService := service.NewEscalationRuleService(escalationRepo, complaintRepo, slaPolicyService)
+	evidenceService := service.NewEvidenceService(evidenceRepo, complaintRepo, officerAbsenceService, blob)
+	if cfg.Pilot.EvidenceSweepIntervalSeconds > 0 {
+		registerJob(worker.NewEvidenceIntegrityJob(evidenceService, worker.Every(time.Duration(cfg.Pilot.EvidenceSweepIntervalSeconds)*time.Second)))
+	} else {
+		log.Println("Evidence integrity job disabled (EVIDENCE_SWEEP_INTERVAL_SECONDS=0)")
+	}
+
+	// job_runs history is trimmed nightly (JOB_RUN_RETENTION_DAYS=0 keeps everything)
+	if cfg.Jobs.RunRetentionDays > 0 {
+		pruneSchedule, _ := worker.ParseSchedule("15 3 * * *")
+		registerJob(worker.NewJobRunsPruneJob(jobRepo, time.Duration(cfg.Jobs.RunRetentionDays)*24*time.Hour, pruneSchedule))
+	}
+	jobManager.Start()
 
 	// Initialize abuse prevention service
 	abusePreventionRepo := repository.NewAbusePreventionRepository(db)
@@ -205,14 +318,21 @@ func main() {
 		complaintService,
 		verificationService,
 		escalationService,
-		escalationWorker,
+		jobManager,
 		userService,
 		complaintRepo,
 		authorityRepo,
+		escalationRepo,
 		abusePreventionService,
 		emailShadowService,
 		pilotMetricsService,
-		voiceNoteRepo,
+		attachmentService,
+		evidenceService,
+		photoReuseService,
+		voiceNoteService,
+		escalationRuleService,
+		officerAbsenceService,
+		blob,
 	)
 
 	// Add CORS middleware
@@ -222,7 +342,8 @@ func main() {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, PUT, DELETE, OPTIONS")
 			// CRITICAL: Include Authorization header for JWT token authentication
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID, X-Actor-Type, X-Officer-ID")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID, X-Actor-Type, X-Officer-ID, If-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
 			w.Header().Set("Access-Control-Max-Age", "3600")
 
 			// Handle preflight requests
@@ -240,6 +361,36 @@ func main() {
 
 	// Start server
 	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
-	log.Printf("Server starting on %s", addr)
-	log.Fatal(http.ListenAndServe(addr, handler))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       2 * time.Minute, // uploads (multipart attachments, voice notes)
+		WriteTimeout:      2 * time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on %s", addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		jobManager.Stop()
+		log.Fatalf("Server failed: %v", err)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process
+
+	// Graceful shutdown: stop accepting requests and let in-flight ones finish, then cancel the
+	// jobs; a running escalation cycle or notification batch finishes the item it is on
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP shutdown: %v", err)
+	}
+	jobManager.Stop()
+	log.Println("Server stopped")
 }
@@ -83,13 +83,13 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 
 		// Verify user exists and phone is verified
 		if m.userService != nil {
-			exists, err := m.userService.VerifyUserExists(userID)
+			exists, err := m.userService.VerifyUserExists(r.Context(), userID)
 			if err != nil || !exists {
 				respondWithError(w, http.StatusUnauthorized, "Unauthorized", "User not found. Please verify your phone number first.")
 				return
 			}
 
-			phoneVerified, err := m.userService.VerifyUserPhoneVerified(userID)
+			phoneVerified, err := m.userService.VerifyUserPhoneVerified(r.Context(), userID)
 			if err != nil || !phoneVerified {
 				respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Phone not verified. Please verify your phone number first.")
 				return
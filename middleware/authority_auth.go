@@ -89,7 +89,7 @@ func (m *AuthorityAuthMiddleware) RequireAuthorityAuth(next http.Handler) http.H
 		}
 
 		if m.authorityService != nil {
-			exists, err := m.authorityService.VerifyOfficerExists(officerID)
+			exists, err := m.authorityService.VerifyOfficerExists(r.Context(), officerID)
 			if err != nil || !exists {
 				respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Officer not found or inactive")
 				return
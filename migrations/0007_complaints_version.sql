@@ -0,0 +1,4 @@
+-- Optimistic concurrency: every status write bumps complaints.version and requires the version it read.
+-- Authority API exposes it as ETag / If-Match. Skip if version already exists (schema init adds it too).
+
+ALTER TABLE complaints ADD COLUMN version INT NOT NULL DEFAULT 0 COMMENT 'Optimistic concurrency version' AFTER supporter_count;
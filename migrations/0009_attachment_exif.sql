@@ -0,0 +1,7 @@
+-- EXIF read from uploaded JPEGs (capture time, GPS, camera model). Used by the verification EXIF consistency rule.
+
+ALTER TABLE complaint_attachments
+    ADD COLUMN exif_captured_at DATETIME NULL COMMENT 'EXIF DateTimeOriginal (UTC)' AFTER is_public,
+    ADD COLUMN exif_latitude DECIMAL(10, 8) NULL COMMENT 'EXIF GPS latitude' AFTER exif_captured_at,
+    ADD COLUMN exif_longitude DECIMAL(11, 8) NULL COMMENT 'EXIF GPS longitude' AFTER exif_latitude,
+    ADD COLUMN exif_camera_model VARCHAR(255) NULL COMMENT 'EXIF camera make/model' AFTER exif_longitude;
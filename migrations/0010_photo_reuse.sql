@@ -0,0 +1,28 @@
+-- Recycled photo detection: perceptual hash (dHash) per attachment and an admin review list of matches.
+-- Matches are never auto-rejected; an admin confirms or dismisses each flag.
+
+ALTER TABLE complaint_attachments
+    ADD COLUMN perceptual_hash BIGINT NULL COMMENT '64-bit dHash (JPEG/PNG); compare with BIT_COUNT(a ^ b)' AFTER exif_camera_model,
+    ADD INDEX idx_perceptual_hash (perceptual_hash);
+
+CREATE TABLE IF NOT EXISTS photo_reuse_flags (
+    flag_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    complaint_id BIGINT NOT NULL COMMENT 'Complaint that reused the photo',
+    attachment_id BIGINT NOT NULL COMMENT 'New attachment',
+    matched_complaint_id BIGINT NOT NULL COMMENT 'Earlier complaint with a near-identical photo',
+    matched_attachment_id BIGINT NOT NULL COMMENT 'Earlier attachment',
+    hamming_distance TINYINT NOT NULL COMMENT 'Differing dHash bits (0 = identical)',
+    different_user BOOLEAN NOT NULL COMMENT 'Earlier complaint filed by another user',
+    different_device BOOLEAN NOT NULL COMMENT 'Earlier complaint has another device fingerprint',
+    status ENUM('pending', 'confirmed', 'dismissed') NOT NULL DEFAULT 'pending' COMMENT 'Admin review state',
+    review_note TEXT NULL COMMENT 'Admin note',
+    reviewed_at TIMESTAMP NULL COMMENT 'When an admin reviewed the flag',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (complaint_id) REFERENCES complaints(complaint_id) ON DELETE CASCADE,
+    FOREIGN KEY (attachment_id) REFERENCES complaint_attachments(attachment_id) ON DELETE CASCADE,
+    FOREIGN KEY (matched_complaint_id) REFERENCES complaints(complaint_id) ON DELETE CASCADE,
+    FOREIGN KEY (matched_attachment_id) REFERENCES complaint_attachments(attachment_id) ON DELETE CASCADE,
+    UNIQUE KEY uk_attachment_match (attachment_id, matched_attachment_id),
+    INDEX idx_status_created (status, created_at),
+    INDEX idx_complaint_id (complaint_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,7 @@
+-- Voice notes: several timestamped clips per complaint instead of one overwritten note.
+-- Existing rows stay as each complaint's first clip. duration_seconds is now always filled on upload.
+
+ALTER TABLE complaint_voice_notes
+    DROP INDEX uk_complaint_voice,
+    ADD COLUMN file_size BIGINT NULL COMMENT 'Bytes' AFTER mime_type,
+    ADD INDEX idx_complaint_created (complaint_id, created_at);
@@ -0,0 +1,30 @@
+-- SLA calendar: escalation time conditions count only working time (office hours on working days,
+-- excluding gazetted holidays). One calendar per district; the row with location_id NULL is the default.
+
+CREATE TABLE IF NOT EXISTS sla_calendars (
+    calendar_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    location_id BIGINT NULL COMMENT 'District (complaints.location_id); NULL = default for all districts',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Kolkata' COMMENT 'IANA time zone of the office hours',
+    work_start TIME NOT NULL DEFAULT '10:00:00' COMMENT 'Office opens (local time)',
+    work_end TIME NOT NULL DEFAULT '18:00:00' COMMENT 'Office closes (local time); 24:00:00 = midnight',
+    working_days SET('mon', 'tue', 'wed', 'thu', 'fri', 'sat', 'sun') NOT NULL DEFAULT 'mon,tue,wed,thu,fri' COMMENT 'Working weekdays',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_location_id (location_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS sla_holidays (
+    holiday_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    location_id BIGINT NULL COMMENT 'District (complaints.location_id); NULL = state-wide holiday',
+    holiday_date DATE NOT NULL COMMENT 'Local date in the calendar time zone',
+    name VARCHAR(255) NOT NULL COMMENT 'As gazetted, e.g. Independence Day',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_location_date (location_id, holiday_date),
+    INDEX idx_holiday_date (holiday_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Default calendar (MySQL allows several NULLs in a UNIQUE key, so guard the seed explicitly)
+INSERT INTO sla_calendars (location_id, timezone, work_start, work_end, working_days)
+SELECT NULL, 'Asia/Kolkata', '10:00:00', '18:00:00', 'mon,tue,wed,thu,fri' FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM sla_calendars WHERE location_id IS NULL);
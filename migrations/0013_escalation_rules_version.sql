@@ -0,0 +1,7 @@
+-- Escalation rules admin API: every change bumps escalation_rules.version (If-Match on updates) and is
+-- written to audit_log (entity_type 'escalation_rule') with the old and new rule, one row per version.
+-- Requires database_migration_escalation_rules_null_dept.sql (to_department_id NULL = same department).
+-- Skip if version already exists.
+
+ALTER TABLE escalation_rules
+    ADD COLUMN version INT NOT NULL DEFAULT 1 COMMENT 'Rule version; bumped on every admin change' AFTER is_active;
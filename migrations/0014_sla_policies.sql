@@ -0,0 +1,44 @@
+-- SLA policies: SLA hours per priority x escalation level (x optional complaint category) in one place.
+-- Escalation rules reference a policy with conditions.time_based.sla_policy_id instead of fixed sla_hours,
+-- so "urgent = 4h at L1, 12h at L2; low = 72h" is one policy, not one rule per priority.
+
+CREATE TABLE IF NOT EXISTS sla_policies (
+    policy_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL COMMENT 'Shown in escalation reasons, e.g. standard',
+    description VARCHAR(500) NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE COMMENT 'Rules referencing an inactive policy never fall due',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS sla_policy_targets (
+    target_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    policy_id BIGINT NOT NULL,
+    priority ENUM('low', 'medium', 'high', 'urgent') NOT NULL COMMENT 'complaints.priority',
+    escalation_level INT NOT NULL COMMENT 'Current level before escalation (0 = L1, 1 = L2), as in escalation_rules',
+    category VARCHAR(100) NOT NULL DEFAULT '' COMMENT 'complaints.category; empty = any category (a category match wins)',
+    sla_hours INT NOT NULL COMMENT 'Working hours since the last status change',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (policy_id) REFERENCES sla_policies(policy_id) ON DELETE CASCADE,
+    UNIQUE KEY uk_policy_target (policy_id, priority, escalation_level, category)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Example policy (not referenced by any rule until an admin points a rule at it)
+INSERT IGNORE INTO sla_policies (name, description)
+VALUES ('standard', 'Pilot SLA matrix: urgent 4h/12h, high 24h/48h, medium and low 72h/120h');
+
+INSERT IGNORE INTO sla_policy_targets (policy_id, priority, escalation_level, sla_hours)
+SELECT p.policy_id, t.priority, t.escalation_level, t.sla_hours
+FROM sla_policies p
+JOIN (
+    SELECT 'urgent' AS priority, 0 AS escalation_level, 4 AS sla_hours
+    UNION ALL SELECT 'urgent', 1, 12
+    UNION ALL SELECT 'high', 0, 24
+    UNION ALL SELECT 'high', 1, 48
+    UNION ALL SELECT 'medium', 0, 72
+    UNION ALL SELECT 'medium', 1, 120
+    UNION ALL SELECT 'low', 0, 72
+    UNION ALL SELECT 'low', 1, 120
+) t
+WHERE p.name = 'standard';
@@ -0,0 +1,25 @@
+-- awaiting_citizen: an officer asks the citizen a question and the SLA clock stops until the citizen replies
+-- (the complaint then returns to the status it had). Unanswered questions auto-close the complaint after
+-- AWAITING_CITIZEN_AUTO_CLOSE_DAYS.
+
+ALTER TABLE complaints
+    MODIFY current_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NOT NULL DEFAULT 'draft' COMMENT 'Current status';
+
+ALTER TABLE complaint_status_history
+    MODIFY old_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NULL COMMENT 'Previous status',
+    MODIFY new_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NOT NULL COMMENT 'New status';
+
+CREATE TABLE IF NOT EXISTS complaint_clarifications (
+    clarification_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    complaint_id BIGINT NOT NULL,
+    asked_by_officer_id BIGINT NULL COMMENT 'Officer who asked',
+    question TEXT NOT NULL,
+    return_status VARCHAR(32) NOT NULL COMMENT 'Status before awaiting_citizen; restored when the citizen replies',
+    status ENUM('open', 'answered', 'withdrawn', 'expired') NOT NULL DEFAULT 'open',
+    answer TEXT NULL COMMENT 'Citizen reply',
+    asked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP NULL COMMENT 'Answered, withdrawn or expired',
+    FOREIGN KEY (complaint_id) REFERENCES complaints(complaint_id) ON DELETE CASCADE,
+    INDEX idx_complaint_status (complaint_id, status),
+    INDEX idx_status_asked_at (status, asked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
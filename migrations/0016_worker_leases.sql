@@ -0,0 +1,17 @@
+-- Multi-instance workers: every server process starts the escalation and notification workers.
+-- worker_leases: one row per background job; the instance holding an unexpired lease runs it, the others skip.
+-- notifications_log.claimed_by / claim_expires_at: each notification worker claims its own batch; claims of a
+-- worker that died expire and the rows are picked up again.
+-- Skip the ALTER if claimed_by already exists.
+
+CREATE TABLE IF NOT EXISTS worker_leases (
+    lease_name VARCHAR(64) PRIMARY KEY COMMENT 'Background job, e.g. escalation',
+    holder VARCHAR(128) NOT NULL COMMENT 'INSTANCE_ID of the server process holding the lease',
+    acquired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL COMMENT 'Lease is free after this time (holder crashed or released it)'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE notifications_log
+    ADD COLUMN claimed_by VARCHAR(64) NULL COMMENT 'Claim token of the worker batch sending it' AFTER error_message,
+    ADD COLUMN claim_expires_at TIMESTAMP NULL COMMENT 'Claim is void after this time' AFTER claimed_by,
+    ADD INDEX idx_status_claim (status, claim_expires_at);
@@ -0,0 +1,31 @@
+-- Worker control plane: every background job (escalation, notification, auto_close, evidence_integrity,
+-- job_runs_prune) registers in worker_jobs and records each run in job_runs.
+-- worker_jobs.paused is shared by all instances: a paused job skips scheduled runs everywhere
+-- (admin run-now still works). Rows are created by the server on startup.
+
+CREATE TABLE IF NOT EXISTS worker_jobs (
+    job_name VARCHAR(64) PRIMARY KEY,
+    schedule VARCHAR(100) NOT NULL COMMENT 'Interval (e.g. 30s) or 5-field cron expression',
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    paused_at TIMESTAMP NULL,
+    pause_reason VARCHAR(255) NULL,
+    last_run_at TIMESTAMP NULL,
+    last_run_status VARCHAR(16) NULL COMMENT 'succeeded or failed',
+    next_run_at TIMESTAMP NULL COMMENT 'Next scheduled run of the instance that ran last',
+    updated_at TIMESTAMP NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    run_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_name VARCHAR(64) NOT NULL,
+    instance_id VARCHAR(128) NOT NULL COMMENT 'INSTANCE_ID of the server process that ran it',
+    trigger_type ENUM('schedule', 'manual') NOT NULL,
+    status ENUM('running', 'succeeded', 'failed') NOT NULL DEFAULT 'running' COMMENT 'running = in progress or the instance died',
+    counts JSON NULL COMMENT 'Outcome counts, e.g. {"escalated": 2, "reminders": 5}',
+    error_message TEXT NULL COMMENT 'Error or recovered panic',
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL,
+    duration_ms BIGINT NULL,
+    INDEX idx_job_started (job_name, started_at),
+    INDEX idx_started_at (started_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
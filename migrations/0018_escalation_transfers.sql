@@ -0,0 +1,6 @@
+-- Officer transfers: a transfer to another department/location is stored in complaint_escalations like an
+-- escalation (from/to department and officer, reason, escalated_by_officer_id) but keeps the escalation level.
+-- escalation_type tells them apart: transfers never count as SLA escalations (engine idempotency, pilot metrics).
+
+ALTER TABLE complaint_escalations
+    ADD COLUMN escalation_type ENUM('escalation', 'transfer') NOT NULL DEFAULT 'escalation' COMMENT 'transfer = officer moved the complaint to another department/location at the same level' AFTER escalation_level;
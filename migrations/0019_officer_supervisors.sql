@@ -0,0 +1,30 @@
+-- Escalation hierarchy: escalations go to an officer by authority_level (1 = L1, 2 = L2, 3 = L3) and,
+-- first, up the officer's reporting line (supervisor_officer_id). The employee_id pattern (PWD-L2-001)
+-- is no longer used for lookup; this migration copies it into authority_level once for existing officers.
+-- Run after 0003_officers_authority_level.sql.
+
+ALTER TABLE officers
+    ADD COLUMN supervisor_officer_id BIGINT NULL COMMENT 'Officer this officer reports to (escalations go here first)' AFTER authority_level,
+    ADD INDEX idx_supervisor_officer_id (supervisor_officer_id),
+    ADD INDEX idx_escalation_lookup (department_id, location_id, authority_level, is_active),
+    ADD CONSTRAINT fk_officers_supervisor FOREIGN KEY (supervisor_officer_id) REFERENCES officers(officer_id) ON DELETE SET NULL;
+
+-- Officers seeded before authority_level was used kept the default (1)
+UPDATE officers SET authority_level = 2 WHERE authority_level = 1 AND employee_id LIKE '%-L2-%';
+UPDATE officers SET authority_level = 3 WHERE authority_level = 1 AND employee_id LIKE '%-L3-%';
+
+-- Default reporting line: each L1/L2 officer reports to the lowest-numbered active officer one level up
+-- in the same department and location. Adjust per officer with PUT /api/v1/admin/authorities/{officer_id}.
+UPDATE officers o
+JOIN (
+    SELECT o2.officer_id, MIN(s.officer_id) AS supervisor_id
+    FROM officers o2
+    JOIN officers s
+        ON s.department_id = o2.department_id
+        AND s.location_id = o2.location_id
+        AND s.authority_level = o2.authority_level + 1
+        AND s.is_active = TRUE
+    WHERE o2.supervisor_officer_id IS NULL
+    GROUP BY o2.officer_id
+) m ON m.officer_id = o.officer_id
+SET o.supervisor_officer_id = m.supervisor_id;
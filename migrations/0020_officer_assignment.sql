@@ -0,0 +1,24 @@
+-- Officer assignment strategies (ASSIGNMENT_STRATEGY, ASSIGNMENT_STRATEGY_<DEPARTMENT_ID>): inputs the strategies
+-- read beyond officers.authority_level. nearest uses the officer's office coordinates, category uses the
+-- categories an officer handles, round_robin keeps one cursor per department and location.
+
+ALTER TABLE officers
+    ADD COLUMN latitude DECIMAL(10, 8) NULL COMMENT 'Office coordinates (nearest strategy)' AFTER supervisor_officer_id,
+    ADD COLUMN longitude DECIMAL(11, 8) NULL COMMENT 'Office coordinates (nearest strategy)' AFTER latitude;
+
+CREATE TABLE IF NOT EXISTS officer_categories (
+    officer_id BIGINT NOT NULL,
+    category VARCHAR(100) NOT NULL COMMENT 'complaints.category the officer handles (category strategy)',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (officer_id, category),
+    INDEX idx_category (category),
+    CONSTRAINT fk_officer_categories_officer FOREIGN KEY (officer_id) REFERENCES officers(officer_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS assignment_cursors (
+    department_id BIGINT NOT NULL,
+    location_id BIGINT NOT NULL,
+    last_officer_id BIGINT NOT NULL COMMENT 'Officer the last round_robin assignment went to',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (department_id, location_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
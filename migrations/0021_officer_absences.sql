@@ -0,0 +1,27 @@
+-- Officer leave and delegation: an absence window names a delegate officer. While it is in effect, new
+-- assignments and escalation lookups that land on the absent officer go to the delegate, and the delegate
+-- works the absent officer's open complaints. What the delegate does is recorded as theirs, on behalf of
+-- the absent officer (on_behalf_of_officer_id).
+-- Skip complaint_status_history.on_behalf_of_officer_id if it already exists (schema init adds it too).
+
+CREATE TABLE IF NOT EXISTS officer_absences (
+    absence_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    officer_id BIGINT NOT NULL COMMENT 'Officer on leave',
+    delegate_officer_id BIGINT NOT NULL COMMENT 'Officer covering for them during the window',
+    starts_at DATETIME NOT NULL COMMENT 'UTC',
+    ends_at DATETIME NOT NULL COMMENT 'UTC; the absence is in effect while starts_at <= now < ends_at',
+    reason VARCHAR(500) NULL,
+    created_by_type ENUM('officer', 'admin') NOT NULL COMMENT 'Recorded by the officer themselves or an admin',
+    cancelled_at DATETIME NULL COMMENT 'Cancelled absences are kept for the record and never in effect',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_officer_window (officer_id, starts_at, ends_at),
+    INDEX idx_delegate_window (delegate_officer_id, starts_at, ends_at),
+    CONSTRAINT fk_officer_absences_officer FOREIGN KEY (officer_id) REFERENCES officers(officer_id) ON DELETE CASCADE,
+    CONSTRAINT fk_officer_absences_delegate FOREIGN KEY (delegate_officer_id) REFERENCES officers(officer_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE complaint_status_history
+    ADD COLUMN on_behalf_of_officer_id BIGINT NULL COMMENT 'Absent officer the acting delegate stood in for' AFTER actor_id;
+
+ALTER TABLE authority_notes
+    ADD COLUMN on_behalf_of_officer_id BIGINT NULL COMMENT 'Absent officer the note was written for by their delegate' AFTER officer_id;
@@ -0,0 +1,6 @@
+-- Whether a photo's EXIF capture time carried its UTC offset (OffsetTimeOriginal). Without it the camera-local
+-- time is stored as if it were UTC, so the verification rule for future capture times allows for the offset.
+-- Existing rows stay FALSE (offset unknown).
+
+ALTER TABLE complaint_attachments
+    ADD COLUMN exif_time_zoned BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'EXIF time had OffsetTimeOriginal; FALSE = camera-local time stored as UTC' AFTER exif_captured_at;
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// OfficerAbsence is a leave window during which an officer's work goes to a delegate (officer_absences).
+// In effect while StartsAt <= now < EndsAt and not cancelled.
+type OfficerAbsence struct {
+	AbsenceID         int64
+	OfficerID         int64
+	DelegateOfficerID int64
+	StartsAt          time.Time // UTC
+	EndsAt            time.Time // UTC
+	Reason            sql.NullString
+	CreatedByType     ActorType // officer (their own leave) or admin
+	CancelledAt       sql.NullTime
+	CreatedAt         time.Time
+}
+
+// ActiveAt reports whether the absence is in effect at t
+func (a *OfficerAbsence) ActiveAt(t time.Time) bool {
+	return !a.CancelledAt.Valid && !t.Before(a.StartsAt) && t.Before(a.EndsAt)
+}
+
+// OnBehalfOf labels a note or reason written by the delegate
+func (a *OfficerAbsence) OnBehalfOf(note string) string {
+	return fmt.Sprintf("%s (on behalf of officer %d)", note, a.OfficerID)
+}
+
+// CreateOfficerAbsenceRequest records an absence (POST /authority/absences for oneself,
+// POST /admin/authorities/{officer_id}/absences for any officer)
+type CreateOfficerAbsenceRequest struct {
+	DelegateOfficerID int64      `json:"delegate_officer_id"`
+	StartsAt          *time.Time `json:"starts_at,omitempty"` // default: now
+	EndsAt            time.Time  `json:"ends_at"`
+	Reason            string     `json:"reason,omitempty"`
+}
+
+// OfficerAbsenceResponse is one absence in API responses
+type OfficerAbsenceResponse struct {
+	AbsenceID         int64      `json:"absence_id"`
+	OfficerID         int64      `json:"officer_id"`
+	DelegateOfficerID int64      `json:"delegate_officer_id"`
+	StartsAt          time.Time  `json:"starts_at"`
+	EndsAt            time.Time  `json:"ends_at"`
+	Reason            *string    `json:"reason,omitempty"`
+	CreatedByType     string     `json:"created_by_type"`
+	CancelledAt       *time.Time `json:"cancelled_at,omitempty"`
+	Active            bool       `json:"active"` // in effect now
+	CreatedAt         time.Time  `json:"created_at"`
+}
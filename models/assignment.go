@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// AssignmentStrategy picks the officer a complaint is assigned to within its department and location
+// (ASSIGNMENT_STRATEGY, ASSIGNMENT_STRATEGY_<DEPARTMENT_ID>)
+type AssignmentStrategy string
+
+const (
+	AssignmentRoundRobin AssignmentStrategy = "round_robin" // officers in turn (cursor per department and location)
+	AssignmentLeastOpen  AssignmentStrategy = "least_open"  // fewest open complaints
+	AssignmentCategory   AssignmentStrategy = "category"    // officers handling the complaint's category (officer_categories), fewest open first
+	AssignmentNearest    AssignmentStrategy = "nearest"     // office nearest to the complaint's coordinates
+)
+
+// AssignableOfficer is an active officer a complaint can be assigned to, with what the strategies compare
+type AssignableOfficer struct {
+	OfficerID      int64
+	AuthorityLevel int
+	OpenComplaints int             // assigned complaints not resolved, rejected or closed
+	Latitude       sql.NullFloat64 // office coordinates
+	Longitude      sql.NullFloat64
+	Categories     []string // officer_categories
+}
+
+// Assignment is the officer picked for a complaint and how; Note() goes into the status history notes
+type Assignment struct {
+	OfficerID     int64
+	Strategy      AssignmentStrategy // strategy that decided
+	FallbackFrom  AssignmentStrategy // configured strategy when it could not decide (e.g. no coordinates); empty otherwise
+	Inputs        string             // what was compared, e.g. "open complaints: officer 4=2, officer 16=5"
+	DelegatedFrom int64              // officer the strategy picked, on leave; OfficerID is their delegate. 0 = not delegated
+}
+
+// Note describes the assignment for complaint_status_history.notes
+func (a *Assignment) Note() string {
+	officer := fmt.Sprintf("officer %d", a.OfficerID)
+	if a.DelegatedFrom != 0 {
+		officer += fmt.Sprintf(" (delegate of officer %d, on leave)", a.DelegatedFrom)
+	}
+	if a.FallbackFrom != "" {
+		return fmt.Sprintf("Assigned to %s by %s after %s could not decide (%s)", officer, a.Strategy, a.FallbackFrom, a.Inputs)
+	}
+	return fmt.Sprintf("Assigned to %s by %s (%s)", officer, a.Strategy, a.Inputs)
+}
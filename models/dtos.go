@@ -36,6 +36,9 @@ type ComplaintSummary struct {
 	Priority       string    `json:"priority"`
 	CreatedAt      time.Time `json:"created_at"`
 	SupporterCount int       `json:"supporter_count"`
+	Version        int64     `json:"version,omitempty"` // Authority list: send back as If-Match when updating status
+	SLADueAt       *time.Time `json:"sla_due_at,omitempty"` // Authority list: when the current escalation rule falls due (working hours)
+	DelegatedFromOfficerID *int64     `json:"delegated_from_officer_id,omitempty"` // Authority list: assigned to this absent officer; listed for their delegate
 }
 
 type ComplaintDetailResponse struct {
@@ -61,12 +64,49 @@ type ComplaintDetailResponse struct {
 
 // AttachmentInfo represents attachment information in responses
 type AttachmentInfo struct {
-	AttachmentID int64  `json:"attachment_id"`
-	FileName     string `json:"file_name"`
-	FilePath     string `json:"file_path"`
+	AttachmentID int64   `json:"attachment_id"`
+	FileName     string  `json:"file_name"`
+	FilePath     string  `json:"file_path"`
 	FileType     *string `json:"file_type,omitempty"`
-	FileSize     *int64 `json:"file_size,omitempty"`
-	IsPublic     bool   `json:"is_public"`
+	FileSize     *int64  `json:"file_size,omitempty"`
+	IsPublic     bool    `json:"is_public"`
+	URL          *string `json:"url,omitempty"` // signed download link (storage.DownloadURLExpiry); absent for external URLs
+}
+
+// UploadedAttachment is one stored attachment with its evidence integrity record
+type UploadedAttachment struct {
+	AttachmentInfo
+	EvidenceHash string    `json:"evidence_hash"`
+	CapturedAt   time.Time `json:"captured_at"` // Server time at upload
+}
+
+// AttachmentUploadResponse represents the response after uploading attachments
+type AttachmentUploadResponse struct {
+	ComplaintID int64                `json:"complaint_id"`
+	Attachments []UploadedAttachment `json:"attachments"`
+}
+
+// EvidenceCheckStatus is the outcome of recomputing an evidence hash
+type EvidenceCheckStatus string
+
+const (
+	EvidenceMatch       EvidenceCheckStatus = "match"        // recomputed hash equals stored hash
+	EvidenceMismatch    EvidenceCheckStatus = "mismatch"     // file or recorded metadata changed after upload
+	EvidenceFileMissing EvidenceCheckStatus = "file_missing" // stored file could not be read
+)
+
+// EvidenceVerificationResult reports a re-verification of one attachment's evidence hash
+type EvidenceVerificationResult struct {
+	EvidenceID   int64               `json:"evidence_id"`
+	AttachmentID int64               `json:"attachment_id"`
+	ComplaintID  int64               `json:"complaint_id"`
+	Status       EvidenceCheckStatus `json:"status"`
+	StoredHash   string              `json:"stored_hash"`
+	ComputedHash string              `json:"computed_hash,omitempty"`
+	CapturedAt   time.Time           `json:"captured_at"`
+	Latitude     *float64            `json:"latitude,omitempty"`
+	Longitude    *float64            `json:"longitude,omitempty"`
+	CheckedAt    time.Time           `json:"checked_at"`
 }
 
 // StatusTimelineResponse represents the status timeline for a complaint
@@ -74,6 +114,7 @@ type StatusTimelineResponse struct {
 	ComplaintID int64              `json:"complaint_id"`
 	ComplaintNumber string          `json:"complaint_number"`
 	Timeline    []StatusTimelineEntry `json:"timeline"`
+	SLADueAt    *time.Time           `json:"sla_due_at,omitempty"` // When the current escalation level's SLA falls due (absent if none applies)
 }
 
 // StatusTimelineEntry represents a single status change entry
@@ -84,6 +125,7 @@ type StatusTimelineEntry struct {
 	ChangedByType        string    `json:"changed_by_type"`
 	ChangedByUserID      *int64    `json:"changed_by_user_id,omitempty"`
 	ChangedByOfficerID   *int64    `json:"changed_by_officer_id,omitempty"`
+	OnBehalfOfOfficerID  *int64    `json:"on_behalf_of_officer_id,omitempty"` // changed by a delegate for this absent officer
 	AssignedDepartmentID *int64    `json:"assigned_department_id,omitempty"`
 	AssignedOfficerID    *int64    `json:"assigned_officer_id,omitempty"`
 	Notes                *string   `json:"notes,omitempty"`
@@ -104,6 +146,7 @@ type UpdateStatusResponse struct {
 	ComplaintNumber string `json:"complaint_number"`
 	OldStatus       string `json:"old_status"`
 	NewStatus       string `json:"new_status"`
+	Version         int64  `json:"version"` // Complaint version after the update (also sent as ETag)
 	Message         string `json:"message"`
 }
 
@@ -120,6 +163,73 @@ type AuthorityUpdateStatusRequest struct {
 	Reason    string `json:"reason" validate:"required"`      // Required reason text
 }
 
+// AuthorityUpdatePriorityRequest represents authority's priority change request
+type AuthorityUpdatePriorityRequest struct {
+	Priority string `json:"priority" validate:"required"` // low, medium, high, urgent
+	Reason   string `json:"reason" validate:"required"`   // Required reason text
+}
+
+// AuthorityEscalateRequest represents an officer's escalation to the next level
+type AuthorityEscalateRequest struct {
+	Reason string `json:"reason" validate:"required"` // Required reason text
+}
+
+// AuthorityTransferRequest represents an officer's transfer to another department and/or location
+type AuthorityTransferRequest struct {
+	ToDepartmentID int64  `json:"to_department_id" validate:"required"`
+	ToLocationID   *int64 `json:"to_location_id,omitempty"`  // Default: the complaint's location
+	Reason         string `json:"reason" validate:"required"` // Required reason text
+}
+
+// TransferResponse represents the response after a transfer
+type TransferResponse struct {
+	ComplaintID      int64  `json:"complaint_id"`
+	ComplaintNumber  string `json:"complaint_number"`
+	EscalationID     int64  `json:"escalation_id"` // complaint_escalations row (escalation_type transfer)
+	FromDepartmentID *int64 `json:"from_department_id,omitempty"`
+	ToDepartmentID   int64  `json:"to_department_id"`
+	ToLocationID     int64  `json:"to_location_id"`
+	ToOfficerID      int64  `json:"to_officer_id"`
+	Version          int64  `json:"version"` // Complaint version after the transfer (also sent as ETag)
+	Message          string `json:"message"`
+}
+
+// UpdatePriorityResponse represents the response after a priority change
+type UpdatePriorityResponse struct {
+	ComplaintID     int64      `json:"complaint_id"`
+	ComplaintNumber string     `json:"complaint_number"`
+	OldPriority     string     `json:"old_priority"`
+	NewPriority     string     `json:"new_priority"`
+	Version         int64      `json:"version"`              // Complaint version after the update (also sent as ETag)
+	SLADueAt        *time.Time `json:"sla_due_at,omitempty"` // Re-evaluated for the new priority
+	Message         string     `json:"message"`
+}
+
+// ResolutionAction is the citizen's response to a resolved complaint
+type ResolutionAction string
+
+const (
+	ResolutionActionConfirm ResolutionAction = "confirm"
+	ResolutionActionDispute ResolutionAction = "dispute"
+)
+
+// ResolutionResponseRequest represents the citizen's confirmation or dispute of a resolution
+type ResolutionResponseRequest struct {
+	Action       ResolutionAction `json:"action" validate:"required"` // confirm, dispute
+	Reason       string           `json:"reason,omitempty"`           // Required when disputing
+	AttachmentID *int64           `json:"attachment_id,omitempty"`    // Optional proof that the issue persists: a photo uploaded to this complaint via the attachments endpoint
+}
+
+// ClarificationAnswerRequest is the citizen's reply to an officer's question (complaint awaiting_citizen)
+type ClarificationAnswerRequest struct {
+	Answer string `json:"answer" validate:"required"`
+}
+
+// CitizenEscalationRequest is the complaint owner's request to escalate after an SLA breach
+type CitizenEscalationRequest struct {
+	Note string `json:"note,omitempty"` // Optional, shown with the escalation reason
+}
+
 // AuthorityAddNoteRequest represents request to add internal note
 type AuthorityAddNoteRequest struct {
 	NoteText string `json:"note_text" validate:"required"`
@@ -134,9 +244,10 @@ type AuthorityNoteResponse struct {
 
 // AuthorityNote represents an internal note added by authority
 type AuthorityNote struct {
-	NoteID      int64     `json:"note_id"`
-	ComplaintID int64     `json:"complaint_id"`
-	OfficerID   int64     `json:"officer_id"`
-	NoteText    string    `json:"note_text"`
-	CreatedAt   time.Time `json:"created_at"`
+	NoteID              int64     `json:"note_id"`
+	ComplaintID         int64     `json:"complaint_id"`
+	OfficerID           int64     `json:"officer_id"`
+	OnBehalfOfOfficerID *int64    `json:"on_behalf_of_officer_id,omitempty"` // written by OfficerID as delegate of this absent officer
+	NoteText            string    `json:"note_text"`
+	CreatedAt           time.Time `json:"created_at"`
 }
@@ -18,6 +18,7 @@ const (
 	StatusRejected     ComplaintStatus = "rejected"
 	StatusClosed       ComplaintStatus = "closed"
 	StatusEscalated    ComplaintStatus = "escalated"
+	StatusAwaitingCitizen ComplaintStatus = "awaiting_citizen" // Officer asked the citizen a question; SLA paused
 )
 
 // Priority represents complaint priority levels
@@ -58,6 +59,7 @@ type Complaint struct {
 	IsPublic             bool            `db:"is_public" json:"is_public"`
 	PublicConsentGiven   bool            `db:"public_consent_given" json:"public_consent_given"`
 	SupporterCount       int             `db:"supporter_count" json:"supporter_count"`
+	Version              int64           `db:"version" json:"version"` // Optimistic concurrency; bumped on every status write
 	Pincode              sql.NullString  `db:"pincode" json:"pincode,omitempty"`
 	DeviceFingerprint    sql.NullString  `db:"device_fingerprint" json:"device_fingerprint,omitempty"`
 	ResolvedAt           sql.NullTime    `db:"resolved_at" json:"resolved_at"`
@@ -92,6 +94,8 @@ type ComplaintStatusHistory struct {
 	ActorID   sql.NullInt64  `db:"actor_id" json:"actor_id"`     // user_id or officer_id; NULL for system
 	Reason    sql.NullString `db:"reason" json:"reason"`         // reason when available
 	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	// Delegation: the acting officer (actor_id) stood in for this absent officer
+	OnBehalfOfOfficerID sql.NullInt64 `db:"on_behalf_of_officer_id" json:"on_behalf_of_officer_id"`
 }
 
 // ComplaintAttachment represents a file attachment
@@ -104,17 +108,84 @@ type ComplaintAttachment struct {
 	FileSize        sql.NullInt64  `db:"file_size" json:"file_size"`
 	UploadedByUserID sql.NullInt64 `db:"uploaded_by_user_id" json:"uploaded_by_user_id"`
 	IsPublic        bool           `db:"is_public" json:"is_public"`
+	// EXIF read from the uploaded JPEG (NULL when absent or not a JPEG)
+	ExifCapturedAt  sql.NullTime    `db:"exif_captured_at" json:"exif_captured_at"`
+	ExifTimeZoned   bool            `db:"exif_time_zoned" json:"exif_time_zoned"` // false = camera-local time read as UTC
+	ExifLatitude    sql.NullFloat64 `db:"exif_latitude" json:"exif_latitude"`
+	ExifLongitude   sql.NullFloat64 `db:"exif_longitude" json:"exif_longitude"`
+	ExifCameraModel sql.NullString  `db:"exif_camera_model" json:"exif_camera_model"`
+	PerceptualHash  sql.NullInt64   `db:"perceptual_hash" json:"-"` // 64-bit dHash stored as signed BIGINT (uint64 bits)
 	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
 }
 
-// ComplaintVoiceNote stores one voice note per complaint (citizen upload, not public; authority can access).
+// ClarificationStatus is the state of an officer's question to the citizen
+type ClarificationStatus string
+
+const (
+	ClarificationOpen      ClarificationStatus = "open"
+	ClarificationAnswered  ClarificationStatus = "answered"  // citizen replied; complaint back in ReturnStatus
+	ClarificationWithdrawn ClarificationStatus = "withdrawn" // officer moved the complaint on without a reply
+	ClarificationExpired   ClarificationStatus = "expired"   // no reply in time; complaint auto-closed
+)
+
+// ComplaintClarification is a question an officer asked the citizen (complaint in awaiting_citizen)
+type ComplaintClarification struct {
+	ClarificationID  int64               `db:"clarification_id" json:"clarification_id"`
+	ComplaintID      int64               `db:"complaint_id" json:"complaint_id"`
+	AskedByOfficerID sql.NullInt64       `db:"asked_by_officer_id" json:"asked_by_officer_id"`
+	Question         string              `db:"question" json:"question"`
+	ReturnStatus     ComplaintStatus     `db:"return_status" json:"return_status"` // status before awaiting; restored on reply
+	Status           ClarificationStatus `db:"status" json:"status"`
+	Answer           sql.NullString      `db:"answer" json:"answer"`
+	AskedAt          time.Time           `db:"asked_at" json:"asked_at"`
+	ClosedAt         sql.NullTime        `db:"closed_at" json:"closed_at"`
+}
+
+// PhotoReuseStatus is the admin review state of a recycled-photo flag
+type PhotoReuseStatus string
+
+const (
+	PhotoReusePending   PhotoReuseStatus = "pending"
+	PhotoReuseConfirmed PhotoReuseStatus = "confirmed" // admin agrees the photo was recycled
+	PhotoReuseDismissed PhotoReuseStatus = "dismissed" // false positive
+)
+
+// PhotoReuseFlag links an attachment to a near-identical photo on another complaint (admin review list)
+type PhotoReuseFlag struct {
+	FlagID              int64            `db:"flag_id" json:"flag_id"`
+	ComplaintID         int64            `db:"complaint_id" json:"complaint_id"`
+	AttachmentID        int64            `db:"attachment_id" json:"attachment_id"`
+	MatchedComplaintID  int64            `db:"matched_complaint_id" json:"matched_complaint_id"`
+	MatchedAttachmentID int64            `db:"matched_attachment_id" json:"matched_attachment_id"`
+	HammingDistance     int              `db:"hamming_distance" json:"hamming_distance"`
+	DifferentUser       bool             `db:"different_user" json:"different_user"`
+	DifferentDevice     bool             `db:"different_device" json:"different_device"`
+	Status              PhotoReuseStatus `db:"status" json:"status"`
+	ReviewNote          sql.NullString   `db:"review_note" json:"review_note"`
+	ReviewedAt          sql.NullTime     `db:"reviewed_at" json:"reviewed_at"`
+	CreatedAt           time.Time        `db:"created_at" json:"created_at"`
+}
+
+// PhotoMatch is an earlier attachment whose perceptual hash is close to a new upload
+type PhotoMatch struct {
+	AttachmentID      int64          `db:"attachment_id"`
+	ComplaintID       int64          `db:"complaint_id"`
+	HammingDistance   int            `db:"hamming_distance"`
+	UserID            int64          `db:"user_id"`
+	DeviceFingerprint sql.NullString `db:"device_fingerprint"`
+}
+
+// ComplaintVoiceNote is one voice clip on a complaint; a complaint can have several, ordered by created_at
+// (citizen upload, not public; assigned officer and supervisors can access).
 type ComplaintVoiceNote struct {
 	ID               int64     `db:"id" json:"id"`
 	ComplaintID      int64     `db:"complaint_id" json:"complaint_id"`
-	FilePath         string    `db:"file_path" json:"file_path"`
+	FilePath         string    `db:"file_path" json:"-"` // storage key; served via the authority voice endpoints
 	MimeType         string    `db:"mime_type" json:"mime_type"`
+	FileSize         *int64    `db:"file_size" json:"file_size,omitempty"`
 	DurationSeconds  *int      `db:"duration_seconds" json:"duration_seconds,omitempty"`
 	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	URL              *string   `db:"-" json:"url,omitempty"` // signed download link, only in the uploading citizen's response
 }
 
 // ComplaintEvidence represents evidence integrity data for complaint attachments
@@ -177,11 +248,15 @@ type EmailLog struct {
 type PilotMetricsEventType string
 
 const (
-	EventComplaintCreated      PilotMetricsEventType = "complaint_created"
-	EventFirstAuthorityAction  PilotMetricsEventType = "first_authority_action"
-	EventEscalationTriggered   PilotMetricsEventType = "escalation_triggered"
-	EventComplaintResolved     PilotMetricsEventType = "complaint_resolved"
-	EventChatAbandoned         PilotMetricsEventType = "chat_abandoned"
+	EventComplaintCreated        PilotMetricsEventType = "complaint_created"
+	EventFirstAuthorityAction    PilotMetricsEventType = "first_authority_action"
+	EventEscalationTriggered     PilotMetricsEventType = "escalation_triggered"
+	EventComplaintResolved       PilotMetricsEventType = "complaint_resolved"
+	EventChatAbandoned           PilotMetricsEventType = "chat_abandoned"
+	EventResolutionConfirmed     PilotMetricsEventType = "resolution_confirmed"
+	EventResolutionDisputed      PilotMetricsEventType = "resolution_disputed"
+	EventComplaintTransferred    PilotMetricsEventType = "complaint_transferred"     // officer transfer; not counted as an escalation
+	EventEscalationTargetMissing PilotMetricsEventType = "escalation_target_missing" // escalation due but no officer in the hierarchy; not escalated
 )
 
 // PilotMetricsEvent represents a pilot metrics event
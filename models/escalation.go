@@ -1,7 +1,10 @@
 package models
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -15,6 +18,7 @@ type EscalationRule struct {
 	EscalationLevel   int            `db:"escalation_level" json:"escalation_level"`
 	Conditions        sql.NullString `db:"conditions" json:"conditions"` // JSON
 	IsActive          bool           `db:"is_active" json:"is_active"`
+	Version           int            `db:"version" json:"version"` // Bumped on every admin change (admin API only; 0 from GetActiveEscalationRules)
 	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt         sql.NullTime   `db:"updated_at" json:"updated_at"`
 }
@@ -50,8 +54,20 @@ type TimeBasedCondition struct {
 	
 	// HoursSinceCreation - Escalate if complaint created X hours ago
 	HoursSinceCreation int `json:"hours_since_creation,omitempty"`
+
+	// SLAPolicyID - Take the SLA hours from an SLA policy (sla_policies) by priority, level and category
+	// instead of sla_hours
+	SLAPolicyID int64 `json:"sla_policy_id,omitempty"`
 }
 
+// EscalationType distinguishes escalations (level up) from officer transfers (same level) in complaint_escalations
+type EscalationType string
+
+const (
+	EscalationTypeEscalation EscalationType = "escalation"
+	EscalationTypeTransfer   EscalationType = "transfer" // another department/location; not an SLA escalation
+)
+
 // ComplaintEscalation represents an escalation record
 type ComplaintEscalation struct {
 	EscalationID        int64          `db:"escalation_id" json:"escalation_id"`
@@ -61,6 +77,7 @@ type ComplaintEscalation struct {
 	ToDepartmentID      int64          `db:"to_department_id" json:"to_department_id"`
 	ToOfficerID         sql.NullInt64  `db:"to_officer_id" json:"to_officer_id"`
 	EscalationLevel     int            `db:"escalation_level" json:"escalation_level"`
+	EscalationType      EscalationType `db:"escalation_type" json:"escalation_type"` // empty = escalation
 	Reason              sql.NullString `db:"reason" json:"reason"`
 	EscalatedByType     ActorType      `db:"escalated_by_type" json:"escalated_by_type"`
 	EscalatedByUserID   sql.NullInt64  `db:"escalated_by_user_id" json:"escalated_by_user_id"`
@@ -69,19 +86,110 @@ type ComplaintEscalation struct {
 	CreatedAt           time.Time      `db:"created_at" json:"created_at"`
 }
 
+// EscalationRuleView is the JSON form of an escalation rule for the admin API and audit snapshots
+// (nullable IDs as null, conditions as a JSON object)
+type EscalationRuleView struct {
+	RuleID           int64           `json:"rule_id"`
+	FromDepartmentID *int64          `json:"from_department_id"`
+	FromLocationID   *int64          `json:"from_location_id"`
+	ToDepartmentID   *int64          `json:"to_department_id"`
+	ToLocationID     *int64          `json:"to_location_id"`
+	EscalationLevel  int             `json:"escalation_level"`
+	Conditions       json.RawMessage `json:"conditions"`
+	IsActive         bool            `json:"is_active"`
+	Version          int             `json:"version"`
+}
+
+// EscalationRuleRequest is the admin API body for one rule, also used for proposed rules in a simulation.
+// Omitted IDs mean NULL (any / same); is_active defaults to true.
+type EscalationRuleRequest struct {
+	FromDepartmentID *int64          `json:"from_department_id"`
+	FromLocationID   *int64          `json:"from_location_id"`
+	ToDepartmentID   *int64          `json:"to_department_id"`
+	ToLocationID     *int64          `json:"to_location_id"`
+	EscalationLevel  *int            `json:"escalation_level"`
+	Conditions       json.RawMessage `json:"conditions"`
+	IsActive         *bool           `json:"is_active,omitempty"`
+}
+
+// ToRule converts the request to a rule (conditions are validated by service.ValidateEscalationRule)
+func (r *EscalationRuleRequest) ToRule() (*EscalationRule, error) {
+	if r.EscalationLevel == nil {
+		return nil, fmt.Errorf("escalation_level is required")
+	}
+	rule := &EscalationRule{
+		FromDepartmentID: optionalID(r.FromDepartmentID),
+		FromLocationID:   optionalID(r.FromLocationID),
+		ToDepartmentID:   optionalID(r.ToDepartmentID),
+		ToLocationID:     optionalID(r.ToLocationID),
+		EscalationLevel:  *r.EscalationLevel,
+		IsActive:         r.IsActive == nil || *r.IsActive,
+	}
+	if conditions := bytes.TrimSpace(r.Conditions); len(conditions) > 0 && !bytes.Equal(conditions, []byte("null")) {
+		rule.Conditions = sql.NullString{String: string(conditions), Valid: true}
+	}
+	return rule, nil
+}
+
+func optionalID(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+// EscalationSimulationRequest is the body of the what-if simulator. Rules nil = current active rules;
+// Now nil = current time.
+type EscalationSimulationRequest struct {
+	Now   *time.Time              `json:"now,omitempty"`
+	Rules []EscalationRuleRequest `json:"rules,omitempty"`
+}
+
+// SimulatedEscalationAction is one decision the engine would take (nothing is written)
+type SimulatedEscalationAction struct {
+	ComplaintID     int64  `json:"complaint_id"`
+	ComplaintNumber string `json:"complaint_number"`
+	Action          string `json:"action"`             // escalate | reminder | target_missing (due, but no officer to escalate to)
+	Rule            string `json:"rule"`               // "rule 5" (current rule set) or "proposed[0]"
+	FromLevel       int    `json:"from_level"`        // current escalation level (0 = L1)
+	ToLevel         *int   `json:"to_level,omitempty"` // escalate only
+	ToDepartmentID  *int64 `json:"to_department_id,omitempty"`
+	ToOfficerID     *int64 `json:"to_officer_id,omitempty"` // escalate only
+	Reason          string `json:"reason"`
+}
+
+// SimulationSkip is a candidate the simulation could not evaluate
+type SimulationSkip struct {
+	ComplaintID int64  `json:"complaint_id"`
+	Error       string `json:"error"`
+}
+
+// EscalationSimulation is the result of a what-if run of the escalation engine
+type EscalationSimulation struct {
+	SimulatedNow time.Time                   `json:"simulated_now"`
+	RuleSet      string                      `json:"rule_set"` // current | proposed
+	Rules        int                         `json:"rules"`
+	Candidates   int                         `json:"candidates"`
+	Actions      []SimulatedEscalationAction `json:"actions"`
+	Skipped      []SimulationSkip            `json:"skipped"`
+}
+
 // EscalationCandidate represents a complaint that may need escalation
 type EscalationCandidate struct {
 	ComplaintID          int64
 	ComplaintNumber      string
 	CurrentStatus        ComplaintStatus
 	Priority             Priority
+	Category             sql.NullString // SLA policy targets can be per category
 	AssignedDepartmentID sql.NullInt64
 	AssignedOfficerID    sql.NullInt64
 	LocationID           int64
 	Pincode              sql.NullString // Pincode for authority lookup
 	CreatedAt            time.Time
 	UpdatedAt            sql.NullTime
-	LastStatusChangeAt   time.Time // From status history
+	LastStatusChangeAt   time.Time // From status history (awaiting_citizen round trips ignored)
+	SLAPauses            []SLAPause // Periods spent in awaiting_citizen; excluded from SLA time
+	Version              int64     // complaints.version at read time (optimistic concurrency)
 }
 
 // EscalationResult represents the result of escalation processing
@@ -92,6 +200,7 @@ type EscalationResult struct {
 	NewStatus        *string   `json:"new_status,omitempty"`
 	Reason           string    `json:"reason"`
 	ProcessedAt      time.Time `json:"processed_at"`
+	Version          int64     `json:"version,omitempty"` // Complaint version after an officer's escalation (also sent as ETag)
 }
 
 // ReminderResult represents the result of reminder processing
@@ -101,3 +210,37 @@ type ReminderResult struct {
 	Reason          string    `json:"reason"`
 	ProcessedAt     time.Time `json:"processed_at"`
 }
+
+// SLACalendarConfig is a row of sla_calendars (working hours of one district; LocationID NULL = default)
+type SLACalendarConfig struct {
+	CalendarID  int64         `db:"calendar_id" json:"calendar_id"`
+	LocationID  sql.NullInt64 `db:"location_id" json:"location_id"`
+	Timezone    string        `db:"timezone" json:"timezone"`
+	WorkStart   string        `db:"work_start" json:"work_start"`     // HH:MM:SS local time
+	WorkEnd     string        `db:"work_end" json:"work_end"`         // HH:MM:SS local time
+	WorkingDays string        `db:"working_days" json:"working_days"` // e.g. "mon,tue,wed,thu,fri"
+}
+
+// SLAHoliday is a row of sla_holidays (LocationID NULL = state-wide)
+type SLAHoliday struct {
+	HolidayID   int64         `db:"holiday_id" json:"holiday_id"`
+	LocationID  sql.NullInt64 `db:"location_id" json:"location_id"`
+	HolidayDate string        `db:"holiday_date" json:"holiday_date"` // YYYY-MM-DD
+	Name        string        `db:"name" json:"name"`
+}
+
+// SLAPause is a period in which the SLA clock was stopped (complaint awaiting the citizen's reply)
+type SLAPause struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SLAPolicyTarget is a row of sla_policy_targets joined with its policy (one cell of an SLA matrix)
+type SLAPolicyTarget struct {
+	PolicyID        int64    `db:"policy_id" json:"policy_id"`
+	PolicyName      string   `db:"name" json:"policy_name"`
+	Priority        Priority `db:"priority" json:"priority"`
+	EscalationLevel int      `db:"escalation_level" json:"escalation_level"`
+	Category        string   `db:"category" json:"category"` // "" = any category
+	SLAHours        int      `db:"sla_hours" json:"sla_hours"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// JobRunStatus represents the outcome of one background job run
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed" // returned an error or panicked
+)
+
+// JobTrigger records why a job ran
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual" // admin run-now
+)
+
+// JobRun represents one run of a background job (job_runs table)
+type JobRun struct {
+	RunID        int64          `db:"run_id" json:"run_id"`
+	JobName      string         `db:"job_name" json:"job_name"`
+	InstanceID   string         `db:"instance_id" json:"instance_id"`
+	Trigger      JobTrigger     `db:"trigger_type" json:"trigger"`
+	Status       JobRunStatus   `db:"status" json:"status"`
+	Counts       map[string]int `db:"counts" json:"counts,omitempty"` // per-run outcome counts, e.g. {"escalated": 2}
+	ErrorMessage string         `db:"error_message" json:"error,omitempty"`
+	StartedAt    time.Time      `db:"started_at" json:"started_at"`
+	FinishedAt   *time.Time     `db:"finished_at" json:"finished_at,omitempty"`
+	DurationMS   *int64         `db:"duration_ms" json:"duration_ms,omitempty"`
+}
+
+// WorkerJobState is the shared control record of a job (worker_jobs table).
+// Pausing is stored here so it applies to every instance.
+type WorkerJobState struct {
+	JobName       string         `db:"job_name"`
+	Schedule      string         `db:"schedule"`
+	Paused        bool           `db:"paused"`
+	PausedAt      sql.NullTime   `db:"paused_at"`
+	PauseReason   sql.NullString `db:"pause_reason"`
+	LastRunAt     sql.NullTime   `db:"last_run_at"`
+	LastRunStatus sql.NullString `db:"last_run_status"`
+	NextRunAt     sql.NullTime   `db:"next_run_at"`
+}
+
+// JobStatus is one job as listed by the admin jobs API
+type JobStatus struct {
+	Name          string     `json:"name"`
+	Schedule      string     `json:"schedule"`
+	JitterSeconds int        `json:"jitter_seconds"`
+	Exclusive     bool       `json:"exclusive"` // runs on one instance at a time (worker_leases)
+	Paused        bool       `json:"paused"`
+	PausedAt      *time.Time `json:"paused_at,omitempty"`
+	PauseReason   string     `json:"pause_reason,omitempty"`
+	Running       bool       `json:"running"`               // a run is in progress on this instance
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"` // next scheduled run on this instance
+	LastRun       *JobRun    `json:"last_run,omitempty"`    // latest run on any instance
+}
@@ -104,6 +104,7 @@ type NotificationConfig struct {
 	// Worker configuration
 	WorkerBatchSize   int
 	WorkerInterval    time.Duration
+	ClaimTimeout      time.Duration // a claimed batch not sent within this is picked up by another worker
 	
 	// Queue configuration
 	QueueMaxSize      int
@@ -118,6 +119,7 @@ func DefaultNotificationConfig() *NotificationConfig {
 		BackoffMultiplier: 2.0,
 		WorkerBatchSize:   100,
 		WorkerInterval:    30 * time.Second,
+		ClaimTimeout:      5 * time.Minute,
 		QueueMaxSize:      10000,
 	}
 }
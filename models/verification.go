@@ -18,6 +18,15 @@ const (
 	// ReasonCodeDuplicateFound - Duplicate complaint found (merged)
 	ReasonCodeDuplicateFound VerificationReasonCode = "DUPLICATE_FOUND"
 	
+	// ReasonCodeExifLocationMismatch - Photo EXIF GPS is too far from the complaint's coordinates
+	ReasonCodeExifLocationMismatch VerificationReasonCode = "EXIF_LOCATION_MISMATCH"
+	
+	// ReasonCodeExifCaptureTooOld - Photo EXIF capture time is too long before submission
+	ReasonCodeExifCaptureTooOld VerificationReasonCode = "EXIF_CAPTURE_TOO_OLD"
+	
+	// ReasonCodeExifCaptureInFuture - Photo EXIF capture time is after the photo was uploaded
+	ReasonCodeExifCaptureInFuture VerificationReasonCode = "EXIF_CAPTURE_IN_FUTURE"
+	
 	// ReasonCodeVerified - Verification successful
 	ReasonCodeVerified VerificationReasonCode = "VERIFIED"
 )
@@ -48,6 +57,15 @@ type VerificationConfig struct {
 	
 	// DuplicateDetectionTimeWindow - Time window in hours for duplicate detection
 	DuplicateDetectionTimeWindow time.Duration
+	
+	// ExifMaxDistanceMeters - Maximum distance between photo EXIF GPS and complaint GPS
+	ExifMaxDistanceMeters float64
+	
+	// ExifMaxCaptureAge - Maximum time between photo EXIF capture and complaint submission
+	ExifMaxCaptureAge time.Duration
+	
+	// ExifMaxClockSkew - How far a photo's EXIF capture time may run past its upload time (camera clock drift)
+	ExifMaxClockSkew time.Duration
 }
 
 // DefaultVerificationConfig returns default verification configuration
@@ -56,6 +74,9 @@ func DefaultVerificationConfig() *VerificationConfig {
 		GPSAccuracyThreshold:         100.0, // 100 meters
 		DuplicateDetectionRadius:     50.0,  // 50 meters
 		DuplicateDetectionTimeWindow: 24 * time.Hour, // 24 hours
+		ExifMaxDistanceMeters:        1000.0,         // 1 km
+		ExifMaxCaptureAge:            48 * time.Hour, // 48 hours (EXIF time is camera-local)
+		ExifMaxClockSkew:             15 * time.Minute,
 	}
 }
 
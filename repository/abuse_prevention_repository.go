@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -18,7 +19,7 @@ func NewAbusePreventionRepository(db *sql.DB) *AbusePreventionRepository {
 }
 
 // CountComplaintsByUserInLast24Hours counts complaints submitted by user in last 24 hours
-func (r *AbusePreventionRepository) CountComplaintsByUserInLast24Hours(userID int64) (int, error) {
+func (r *AbusePreventionRepository) CountComplaintsByUserInLast24Hours(ctx context.Context, userID int64) (int, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM complaints
@@ -28,7 +29,7 @@ func (r *AbusePreventionRepository) CountComplaintsByUserInLast24Hours(userID in
 	`
 
 	var count int
-	err := r.db.QueryRow(query, userID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count complaints: %w", err)
 	}
@@ -39,7 +40,7 @@ func (r *AbusePreventionRepository) CountComplaintsByUserInLast24Hours(userID in
 // HasDuplicateComplaint checks if user has submitted similar complaint recently
 // Same user + same issue_summary (title) + same pincode within time window.
 // If pincode column is missing (migration not run), falls back to user_id + title only.
-func (r *AbusePreventionRepository) HasDuplicateComplaint(
+func (r *AbusePreventionRepository) HasDuplicateComplaint(ctx context.Context,
 	userID int64,
 	issueSummary string,
 	pincode string,
@@ -57,7 +58,7 @@ func (r *AbusePreventionRepository) HasDuplicateComplaint(
 		  AND current_status != 'rejected'
 	`
 	var exists bool
-	err := r.db.QueryRow(queryWithPincode, userID, issueSummary, pincode, pincode, cutoffTime).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, queryWithPincode, userID, issueSummary, pincode, pincode, cutoffTime).Scan(&exists)
 	if err != nil {
 		// If pincode column does not exist (migration not run), fall back to check without pincode
 		if strings.Contains(err.Error(), "pincode") || strings.Contains(err.Error(), "Unknown column") {
@@ -69,7 +70,7 @@ func (r *AbusePreventionRepository) HasDuplicateComplaint(
 				  AND created_at >= ?
 				  AND current_status != 'rejected'
 			`
-			err = r.db.QueryRow(queryWithoutPincode, userID, issueSummary, cutoffTime).Scan(&exists)
+			err = r.db.QueryRowContext(ctx, queryWithoutPincode, userID, issueSummary, cutoffTime).Scan(&exists)
 		}
 		if err != nil {
 			return false, fmt.Errorf("failed to check duplicate: %w", err)
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"finalneta/models"
+	"fmt"
+	"strings"
+)
+
+// AssignmentRepository reads the officer pool and workload for assignment strategies and keeps the
+// round_robin cursors (assignment_cursors)
+type AssignmentRepository struct {
+	db *sql.DB
+}
+
+// NewAssignmentRepository creates a new assignment repository
+func NewAssignmentRepository(db *sql.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+// openComplaintFilter matches complaints still on an officer's desk
+const openComplaintFilter = `c.current_status NOT IN ('resolved', 'rejected', 'closed')`
+
+// ListAssignableOfficers returns the active officers in the department and location at the lowest authority_level
+// at or above minAuthorityLevel (L1 for new complaints), ordered by officer_id, with their open complaint count,
+// office coordinates and categories. excludeOfficerID (0 = none) is left out.
+func (r *AssignmentRepository) ListAssignableOfficers(ctx context.Context,
+	departmentID int64,
+	locationID int64,
+	minAuthorityLevel int,
+	excludeOfficerID int64,
+) ([]models.AssignableOfficer, error) {
+	query := `
+		SELECT o.officer_id, COALESCE(o.authority_level, 1), o.latitude, o.longitude,
+			(SELECT COUNT(*) FROM complaints c WHERE c.assigned_officer_id = o.officer_id AND ` + openComplaintFilter + `)
+		FROM officers o
+		WHERE o.department_id = ? AND o.location_id = ? AND o.is_active = true
+			AND COALESCE(o.authority_level, 1) >= ?
+			AND o.officer_id <> ?
+		ORDER BY COALESCE(o.authority_level, 1) ASC, o.officer_id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, departmentID, locationID, minAuthorityLevel, excludeOfficerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assignable officers: %w", err)
+	}
+	defer rows.Close()
+
+	var officers []models.AssignableOfficer
+	for rows.Next() {
+		var o models.AssignableOfficer
+		if err := rows.Scan(&o.OfficerID, &o.AuthorityLevel, &o.Latitude, &o.Longitude, &o.OpenComplaints); err != nil {
+			return nil, fmt.Errorf("failed to scan assignable officer: %w", err)
+		}
+		// Only the lowest level present: a complaint never skips a level on assignment
+		if len(officers) > 0 && o.AuthorityLevel != officers[0].AuthorityLevel {
+			break
+		}
+		officers = append(officers, o)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assignable officers: %w", err)
+	}
+	if len(officers) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(officers))
+	args := make([]interface{}, len(officers))
+	index := make(map[int64]int, len(officers))
+	for i, o := range officers {
+		placeholders[i] = "?"
+		args[i] = o.OfficerID
+		index[o.OfficerID] = i
+	}
+	catRows, err := r.db.QueryContext(ctx,
+		`SELECT officer_id, category FROM officer_categories WHERE officer_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY category`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query officer categories: %w", err)
+	}
+	defer catRows.Close()
+	for catRows.Next() {
+		var officerID int64
+		var category string
+		if err := catRows.Scan(&officerID, &category); err != nil {
+			return nil, fmt.Errorf("failed to scan officer category: %w", err)
+		}
+		i := index[officerID]
+		officers[i].Categories = append(officers[i].Categories, category)
+	}
+	if err = catRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating officer categories: %w", err)
+	}
+	return officers, nil
+}
+
+// NextRoundRobin advances the department and location's cursor to the officer after the previous one in
+// officerIDs (ascending; wraps around) and returns both. previous is 0 on the first assignment.
+// The cursor row is locked, so concurrent assignments on any replica take consecutive turns.
+func (r *AssignmentRepository) NextRoundRobin(ctx context.Context,
+	departmentID int64,
+	locationID int64,
+	officerIDs []int64,
+) (next int64, previous int64, err error) {
+	if len(officerIDs) == 0 {
+		return 0, 0, fmt.Errorf("no officers to rotate")
+	}
+	err = RunInTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT IGNORE INTO assignment_cursors (department_id, location_id, last_officer_id)
+			VALUES (?, ?, 0)
+		`, departmentID, locationID); err != nil {
+			return fmt.Errorf("failed to create assignment cursor: %w", err)
+		}
+		if err := tx.QueryRowContext(ctx, `
+			SELECT last_officer_id FROM assignment_cursors
+			WHERE department_id = ? AND location_id = ?
+			FOR UPDATE
+		`, departmentID, locationID).Scan(&previous); err != nil {
+			return fmt.Errorf("failed to read assignment cursor: %w", err)
+		}
+
+		next = officerIDs[0]
+		for _, id := range officerIDs {
+			if id > previous {
+				next = id
+				break
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE assignment_cursors SET last_officer_id = ?
+			WHERE department_id = ? AND location_id = ?
+		`, next, departmentID, locationID); err != nil {
+			return fmt.Errorf("failed to advance assignment cursor: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return next, previous, nil
+}
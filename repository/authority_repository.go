@@ -1,24 +1,33 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"finalneta/models"
 	"finalneta/utils"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // AuthorityRepository handles database operations for authority dashboard
 type AuthorityRepository struct {
-	db *sql.DB
+	db DBTX // *sql.DB, or *sql.Tx for copies made by WithTx
+	txScope
 }
 
 // NewAuthorityRepository creates a new authority repository
 func NewAuthorityRepository(db *sql.DB) *AuthorityRepository {
-	return &AuthorityRepository{db: db}
+	return &AuthorityRepository{db: db, txScope: txScope{conn: db}}
+}
+
+// WithTx returns a copy of the repository whose statements run inside tx
+func (r *AuthorityRepository) WithTx(tx *sql.Tx) *AuthorityRepository {
+	return &AuthorityRepository{db: tx, txScope: txScope{conn: r.conn, tx: tx}}
 }
 
 // ValidateCredentials validates email and password for authority login. Passwords stored as bcrypt hashes.
-func (r *AuthorityRepository) ValidateCredentials(email, password string) (int64, error) {
+func (r *AuthorityRepository) ValidateCredentials(ctx context.Context, email, password string) (int64, error) {
 	query := `
 		SELECT ac.officer_id, ac.password_hash, o.is_active
 		FROM authority_credentials ac
@@ -31,7 +40,7 @@ func (r *AuthorityRepository) ValidateCredentials(email, password string) (int64
 	var passwordHash string
 	var isActive bool
 
-	err := r.db.QueryRow(query, email).Scan(&officerID, &passwordHash, &isActive)
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&officerID, &passwordHash, &isActive)
 	if err == sql.ErrNoRows {
 		return 0, fmt.Errorf("invalid credentials")
 	}
@@ -48,13 +57,13 @@ func (r *AuthorityRepository) ValidateCredentials(email, password string) (int64
 	}
 
 	// Update last login
-	r.db.Exec("UPDATE authority_credentials SET last_login_at = NOW() WHERE officer_id = ?", officerID)
+	r.db.ExecContext(ctx, "UPDATE authority_credentials SET last_login_at = NOW() WHERE officer_id = ?", officerID)
 
 	return officerID, nil
 }
 
 // ValidateStaticToken validates static token for pilot authentication
-func (r *AuthorityRepository) ValidateStaticToken(token string) (int64, error) {
+func (r *AuthorityRepository) ValidateStaticToken(ctx context.Context, token string) (int64, error) {
 	query := `
 		SELECT ac.officer_id, o.is_active
 		FROM authority_credentials ac
@@ -68,7 +77,7 @@ func (r *AuthorityRepository) ValidateStaticToken(token string) (int64, error) {
 	var officerID int64
 	var isActive bool
 
-	err := r.db.QueryRow(query, token).Scan(&officerID, &isActive)
+	err := r.db.QueryRowContext(ctx, query, token).Scan(&officerID, &isActive)
 	if err == sql.ErrNoRows {
 		return 0, fmt.Errorf("invalid static token")
 	}
@@ -84,7 +93,7 @@ func (r *AuthorityRepository) ValidateStaticToken(token string) (int64, error) {
 }
 
 // VerifyOfficerExists checks if officer exists and is active
-func (r *AuthorityRepository) VerifyOfficerExists(officerID int64) (bool, error) {
+func (r *AuthorityRepository) VerifyOfficerExists(ctx context.Context, officerID int64) (bool, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM officers
@@ -92,7 +101,7 @@ func (r *AuthorityRepository) VerifyOfficerExists(officerID int64) (bool, error)
 	`
 
 	var count int
-	err := r.db.QueryRow(query, officerID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, officerID).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to verify officer: %w", err)
 	}
@@ -100,26 +109,38 @@ func (r *AuthorityRepository) VerifyOfficerExists(officerID int64) (bool, error)
 	return count > 0, nil
 }
 
-// GetComplaintsByOfficerIDPaginated returns complaints assigned to officer with optional status filter; total count for pagination (read-only, uses assigned_officer_id only).
-func (r *AuthorityRepository) GetComplaintsByOfficerIDPaginated(officerID int64, statusFilter string, limit, offset int) ([]models.Complaint, int64, error) {
-	args := []interface{}{officerID}
-	countQuery := `SELECT COUNT(*) FROM complaints WHERE assigned_officer_id = ?`
+// delegatedComplaintFilter matches complaints assigned to the officer (first ?) or open complaints of officers
+// whose absence in effect (at the third and fourth ?) names them as delegate (second ?)
+const delegatedComplaintFilter = `(assigned_officer_id = ? OR (
+		assigned_officer_id IN (
+			SELECT a.officer_id FROM officer_absences a
+			WHERE a.delegate_officer_id = ? AND a.cancelled_at IS NULL AND a.starts_at <= ? AND a.ends_at > ?
+		)
+		AND current_status NOT IN ('resolved', 'rejected', 'closed')
+	))`
+
+// GetComplaintsByOfficerIDPaginated returns complaints assigned to officer with optional status filter; total count for pagination (read-only).
+// Includes the open complaints of officers the officer covers for as delegate right now (complaint.AssignedOfficerID tells them apart).
+func (r *AuthorityRepository) GetComplaintsByOfficerIDPaginated(ctx context.Context, officerID int64, statusFilter string, limit, offset int) ([]models.Complaint, int64, error) {
+	now := time.Now().UTC()
+	args := []interface{}{officerID, officerID, now, now}
+	countQuery := `SELECT COUNT(*) FROM complaints WHERE ` + delegatedComplaintFilter
 	if statusFilter != "" {
 		countQuery += ` AND current_status = ?`
 		args = append(args, statusFilter)
 	}
 	var total int64
-	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("failed to count complaints: %w", err)
 	}
-	listArgs := []interface{}{officerID}
+	listArgs := []interface{}{officerID, officerID, now, now}
 	listQuery := `
 		SELECT complaint_id, complaint_number, user_id, title, description, category,
 			location_id, latitude, longitude, assigned_department_id, assigned_officer_id,
-			current_status, priority, is_public, public_consent_given, supporter_count,
+			current_status, priority, is_public, public_consent_given, supporter_count, version,
 			resolved_at, closed_at, created_at, updated_at
 		FROM complaints
-		WHERE assigned_officer_id = ?
+		WHERE ` + delegatedComplaintFilter + `
 	`
 	if statusFilter != "" {
 		listQuery += ` AND current_status = ?`
@@ -127,7 +148,7 @@ func (r *AuthorityRepository) GetComplaintsByOfficerIDPaginated(officerID int64,
 	}
 	listQuery += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
 	listArgs = append(listArgs, limit, offset)
-	rows, err := r.db.Query(listQuery, listArgs...)
+	rows, err := r.db.QueryContext(ctx, listQuery, listArgs...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query complaints: %w", err)
 	}
@@ -140,7 +161,7 @@ func (r *AuthorityRepository) GetComplaintsByOfficerIDPaginated(officerID int64,
 			&c.ComplaintID, &c.ComplaintNumber, &c.UserID, &c.Title, &c.Description, &c.Category,
 			&c.LocationID, &c.Latitude, &c.Longitude, &c.AssignedDepartmentID, &c.AssignedOfficerID,
 			&c.CurrentStatus, &c.Priority, &c.IsPublic, &c.PublicConsentGiven, &c.SupporterCount,
-			&c.ResolvedAt, &c.ClosedAt, &c.CreatedAt, &updatedAt,
+			&c.Version, &c.ResolvedAt, &c.ClosedAt, &c.CreatedAt, &updatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan complaint: %w", err)
@@ -157,25 +178,70 @@ func (r *AuthorityRepository) GetComplaintsByOfficerIDPaginated(officerID int64,
 }
 
 // GetOfficerProfile returns department_id, location_id, authority_level for login/me (pilot: authority_level from column if present, else 1).
-func (r *AuthorityRepository) GetOfficerProfile(officerID int64) (departmentID, locationID int64, authorityLevel int, err error) {
+func (r *AuthorityRepository) GetOfficerProfile(ctx context.Context, officerID int64) (departmentID, locationID int64, authorityLevel int, err error) {
 	query := `SELECT department_id, location_id FROM officers WHERE officer_id = ? AND is_active = true`
-	err = r.db.QueryRow(query, officerID).Scan(&departmentID, &locationID)
+	err = r.db.QueryRowContext(ctx, query, officerID).Scan(&departmentID, &locationID)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to get officer profile: %w", err)
 	}
 	authorityLevel = 1
-	_ = r.db.QueryRow(`SELECT COALESCE(authority_level, 1) FROM officers WHERE officer_id = ?`, officerID).Scan(&authorityLevel)
+	_ = r.db.QueryRowContext(ctx, `SELECT COALESCE(authority_level, 1) FROM officers WHERE officer_id = ?`, officerID).Scan(&authorityLevel)
 	return departmentID, locationID, authorityLevel, nil
 }
 
+// IsSupervisorOf reports whether supervisorID is an active officer above officerID: in officerID's reporting
+// line (supervisor_officer_id, any department) or in the same department and location with a higher
+// authority_level (the officers a complaint escalates to).
+func (r *AuthorityRepository) IsSupervisorOf(ctx context.Context, supervisorID, officerID int64) (bool, error) {
+	query := `
+		SELECT COUNT(*) > 0
+		FROM officers s
+		JOIN officers o ON o.officer_id = ?
+		WHERE s.officer_id = ?
+		  AND s.is_active = true
+		  AND s.department_id = o.department_id
+		  AND s.location_id = o.location_id
+		  AND COALESCE(s.authority_level, 1) > COALESCE(o.authority_level, 1)
+	`
+	var ok bool
+	if err := r.db.QueryRowContext(ctx, query, officerID, supervisorID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check supervisor: %w", err)
+	}
+	if ok {
+		return true, nil
+	}
+
+	// Reporting line, bounded like the escalation lookup
+	current := officerID
+	for hop := 0; hop < maxReportingLineHops; hop++ {
+		var next sql.NullInt64
+		err := r.db.QueryRowContext(ctx, `SELECT supervisor_officer_id FROM officers WHERE officer_id = ?`, current).Scan(&next)
+		if err == sql.ErrNoRows || (err == nil && !next.Valid) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to check supervisor: %w", err)
+		}
+		if next.Int64 == supervisorID {
+			var active bool
+			if err := r.db.QueryRowContext(ctx, `SELECT is_active FROM officers WHERE officer_id = ?`, supervisorID).Scan(&active); err != nil {
+				return false, fmt.Errorf("failed to check supervisor: %w", err)
+			}
+			return active, nil
+		}
+		current = next.Int64
+	}
+	return false, nil
+}
+
 // GetComplaintsByOfficerID retrieves all complaints assigned to an officer
 // Returns complaints sorted by created_at DESC
-func (r *AuthorityRepository) GetComplaintsByOfficerID(officerID int64) ([]models.Complaint, error) {
+func (r *AuthorityRepository) GetComplaintsByOfficerID(ctx context.Context, officerID int64) ([]models.Complaint, error) {
 	query := `
 		SELECT 
 			complaint_id, complaint_number, user_id, title, description, category,
 			location_id, latitude, longitude, assigned_department_id, assigned_officer_id,
-			current_status, priority, is_public, public_consent_given, supporter_count,
+			current_status, priority, is_public, public_consent_given, supporter_count, version,
 			resolved_at, closed_at, created_at, updated_at
 		FROM complaints
 		WHERE assigned_officer_id = ?
@@ -183,7 +249,7 @@ func (r *AuthorityRepository) GetComplaintsByOfficerID(officerID int64) ([]model
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, officerID)
+	rows, err := r.db.QueryContext(ctx, query, officerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query complaints: %w", err)
 	}
@@ -201,6 +267,7 @@ func (r *AuthorityRepository) GetComplaintsByOfficerID(officerID int64) ([]model
 			&complaint.AssignedDepartmentID, &complaint.AssignedOfficerID,
 			&complaint.CurrentStatus, &complaint.Priority,
 			&complaint.IsPublic, &complaint.PublicConsentGiven, &complaint.SupporterCount,
+			&complaint.Version,
 			&complaint.ResolvedAt, &complaint.ClosedAt,
 			&complaint.CreatedAt, &updatedAt,
 		)
@@ -223,18 +290,20 @@ func (r *AuthorityRepository) GetComplaintsByOfficerID(officerID int64) ([]model
 }
 
 // CreateNote creates an internal note for a complaint
-func (r *AuthorityRepository) CreateNote(
+// onBehalfOfOfficerID is the absent officer when officerID writes it as their delegate (nil otherwise)
+func (r *AuthorityRepository) CreateNote(ctx context.Context,
 	complaintID int64,
 	officerID int64,
+	onBehalfOfOfficerID *int64,
 	noteText string,
 ) (int64, error) {
 	query := `
 		INSERT INTO authority_notes (
-			complaint_id, officer_id, note_text, is_visible_to_citizen, created_at
-		) VALUES (?, ?, ?, FALSE, NOW())
+			complaint_id, officer_id, on_behalf_of_officer_id, note_text, is_visible_to_citizen, created_at
+		) VALUES (?, ?, ?, ?, FALSE, NOW())
 	`
 
-	result, err := r.db.Exec(query, complaintID, officerID, noteText)
+	result, err := r.db.ExecContext(ctx, query, complaintID, officerID, onBehalfOfOfficerID, noteText)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create note: %w", err)
 	}
@@ -248,15 +317,15 @@ func (r *AuthorityRepository) CreateNote(
 }
 
 // GetNotesByComplaintID retrieves all notes for a complaint (authority view)
-func (r *AuthorityRepository) GetNotesByComplaintID(complaintID int64) ([]models.AuthorityNote, error) {
+func (r *AuthorityRepository) GetNotesByComplaintID(ctx context.Context, complaintID int64) ([]models.AuthorityNote, error) {
 	query := `
-		SELECT note_id, complaint_id, officer_id, note_text, created_at
+		SELECT note_id, complaint_id, officer_id, on_behalf_of_officer_id, note_text, created_at
 		FROM authority_notes
 		WHERE complaint_id = ?
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, complaintID)
+	rows, err := r.db.QueryContext(ctx, query, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query notes: %w", err)
 	}
@@ -265,16 +334,21 @@ func (r *AuthorityRepository) GetNotesByComplaintID(complaintID int64) ([]models
 	var notes []models.AuthorityNote
 	for rows.Next() {
 		var note models.AuthorityNote
+		var onBehalfOf sql.NullInt64
 		err := rows.Scan(
 			&note.NoteID,
 			&note.ComplaintID,
 			&note.OfficerID,
+			&onBehalfOf,
 			&note.NoteText,
 			&note.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan note: %w", err)
 		}
+		if onBehalfOf.Valid {
+			note.OnBehalfOfOfficerID = &onBehalfOf.Int64
+		}
 		notes = append(notes, note)
 	}
 
@@ -287,26 +361,32 @@ func (r *AuthorityRepository) GetNotesByComplaintID(complaintID int64) ([]models
 
 // OfficerListItem is used by admin list; no schema change.
 type OfficerListItem struct {
-	OfficerID      int64
-	FullName       string
-	DepartmentID   int64
-	LocationID     int64
-	AuthorityLevel int
-	IsActive       bool
-	Email          string
+	OfficerID           int64
+	FullName            string
+	DepartmentID        int64
+	LocationID          int64
+	AuthorityLevel      int
+	SupervisorOfficerID sql.NullInt64 // reporting line (escalations go here first)
+	IsActive            bool
+	Email               string
+	Latitude            sql.NullFloat64 // office coordinates (nearest assignment strategy)
+	Longitude           sql.NullFloat64
+	Categories          []string // officer_categories (category assignment strategy)
 }
 
 // ListOfficers returns all officers with login email (for admin GET /authorities).
-func (r *AuthorityRepository) ListOfficers() ([]OfficerListItem, error) {
+func (r *AuthorityRepository) ListOfficers(ctx context.Context) ([]OfficerListItem, error) {
 	query := `
 		SELECT o.officer_id, o.full_name, o.department_id, o.location_id,
-		       COALESCE(o.authority_level, 1), o.is_active,
-		       COALESCE(ac.email, '')
+		       COALESCE(o.authority_level, 1), o.supervisor_officer_id, o.is_active,
+		       COALESCE(ac.email, ''), o.latitude, o.longitude,
+		       (SELECT GROUP_CONCAT(oc.category ORDER BY oc.category SEPARATOR '\n')
+		        FROM officer_categories oc WHERE oc.officer_id = o.officer_id)
 		FROM officers o
 		LEFT JOIN authority_credentials ac ON ac.officer_id = o.officer_id AND ac.is_active = true
 		ORDER BY o.officer_id
 	`
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list officers: %w", err)
 	}
@@ -314,11 +394,16 @@ func (r *AuthorityRepository) ListOfficers() ([]OfficerListItem, error) {
 	var list []OfficerListItem
 	for rows.Next() {
 		var row OfficerListItem
+		var categories sql.NullString
 		err := rows.Scan(&row.OfficerID, &row.FullName, &row.DepartmentID, &row.LocationID,
-			&row.AuthorityLevel, &row.IsActive, &row.Email)
+			&row.AuthorityLevel, &row.SupervisorOfficerID, &row.IsActive, &row.Email,
+			&row.Latitude, &row.Longitude, &categories)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan officer: %w", err)
 		}
+		if categories.Valid {
+			row.Categories = strings.Split(categories.String, "\n")
+		}
 		list = append(list, row)
 	}
 	if err = rows.Err(); err != nil {
@@ -328,7 +413,8 @@ func (r *AuthorityRepository) ListOfficers() ([]OfficerListItem, error) {
 }
 
 // CreateOfficer inserts officer and one credential row. Password is hashed (bcrypt); no plaintext storage.
-func (r *AuthorityRepository) CreateOfficer(fullName, designation, email, password string, departmentID, locationID int64, authorityLevel int, isActive bool) (int64, error) {
+// supervisorOfficerID (nil = none) must pass checkSupervisor.
+func (r *AuthorityRepository) CreateOfficer(ctx context.Context, fullName, designation, email, password string, departmentID, locationID int64, authorityLevel int, supervisorOfficerID *int64, isActive bool) (int64, error) {
 	hashed, err := utils.HashAuthorityPassword(password)
 	if err != nil {
 		return 0, fmt.Errorf("failed to hash password: %w", err)
@@ -337,10 +423,17 @@ func (r *AuthorityRepository) CreateOfficer(fullName, designation, email, passwo
 	if authLevel < 1 || authLevel > 3 {
 		authLevel = 1
 	}
-	res, err := r.db.Exec(`
-		INSERT INTO officers (full_name, designation, email, department_id, location_id, authority_level, is_active, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, NOW())`,
-		fullName, designation, email, departmentID, locationID, authLevel, isActive)
+	var supervisor sql.NullInt64
+	if supervisorOfficerID != nil {
+		if err := r.checkSupervisor(ctx, 0, authLevel, *supervisorOfficerID); err != nil {
+			return 0, err
+		}
+		supervisor = sql.NullInt64{Int64: *supervisorOfficerID, Valid: true}
+	}
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO officers (full_name, designation, email, department_id, location_id, authority_level, supervisor_officer_id, is_active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())`,
+		fullName, designation, email, departmentID, locationID, authLevel, supervisor, isActive)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create officer: %w", err)
 	}
@@ -348,7 +441,7 @@ func (r *AuthorityRepository) CreateOfficer(fullName, designation, email, passwo
 	if err != nil {
 		return 0, fmt.Errorf("failed to get officer id: %w", err)
 	}
-	_, err = r.db.Exec(`
+	_, err = r.db.ExecContext(ctx, `
 		INSERT INTO authority_credentials (officer_id, email, password_hash, is_active, created_at)
 		VALUES (?, ?, ?, ?, NOW())`,
 		officerID, email, hashed, isActive)
@@ -359,24 +452,26 @@ func (r *AuthorityRepository) CreateOfficer(fullName, designation, email, passwo
 }
 
 // GetOfficerByID returns one officer for admin update; error if not found.
-func (r *AuthorityRepository) GetOfficerByID(officerID int64) (fullName string, departmentID, locationID int64, authorityLevel int, isActive bool, err error) {
-	err = r.db.QueryRow(`
-		SELECT full_name, department_id, location_id, COALESCE(authority_level, 1), is_active
+func (r *AuthorityRepository) GetOfficerByID(ctx context.Context, officerID int64) (fullName string, departmentID, locationID int64, authorityLevel int, supervisorOfficerID sql.NullInt64, isActive bool, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT full_name, department_id, location_id, COALESCE(authority_level, 1), supervisor_officer_id, is_active
 		FROM officers WHERE officer_id = ?`, officerID).
-		Scan(&fullName, &departmentID, &locationID, &authorityLevel, &isActive)
+		Scan(&fullName, &departmentID, &locationID, &authorityLevel, &supervisorOfficerID, &isActive)
 	if err != nil {
-		return "", 0, 0, 0, false, fmt.Errorf("officer not found: %w", err)
+		return "", 0, 0, 0, sql.NullInt64{}, false, fmt.Errorf("officer not found: %w", err)
 	}
-	return fullName, departmentID, locationID, authorityLevel, isActive, nil
+	return fullName, departmentID, locationID, authorityLevel, supervisorOfficerID, isActive, nil
 }
 
-// UpdateOfficer updates only non-nil fields (department_id, location_id, authority_level, is_active). No escalation rule changes.
-func (r *AuthorityRepository) UpdateOfficer(officerID int64, departmentID, locationID *int64, authorityLevel *int, isActive *bool) error {
-	row := r.db.QueryRow(`SELECT department_id, location_id, COALESCE(authority_level,1), is_active FROM officers WHERE officer_id = ?`, officerID)
+// UpdateOfficer updates only non-nil fields (department_id, location_id, authority_level, supervisor_officer_id, is_active).
+// supervisorOfficerID 0 clears the reporting line; a new level or supervisor must pass checkSupervisor. No escalation rule changes.
+func (r *AuthorityRepository) UpdateOfficer(ctx context.Context, officerID int64, departmentID, locationID *int64, authorityLevel *int, supervisorOfficerID *int64, isActive *bool) error {
+	row := r.db.QueryRowContext(ctx, `SELECT department_id, location_id, COALESCE(authority_level,1), supervisor_officer_id, is_active FROM officers WHERE officer_id = ?`, officerID)
 	var dept, loc int64
 	var level int
+	var supervisor sql.NullInt64
 	var active bool
-	if err := row.Scan(&dept, &loc, &level, &active); err != nil {
+	if err := row.Scan(&dept, &loc, &level, &supervisor, &active); err != nil {
 		return fmt.Errorf("officer not found: %w", err)
 	}
 	if departmentID != nil {
@@ -397,11 +492,80 @@ func (r *AuthorityRepository) UpdateOfficer(officerID int64, departmentID, locat
 	if isActive != nil {
 		active = *isActive
 	}
-	_, err := r.db.Exec(`
-		UPDATE officers SET department_id = ?, location_id = ?, authority_level = ?, is_active = ?, updated_at = NOW()
-		WHERE officer_id = ?`, dept, loc, level, active, officerID)
+	if supervisorOfficerID != nil {
+		supervisor = sql.NullInt64{Int64: *supervisorOfficerID, Valid: *supervisorOfficerID != 0}
+	}
+	if supervisor.Valid && (supervisorOfficerID != nil || authorityLevel != nil) {
+		if err := r.checkSupervisor(ctx, officerID, level, supervisor.Int64); err != nil {
+			return err
+		}
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE officers SET department_id = ?, location_id = ?, authority_level = ?, supervisor_officer_id = ?, is_active = ?, updated_at = NOW()
+		WHERE officer_id = ?`, dept, loc, level, supervisor, active, officerID)
 	if err != nil {
 		return fmt.Errorf("failed to update officer: %w", err)
 	}
 	return nil
 }
+
+// SetOfficerCategories replaces the complaint categories the officer handles (category assignment strategy).
+// Names are trimmed; empty and duplicate names (case-insensitive) are dropped, so an empty list clears them.
+func (r *AuthorityRepository) SetOfficerCategories(ctx context.Context, officerID int64, categories []string) error {
+	return r.InTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM officer_categories WHERE officer_id = ?`, officerID); err != nil {
+			return fmt.Errorf("failed to clear officer categories: %w", err)
+		}
+		seen := make(map[string]bool)
+		for _, category := range categories {
+			category = strings.TrimSpace(category)
+			if category == "" || seen[strings.ToLower(category)] {
+				continue
+			}
+			seen[strings.ToLower(category)] = true
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO officer_categories (officer_id, category) VALUES (?, ?)`, officerID, category,
+			); err != nil {
+				return fmt.Errorf("failed to add officer category: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// SetOfficerCoordinates sets the officer's office coordinates (nearest assignment strategy)
+func (r *AuthorityRepository) SetOfficerCoordinates(ctx context.Context, officerID int64, latitude, longitude float64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE officers SET latitude = ?, longitude = ?, updated_at = NOW() WHERE officer_id = ?`,
+		latitude, longitude, officerID)
+	if err != nil {
+		return fmt.Errorf("failed to update officer coordinates: %w", err)
+	}
+	return nil
+}
+
+// checkSupervisor validates a reporting line: the supervisor must be another active officer with a higher
+// authority_level than the officer (officerID 0 = not created yet). Levels only go up, so a line cannot loop.
+func (r *AuthorityRepository) checkSupervisor(ctx context.Context, officerID int64, officerLevel int, supervisorID int64) error {
+	if supervisorID == officerID {
+		return fmt.Errorf("invalid supervisor: an officer cannot report to themselves")
+	}
+	var level int
+	var active bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(authority_level, 1), is_active FROM officers WHERE officer_id = ?`, supervisorID,
+	).Scan(&level, &active)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("invalid supervisor: officer %d not found", supervisorID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get supervisor: %w", err)
+	}
+	if !active {
+		return fmt.Errorf("invalid supervisor: officer %d is inactive", supervisorID)
+	}
+	if level <= officerLevel {
+		return fmt.Errorf("invalid supervisor: officer %d is L%d, must be above L%d", supervisorID, level, officerLevel)
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"finalneta/models"
 	"fmt"
 	"strings"
@@ -13,12 +15,18 @@ import (
 
 // ComplaintRepository handles database operations for complaints
 type ComplaintRepository struct {
-	db *sql.DB
+	db DBTX // *sql.DB, or *sql.Tx for copies made by WithTx
+	txScope
 }
 
 // NewComplaintRepository creates a new complaint repository
 func NewComplaintRepository(db *sql.DB) *ComplaintRepository {
-	return &ComplaintRepository{db: db}
+	return &ComplaintRepository{db: db, txScope: txScope{conn: db}}
+}
+
+// WithTx returns a copy of the repository whose statements run inside tx
+func (r *ComplaintRepository) WithTx(tx *sql.Tx) *ComplaintRepository {
+	return &ComplaintRepository{db: tx, txScope: txScope{conn: r.conn, tx: tx}}
 }
 
 // GenerateComplaintNumber generates a unique complaint number
@@ -30,12 +38,12 @@ func (r *ComplaintRepository) GenerateComplaintNumber() (string, error) {
 }
 
 // CreateComplaint creates a new complaint in the database
-func (r *ComplaintRepository) CreateComplaint(complaint *models.Complaint) error {
+func (r *ComplaintRepository) CreateComplaint(ctx context.Context, complaint *models.Complaint) error {
 	// ISSUE 4: Final defensive check - verify user exists before insert
 	// This is a last-resort check in case handler check was bypassed
 	var userExists int
 	checkQuery := `SELECT COUNT(*) FROM users WHERE user_id = ?`
-	err := r.db.QueryRow(checkQuery, complaint.UserID).Scan(&userExists)
+	err := r.db.QueryRowContext(ctx, checkQuery, complaint.UserID).Scan(&userExists)
 	if err != nil {
 		return fmt.Errorf("failed to verify user existence before insert: %w", err)
 	}
@@ -52,7 +60,7 @@ func (r *ComplaintRepository) CreateComplaint(complaint *models.Complaint) error
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		complaint.ComplaintNumber,
 		complaint.UserID,
@@ -82,7 +90,7 @@ func (r *ComplaintRepository) CreateComplaint(complaint *models.Complaint) error
 				is_public, public_consent_given, supporter_count
 			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
-		result, err = r.db.Exec(
+		result, err = r.db.ExecContext(ctx,
 			queryFallback,
 			complaint.ComplaintNumber,
 			complaint.UserID,
@@ -115,19 +123,19 @@ func (r *ComplaintRepository) CreateComplaint(complaint *models.Complaint) error
 }
 
 // GetComplaintsByUserID retrieves all complaints for a specific user
-func (r *ComplaintRepository) GetComplaintsByUserID(userID int64) ([]models.Complaint, error) {
+func (r *ComplaintRepository) GetComplaintsByUserID(ctx context.Context, userID int64) ([]models.Complaint, error) {
 	query := `
 		SELECT 
 			complaint_id, complaint_number, user_id, title, description, category,
 			location_id, latitude, longitude, assigned_department_id, assigned_officer_id,
-			current_status, priority, is_public, public_consent_given, supporter_count,
+			current_status, priority, is_public, public_consent_given, supporter_count, version,
 			resolved_at, closed_at, created_at, updated_at
 		FROM complaints
 		WHERE user_id = ?
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query complaints: %w", err)
 	}
@@ -145,6 +153,7 @@ func (r *ComplaintRepository) GetComplaintsByUserID(userID int64) ([]models.Comp
 			&complaint.AssignedDepartmentID, &complaint.AssignedOfficerID,
 			&complaint.CurrentStatus, &complaint.Priority,
 			&complaint.IsPublic, &complaint.PublicConsentGiven, &complaint.SupporterCount,
+			&complaint.Version,
 			&complaint.ResolvedAt, &complaint.ClosedAt,
 			&complaint.CreatedAt, &updatedAt,
 		)
@@ -167,9 +176,9 @@ func (r *ComplaintRepository) GetComplaintsByUserID(userID int64) ([]models.Comp
 }
 
 // GetComplaintOwnerID returns the user_id (owner) of the complaint, or error if not found.
-func (r *ComplaintRepository) GetComplaintOwnerID(complaintID int64) (int64, error) {
+func (r *ComplaintRepository) GetComplaintOwnerID(ctx context.Context, complaintID int64) (int64, error) {
 	var userID int64
-	err := r.db.QueryRow(`SELECT user_id FROM complaints WHERE complaint_id = ?`, complaintID).Scan(&userID)
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM complaints WHERE complaint_id = ?`, complaintID).Scan(&userID)
 	if err == sql.ErrNoRows {
 		return 0, fmt.Errorf("complaint not found")
 	}
@@ -180,20 +189,20 @@ func (r *ComplaintRepository) GetComplaintOwnerID(complaintID int64) (int64, err
 }
 
 // GetComplaintByID retrieves a complaint by its ID
-func (r *ComplaintRepository) GetComplaintByID(complaintID int64) (*models.Complaint, error) {
+func (r *ComplaintRepository) GetComplaintByID(ctx context.Context, complaintID int64) (*models.Complaint, error) {
 	query := `
 		SELECT 
 			complaint_id, complaint_number, user_id, title, description,
 			category, location_id, latitude, longitude,
 			assigned_department_id, assigned_officer_id, current_status,
-			priority, is_public, public_consent_given, supporter_count,
+			priority, is_public, public_consent_given, supporter_count, version,
 			resolved_at, closed_at, created_at, updated_at
 		FROM complaints
 		WHERE complaint_id = ?
 	`
 
 	var complaint models.Complaint
-	err := r.db.QueryRow(query, complaintID).Scan(
+	err := r.db.QueryRowContext(ctx, query, complaintID).Scan(
 		&complaint.ComplaintID,
 		&complaint.ComplaintNumber,
 		&complaint.UserID,
@@ -210,6 +219,7 @@ func (r *ComplaintRepository) GetComplaintByID(complaintID int64) (*models.Compl
 		&complaint.IsPublic,
 		&complaint.PublicConsentGiven,
 		&complaint.SupporterCount,
+		&complaint.Version,
 		&complaint.ResolvedAt,
 		&complaint.ClosedAt,
 		&complaint.CreatedAt,
@@ -225,21 +235,55 @@ func (r *ComplaintRepository) GetComplaintByID(complaintID int64) (*models.Compl
 	return &complaint, nil
 }
 
+// GetResolvedComplaintIDsBefore returns complaints still in 'resolved' whose resolution is older than cutoff.
+// Falls back to updated_at for legacy rows without resolved_at. Oldest first, capped at limit.
+func (r *ComplaintRepository) GetResolvedComplaintIDsBefore(ctx context.Context, cutoff time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT complaint_id
+		FROM complaints
+		WHERE current_status = 'resolved'
+			AND COALESCE(resolved_at, updated_at, created_at) <= ?
+		ORDER BY COALESCE(resolved_at, updated_at, created_at) ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resolved complaints: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan resolved complaint: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating resolved complaints: %w", err)
+	}
+
+	return ids, nil
+}
+
 // GetComplaintByNumber retrieves a complaint by its complaint number
-func (r *ComplaintRepository) GetComplaintByNumber(complaintNumber string) (*models.Complaint, error) {
+func (r *ComplaintRepository) GetComplaintByNumber(ctx context.Context, complaintNumber string) (*models.Complaint, error) {
 	query := `
 		SELECT 
 			complaint_id, complaint_number, user_id, title, description,
 			category, location_id, latitude, longitude,
 			assigned_department_id, assigned_officer_id, current_status,
-			priority, is_public, public_consent_given, supporter_count,
+			priority, is_public, public_consent_given, supporter_count, version,
 			resolved_at, closed_at, created_at, updated_at
 		FROM complaints
 		WHERE complaint_number = ?
 	`
 
 	var complaint models.Complaint
-	err := r.db.QueryRow(query, complaintNumber).Scan(
+	err := r.db.QueryRowContext(ctx, query, complaintNumber).Scan(
 		&complaint.ComplaintID,
 		&complaint.ComplaintNumber,
 		&complaint.UserID,
@@ -256,6 +300,7 @@ func (r *ComplaintRepository) GetComplaintByNumber(complaintNumber string) (*mod
 		&complaint.IsPublic,
 		&complaint.PublicConsentGiven,
 		&complaint.SupporterCount,
+		&complaint.Version,
 		&complaint.ResolvedAt,
 		&complaint.ClosedAt,
 		&complaint.CreatedAt,
@@ -271,36 +316,59 @@ func (r *ComplaintRepository) GetComplaintByNumber(complaintNumber string) (*mod
 	return &complaint, nil
 }
 
+// ErrVersionConflict is returned by status writes when the complaint's version no longer matches the
+// version the caller read (another actor changed it first). Callers should re-read and retry or report 409.
+var ErrVersionConflict = errors.New("complaint version conflict")
+
+// checkVersionedUpdate maps zero affected rows on a version-guarded UPDATE to ErrVersionConflict
+func checkVersionedUpdate(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
 // UpdateComplaintStatus updates the status and related fields of a complaint
-func (r *ComplaintRepository) UpdateComplaintStatus(
+// Returns ErrVersionConflict if the complaint is no longer at expectedVersion; bumps version on success.
+func (r *ComplaintRepository) UpdateComplaintStatus(ctx context.Context,
 	complaintID int64,
 	newStatus models.ComplaintStatus,
 	assignedDepartmentID *int64,
 	assignedOfficerID *int64,
+	expectedVersion int64,
 ) error {
 	query := `
 		UPDATE complaints
 		SET current_status = ?,
 			assigned_department_id = ?,
 			assigned_officer_id = ?,
+			version = version + 1,
 			updated_at = NOW()
-		WHERE complaint_id = ?
+		WHERE complaint_id = ? AND version = ?
 	`
 
-	_, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		newStatus,
 		assignedDepartmentID,
 		assignedOfficerID,
 		complaintID,
+		expectedVersion,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update complaint status: %w", err)
 	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return err
+	}
 
 	// Update resolved_at or closed_at based on status
 	if newStatus == models.StatusResolved {
-		_, err = r.db.Exec(
+		_, err = r.db.ExecContext(ctx,
 			"UPDATE complaints SET resolved_at = NOW() WHERE complaint_id = ? AND resolved_at IS NULL",
 			complaintID,
 		)
@@ -310,7 +378,7 @@ func (r *ComplaintRepository) UpdateComplaintStatus(
 	}
 
 	if newStatus == models.StatusClosed {
-		_, err = r.db.Exec(
+		_, err = r.db.ExecContext(ctx,
 			"UPDATE complaints SET closed_at = NOW() WHERE complaint_id = ? AND closed_at IS NULL",
 			complaintID,
 		)
@@ -322,10 +390,58 @@ func (r *ComplaintRepository) UpdateComplaintStatus(
 	return nil
 }
 
+// UpdateComplaintPriority sets complaints.priority
+// Returns ErrVersionConflict if the complaint is no longer at expectedVersion; bumps version on success.
+func (r *ComplaintRepository) UpdateComplaintPriority(ctx context.Context, complaintID int64, priority models.Priority, expectedVersion int64) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE complaints SET priority = ?, version = version + 1, updated_at = NOW() WHERE complaint_id = ? AND version = ?`,
+		priority,
+		complaintID,
+		expectedVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update complaint priority: %w", err)
+	}
+	return checkVersionedUpdate(result)
+}
+
+// UpdateComplaintAssignment moves a complaint to another department, location and officer (a transfer)
+// Returns ErrVersionConflict if the complaint is no longer at expectedVersion; bumps version on success.
+func (r *ComplaintRepository) UpdateComplaintAssignment(ctx context.Context,
+	complaintID int64,
+	departmentID int64,
+	locationID int64,
+	officerID int64,
+	expectedVersion int64,
+) error {
+	query := `
+		UPDATE complaints
+		SET assigned_department_id = ?,
+			location_id = ?,
+			assigned_officer_id = ?,
+			version = version + 1,
+			updated_at = NOW()
+		WHERE complaint_id = ? AND version = ?
+	`
+
+	result, err := r.db.ExecContext(ctx,
+		query,
+		departmentID,
+		locationID,
+		officerID,
+		complaintID,
+		expectedVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update complaint assignment: %w", err)
+	}
+	return checkVersionedUpdate(result)
+}
+
 // UpdateComplaintEscalationLevel sets complaints.current_escalation_level (0=L1, 1=L2, 2=L3).
 // Call after creating an escalation record so the complaint reflects the new level.
-func (r *ComplaintRepository) UpdateComplaintEscalationLevel(complaintID int64, level int) error {
-	_, err := r.db.Exec(
+func (r *ComplaintRepository) UpdateComplaintEscalationLevel(ctx context.Context, complaintID int64, level int) error {
+	_, err := r.db.ExecContext(ctx,
 		`UPDATE complaints SET current_escalation_level = ?, updated_at = NOW() WHERE complaint_id = ?`,
 		level,
 		complaintID,
@@ -337,33 +453,37 @@ func (r *ComplaintRepository) UpdateComplaintEscalationLevel(complaintID int64,
 }
 
 // UpdateComplaintStatusWithTimestamps updates complaint status with resolved_at/closed_at timestamps
-func (r *ComplaintRepository) UpdateComplaintStatusWithTimestamps(
+// Returns ErrVersionConflict if the complaint is no longer at expectedVersion; bumps version on success.
+func (r *ComplaintRepository) UpdateComplaintStatusWithTimestamps(ctx context.Context,
 	complaintID int64,
 	newStatus models.ComplaintStatus,
 	resolvedAt *time.Time,
 	closedAt *time.Time,
+	expectedVersion int64,
 ) error {
 	query := `
 		UPDATE complaints
 		SET current_status = ?,
 			resolved_at = ?,
 			closed_at = ?,
+			version = version + 1,
 			updated_at = NOW()
-		WHERE complaint_id = ?
+		WHERE complaint_id = ? AND version = ?
 	`
 
-	_, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		newStatus,
 		resolvedAt,
 		closedAt,
 		complaintID,
+		expectedVersion,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update complaint status: %w", err)
 	}
 
-	return nil
+	return checkVersionedUpdate(result)
 }
 
 // auditActorType maps ActorType to status_history actor_type enum ('system','authority','user').
@@ -381,7 +501,7 @@ func auditActorType(t models.ActorType) string {
 }
 
 // CreateStatusHistory creates a new status history entry (immutable). Always writes actor_type, actor_id, reason when available.
-func (r *ComplaintRepository) CreateStatusHistory(history *models.ComplaintStatusHistory) error {
+func (r *ComplaintRepository) CreateStatusHistory(ctx context.Context, history *models.ComplaintStatusHistory) error {
 	var actorType string
 	if history.ActorType.Valid && history.ActorType.String != "" {
 		actorType = history.ActorType.String
@@ -406,11 +526,11 @@ func (r *ComplaintRepository) CreateStatusHistory(history *models.ComplaintStatu
 			complaint_id, old_status, new_status, changed_by_type,
 			changed_by_user_id, changed_by_officer_id,
 			assigned_department_id, assigned_officer_id, notes,
-			actor_type, actor_id, reason
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			actor_type, actor_id, reason, on_behalf_of_officer_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		history.ComplaintID,
 		history.OldStatus,
@@ -424,6 +544,7 @@ func (r *ComplaintRepository) CreateStatusHistory(history *models.ComplaintStatu
 		actorType,
 		actorID,
 		reason,
+		history.OnBehalfOfOfficerID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create status history: %w", err)
@@ -448,7 +569,7 @@ type PublicComplaintData struct {
 }
 
 // GetPublicComplaintByNumber fetches by complaint_number (shareable identifier). Returns data + complaintID for internal timeline fetch only; complaint_id never exposed.
-func (r *ComplaintRepository) GetPublicComplaintByNumber(complaintNumber string) (*PublicComplaintData, int64, error) {
+func (r *ComplaintRepository) GetPublicComplaintByNumber(ctx context.Context, complaintNumber string) (*PublicComplaintData, int64, error) {
 	query := `
 		SELECT complaint_id, complaint_number, location_id, COALESCE(assigned_department_id, 0), current_status, created_at
 		FROM complaints
@@ -456,7 +577,7 @@ func (r *ComplaintRepository) GetPublicComplaintByNumber(complaintNumber string)
 	`
 	var complaintID int64
 	var data PublicComplaintData
-	err := r.db.QueryRow(query, complaintNumber).Scan(
+	err := r.db.QueryRowContext(ctx, query, complaintNumber).Scan(
 		&complaintID,
 		&data.ComplaintNumber,
 		&data.LocationID,
@@ -474,20 +595,20 @@ func (r *ComplaintRepository) GetPublicComplaintByNumber(complaintNumber string)
 }
 
 // GetStatusHistory retrieves the status timeline for a complaint (ordered by created_at DESC)
-func (r *ComplaintRepository) GetStatusHistory(complaintID int64) ([]models.ComplaintStatusHistory, error) {
+func (r *ComplaintRepository) GetStatusHistory(ctx context.Context, complaintID int64) ([]models.ComplaintStatusHistory, error) {
 	query := `
 		SELECT 
 			history_id, complaint_id, old_status, new_status,
 			changed_by_type, changed_by_user_id, changed_by_officer_id,
 			assigned_department_id, assigned_officer_id, notes,
 			actor_type, actor_id, reason,
-			created_at
+			created_at, on_behalf_of_officer_id
 		FROM complaint_status_history
 		WHERE complaint_id = ?
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, complaintID)
+	rows, err := r.db.QueryContext(ctx, query, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query status history: %w", err)
 	}
@@ -511,6 +632,7 @@ func (r *ComplaintRepository) GetStatusHistory(complaintID int64) ([]models.Comp
 			&h.ActorID,
 			&h.Reason,
 			&h.CreatedAt,
+			&h.OnBehalfOfOfficerID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan status history: %w", err)
@@ -526,15 +648,17 @@ func (r *ComplaintRepository) GetStatusHistory(complaintID int64) ([]models.Comp
 }
 
 // CreateAttachment creates a new attachment record
-func (r *ComplaintRepository) CreateAttachment(attachment *models.ComplaintAttachment) error {
+func (r *ComplaintRepository) CreateAttachment(ctx context.Context, attachment *models.ComplaintAttachment) error {
 	query := `
 		INSERT INTO complaint_attachments (
 			complaint_id, file_name, file_path, file_type,
-			file_size, uploaded_by_user_id, is_public
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
+			file_size, uploaded_by_user_id, is_public,
+			exif_captured_at, exif_time_zoned, exif_latitude, exif_longitude, exif_camera_model,
+			perceptual_hash
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		attachment.ComplaintID,
 		attachment.FileName,
@@ -543,6 +667,12 @@ func (r *ComplaintRepository) CreateAttachment(attachment *models.ComplaintAttac
 		attachment.FileSize,
 		attachment.UploadedByUserID,
 		attachment.IsPublic,
+		attachment.ExifCapturedAt,
+		attachment.ExifTimeZoned,
+		attachment.ExifLatitude,
+		attachment.ExifLongitude,
+		attachment.ExifCameraModel,
+		attachment.PerceptualHash,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create attachment: %w", err)
@@ -557,18 +687,58 @@ func (r *ComplaintRepository) CreateAttachment(attachment *models.ComplaintAttac
 	return nil
 }
 
+// GetAttachmentByID retrieves a single attachment
+func (r *ComplaintRepository) GetAttachmentByID(ctx context.Context, attachmentID int64) (*models.ComplaintAttachment, error) {
+	query := `
+		SELECT 
+			attachment_id, complaint_id, file_name, file_path,
+			file_type, file_size, uploaded_by_user_id, is_public,
+			exif_captured_at, exif_time_zoned, exif_latitude, exif_longitude, exif_camera_model, perceptual_hash, created_at
+		FROM complaint_attachments
+		WHERE attachment_id = ?
+	`
+
+	var a models.ComplaintAttachment
+	err := r.db.QueryRowContext(ctx, query, attachmentID).Scan(
+		&a.AttachmentID,
+		&a.ComplaintID,
+		&a.FileName,
+		&a.FilePath,
+		&a.FileType,
+		&a.FileSize,
+		&a.UploadedByUserID,
+		&a.IsPublic,
+		&a.ExifCapturedAt,
+		&a.ExifTimeZoned,
+		&a.ExifLatitude,
+		&a.ExifLongitude,
+		&a.ExifCameraModel,
+		&a.PerceptualHash,
+		&a.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return &a, nil
+}
+
 // GetAttachmentsByComplaintID retrieves all attachments for a complaint
-func (r *ComplaintRepository) GetAttachmentsByComplaintID(complaintID int64) ([]models.ComplaintAttachment, error) {
+func (r *ComplaintRepository) GetAttachmentsByComplaintID(ctx context.Context, complaintID int64) ([]models.ComplaintAttachment, error) {
 	query := `
 		SELECT 
 			attachment_id, complaint_id, file_name, file_path,
-			file_type, file_size, uploaded_by_user_id, is_public, created_at
+			file_type, file_size, uploaded_by_user_id, is_public,
+			exif_captured_at, exif_time_zoned, exif_latitude, exif_longitude, exif_camera_model, perceptual_hash, created_at
 		FROM complaint_attachments
 		WHERE complaint_id = ?
 		ORDER BY created_at ASC
 	`
 
-	rows, err := r.db.Query(query, complaintID)
+	rows, err := r.db.QueryContext(ctx, query, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query attachments: %w", err)
 	}
@@ -586,6 +756,12 @@ func (r *ComplaintRepository) GetAttachmentsByComplaintID(complaintID int64) ([]
 			&a.FileSize,
 			&a.UploadedByUserID,
 			&a.IsPublic,
+			&a.ExifCapturedAt,
+			&a.ExifTimeZoned,
+			&a.ExifLatitude,
+			&a.ExifLongitude,
+			&a.ExifCameraModel,
+			&a.PerceptualHash,
 			&a.CreatedAt,
 		)
 		if err != nil {
@@ -602,7 +778,7 @@ func (r *ComplaintRepository) GetAttachmentsByComplaintID(complaintID int64) ([]
 }
 
 // CreateAuditLog creates a new audit log entry (immutable)
-func (r *ComplaintRepository) CreateAuditLog(audit *models.AuditLog) error {
+func (r *ComplaintRepository) CreateAuditLog(ctx context.Context, audit *models.AuditLog) error {
 	query := `
 		INSERT INTO audit_log (
 			entity_type, entity_id, action, action_by_type,
@@ -612,7 +788,7 @@ func (r *ComplaintRepository) CreateAuditLog(audit *models.AuditLog) error {
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		audit.EntityType,
 		audit.EntityID,
@@ -640,6 +816,103 @@ func (r *ComplaintRepository) CreateAuditLog(audit *models.AuditLog) error {
 	return nil
 }
 
+// CreateClarification records an officer's question to the citizen (status open)
+func (r *ComplaintRepository) CreateClarification(ctx context.Context, c *models.ComplaintClarification) error {
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO complaint_clarifications (complaint_id, asked_by_officer_id, question, return_status, status, asked_at)
+		VALUES (?, ?, ?, ?, 'open', NOW())`,
+		c.ComplaintID,
+		c.AskedByOfficerID,
+		c.Question,
+		c.ReturnStatus,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create clarification: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get clarification ID: %w", err)
+	}
+	c.ClarificationID = id
+	c.Status = models.ClarificationOpen
+	return nil
+}
+
+// GetOpenClarification returns the open question of a complaint, or nil if there is none
+func (r *ComplaintRepository) GetOpenClarification(ctx context.Context, complaintID int64) (*models.ComplaintClarification, error) {
+	query := `
+		SELECT clarification_id, complaint_id, asked_by_officer_id, question, return_status,
+			status, answer, asked_at, closed_at
+		FROM complaint_clarifications
+		WHERE complaint_id = ? AND status = 'open'
+		ORDER BY asked_at DESC, clarification_id DESC
+		LIMIT 1
+	`
+	var c models.ComplaintClarification
+	err := r.db.QueryRowContext(ctx, query, complaintID).Scan(
+		&c.ClarificationID, &c.ComplaintID, &c.AskedByOfficerID, &c.Question, &c.ReturnStatus,
+		&c.Status, &c.Answer, &c.AskedAt, &c.ClosedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open clarification: %w", err)
+	}
+	return &c, nil
+}
+
+// CloseClarification moves an open clarification to answered, withdrawn or expired.
+// Returns ErrVersionConflict if it is no longer open.
+func (r *ComplaintRepository) CloseClarification(ctx context.Context, clarificationID int64, status models.ClarificationStatus, answer sql.NullString) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE complaint_clarifications SET status = ?, answer = ?, closed_at = NOW()
+		WHERE clarification_id = ? AND status = 'open'`,
+		status,
+		answer,
+		clarificationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to close clarification: %w", err)
+	}
+	return checkVersionedUpdate(result)
+}
+
+// GetAwaitingCitizenComplaintIDsBefore returns complaints in 'awaiting_citizen' whose open question was asked
+// before cutoff. Oldest first, capped at limit.
+func (r *ComplaintRepository) GetAwaitingCitizenComplaintIDsBefore(ctx context.Context, cutoff time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT c.complaint_id
+		FROM complaints c
+		JOIN complaint_clarifications q ON q.complaint_id = c.complaint_id AND q.status = 'open'
+		WHERE c.current_status = 'awaiting_citizen'
+			AND q.asked_at <= ?
+		ORDER BY q.asked_at ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query awaiting complaints: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan awaiting complaint: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating awaiting complaints: %w", err)
+	}
+
+	return ids, nil
+}
+
 // SerializeToJSON converts a struct to JSON string for audit log storage
 func SerializeToJSON(v interface{}) (string, error) {
 	data, err := json.Marshal(v)
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
@@ -113,41 +114,9 @@ func (r *DepartmentRepository) GetDepartmentByCategoryAndLocation(
 	return &departmentID, priorityOverride, nil
 }
 
-// FindOfficerForDepartment finds an officer for a department and location
-// Returns first available officer or nil if none found
-func (r *DepartmentRepository) FindOfficerForDepartment(
-	departmentID int64,
-	locationID int64,
-) (*int64, error) {
-	query := `
-		SELECT officer_id
-		FROM officers
-		WHERE department_id = ?
-			AND location_id = ?
-			AND is_active = true
-		LIMIT 1
-	`
-	
-	var officerID sql.NullInt64
-	err := r.db.QueryRow(query, departmentID, locationID).Scan(&officerID)
-	if err == sql.ErrNoRows {
-		// No officer found - that's OK, complaint can be assigned to department only
-		return nil, nil
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to find officer: %w", err)
-	}
-	
-	if !officerID.Valid {
-		return nil, nil
-	}
-	
-	return &officerID.Int64, nil
-}
-
 // GetDepartmentName gets department name by ID
 // Returns department name or fallback name if not found
-func (r *DepartmentRepository) GetDepartmentName(departmentID int64) (string, error) {
+func (r *DepartmentRepository) GetDepartmentName(ctx context.Context, departmentID int64) (string, error) {
 	query := `
 		SELECT name
 		FROM departments
@@ -156,7 +125,7 @@ func (r *DepartmentRepository) GetDepartmentName(departmentID int64) (string, er
 	`
 	
 	var name string
-	err := r.db.QueryRow(query, departmentID).Scan(&name)
+	err := r.db.QueryRowContext(ctx, query, departmentID).Scan(&name)
 	if err == sql.ErrNoRows {
 		// Fallback: return descriptive name based on ID (for pilot)
 		return getFallbackDepartmentName(departmentID), nil
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"finalneta/models"
 	"fmt"
@@ -17,7 +18,7 @@ func NewEmailLogRepository(db *sql.DB) *EmailLogRepository {
 }
 
 // Create inserts an email log record (status = pending until send completes).
-func (r *EmailLogRepository) Create(log *models.EmailLog) error {
+func (r *EmailLogRepository) Create(ctx context.Context, log *models.EmailLog) error {
 	status := log.Status
 	if status == "" {
 		status = "pending"
@@ -28,7 +29,7 @@ func (r *EmailLogRepository) Create(log *models.EmailLog) error {
 			department_id, sent_to_email, subject, body, status, error_message, created_at
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
 	`
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		log.ComplaintID,
 		log.EmailType,
@@ -53,14 +54,14 @@ func (r *EmailLogRepository) Create(log *models.EmailLog) error {
 }
 
 // UpdateStatus sets status and optional error_message for an email log (after send attempt).
-func (r *EmailLogRepository) UpdateStatus(id int64, status string, errorMessage string) error {
+func (r *EmailLogRepository) UpdateStatus(ctx context.Context, id int64, status string, errorMessage string) error {
 	var errMsg interface{}
 	if errorMessage != "" {
 		errMsg = errorMessage
 	} else {
 		errMsg = nil
 	}
-	_, err := r.db.Exec(
+	_, err := r.db.ExecContext(ctx,
 		`UPDATE email_logs SET status = ?, error_message = ? WHERE id = ?`,
 		status, errMsg, id,
 	)
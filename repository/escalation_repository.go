@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"finalneta/models"
 	"fmt"
 	"log"
@@ -11,16 +13,22 @@ import (
 
 // EscalationRepository handles database operations for escalations
 type EscalationRepository struct {
-	db *sql.DB
+	db DBTX // *sql.DB, or *sql.Tx for copies made by WithTx
+	txScope
 }
 
 // NewEscalationRepository creates a new escalation repository
 func NewEscalationRepository(db *sql.DB) *EscalationRepository {
-	return &EscalationRepository{db: db}
+	return &EscalationRepository{db: db, txScope: txScope{conn: db}}
+}
+
+// WithTx returns a copy of the repository whose statements run inside tx
+func (r *EscalationRepository) WithTx(tx *sql.Tx) *EscalationRepository {
+	return &EscalationRepository{db: tx, txScope: txScope{conn: r.conn, tx: tx}}
 }
 
 // GetActiveEscalationRules retrieves all active escalation rules
-func (r *EscalationRepository) GetActiveEscalationRules() ([]models.EscalationRule, error) {
+func (r *EscalationRepository) GetActiveEscalationRules(ctx context.Context) ([]models.EscalationRule, error) {
 	query := `
 		SELECT 
 			rule_id, from_department_id, from_location_id,
@@ -31,7 +39,7 @@ func (r *EscalationRepository) GetActiveEscalationRules() ([]models.EscalationRu
 		ORDER BY escalation_level ASC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query escalation rules: %w", err)
 	}
@@ -65,10 +73,198 @@ func (r *EscalationRepository) GetActiveEscalationRules() ([]models.EscalationRu
 	return rules, nil
 }
 
+// ErrRuleVersionConflict is returned by escalation rule writes when the rule's version no longer
+// matches the version the caller read
+var ErrRuleVersionConflict = errors.New("escalation rule version conflict")
+
+const escalationRuleColumns = `rule_id, from_department_id, from_location_id,
+	to_department_id, to_location_id, escalation_level,
+	conditions, is_active, version, created_at, updated_at`
+
+// scanEscalationRule scans one row selected with escalationRuleColumns
+func scanEscalationRule(row rowScanner) (*models.EscalationRule, error) {
+	var rule models.EscalationRule
+	err := row.Scan(
+		&rule.RuleID,
+		&rule.FromDepartmentID,
+		&rule.FromLocationID,
+		&rule.ToDepartmentID,
+		&rule.ToLocationID,
+		&rule.EscalationLevel,
+		&rule.Conditions,
+		&rule.IsActive,
+		&rule.Version,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListEscalationRules returns escalation rules with their version (admin API), active only unless includeInactive
+func (r *EscalationRepository) ListEscalationRules(ctx context.Context, includeInactive bool) ([]models.EscalationRule, error) {
+	query := `SELECT ` + escalationRuleColumns + ` FROM escalation_rules`
+	if !includeInactive {
+		query += ` WHERE is_active = TRUE`
+	}
+	query += ` ORDER BY escalation_level ASC, rule_id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query escalation rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.EscalationRule{}
+	for rows.Next() {
+		rule, err := scanEscalationRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating escalation rules: %w", err)
+	}
+	return rules, nil
+}
+
+// LockActiveEscalationRulesAtLevel returns the active rules at a level with row locks (SELECT ... FOR UPDATE).
+// Call inside a transaction (WithTx) so a concurrent admin write at the same level waits for the caller's conflict check.
+func (r *EscalationRepository) LockActiveEscalationRulesAtLevel(ctx context.Context, level int) ([]models.EscalationRule, error) {
+	query := `SELECT ` + escalationRuleColumns + `
+		FROM escalation_rules
+		WHERE escalation_level = ? AND is_active = TRUE
+		ORDER BY rule_id ASC
+		FOR UPDATE`
+
+	rows, err := r.db.QueryContext(ctx, query, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock escalation rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.EscalationRule
+	for rows.Next() {
+		rule, err := scanEscalationRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating escalation rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetEscalationRule returns one rule by ID (active or not)
+func (r *EscalationRepository) GetEscalationRule(ctx context.Context, ruleID int64) (*models.EscalationRule, error) {
+	query := `SELECT ` + escalationRuleColumns + ` FROM escalation_rules WHERE rule_id = ?`
+	rule, err := scanEscalationRule(r.db.QueryRowContext(ctx, query, ruleID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("escalation rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation rule: %w", err)
+	}
+	return rule, nil
+}
+
+// CreateEscalationRule inserts a rule at version 1 and returns its ID
+func (r *EscalationRepository) CreateEscalationRule(ctx context.Context, rule *models.EscalationRule) (int64, error) {
+	query := `
+		INSERT INTO escalation_rules (
+			from_department_id, from_location_id, to_department_id, to_location_id,
+			escalation_level, conditions, is_active, version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		rule.FromDepartmentID, rule.FromLocationID, rule.ToDepartmentID, rule.ToLocationID,
+		rule.EscalationLevel, rule.Conditions, rule.IsActive,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create escalation rule: %w", err)
+	}
+	ruleID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get escalation rule ID: %w", err)
+	}
+	return ruleID, nil
+}
+
+// UpdateEscalationRule replaces a rule's scope, target, level, conditions and active flag.
+// Returns ErrRuleVersionConflict if the rule is no longer at expectedVersion; bumps version on success.
+func (r *EscalationRepository) UpdateEscalationRule(ctx context.Context, rule *models.EscalationRule, expectedVersion int) error {
+	query := `
+		UPDATE escalation_rules
+		SET from_department_id = ?, from_location_id = ?, to_department_id = ?, to_location_id = ?,
+			escalation_level = ?, conditions = ?, is_active = ?,
+			version = version + 1, updated_at = NOW()
+		WHERE rule_id = ? AND version = ?
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		rule.FromDepartmentID, rule.FromLocationID, rule.ToDepartmentID, rule.ToLocationID,
+		rule.EscalationLevel, rule.Conditions, rule.IsActive,
+		rule.RuleID, expectedVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update escalation rule: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrRuleVersionConflict
+	}
+	return nil
+}
+
+// GetEscalationRuleHistory returns the audit_log rows of a rule, oldest first (one per version)
+func (r *EscalationRepository) GetEscalationRuleHistory(ctx context.Context, ruleID int64) ([]models.AuditLog, error) {
+	query := `
+		SELECT audit_id, entity_type, entity_id, action, action_by_type,
+			old_values, new_values, ip_address, user_agent, metadata, created_at
+		FROM audit_log
+		WHERE entity_type = 'escalation_rule' AND entity_id = ?
+		ORDER BY created_at ASC, audit_id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query escalation rule history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.AuditLog{}
+	for rows.Next() {
+		var a models.AuditLog
+		if err := rows.Scan(
+			&a.AuditID, &a.EntityType, &a.EntityID, &a.Action, &a.ActionByType,
+			&a.OldValues, &a.NewValues, &a.IPAddress, &a.UserAgent, &a.Metadata, &a.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan escalation rule history: %w", err)
+		}
+		history = append(history, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating escalation rule history: %w", err)
+	}
+	return history, nil
+}
+
+// slaClockHistoryFilter drops awaiting_citizen round trips (question asked, citizen replied) from the SLA clock
+// start: the complaint keeps the clock of the status it returned to and the wait is excluded as an SLA pause.
+// An officer moving on without a reply is a real status change and restarts the clock.
+const slaClockHistoryFilter = `AND new_status <> 'awaiting_citizen' AND NOT (old_status <=> 'awaiting_citizen' AND changed_by_type = 'user')`
+
 // GetEscalationCandidates retrieves complaints that may need escalation.
 // Only filters by status; SLA timing is applied later in evaluateEscalationConditions.
+// Complaints awaiting the citizen are skipped: their SLA clock is paused.
 // (Previously a 24h "stale" filter excluded recent complaints and prevented 2-min pilot escalation.)
-func (r *EscalationRepository) GetEscalationCandidates(
+func (r *EscalationRepository) GetEscalationCandidates(ctx context.Context,
 	statuses []models.ComplaintStatus,
 	_ time.Duration, // unused; kept for API compatibility
 ) ([]models.EscalationCandidate, error) {
@@ -87,31 +283,51 @@ func (r *EscalationRepository) GetEscalationCandidates(
 		statusFilter += ")"
 	}
 
+	return r.queryEscalationCandidates(ctx, statusFilter, args...)
+}
+
+// GetEscalationCandidate returns one complaint as an escalation candidate (a citizen's escalation request),
+// or nil if it does not exist or is in a status the engine never escalates
+func (r *EscalationRepository) GetEscalationCandidate(ctx context.Context, complaintID int64) (*models.EscalationCandidate, error) {
+	candidates, err := r.queryEscalationCandidates(ctx, "AND c.complaint_id = ?", complaintID)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	return &candidates[0], nil
+}
+
+// queryEscalationCandidates selects open complaints with their SLA clock start; filter is appended to the WHERE clause
+func (r *EscalationRepository) queryEscalationCandidates(ctx context.Context, filter string, args ...interface{}) ([]models.EscalationCandidate, error) {
 	query := fmt.Sprintf(`
 		SELECT 
 			c.complaint_id,
 			c.complaint_number,
 			c.current_status,
 			c.priority,
+			c.category,
 			c.assigned_department_id,
 			c.assigned_officer_id,
 			c.location_id,
 			c.pincode,
 			c.created_at,
 			c.updated_at,
+			c.version,
 			COALESCE(
 				(SELECT MAX(created_at) 
 				 FROM complaint_status_history 
-				 WHERE complaint_id = c.complaint_id),
+				 WHERE complaint_id = c.complaint_id `+slaClockHistoryFilter+`),
 				c.created_at
 			) as last_status_change_at
 		FROM complaints c
-		WHERE c.current_status NOT IN ('resolved', 'closed', 'rejected')
+		WHERE c.current_status NOT IN ('resolved', 'closed', 'rejected', 'awaiting_citizen')
 			%s
 		ORDER BY c.created_at ASC
-	`, statusFilter)
+	`, filter)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query escalation candidates: %w", err)
 	}
@@ -125,12 +341,14 @@ func (r *EscalationRepository) GetEscalationCandidates(
 			&candidate.ComplaintNumber,
 			&candidate.CurrentStatus,
 			&candidate.Priority,
+			&candidate.Category,
 			&candidate.AssignedDepartmentID,
 			&candidate.AssignedOfficerID,
 			&candidate.LocationID,
 			&candidate.Pincode,
 			&candidate.CreatedAt,
 			&candidate.UpdatedAt,
+			&candidate.Version,
 			&candidate.LastStatusChangeAt,
 		)
 		if err != nil {
@@ -147,8 +365,8 @@ func (r *EscalationRepository) GetEscalationCandidates(
 }
 
 // HasExistingEscalation checks if complaint already has an escalation at the given level
-// This ensures idempotency - don't escalate twice at the same level
-func (r *EscalationRepository) HasExistingEscalation(
+// This ensures idempotency - don't escalate twice at the same level (transfers do not count)
+func (r *EscalationRepository) HasExistingEscalation(ctx context.Context,
 	complaintID int64,
 	escalationLevel int,
 	withinHours int,
@@ -160,11 +378,36 @@ func (r *EscalationRepository) HasExistingEscalation(
 		FROM complaint_escalations
 		WHERE complaint_id = ?
 			AND escalation_level = ?
+			AND escalation_type = 'escalation'
 			AND created_at >= ?
 	`
 
 	var count int
-	err := r.db.QueryRow(query, complaintID, escalationLevel, cutoffTime).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, complaintID, escalationLevel, cutoffTime).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing escalation: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// HasEscalationBy reports whether the complaint was ever escalated at the given level by this kind of actor
+// (a citizen may request escalation once per level)
+func (r *EscalationRepository) HasEscalationBy(ctx context.Context,
+	complaintID int64,
+	escalationLevel int,
+	escalatedByType models.ActorType,
+) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM complaint_escalations
+		WHERE complaint_id = ?
+			AND escalation_level = ?
+			AND escalated_by_type = ?
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, complaintID, escalationLevel, escalatedByType).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check existing escalation: %w", err)
 	}
@@ -172,35 +415,97 @@ func (r *EscalationRepository) HasExistingEscalation(
 	return count > 0, nil
 }
 
-// GetLastEscalationLevel gets the highest escalation level for a complaint
-func (r *EscalationRepository) GetLastEscalationLevel(complaintID int64) (int, error) {
+// GetEscalationsByComplaint returns a complaint's escalations, oldest first
+func (r *EscalationRepository) GetEscalationsByComplaint(ctx context.Context, complaintID int64) ([]models.ComplaintEscalation, error) {
 	query := `
-		SELECT COALESCE(MAX(escalation_level), 0)
+		SELECT escalation_id, complaint_id, from_department_id, from_officer_id,
+			to_department_id, to_officer_id, escalation_level, escalation_type, reason,
+			escalated_by_type, escalated_by_user_id, escalated_by_officer_id,
+			status_history_id, created_at
 		FROM complaint_escalations
 		WHERE complaint_id = ?
+		ORDER BY created_at ASC, escalation_id ASC
 	`
 
-	var level int
-	err := r.db.QueryRow(query, complaintID).Scan(&level)
+	rows, err := r.db.QueryContext(ctx, query, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query escalations: %w", err)
+	}
+	defer rows.Close()
+
+	var escalations []models.ComplaintEscalation
+	for rows.Next() {
+		var e models.ComplaintEscalation
+		if err := rows.Scan(
+			&e.EscalationID,
+			&e.ComplaintID,
+			&e.FromDepartmentID,
+			&e.FromOfficerID,
+			&e.ToDepartmentID,
+			&e.ToOfficerID,
+			&e.EscalationLevel,
+			&e.EscalationType,
+			&e.Reason,
+			&e.EscalatedByType,
+			&e.EscalatedByUserID,
+			&e.EscalatedByOfficerID,
+			&e.StatusHistoryID,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan escalation: %w", err)
+		}
+		escalations = append(escalations, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating escalations: %w", err)
+	}
+
+	return escalations, nil
+}
+
+// GetLastEscalationLevel gets the complaint's current escalation level (0 = L1, 1 = L2, 2 = L3)
+func (r *EscalationRepository) GetLastEscalationLevel(ctx context.Context, complaintID int64) (int, error) {
+	escalations, err := r.GetEscalationsByComplaint(ctx, complaintID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get last escalation level: %w", err)
 	}
+	return currentEscalationLevel(escalations), nil
+}
 
-	return level, nil
+// currentEscalationLevel is the level a complaint has reached through its escalations.
+// Escalation rows store the level before escalating, so each one counts one level up; transfers keep their level.
+func currentEscalationLevel(escalations []models.ComplaintEscalation) int {
+	level := 0
+	for _, e := range escalations {
+		reached := e.EscalationLevel + 1
+		if e.EscalationType == models.EscalationTypeTransfer {
+			reached = e.EscalationLevel
+		}
+		if reached > level {
+			level = reached
+		}
+	}
+	return level
 }
 
 // CreateEscalation creates a new escalation record
-func (r *EscalationRepository) CreateEscalation(escalation *models.ComplaintEscalation) error {
+func (r *EscalationRepository) CreateEscalation(ctx context.Context, escalation *models.ComplaintEscalation) error {
 	query := `
 		INSERT INTO complaint_escalations (
 			complaint_id, from_department_id, from_officer_id,
-			to_department_id, to_officer_id, escalation_level,
+			to_department_id, to_officer_id, escalation_level, escalation_type,
 			reason, escalated_by_type, escalated_by_user_id,
 			escalated_by_officer_id, status_history_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(
+	escalationType := escalation.EscalationType
+	if escalationType == "" {
+		escalationType = models.EscalationTypeEscalation
+	}
+
+	result, err := r.db.ExecContext(ctx,
 		query,
 		escalation.ComplaintID,
 		escalation.FromDepartmentID,
@@ -208,6 +513,7 @@ func (r *EscalationRepository) CreateEscalation(escalation *models.ComplaintEsca
 		escalation.ToDepartmentID,
 		escalation.ToOfficerID,
 		escalation.EscalationLevel,
+		escalationType,
 		escalation.Reason,
 		escalation.EscalatedByType,
 		escalation.EscalatedByUserID,
@@ -229,26 +535,94 @@ func (r *EscalationRepository) CreateEscalation(escalation *models.ComplaintEsca
 
 // GetLastReminderTime gets the last reminder time for a complaint (if any)
 // Checks audit_log for reminder actions
-func (r *EscalationRepository) GetLastReminderTime(complaintID int64) (*time.Time, error) {
+func (r *EscalationRepository) GetLastReminderTime(ctx context.Context, complaintID int64) (*time.Time, error) {
+	return r.lastAuditActionTime(ctx, complaintID, "reminder")
+}
+
+// GetLastTargetMissingAlertTime gets when the engine last alerted that a complaint had no escalation target (if ever)
+// Checks audit_log for escalation_target_missing actions
+func (r *EscalationRepository) GetLastTargetMissingAlertTime(ctx context.Context, complaintID int64) (*time.Time, error) {
+	return r.lastAuditActionTime(ctx, complaintID, "escalation_target_missing")
+}
+
+func (r *EscalationRepository) lastAuditActionTime(ctx context.Context, complaintID int64, action string) (*time.Time, error) {
 	query := `
 		SELECT MAX(created_at)
 		FROM audit_log
 		WHERE entity_type = 'complaint'
 			AND entity_id = ?
-			AND action = 'reminder'
+			AND action = ?
 	`
 
-	var lastReminder sql.NullTime
-	err := r.db.QueryRow(query, complaintID).Scan(&lastReminder)
+	var last sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, complaintID, action).Scan(&last)
 	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get last reminder time: %w", err)
+		return nil, fmt.Errorf("failed to get last %s time: %w", action, err)
 	}
 
-	if !lastReminder.Valid {
+	if !last.Valid {
 		return nil, nil
 	}
 
-	return &lastReminder.Time, nil
+	return &last.Time, nil
+}
+
+// GetLastStatusChangeAt returns when the complaint last changed status (created_at if it never has).
+// Same clock as last_status_change_at in GetEscalationCandidates.
+func (r *EscalationRepository) GetLastStatusChangeAt(ctx context.Context, complaintID int64) (time.Time, error) {
+	query := `
+		SELECT COALESCE(
+			(SELECT MAX(created_at) FROM complaint_status_history WHERE complaint_id = c.complaint_id ` + slaClockHistoryFilter + `),
+			c.created_at
+		)
+		FROM complaints c
+		WHERE c.complaint_id = ?
+	`
+
+	var lastChange time.Time
+	if err := r.db.QueryRowContext(ctx, query, complaintID).Scan(&lastChange); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last status change: %w", err)
+	}
+
+	return lastChange, nil
+}
+
+// GetSLAPauses returns the completed periods a complaint spent in awaiting_citizen, oldest first
+// (the SLA clock is stopped while the officer waits for the citizen)
+func (r *EscalationRepository) GetSLAPauses(ctx context.Context, complaintID int64) ([]models.SLAPause, error) {
+	query := `
+		SELECT old_status, new_status, created_at
+		FROM complaint_status_history
+		WHERE complaint_id = ? AND (new_status = 'awaiting_citizen' OR old_status = 'awaiting_citizen')
+		ORDER BY created_at ASC, history_id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SLA pauses: %w", err)
+	}
+	defer rows.Close()
+
+	var pauses []models.SLAPause
+	var start *time.Time
+	for rows.Next() {
+		var oldStatus sql.NullString
+		var newStatus string
+		var at time.Time
+		if err := rows.Scan(&oldStatus, &newStatus, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan SLA pause: %w", err)
+		}
+		switch {
+		case newStatus == string(models.StatusAwaitingCitizen):
+			start = &at
+		case start != nil:
+			pauses = append(pauses, models.SLAPause{Start: *start, End: at})
+			start = nil
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SLA pauses: %w", err)
+	}
+	return pauses, nil
 }
 
 // ParseEscalationConditions parses JSON conditions from escalation rule
@@ -266,56 +640,87 @@ func ParseEscalationConditions(conditionsJSON sql.NullString) (*models.Escalatio
 	return &conditions, nil
 }
 
-// FindAuthorityByDepartmentPincodeLevel finds authority (officer) for escalation.
-// Tries (1) employee_id pattern L{level}. [PILOT] Then (2) any active officer in department+location.
-func (r *EscalationRepository) FindAuthorityByDepartmentPincodeLevel(
+// maxReportingLineHops bounds the walk up supervisor_officer_id (guards against a cycle in officer data)
+const maxReportingLineHops = 5
+
+// FindEscalationOfficer finds the officer a complaint escalates to: the nearest active officer up
+// fromOfficerID's reporting line (supervisor_officer_id) in the department and location with authority_level
+// at or above minAuthorityLevel, else FindOfficerAtLevel. The level is raised above fromOfficerID's own, and
+// fromOfficerID (0 = complaint unassigned) is never returned. nil when the hierarchy has no such officer.
+func (r *EscalationRepository) FindEscalationOfficer(ctx context.Context,
 	departmentID int64,
 	locationID int64,
-	escalationLevel int, // CURRENT level before escalation (0=L1, 1=L2)
+	minAuthorityLevel int, // 2 = L2, 3 = L3
+	fromOfficerID int64,
 ) (*int64, error) {
-	targetAuthorityLevel := escalationLevel + 1
-	log.Printf("[ESCALATION_DEBUG] FindAuthorityByDepartmentPincodeLevel: department_id=%d location_id=%d current_level=%d target_authority_level=%d",
-		departmentID, locationID, escalationLevel, targetAuthorityLevel)
-
-	// 1) Prefer officer matching employee_id pattern (e.g. PHED-L2-001)
-	pattern1 := fmt.Sprintf("%%-L%d-%%", targetAuthorityLevel)
-	pattern2 := fmt.Sprintf("%%-L%d%%", targetAuthorityLevel)
-	queryPattern := `
-		SELECT officer_id FROM officers
-		WHERE department_id = ? AND location_id = ? AND is_active = true
-		AND (employee_id LIKE ? OR employee_id LIKE ?)
-		LIMIT 1
-	`
-	log.Printf("[ESCALATION_DEBUG] Authority SQL (pattern): SELECT officer_id FROM officers WHERE department_id=? AND location_id=? AND is_active=true AND (employee_id LIKE ? OR employee_id LIKE ?) LIMIT 1")
-	var officerID sql.NullInt64
-	err := r.db.QueryRow(queryPattern, departmentID, locationID, pattern1, pattern2).Scan(&officerID)
-	if err == nil && officerID.Valid {
-		log.Printf("[ESCALATION_DEBUG] Authority lookup (pattern): rows_returned=1 officer_id=%d", officerID.Int64)
-		return &officerID.Int64, nil
-	}
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to find authority (pattern): %w", err)
+	if fromOfficerID != 0 {
+		var fromLevel int
+		var supervisorID sql.NullInt64
+		err := r.db.QueryRowContext(ctx,
+			`SELECT COALESCE(authority_level, 1), supervisor_officer_id FROM officers WHERE officer_id = ?`,
+			fromOfficerID,
+		).Scan(&fromLevel, &supervisorID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get officer %d: %w", fromOfficerID, err)
+		}
+		if fromLevel >= minAuthorityLevel {
+			minAuthorityLevel = fromLevel + 1
+		}
+
+		for hop := 0; supervisorID.Valid && hop < maxReportingLineHops; hop++ {
+			officerID := supervisorID.Int64
+			var dept, loc int64
+			var level int
+			var active bool
+			err := r.db.QueryRowContext(ctx, `
+				SELECT department_id, location_id, COALESCE(authority_level, 1), is_active, supervisor_officer_id
+				FROM officers WHERE officer_id = ?
+			`, officerID).Scan(&dept, &loc, &level, &active, &supervisorID)
+			if err == sql.ErrNoRows {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get supervisor %d: %w", officerID, err)
+			}
+			if active && officerID != fromOfficerID && dept == departmentID && loc == locationID && level >= minAuthorityLevel {
+				log.Printf("[ESCALATION_DEBUG] Authority lookup (reporting line): officer_id=%d reports to officer_id=%d (L%d, %d hop(s))",
+					fromOfficerID, officerID, level, hop+1)
+				return &officerID, nil
+			}
+		}
 	}
-	log.Printf("[ESCALATION_DEBUG] Authority lookup (pattern): rows_returned=0, trying any active officer [PILOT]")
 
-	// [PILOT] Fallback: any active officer in department + location
-	queryAny := `
+	return r.FindOfficerAtLevel(ctx, departmentID, locationID, minAuthorityLevel, fromOfficerID)
+}
+
+// FindOfficerAtLevel returns the active officer in the department and location with the lowest
+// authority_level at or above minAuthorityLevel (lowest officer_id among equals), skipping excludeOfficerID.
+// nil when there is none.
+func (r *EscalationRepository) FindOfficerAtLevel(ctx context.Context,
+	departmentID int64,
+	locationID int64,
+	minAuthorityLevel int,
+	excludeOfficerID int64,
+) (*int64, error) {
+	query := `
 		SELECT officer_id FROM officers
 		WHERE department_id = ? AND location_id = ? AND is_active = true
+			AND COALESCE(authority_level, 1) >= ?
+			AND officer_id <> ?
+		ORDER BY COALESCE(authority_level, 1) ASC, officer_id ASC
 		LIMIT 1
 	`
-	err = r.db.QueryRow(queryAny, departmentID, locationID).Scan(&officerID)
+	var officerID int64
+	err := r.db.QueryRowContext(ctx, query, departmentID, locationID, minAuthorityLevel, excludeOfficerID).Scan(&officerID)
 	if err == sql.ErrNoRows {
-		log.Printf("[ESCALATION_DEBUG] Authority lookup (any): rows_returned=0")
+		log.Printf("[ESCALATION_DEBUG] Authority lookup (level): no active officer at L%d or above in department_id=%d location_id=%d",
+			minAuthorityLevel, departmentID, locationID)
 		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to find authority (any): %w", err)
-	}
-	if !officerID.Valid {
-		log.Printf("[ESCALATION_DEBUG] Authority lookup (any): rows_returned=1 but officer_id NULL")
-		return nil, nil
+		return nil, fmt.Errorf("failed to find authority: %w", err)
 	}
-	log.Printf("[ESCALATION_DEBUG] Authority lookup (any): rows_returned=1 officer_id=%d", officerID.Int64)
-	return &officerID.Int64, nil
+	log.Printf("[ESCALATION_DEBUG] Authority lookup (level): department_id=%d location_id=%d min_level=L%d officer_id=%d",
+		departmentID, locationID, minAuthorityLevel, officerID)
+	return &officerID, nil
 }
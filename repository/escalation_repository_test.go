@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"testing"
+
+	"finalneta/models"
+)
+
+func TestCurrentEscalationLevel(t *testing.T) {
+	at := func(levels ...int) []models.ComplaintEscalation {
+		escalations := make([]models.ComplaintEscalation, len(levels))
+		for i, l := range levels {
+			escalations[i] = models.ComplaintEscalation{EscalationLevel: l}
+		}
+		return escalations
+	}
+
+	tests := []struct {
+		name        string
+		escalations []models.ComplaintEscalation
+		want        int
+	}{
+		{"never escalated", nil, 0},
+		{"escalated from L1", at(0), 1},
+		{"escalated from L1 and L2", at(0, 1), 2},
+		{"out of order", at(1, 0), 2},
+		{"repeated request at one level", at(0, 0), 1},
+		{"transfer at L1", []models.ComplaintEscalation{
+			{EscalationLevel: 0, EscalationType: models.EscalationTypeTransfer},
+		}, 0},
+		{"transfer after escalating", []models.ComplaintEscalation{
+			{EscalationLevel: 0, EscalationType: models.EscalationTypeEscalation},
+			{EscalationLevel: 1, EscalationType: models.EscalationTypeTransfer},
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := currentEscalationLevel(tt.escalations); got != tt.want {
+				t.Errorf("currentEscalationLevel = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
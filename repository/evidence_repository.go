@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"finalneta/models"
 	"fmt"
@@ -9,16 +10,22 @@ import (
 // EvidenceRepository handles database operations for evidence integrity.
 // complaint_evidence is write-once: no updates to evidence_hash, latitude, longitude, captured_at.
 type EvidenceRepository struct {
-	db *sql.DB
+	db DBTX // *sql.DB, or *sql.Tx for copies made by WithTx
+	txScope
 }
 
 // NewEvidenceRepository creates a new evidence repository
 func NewEvidenceRepository(db *sql.DB) *EvidenceRepository {
-	return &EvidenceRepository{db: db}
+	return &EvidenceRepository{db: db, txScope: txScope{conn: db}}
+}
+
+// WithTx returns a copy of the repository whose statements run inside tx
+func (r *EvidenceRepository) WithTx(tx *sql.Tx) *EvidenceRepository {
+	return &EvidenceRepository{db: tx, txScope: txScope{conn: r.conn, tx: tx}}
 }
 
 // CreateEvidence creates a new evidence integrity record
-func (r *EvidenceRepository) CreateEvidence(evidence *models.ComplaintEvidence) error {
+func (r *EvidenceRepository) CreateEvidence(ctx context.Context, evidence *models.ComplaintEvidence) error {
 	query := `
 		INSERT INTO complaint_evidence (
 			attachment_id, complaint_id, evidence_hash, captured_at,
@@ -26,7 +33,7 @@ func (r *EvidenceRepository) CreateEvidence(evidence *models.ComplaintEvidence)
 		) VALUES (?, ?, ?, ?, ?, ?, NOW())
 	`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		evidence.AttachmentID,
 		evidence.ComplaintID,
@@ -49,7 +56,7 @@ func (r *EvidenceRepository) CreateEvidence(evidence *models.ComplaintEvidence)
 }
 
 // GetEvidenceByAttachmentID retrieves evidence record for an attachment
-func (r *EvidenceRepository) GetEvidenceByAttachmentID(attachmentID int64) (*models.ComplaintEvidence, error) {
+func (r *EvidenceRepository) GetEvidenceByAttachmentID(ctx context.Context, attachmentID int64) (*models.ComplaintEvidence, error) {
 	query := `
 		SELECT 
 			evidence_id, attachment_id, complaint_id, evidence_hash,
@@ -60,7 +67,7 @@ func (r *EvidenceRepository) GetEvidenceByAttachmentID(attachmentID int64) (*mod
 	`
 
 	var evidence models.ComplaintEvidence
-	err := r.db.QueryRow(query, attachmentID).Scan(
+	err := r.db.QueryRowContext(ctx, query, attachmentID).Scan(
 		&evidence.EvidenceID,
 		&evidence.AttachmentID,
 		&evidence.ComplaintID,
@@ -81,7 +88,7 @@ func (r *EvidenceRepository) GetEvidenceByAttachmentID(attachmentID int64) (*mod
 }
 
 // GetEvidenceByComplaintID retrieves all evidence records for a complaint
-func (r *EvidenceRepository) GetEvidenceByComplaintID(complaintID int64) ([]models.ComplaintEvidence, error) {
+func (r *EvidenceRepository) GetEvidenceByComplaintID(ctx context.Context, complaintID int64) ([]models.ComplaintEvidence, error) {
 	query := `
 		SELECT 
 			evidence_id, attachment_id, complaint_id, evidence_hash,
@@ -91,7 +98,52 @@ func (r *EvidenceRepository) GetEvidenceByComplaintID(complaintID int64) ([]mode
 		ORDER BY captured_at ASC
 	`
 
-	rows, err := r.db.Query(query, complaintID)
+	rows, err := r.db.QueryContext(ctx, query, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evidence: %w", err)
+	}
+	defer rows.Close()
+
+	var evidenceList []models.ComplaintEvidence
+	for rows.Next() {
+		var evidence models.ComplaintEvidence
+		err := rows.Scan(
+			&evidence.EvidenceID,
+			&evidence.AttachmentID,
+			&evidence.ComplaintID,
+			&evidence.EvidenceHash,
+			&evidence.CapturedAt,
+			&evidence.Latitude,
+			&evidence.Longitude,
+			&evidence.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan evidence: %w", err)
+		}
+		evidenceList = append(evidenceList, evidence)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating evidence: %w", err)
+	}
+
+	return evidenceList, nil
+}
+
+// ListEvidenceAfter returns up to limit evidence records with evidence_id > afterID, in id order.
+// Used by the integrity sweep to page through the whole table.
+func (r *EvidenceRepository) ListEvidenceAfter(ctx context.Context, afterID int64, limit int) ([]models.ComplaintEvidence, error) {
+	query := `
+		SELECT 
+			evidence_id, attachment_id, complaint_id, evidence_hash,
+			captured_at, latitude, longitude, created_at
+		FROM complaint_evidence
+		WHERE evidence_id > ?
+		ORDER BY evidence_id ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query evidence: %w", err)
 	}
@@ -122,3 +174,27 @@ func (r *EvidenceRepository) GetEvidenceByComplaintID(complaintID int64) ([]mode
 
 	return evidenceList, nil
 }
+
+// GetLastIntegrityStatus returns the status recorded by the newest integrity audit row for one evidence record
+// (sweep failure or restoration, or an on-demand check), or "" if none was ever written
+func (r *EvidenceRepository) GetLastIntegrityStatus(ctx context.Context, complaintID, evidenceID int64) (models.EvidenceCheckStatus, error) {
+	query := `
+		SELECT JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.status'))
+		FROM audit_log
+		WHERE entity_type = 'complaint' AND entity_id = ?
+			AND action IN ('evidence_integrity_failed', 'evidence_integrity_restored', 'evidence_verified')
+			AND JSON_EXTRACT(metadata, '$.evidence_id') = ?
+		ORDER BY created_at DESC, audit_id DESC
+		LIMIT 1
+	`
+
+	var status sql.NullString
+	err := r.db.QueryRowContext(ctx, query, complaintID, evidenceID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get last integrity status: %w", err)
+	}
+	return models.EvidenceCheckStatus(status.String), nil
+}
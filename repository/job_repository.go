@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"finalneta/models"
+	"fmt"
+	"time"
+)
+
+// JobRepository handles the worker control tables: worker_jobs (shared pause state, last/next run)
+// and job_runs (one row per run with outcome counts)
+type JobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// RegisterJob creates the job's worker_jobs row, or updates its schedule (pause state is kept)
+func (r *JobRepository) RegisterJob(ctx context.Context, name, schedule string) error {
+	query := `
+		INSERT INTO worker_jobs (job_name, schedule, updated_at)
+		VALUES (?, ?, NOW())
+		ON DUPLICATE KEY UPDATE schedule = VALUES(schedule), updated_at = NOW()
+	`
+	if _, err := r.db.ExecContext(ctx, query, name, schedule); err != nil {
+		return fmt.Errorf("failed to register job %s: %w", name, err)
+	}
+	return nil
+}
+
+// GetJobStates returns the control record of every registered job, keyed by name
+func (r *JobRepository) GetJobStates(ctx context.Context) (map[string]models.WorkerJobState, error) {
+	query := `
+		SELECT job_name, schedule, paused, paused_at, pause_reason, last_run_at, last_run_status, next_run_at
+		FROM worker_jobs
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]models.WorkerJobState)
+	for rows.Next() {
+		var s models.WorkerJobState
+		if err := rows.Scan(&s.JobName, &s.Schedule, &s.Paused, &s.PausedAt, &s.PauseReason,
+			&s.LastRunAt, &s.LastRunStatus, &s.NextRunAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		states[s.JobName] = s
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+	return states, nil
+}
+
+// IsJobPaused reports whether scheduled runs of the job are paused (false if the job has no row)
+func (r *JobRepository) IsJobPaused(ctx context.Context, name string) (bool, error) {
+	var paused bool
+	err := r.db.QueryRowContext(ctx, `SELECT paused FROM worker_jobs WHERE job_name = ?`, name).Scan(&paused)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read job %s: %w", name, err)
+	}
+	return paused, nil
+}
+
+// SetJobPaused pauses or resumes a job on every instance
+func (r *JobRepository) SetJobPaused(ctx context.Context, name string, paused bool, reason string) error {
+	query := `
+		UPDATE worker_jobs
+		SET paused = ?,
+			paused_at = IF(?, NOW(), NULL),
+			pause_reason = ?,
+			updated_at = NOW()
+		WHERE job_name = ?
+	`
+	result, err := r.db.ExecContext(ctx, query, paused, paused, sql.NullString{String: reason, Valid: paused && reason != ""}, name)
+	if err != nil {
+		return fmt.Errorf("failed to update job %s: %w", name, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("job %s not found", name)
+	}
+	return nil
+}
+
+// SetJobNextRun records when this instance will next run the job
+func (r *JobRepository) SetJobNextRun(ctx context.Context, name string, nextRunAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE worker_jobs SET next_run_at = ?, updated_at = NOW() WHERE job_name = ?`, nextRunAt, name)
+	if err != nil {
+		return fmt.Errorf("failed to update job %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetJobLastRun records the end of a run on the job's control record
+func (r *JobRepository) SetJobLastRun(ctx context.Context, name string, finishedAt time.Time, status models.JobRunStatus) error {
+	query := `UPDATE worker_jobs SET last_run_at = ?, last_run_status = ?, updated_at = NOW() WHERE job_name = ?`
+	if _, err := r.db.ExecContext(ctx, query, finishedAt, status, name); err != nil {
+		return fmt.Errorf("failed to update job %s: %w", name, err)
+	}
+	return nil
+}
+
+// CreateJobRun inserts a run in status running and sets run.RunID
+func (r *JobRepository) CreateJobRun(ctx context.Context, run *models.JobRun) error {
+	query := `
+		INSERT INTO job_runs (job_name, instance_id, trigger_type, status, started_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, run.JobName, run.InstanceID, run.Trigger, run.Status, run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create job run: %w", err)
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get job run ID: %w", err)
+	}
+	run.RunID = runID
+	return nil
+}
+
+// FinishJobRun stores the outcome of a run created by CreateJobRun
+func (r *JobRepository) FinishJobRun(ctx context.Context, run *models.JobRun) error {
+	var counts sql.NullString
+	if len(run.Counts) > 0 {
+		countsJSON, err := json.Marshal(run.Counts)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job run counts: %w", err)
+		}
+		counts = sql.NullString{String: string(countsJSON), Valid: true}
+	}
+
+	query := `
+		UPDATE job_runs
+		SET status = ?, counts = ?, error_message = ?, finished_at = ?, duration_ms = ?
+		WHERE run_id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		run.Status,
+		counts,
+		sql.NullString{String: run.ErrorMessage, Valid: run.ErrorMessage != ""},
+		run.FinishedAt,
+		run.DurationMS,
+		run.RunID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish job run %d: %w", run.RunID, err)
+	}
+	return nil
+}
+
+// GetJobRuns returns a job's most recent runs, newest first
+func (r *JobRepository) GetJobRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	query := `
+		SELECT run_id, job_name, instance_id, trigger_type, status, counts, error_message,
+			started_at, finished_at, duration_ms
+		FROM job_runs
+		WHERE job_name = ?
+		ORDER BY started_at DESC, run_id DESC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.JobRun
+	for rows.Next() {
+		var run models.JobRun
+		var counts, errorMessage sql.NullString
+		var finishedAt sql.NullTime
+		var durationMS sql.NullInt64
+		if err := rows.Scan(&run.RunID, &run.JobName, &run.InstanceID, &run.Trigger, &run.Status,
+			&counts, &errorMessage, &run.StartedAt, &finishedAt, &durationMS); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		if counts.Valid && counts.String != "" {
+			if err := json.Unmarshal([]byte(counts.String), &run.Counts); err != nil {
+				return nil, fmt.Errorf("failed to parse counts of job run %d: %w", run.RunID, err)
+			}
+		}
+		run.ErrorMessage = errorMessage.String
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		if durationMS.Valid {
+			run.DurationMS = &durationMS.Int64
+		}
+		runs = append(runs, run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// DeleteJobRunsBefore removes run history older than cutoff and returns how many rows were deleted
+func (r *JobRepository) DeleteJobRunsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM job_runs WHERE started_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete job runs: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"finalneta/models"
@@ -19,7 +20,7 @@ func NewNotificationRepository(db *sql.DB) *NotificationRepository {
 }
 
 // CreateNotification creates a new notification record
-func (r *NotificationRepository) CreateNotification(notification *models.Notification) error {
+func (r *NotificationRepository) CreateNotification(ctx context.Context, notification *models.Notification) error {
 	query := `
 		INSERT INTO notifications_log (
 			entity_type, entity_id, channel, recipient,
@@ -37,7 +38,7 @@ func (r *NotificationRepository) CreateNotification(notification *models.Notific
 		templateDataJSON = sql.NullString{String: "{}", Valid: true}
 	}
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		notification.EntityType,
 		notification.EntityID,
@@ -66,8 +67,34 @@ func (r *NotificationRepository) CreateNotification(notification *models.Notific
 	return nil
 }
 
-// GetPendingNotifications retrieves pending notifications ready to be sent
-func (r *NotificationRepository) GetPendingNotifications(limit int) ([]models.Notification, error) {
+// ClaimPendingNotifications claims up to limit notifications ready to be sent for claimToken and returns them.
+// The claim is a single UPDATE ... ORDER BY ... LIMIT, so workers in other server instances never get the same
+// row; a claim is void after claimTTL (worker died mid-batch) and the row is picked up again.
+// The status update after sending clears the claim.
+func (r *NotificationRepository) ClaimPendingNotifications(ctx context.Context, limit int, claimToken string, claimTTL time.Duration) ([]models.Notification, error) {
+	claimSeconds := int64(claimTTL.Seconds())
+	if claimSeconds < 1 {
+		claimSeconds = 1
+	}
+
+	claim := `
+		UPDATE notifications_log
+		SET claimed_by = ?,
+			claim_expires_at = NOW() + INTERVAL ? SECOND
+		WHERE status IN ('pending', 'retrying')
+			AND (next_retry_at IS NULL OR next_retry_at <= NOW())
+			AND (claim_expires_at IS NULL OR claim_expires_at <= NOW())
+		ORDER BY ` + notificationPriorityOrder + `
+		LIMIT ?
+	`
+	result, err := r.db.ExecContext(ctx, claim, claimToken, claimSeconds, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending notifications: %w", err)
+	}
+	if claimed, err := result.RowsAffected(); err == nil && claimed == 0 {
+		return nil, nil
+	}
+
 	query := `
 		SELECT 
 			notification_id, entity_type, entity_id, channel, recipient,
@@ -76,20 +103,12 @@ func (r *NotificationRepository) GetPendingNotifications(limit int) ([]models.No
 			next_retry_at, sent_at, failed_at, error_message,
 			created_at, updated_at
 		FROM notifications_log
-		WHERE status IN ('pending', 'retrying')
-			AND (next_retry_at IS NULL OR next_retry_at <= NOW())
-		ORDER BY 
-			CASE priority
-				WHEN 'urgent' THEN 1
-				WHEN 'high' THEN 2
-				WHEN 'normal' THEN 3
-				WHEN 'low' THEN 4
-			END,
-			created_at ASC
-		LIMIT ?
+		WHERE claimed_by = ?
+			AND status IN ('pending', 'retrying')
+		ORDER BY ` + notificationPriorityOrder + `
 	`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.QueryContext(ctx, query, claimToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query pending notifications: %w", err)
 	}
@@ -132,8 +151,18 @@ func (r *NotificationRepository) GetPendingNotifications(limit int) ([]models.No
 	return notifications, nil
 }
 
-// UpdateNotificationStatus updates notification status and related fields
-func (r *NotificationRepository) UpdateNotificationStatus(
+// notificationPriorityOrder sends urgent notifications first, then oldest first
+const notificationPriorityOrder = `
+			CASE priority
+				WHEN 'urgent' THEN 1
+				WHEN 'high' THEN 2
+				WHEN 'normal' THEN 3
+				WHEN 'low' THEN 4
+			END,
+			created_at ASC`
+
+// UpdateNotificationStatus updates notification status and related fields (and releases the worker's claim)
+func (r *NotificationRepository) UpdateNotificationStatus(ctx context.Context,
 	notificationID int64,
 	status models.NotificationStatus,
 	errorMessage *string,
@@ -148,6 +177,8 @@ func (r *NotificationRepository) UpdateNotificationStatus(
 			SET status = ?,
 				sent_at = NOW(),
 				updated_at = NOW(),
+				claimed_by = NULL,
+				claim_expires_at = NULL,
 				error_message = NULL
 			WHERE notification_id = ?
 		`
@@ -158,6 +189,8 @@ func (r *NotificationRepository) UpdateNotificationStatus(
 			SET status = ?,
 				failed_at = NOW(),
 				updated_at = NOW(),
+				claimed_by = NULL,
+				claim_expires_at = NULL,
 				error_message = ?
 			WHERE notification_id = ?
 		`
@@ -172,6 +205,8 @@ func (r *NotificationRepository) UpdateNotificationStatus(
 			SET status = ?,
 				retry_count = retry_count + 1,
 				updated_at = NOW(),
+				claimed_by = NULL,
+				claim_expires_at = NULL,
 				error_message = ?
 			WHERE notification_id = ?
 		`
@@ -184,13 +219,15 @@ func (r *NotificationRepository) UpdateNotificationStatus(
 		query = `
 			UPDATE notifications_log
 			SET status = ?,
-				updated_at = NOW()
+				updated_at = NOW(),
+				claimed_by = NULL,
+				claim_expires_at = NULL
 			WHERE notification_id = ?
 		`
 		args = []interface{}{status, notificationID}
 	}
 
-	_, err := r.db.Exec(query, args...)
+	_, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update notification status: %w", err)
 	}
@@ -198,8 +235,8 @@ func (r *NotificationRepository) UpdateNotificationStatus(
 	return nil
 }
 
-// ScheduleRetry schedules a retry for a failed notification
-func (r *NotificationRepository) ScheduleRetry(
+// ScheduleRetry schedules a retry for a failed notification (and releases the worker's claim)
+func (r *NotificationRepository) ScheduleRetry(ctx context.Context,
 	notificationID int64,
 	nextRetryAt time.Time,
 	errorMessage string,
@@ -210,11 +247,13 @@ func (r *NotificationRepository) ScheduleRetry(
 			retry_count = retry_count + 1,
 			next_retry_at = ?,
 			error_message = ?,
-			updated_at = NOW()
+			updated_at = NOW(),
+			claimed_by = NULL,
+			claim_expires_at = NULL
 		WHERE notification_id = ?
 	`
 
-	_, err := r.db.Exec(query, nextRetryAt, errorMessage, notificationID)
+	_, err := r.db.ExecContext(ctx, query, nextRetryAt, errorMessage, notificationID)
 	if err != nil {
 		return fmt.Errorf("failed to schedule retry: %w", err)
 	}
@@ -222,8 +261,25 @@ func (r *NotificationRepository) ScheduleRetry(
 	return nil
 }
 
+// ReleaseNotificationClaim returns a claimed but unsent notification to the queue (worker shutting down)
+func (r *NotificationRepository) ReleaseNotificationClaim(ctx context.Context, notificationID int64) error {
+	query := `
+		UPDATE notifications_log
+		SET claimed_by = NULL,
+			claim_expires_at = NULL
+		WHERE notification_id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, notificationID)
+	if err != nil {
+		return fmt.Errorf("failed to release notification claim: %w", err)
+	}
+
+	return nil
+}
+
 // CreateNotificationAttemptLog creates a log entry for a notification attempt
-func (r *NotificationRepository) CreateNotificationAttemptLog(log *models.NotificationLog) error {
+func (r *NotificationRepository) CreateNotificationAttemptLog(ctx context.Context, log *models.NotificationLog) error {
 	query := `
 		INSERT INTO notification_attempts_log (
 			notification_id, attempt_number, status,
@@ -236,7 +292,7 @@ func (r *NotificationRepository) CreateNotificationAttemptLog(log *models.Notifi
 		responseDataJSON = log.ResponseData
 	}
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		log.NotificationID,
 		log.AttemptNumber,
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"finalneta/models"
+	"fmt"
+	"time"
+)
+
+// OfficerAbsenceRepository handles officer leave windows and their delegates (officer_absences)
+type OfficerAbsenceRepository struct {
+	db *sql.DB
+}
+
+// NewOfficerAbsenceRepository creates a new officer absence repository
+func NewOfficerAbsenceRepository(db *sql.DB) *OfficerAbsenceRepository {
+	return &OfficerAbsenceRepository{db: db}
+}
+
+const officerAbsenceColumns = `absence_id, officer_id, delegate_officer_id, starts_at, ends_at,
+	reason, created_by_type, cancelled_at, created_at`
+
+// scanOfficerAbsence scans one row selected with officerAbsenceColumns
+func scanOfficerAbsence(row rowScanner) (*models.OfficerAbsence, error) {
+	var a models.OfficerAbsence
+	err := row.Scan(
+		&a.AbsenceID,
+		&a.OfficerID,
+		&a.DelegateOfficerID,
+		&a.StartsAt,
+		&a.EndsAt,
+		&a.Reason,
+		&a.CreatedByType,
+		&a.CancelledAt,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateAbsence inserts an absence and sets its AbsenceID
+func (r *OfficerAbsenceRepository) CreateAbsence(ctx context.Context, absence *models.OfficerAbsence) error {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO officer_absences (officer_id, delegate_officer_id, starts_at, ends_at, reason, created_by_type)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, absence.OfficerID, absence.DelegateOfficerID, absence.StartsAt, absence.EndsAt, absence.Reason, absence.CreatedByType)
+	if err != nil {
+		return fmt.Errorf("failed to create absence: %w", err)
+	}
+	absence.AbsenceID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get absence ID: %w", err)
+	}
+	return nil
+}
+
+// GetAbsenceByID returns one absence, or nil if it does not exist
+func (r *OfficerAbsenceRepository) GetAbsenceByID(ctx context.Context, absenceID int64) (*models.OfficerAbsence, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+officerAbsenceColumns+` FROM officer_absences WHERE absence_id = ?`, absenceID)
+	absence, err := scanOfficerAbsence(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absence: %w", err)
+	}
+	return absence, nil
+}
+
+// ListAbsences returns the officer's own absences and those they cover as delegate, latest start first
+// (at most 100, cancelled included)
+func (r *OfficerAbsenceRepository) ListAbsences(ctx context.Context, officerID int64) ([]models.OfficerAbsence, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+officerAbsenceColumns+`
+		FROM officer_absences
+		WHERE officer_id = ? OR delegate_officer_id = ?
+		ORDER BY starts_at DESC, absence_id DESC
+		LIMIT 100
+	`, officerID, officerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query absences: %w", err)
+	}
+	defer rows.Close()
+
+	absences := []models.OfficerAbsence{}
+	for rows.Next() {
+		absence, err := scanOfficerAbsence(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan absence: %w", err)
+		}
+		absences = append(absences, *absence)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating absences: %w", err)
+	}
+	return absences, nil
+}
+
+// CancelAbsence marks an absence cancelled at the given time; false if it was already cancelled
+func (r *OfficerAbsenceRepository) CancelAbsence(ctx context.Context, absenceID int64, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE officer_absences SET cancelled_at = ? WHERE absence_id = ? AND cancelled_at IS NULL`,
+		at, absenceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel absence: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel absence: %w", err)
+	}
+	return n > 0, nil
+}
+
+// FindOverlappingAbsence returns an uncancelled absence overlapping [startsAt, endsAt) in which the officer is
+// away (asDelegate false) or covers as delegate (asDelegate true), or nil
+func (r *OfficerAbsenceRepository) FindOverlappingAbsence(ctx context.Context,
+	officerID int64,
+	startsAt, endsAt time.Time,
+	asDelegate bool,
+) (*models.OfficerAbsence, error) {
+	column := "officer_id"
+	if asDelegate {
+		column = "delegate_officer_id"
+	}
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+officerAbsenceColumns+`
+		FROM officer_absences
+		WHERE `+column+` = ? AND cancelled_at IS NULL AND starts_at < ? AND ends_at > ?
+		ORDER BY starts_at ASC
+		LIMIT 1
+	`, officerID, endsAt, startsAt)
+	absence, err := scanOfficerAbsence(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check overlapping absences: %w", err)
+	}
+	return absence, nil
+}
+
+// GetActiveAbsence returns the officer's absence in effect at the given time, or nil
+func (r *OfficerAbsenceRepository) GetActiveAbsence(ctx context.Context, officerID int64, at time.Time) (*models.OfficerAbsence, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+officerAbsenceColumns+`
+		FROM officer_absences
+		WHERE officer_id = ? AND cancelled_at IS NULL AND starts_at <= ? AND ends_at > ?
+		ORDER BY starts_at DESC
+		LIMIT 1
+	`, officerID, at, at)
+	absence, err := scanOfficerAbsence(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active absence: %w", err)
+	}
+	return absence, nil
+}
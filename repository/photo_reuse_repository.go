@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"finalneta/models"
+	"fmt"
+)
+
+// PhotoReuseRepository handles perceptual-hash lookups and the recycled-photo review list
+type PhotoReuseRepository struct {
+	db DBTX // *sql.DB, or *sql.Tx for copies made by WithTx
+	txScope
+}
+
+// NewPhotoReuseRepository creates a new photo reuse repository
+func NewPhotoReuseRepository(db *sql.DB) *PhotoReuseRepository {
+	return &PhotoReuseRepository{db: db, txScope: txScope{conn: db}}
+}
+
+// WithTx returns a copy of the repository whose statements run inside tx
+func (r *PhotoReuseRepository) WithTx(tx *sql.Tx) *PhotoReuseRepository {
+	return &PhotoReuseRepository{db: tx, txScope: txScope{conn: r.conn, tx: tx}}
+}
+
+// FindSimilarAttachments returns attachments on other complaints whose perceptual hash differs
+// from hash by at most maxDistance bits, closest first
+func (r *PhotoReuseRepository) FindSimilarAttachments(ctx context.Context,
+	hash int64,
+	excludeComplaintID int64,
+	maxDistance int,
+	limit int,
+) ([]models.PhotoMatch, error) {
+	query := `
+		SELECT 
+			a.attachment_id, a.complaint_id,
+			BIT_COUNT(a.perceptual_hash ^ ?) AS hamming_distance,
+			c.user_id, c.device_fingerprint
+		FROM complaint_attachments a
+		JOIN complaints c ON c.complaint_id = a.complaint_id
+		WHERE a.perceptual_hash IS NOT NULL
+		  AND a.complaint_id <> ?
+		  AND BIT_COUNT(a.perceptual_hash ^ ?) <= ?
+		ORDER BY hamming_distance ASC, a.attachment_id ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, hash, excludeComplaintID, hash, maxDistance, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query similar attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []models.PhotoMatch
+	for rows.Next() {
+		var m models.PhotoMatch
+		if err := rows.Scan(
+			&m.AttachmentID,
+			&m.ComplaintID,
+			&m.HammingDistance,
+			&m.UserID,
+			&m.DeviceFingerprint,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan similar attachment: %w", err)
+		}
+		matches = append(matches, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating similar attachments: %w", err)
+	}
+
+	return matches, nil
+}
+
+// CreateFlag inserts a recycled-photo flag. A pair already flagged is left unchanged.
+func (r *PhotoReuseRepository) CreateFlag(ctx context.Context, flag *models.PhotoReuseFlag) error {
+	query := `
+		INSERT IGNORE INTO photo_reuse_flags (
+			complaint_id, attachment_id, matched_complaint_id, matched_attachment_id,
+			hamming_distance, different_user, different_device, status
+		) VALUES (?, ?, ?, ?, ?, ?, ?, 'pending')
+	`
+
+	result, err := r.db.ExecContext(ctx,
+		query,
+		flag.ComplaintID,
+		flag.AttachmentID,
+		flag.MatchedComplaintID,
+		flag.MatchedAttachmentID,
+		flag.HammingDistance,
+		flag.DifferentUser,
+		flag.DifferentDevice,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create photo reuse flag: %w", err)
+	}
+
+	flagID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get photo reuse flag ID: %w", err)
+	}
+
+	flag.FlagID = flagID
+	flag.Status = models.PhotoReusePending
+	return nil
+}
+
+const photoReuseFlagColumns = `
+	flag_id, complaint_id, attachment_id, matched_complaint_id, matched_attachment_id,
+	hamming_distance, different_user, different_device, status,
+	review_note, reviewed_at, created_at
+`
+
+// scanPhotoReuseFlag scans one photo_reuse_flags row selected with photoReuseFlagColumns
+func scanPhotoReuseFlag(row rowScanner, f *models.PhotoReuseFlag) error {
+	return row.Scan(
+		&f.FlagID,
+		&f.ComplaintID,
+		&f.AttachmentID,
+		&f.MatchedComplaintID,
+		&f.MatchedAttachmentID,
+		&f.HammingDistance,
+		&f.DifferentUser,
+		&f.DifferentDevice,
+		&f.Status,
+		&f.ReviewNote,
+		&f.ReviewedAt,
+		&f.CreatedAt,
+	)
+}
+
+// GetFlag retrieves a single flag
+func (r *PhotoReuseRepository) GetFlag(ctx context.Context, flagID int64) (*models.PhotoReuseFlag, error) {
+	query := `SELECT ` + photoReuseFlagColumns + ` FROM photo_reuse_flags WHERE flag_id = ?`
+
+	var f models.PhotoReuseFlag
+	err := scanPhotoReuseFlag(r.db.QueryRowContext(ctx, query, flagID), &f)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("photo reuse flag not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photo reuse flag: %w", err)
+	}
+	return &f, nil
+}
+
+// ListFlags returns flags with the given status (all when empty), cross-user matches first, then newest.
+// Returns the page and the total count.
+func (r *PhotoReuseRepository) ListFlags(ctx context.Context, status string, limit, offset int) ([]models.PhotoReuseFlag, int64, error) {
+	where := ""
+	args := []interface{}{}
+	if status != "" {
+		where = "WHERE status = ?"
+		args = append(args, status)
+	}
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM photo_reuse_flags `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count photo reuse flags: %w", err)
+	}
+
+	query := `SELECT ` + photoReuseFlagColumns + ` FROM photo_reuse_flags ` + where + `
+		ORDER BY different_user DESC, different_device DESC, created_at DESC
+		LIMIT ? OFFSET ?`
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query photo reuse flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []models.PhotoReuseFlag{}
+	for rows.Next() {
+		var f models.PhotoReuseFlag
+		if err := scanPhotoReuseFlag(rows, &f); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan photo reuse flag: %w", err)
+		}
+		flags = append(flags, f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating photo reuse flags: %w", err)
+	}
+
+	return flags, total, nil
+}
+
+// ReviewFlag records an admin decision on a pending flag.
+// Returns an error containing "already reviewed" if the flag is no longer pending.
+func (r *PhotoReuseRepository) ReviewFlag(ctx context.Context, flagID int64, status models.PhotoReuseStatus, note string) error {
+	query := `
+		UPDATE photo_reuse_flags
+		SET status = ?, review_note = ?, reviewed_at = NOW()
+		WHERE flag_id = ? AND status = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, status, sql.NullString{String: note, Valid: note != ""}, flagID)
+	if err != nil {
+		return fmt.Errorf("failed to review photo reuse flag: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("photo reuse flag already reviewed")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"finalneta/models"
@@ -18,7 +19,7 @@ func NewPilotMetricsRepository(db *sql.DB) *PilotMetricsRepository {
 }
 
 // CreateEvent creates a new pilot metrics event
-func (r *PilotMetricsRepository) CreateEvent(event *models.PilotMetricsEvent) error {
+func (r *PilotMetricsRepository) CreateEvent(ctx context.Context, event *models.PilotMetricsEvent) error {
 	// Serialize metadata to JSON if provided
 	var metadataJSON sql.NullString
 	if event.Metadata.Valid && event.Metadata.String != "" {
@@ -31,7 +32,7 @@ func (r *PilotMetricsRepository) CreateEvent(event *models.PilotMetricsEvent) er
 		) VALUES (?, ?, ?, ?, NOW())
 	`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		string(event.EventType),
 		event.ComplaintID,
@@ -52,7 +53,7 @@ func (r *PilotMetricsRepository) CreateEvent(event *models.PilotMetricsEvent) er
 }
 
 // CreateEventWithMetadata creates a new pilot metrics event with metadata object
-func (r *PilotMetricsRepository) CreateEventWithMetadata(
+func (r *PilotMetricsRepository) CreateEventWithMetadata(ctx context.Context,
 	eventType models.PilotMetricsEventType,
 	complaintID *int64,
 	userID *int64,
@@ -84,5 +85,5 @@ func (r *PilotMetricsRepository) CreateEventWithMetadata(
 		Metadata:    metadataJSON,
 	}
 
-	return r.CreateEvent(event)
+	return r.CreateEvent(ctx, event)
 }
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"finalneta/models"
+	"fmt"
+)
+
+// SLACalendarRepository reads sla_calendars and sla_holidays (read-only; rows are managed in SQL)
+type SLACalendarRepository struct {
+	db *sql.DB
+}
+
+// NewSLACalendarRepository creates a new SLA calendar repository
+func NewSLACalendarRepository(db *sql.DB) *SLACalendarRepository {
+	return &SLACalendarRepository{db: db}
+}
+
+// GetCalendarForLocation returns the active calendar of the location, else the default (location_id NULL).
+// Returns nil if neither is configured.
+func (r *SLACalendarRepository) GetCalendarForLocation(ctx context.Context, locationID int64) (*models.SLACalendarConfig, error) {
+	query := `
+		SELECT calendar_id, location_id, timezone,
+			TIME_FORMAT(work_start, '%H:%i:%s'), TIME_FORMAT(work_end, '%H:%i:%s'), working_days
+		FROM sla_calendars
+		WHERE is_active = TRUE AND (location_id = ? OR location_id IS NULL)
+		ORDER BY location_id IS NULL ASC
+		LIMIT 1
+	`
+	var c models.SLACalendarConfig
+	err := r.db.QueryRowContext(ctx, query, locationID).Scan(
+		&c.CalendarID, &c.LocationID, &c.Timezone, &c.WorkStart, &c.WorkEnd, &c.WorkingDays,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SLA calendar: %w", err)
+	}
+	return &c, nil
+}
+
+// GetHolidaysForLocation returns the holidays of the location plus state-wide holidays (location_id NULL)
+func (r *SLACalendarRepository) GetHolidaysForLocation(ctx context.Context, locationID int64) ([]models.SLAHoliday, error) {
+	query := `
+		SELECT holiday_id, location_id, DATE_FORMAT(holiday_date, '%Y-%m-%d'), name
+		FROM sla_holidays
+		WHERE location_id = ? OR location_id IS NULL
+		ORDER BY holiday_date ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SLA holidays: %w", err)
+	}
+	defer rows.Close()
+
+	var holidays []models.SLAHoliday
+	for rows.Next() {
+		var h models.SLAHoliday
+		if err := rows.Scan(&h.HolidayID, &h.LocationID, &h.HolidayDate, &h.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan SLA holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SLA holidays: %w", err)
+	}
+	return holidays, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"finalneta/models"
+	"fmt"
+)
+
+// SLAPolicyRepository reads sla_policies and sla_policy_targets (read-only; rows are managed in SQL)
+type SLAPolicyRepository struct {
+	db *sql.DB
+}
+
+// NewSLAPolicyRepository creates a new SLA policy repository
+func NewSLAPolicyRepository(db *sql.DB) *SLAPolicyRepository {
+	return &SLAPolicyRepository{db: db}
+}
+
+// GetActivePolicyName returns the name of an active policy; false if it does not exist or is inactive
+func (r *SLAPolicyRepository) GetActivePolicyName(ctx context.Context, policyID int64) (string, bool, error) {
+	var name string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT name FROM sla_policies WHERE policy_id = ? AND is_active = TRUE`,
+		policyID,
+	).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get SLA policy: %w", err)
+	}
+	return name, true, nil
+}
+
+// GetPolicyTargets returns the targets of a policy
+func (r *SLAPolicyRepository) GetPolicyTargets(ctx context.Context, policyID int64) ([]models.SLAPolicyTarget, error) {
+	query := `
+		SELECT t.policy_id, p.name, t.priority, t.escalation_level, t.category, t.sla_hours
+		FROM sla_policy_targets t
+		JOIN sla_policies p ON p.policy_id = t.policy_id
+		WHERE t.policy_id = ?
+		ORDER BY t.escalation_level ASC, t.priority ASC, t.category ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SLA policy targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []models.SLAPolicyTarget
+	for rows.Next() {
+		var t models.SLAPolicyTarget
+		if err := rows.Scan(&t.PolicyID, &t.PolicyName, &t.Priority, &t.EscalationLevel, &t.Category, &t.SLAHours); err != nil {
+			return nil, fmt.Errorf("failed to scan SLA policy target: %w", err)
+		}
+		targets = append(targets, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SLA policy targets: %w", err)
+	}
+	return targets, nil
+}
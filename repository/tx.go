@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by repositories.
+// Repositories hold a DBTX so the same code runs standalone or inside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// rowScanner is a *sql.Row or *sql.Rows, so one scan function serves single-row and list queries
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// RunInTx begins a transaction on db, runs fn and commits.
+// Rolls back if fn returns an error or panics (the panic is re-raised after rollback),
+// and when ctx is cancelled before the commit.
+func RunInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// txScope is embedded by repositories that support transaction-scoped copies.
+// conn is the root pool (used to begin transactions); tx is set on copies made by WithTx.
+type txScope struct {
+	conn *sql.DB
+	tx   *sql.Tx
+}
+
+// InTx runs fn in the repository's current transaction if it has one, otherwise in a new one.
+// Build transaction-scoped repositories inside fn with WithTx(tx).
+func (s txScope) InTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx) // already inside a unit of work; caller commits
+	}
+	if s.conn == nil {
+		return fmt.Errorf("repository has no database connection")
+	}
+	return RunInTx(ctx, s.conn, fn)
+}
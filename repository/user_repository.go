@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -17,7 +18,7 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 // GetUserByPhone retrieves user by phone number
-func (r *UserRepository) GetUserByPhone(phoneNumber string) (*User, error) {
+func (r *UserRepository) GetUserByPhone(ctx context.Context, phoneNumber string) (*User, error) {
 	query := `
 		SELECT user_id, phone_number, phone_verified_at, created_at
 		FROM users
@@ -27,7 +28,7 @@ func (r *UserRepository) GetUserByPhone(phoneNumber string) (*User, error) {
 
 	user := &User{}
 	var verifiedAt sql.NullTime
-	err := r.db.QueryRow(query, phoneNumber).Scan(
+	err := r.db.QueryRowContext(ctx, query, phoneNumber).Scan(
 		&user.UserID,
 		&user.PhoneNumber,
 		&verifiedAt,
@@ -48,7 +49,7 @@ func (r *UserRepository) GetUserByPhone(phoneNumber string) (*User, error) {
 }
 
 // GetUserByID retrieves user by ID
-func (r *UserRepository) GetUserByID(userID int64) (*User, error) {
+func (r *UserRepository) GetUserByID(ctx context.Context, userID int64) (*User, error) {
 	query := `
 		SELECT user_id, phone_number, phone_verified_at, created_at
 		FROM users
@@ -58,7 +59,7 @@ func (r *UserRepository) GetUserByID(userID int64) (*User, error) {
 
 	user := &User{}
 	var verifiedAt sql.NullTime
-	err := r.db.QueryRow(query, userID).Scan(
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
 		&user.UserID,
 		&user.PhoneNumber,
 		&verifiedAt,
@@ -79,13 +80,13 @@ func (r *UserRepository) GetUserByID(userID int64) (*User, error) {
 }
 
 // CreateUser creates a new user
-func (r *UserRepository) CreateUser(phoneNumber string) (int64, error) {
+func (r *UserRepository) CreateUser(ctx context.Context, phoneNumber string) (int64, error) {
 	query := `
 		INSERT INTO users (phone_number, created_at)
 		VALUES (?, NOW())
 	`
 
-	result, err := r.db.Exec(query, phoneNumber)
+	result, err := r.db.ExecContext(ctx, query, phoneNumber)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -99,7 +100,7 @@ func (r *UserRepository) CreateUser(phoneNumber string) (int64, error) {
 }
 
 // VerifyUserPhone marks user's phone as verified
-func (r *UserRepository) VerifyUserPhone(userID int64) error {
+func (r *UserRepository) VerifyUserPhone(ctx context.Context, userID int64) error {
 	query := `
 		UPDATE users
 		SET phone_verified_at = NOW(),
@@ -107,7 +108,7 @@ func (r *UserRepository) VerifyUserPhone(userID int64) error {
 		WHERE user_id = ?
 	`
 
-	_, err := r.db.Exec(query, userID)
+	_, err := r.db.ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("failed to verify user phone: %w", err)
 	}
@@ -116,14 +117,14 @@ func (r *UserRepository) VerifyUserPhone(userID int64) error {
 }
 
 // UpdateLastActive updates user's last active timestamp
-func (r *UserRepository) UpdateLastActive(userID int64) error {
+func (r *UserRepository) UpdateLastActive(ctx context.Context, userID int64) error {
 	query := `
 		UPDATE users
 		SET last_active_at = NOW()
 		WHERE user_id = ?
 	`
 
-	_, err := r.db.Exec(query, userID)
+	_, err := r.db.ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update last active: %w", err)
 	}
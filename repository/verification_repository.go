@@ -1,25 +1,32 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"finalneta/models"
+	"finalneta/utils"
 	"fmt"
-	"math"
 	"time"
 )
 
 // VerificationRepository handles database operations for verification
 type VerificationRepository struct {
-	db *sql.DB
+	db DBTX // *sql.DB, or *sql.Tx for copies made by WithTx
+	txScope
 }
 
 // NewVerificationRepository creates a new verification repository
 func NewVerificationRepository(db *sql.DB) *VerificationRepository {
-	return &VerificationRepository{db: db}
+	return &VerificationRepository{db: db, txScope: txScope{conn: db}}
+}
+
+// WithTx returns a copy of the repository whose statements run inside tx
+func (r *VerificationRepository) WithTx(tx *sql.Tx) *VerificationRepository {
+	return &VerificationRepository{db: tx, txScope: txScope{conn: r.conn, tx: tx}}
 }
 
 // IsUserPhoneVerified checks if a user's phone is verified
-func (r *VerificationRepository) IsUserPhoneVerified(userID int64) (bool, error) {
+func (r *VerificationRepository) IsUserPhoneVerified(ctx context.Context, userID int64) (bool, error) {
 	query := `
 		SELECT phone_verified_at
 		FROM users
@@ -27,7 +34,7 @@ func (r *VerificationRepository) IsUserPhoneVerified(userID int64) (bool, error)
 	`
 
 	var verifiedAt sql.NullTime
-	err := r.db.QueryRow(query, userID).Scan(&verifiedAt)
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&verifiedAt)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -49,7 +56,7 @@ func (r *VerificationRepository) IsUserPhoneVerified(userID int64) (bool, error)
 //
 // Current implementation: Assumes at least one attachment means live capture exists.
 // This is a temporary workaround - update when schema allows.
-func (r *VerificationRepository) HasLiveCaptureAttachment(complaintID int64) (bool, error) {
+func (r *VerificationRepository) HasLiveCaptureAttachment(ctx context.Context, complaintID int64) (bool, error) {
 	// TODO: Update query when live_capture field is added to schema:
 	// SELECT COUNT(*) FROM complaint_attachments 
 	// WHERE complaint_id = ? AND live_capture = true
@@ -61,7 +68,7 @@ func (r *VerificationRepository) HasLiveCaptureAttachment(complaintID int64) (bo
 	`
 
 	var count int
-	err := r.db.QueryRow(query, complaintID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, complaintID).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check live capture attachment: %w", err)
 	}
@@ -75,7 +82,7 @@ func (r *VerificationRepository) HasLiveCaptureAttachment(complaintID int64) (bo
 // - Same category (if category is not null)
 // - Same location (within radius)
 // - Within time window
-func (r *VerificationRepository) FindDuplicateComplaints(
+func (r *VerificationRepository) FindDuplicateComplaints(ctx context.Context,
 	complaintID int64,
 	category *string,
 	latitude, longitude float64,
@@ -119,7 +126,7 @@ func (r *VerificationRepository) FindDuplicateComplaints(
 		args = []interface{}{complaintID, timeWindowStart}
 	}
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query duplicate complaints: %w", err)
 	}
@@ -146,8 +153,8 @@ func (r *VerificationRepository) FindDuplicateComplaints(
 			dup.Category = cat.String
 		}
 
-		// Calculate distance using Haversine formula
-		distance := calculateDistance(latitude, longitude, dup.Latitude, dup.Longitude)
+		// Great-circle distance (utils.DistanceMeters)
+		distance := utils.DistanceMeters(latitude, longitude, dup.Latitude, dup.Longitude)
 		
 		// Only include if within radius
 		if distance <= radiusMeters {
@@ -163,14 +170,14 @@ func (r *VerificationRepository) FindDuplicateComplaints(
 }
 
 // IncrementSupporterCount increments the supporter count for a complaint
-func (r *VerificationRepository) IncrementSupporterCount(complaintID int64) error {
+func (r *VerificationRepository) IncrementSupporterCount(ctx context.Context, complaintID int64) error {
 	query := `
 		UPDATE complaints
 		SET supporter_count = supporter_count + 1
 		WHERE complaint_id = ?
 	`
 
-	_, err := r.db.Exec(query, complaintID)
+	_, err := r.db.ExecContext(ctx, query, complaintID)
 	if err != nil {
 		return fmt.Errorf("failed to increment supporter count: %w", err)
 	}
@@ -179,7 +186,7 @@ func (r *VerificationRepository) IncrementSupporterCount(complaintID int64) erro
 }
 
 // AddSupporter adds a user as a supporter for a complaint
-func (r *VerificationRepository) AddSupporter(complaintID, userID int64, isDuplicate bool, duplicateNotes string) error {
+func (r *VerificationRepository) AddSupporter(ctx context.Context, complaintID, userID int64, isDuplicate bool, duplicateNotes string) error {
 	query := `
 		INSERT INTO complaint_supporters (
 			complaint_id, user_id, is_duplicate, duplicate_notes
@@ -189,7 +196,7 @@ func (r *VerificationRepository) AddSupporter(complaintID, userID int64, isDupli
 			duplicate_notes = VALUES(duplicate_notes)
 	`
 
-	_, err := r.db.Exec(query, complaintID, userID, isDuplicate, duplicateNotes)
+	_, err := r.db.ExecContext(ctx, query, complaintID, userID, isDuplicate, duplicateNotes)
 	if err != nil {
 		return fmt.Errorf("failed to add supporter: %w", err)
 	}
@@ -198,7 +205,7 @@ func (r *VerificationRepository) AddSupporter(complaintID, userID int64, isDupli
 }
 
 // GetComplaintCoordinates retrieves latitude and longitude for a complaint
-func (r *VerificationRepository) GetComplaintCoordinates(complaintID int64) (float64, float64, bool, error) {
+func (r *VerificationRepository) GetComplaintCoordinates(ctx context.Context, complaintID int64) (float64, float64, bool, error) {
 	query := `
 		SELECT latitude, longitude
 		FROM complaints
@@ -206,7 +213,7 @@ func (r *VerificationRepository) GetComplaintCoordinates(complaintID int64) (flo
 	`
 
 	var lat, lon sql.NullFloat64
-	err := r.db.QueryRow(query, complaintID).Scan(&lat, &lon)
+	err := r.db.QueryRowContext(ctx, query, complaintID).Scan(&lat, &lon)
 	if err == sql.ErrNoRows {
 		return 0, 0, false, fmt.Errorf("complaint not found")
 	}
@@ -222,7 +229,7 @@ func (r *VerificationRepository) GetComplaintCoordinates(complaintID int64) (flo
 }
 
 // GetComplaintCategory retrieves category for a complaint
-func (r *VerificationRepository) GetComplaintCategory(complaintID int64) (*string, error) {
+func (r *VerificationRepository) GetComplaintCategory(ctx context.Context, complaintID int64) (*string, error) {
 	query := `
 		SELECT category
 		FROM complaints
@@ -230,7 +237,7 @@ func (r *VerificationRepository) GetComplaintCategory(complaintID int64) (*strin
 	`
 
 	var category sql.NullString
-	err := r.db.QueryRow(query, complaintID).Scan(&category)
+	err := r.db.QueryRowContext(ctx, query, complaintID).Scan(&category)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("complaint not found")
 	}
@@ -245,24 +252,3 @@ func (r *VerificationRepository) GetComplaintCategory(complaintID int64) (*strin
 	cat := category.String
 	return &cat, nil
 }
-
-// calculateDistance calculates the distance between two coordinates using Haversine formula
-// Returns distance in meters
-func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadiusMeters = 6371000 // Earth radius in meters
-
-	// Convert to radians
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	deltaLat := (lat2 - lat1) * math.Pi / 180
-	deltaLon := (lon2 - lon1) * math.Pi / 180
-
-	// Haversine formula
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	distance := earthRadiusMeters * c
-	return distance
-}
@@ -1,12 +1,17 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"finalneta/models"
 	"fmt"
 )
 
-// VoiceNoteRepository handles complaint_voice_notes table.
+// ErrVoiceClipLimit is returned by Create when the complaint already has the maximum number of clips
+var ErrVoiceClipLimit = errors.New("voice clip limit reached")
+
+// VoiceNoteRepository handles complaint_voice_notes table (several clips per complaint).
 type VoiceNoteRepository struct {
 	db *sql.DB
 }
@@ -16,45 +21,112 @@ func NewVoiceNoteRepository(db *sql.DB) *VoiceNoteRepository {
 	return &VoiceNoteRepository{db: db}
 }
 
-// GetByComplaintID returns the voice note for a complaint, if any.
-func (r *VoiceNoteRepository) GetByComplaintID(complaintID int64) (*models.ComplaintVoiceNote, error) {
-	query := `SELECT id, complaint_id, file_path, mime_type, duration_seconds, created_at
-		FROM complaint_voice_notes WHERE complaint_id = ?`
-	var v models.ComplaintVoiceNote
-	var dur sql.NullInt64
-	err := r.db.QueryRow(query, complaintID).Scan(
-		&v.ID, &v.ComplaintID, &v.FilePath, &v.MimeType, &dur, &v.CreatedAt,
-	)
+const voiceNoteColumns = `id, complaint_id, file_path, mime_type, file_size, duration_seconds, created_at`
+
+// GetLatestByComplaintID returns the most recent clip for a complaint, if any.
+func (r *VoiceNoteRepository) GetLatestByComplaintID(ctx context.Context, complaintID int64) (*models.ComplaintVoiceNote, error) {
+	query := `SELECT ` + voiceNoteColumns + `
+		FROM complaint_voice_notes WHERE complaint_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT 1`
+	v, err := scanVoiceNote(r.db.QueryRowContext(ctx, query, complaintID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get voice note: %w", err)
 	}
-	if dur.Valid {
-		d := int(dur.Int64)
-		v.DurationSeconds = &d
+	return v, nil
+}
+
+// GetByID returns one clip of a complaint, if it exists.
+func (r *VoiceNoteRepository) GetByID(ctx context.Context, complaintID, clipID int64) (*models.ComplaintVoiceNote, error) {
+	query := `SELECT ` + voiceNoteColumns + `
+		FROM complaint_voice_notes WHERE id = ? AND complaint_id = ?`
+	v, err := scanVoiceNote(r.db.QueryRowContext(ctx, query, clipID, complaintID))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	return &v, nil
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voice note: %w", err)
+	}
+	return v, nil
 }
 
-// CreateOrUpdate saves or overwrites the single voice note for a complaint (one per complaint).
-func (r *VoiceNoteRepository) CreateOrUpdate(v *models.ComplaintVoiceNote) error {
-	// Upsert: if row exists for complaint_id, update; else insert.
-	existing, err := r.GetByComplaintID(v.ComplaintID)
+// ListByComplaintID returns all clips for a complaint, oldest first.
+func (r *VoiceNoteRepository) ListByComplaintID(ctx context.Context, complaintID int64) ([]models.ComplaintVoiceNote, error) {
+	query := `SELECT ` + voiceNoteColumns + `
+		FROM complaint_voice_notes WHERE complaint_id = ?
+		ORDER BY created_at ASC, id ASC`
+	rows, err := r.db.QueryContext(ctx, query, complaintID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to list voice notes: %w", err)
 	}
-	if existing != nil {
-		_, err = r.db.Exec(
-			`UPDATE complaint_voice_notes SET file_path = ?, mime_type = ?, duration_seconds = ? WHERE complaint_id = ?`,
-			v.FilePath, v.MimeType, v.DurationSeconds, v.ComplaintID,
-		)
-		return err
+	defer rows.Close()
+
+	clips := []models.ComplaintVoiceNote{}
+	for rows.Next() {
+		v, err := scanVoiceNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan voice note: %w", err)
+		}
+		clips = append(clips, *v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating voice notes: %w", err)
 	}
-	_, err = r.db.Exec(
-		`INSERT INTO complaint_voice_notes (complaint_id, file_path, mime_type, duration_seconds) VALUES (?, ?, ?, ?)`,
-		v.ComplaintID, v.FilePath, v.MimeType, v.DurationSeconds,
+	return clips, nil
+}
+
+// Create adds a clip unless the complaint already has maxClips (ErrVoiceClipLimit).
+// Count and insert run as one statement (two uploads racing on the last slot may both succeed).
+// Sets v.ID and v.CreatedAt.
+func (r *VoiceNoteRepository) Create(ctx context.Context, v *models.ComplaintVoiceNote, maxClips int) error {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO complaint_voice_notes (complaint_id, file_path, mime_type, file_size, duration_seconds)
+		SELECT ?, ?, ?, ?, ? FROM DUAL
+		WHERE (SELECT c.n FROM (SELECT COUNT(*) AS n FROM complaint_voice_notes WHERE complaint_id = ?) AS c) < ?`,
+		v.ComplaintID, v.FilePath, v.MimeType, v.FileSize, v.DurationSeconds,
+		v.ComplaintID, maxClips,
 	)
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to create voice note: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create voice note: %w", err)
+	}
+	if affected == 0 {
+		return ErrVoiceClipLimit
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get voice note ID: %w", err)
+	}
+
+	created, err := r.GetByID(ctx, v.ComplaintID, id)
+	if err != nil {
+		return err
+	}
+	if created == nil {
+		return fmt.Errorf("voice note %d not found after insert", id)
+	}
+	*v = *created
+	return nil
+}
+
+// scanVoiceNote scans one row selected with voiceNoteColumns
+func scanVoiceNote(row rowScanner) (*models.ComplaintVoiceNote, error) {
+	var v models.ComplaintVoiceNote
+	var size, dur sql.NullInt64
+	if err := row.Scan(&v.ID, &v.ComplaintID, &v.FilePath, &v.MimeType, &size, &dur, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	if size.Valid {
+		v.FileSize = &size.Int64
+	}
+	if dur.Valid {
+		d := int(dur.Int64)
+		v.DurationSeconds = &d
+	}
+	return &v, nil
 }
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// WorkerLeaseRepository handles the worker_leases table: named, expiring leases that let
+// one server instance at a time run a background job
+type WorkerLeaseRepository struct {
+	db *sql.DB
+}
+
+// NewWorkerLeaseRepository creates a new worker lease repository
+func NewWorkerLeaseRepository(db *sql.DB) *WorkerLeaseRepository {
+	return &WorkerLeaseRepository{db: db}
+}
+
+// TryAcquireLease takes the lease for holder, or renews it if holder already has it, until ttl from now.
+// Returns false if another holder's lease has not expired. Expiry uses the database clock, so instances
+// with skewed clocks agree on it.
+func (r *WorkerLeaseRepository) TryAcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	seconds := int64(ttl.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	// Assignments run left to right: acquired_at and holder see the old row, expires_at the new holder
+	query := `
+		INSERT INTO worker_leases (lease_name, holder, acquired_at, expires_at)
+		VALUES (?, ?, NOW(), NOW() + INTERVAL ? SECOND)
+		ON DUPLICATE KEY UPDATE
+			acquired_at = IF(holder <> VALUES(holder) AND expires_at <= NOW(), VALUES(acquired_at), acquired_at),
+			holder = IF(expires_at <= NOW(), VALUES(holder), holder),
+			expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at)
+	`
+	if _, err := r.db.ExecContext(ctx, query, name, holder, seconds); err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+
+	var current string
+	err := r.db.QueryRowContext(ctx, `SELECT holder FROM worker_leases WHERE lease_name = ?`, name).Scan(&current)
+	if err != nil {
+		return false, fmt.Errorf("failed to read lease %s: %w", name, err)
+	}
+	return current == holder, nil
+}
+
+// ReleaseLease ends holder's lease so another instance can take it immediately.
+// No-op if the lease is held by someone else.
+func (r *WorkerLeaseRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM worker_leases WHERE lease_name = ? AND holder = ?`, name, holder)
+	if err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
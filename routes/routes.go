@@ -6,6 +6,7 @@ import (
 	"finalneta/middleware"
 	"finalneta/repository"
 	"finalneta/service"
+	"finalneta/storage"
 	"finalneta/worker"
 	"net/http"
 	"os"
@@ -23,14 +24,21 @@ func SetupRoutes(
 	complaintService *service.ComplaintService,
 	verificationService *service.VerificationService,
 	escalationService *service.EscalationService,
-	escalationWorker *worker.EscalationWorker,
+	jobManager *worker.Manager,
 	userService *service.UserService,
 	complaintRepo *repository.ComplaintRepository,
 	authorityRepo *repository.AuthorityRepository,
+	escalationRepo *repository.EscalationRepository,
 	abusePreventionService *service.AbusePreventionService,
 	emailShadowService *service.EmailShadowService,
 	pilotMetricsService *service.PilotMetricsService,
-	voiceNoteRepo *repository.VoiceNoteRepository,
+	attachmentService *service.AttachmentService,
+	evidenceService *service.EvidenceService,
+	photoReuseService *service.PhotoReuseService,
+	voiceNoteService *service.VoiceNoteService,
+	escalationRuleService *service.EscalationRuleService,
+	absenceService *service.OfficerAbsenceService,
+	blob storage.Blob,
 ) *mux.Router {
 	router := mux.NewRouter()
 
@@ -42,11 +50,15 @@ func SetupRoutes(
 	}).Methods("GET")
 
 	// Initialize handlers
-	complaintHandler := handler.NewComplaintHandler(complaintService, userService, abusePreventionService, complaintRepo, voiceNoteRepo)
+	complaintHandler := handler.NewComplaintHandler(complaintService, userService, abusePreventionService, complaintRepo)
 	verificationHandler := handler.NewVerificationHandler(verificationService)
-	escalationHandler := handler.NewEscalationHandler(escalationService, escalationWorker)
+	escalationHandler := handler.NewEscalationHandler(escalationService, jobManager)
 	phoneVerificationHandler := handler.NewPhoneVerificationHandler(userService)
 	chatHandler := handler.NewChatHandler(pilotMetricsService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	evidenceHandler := handler.NewEvidenceHandler(evidenceService)
+	voiceNoteHandler := handler.NewVoiceNoteHandler(voiceNoteService)
+	absenceHandler := handler.NewAbsenceHandler(absenceService)
 
 	// Initialize auth middleware
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -56,7 +68,7 @@ func SetupRoutes(
 	authMiddleware := middleware.NewAuthMiddleware(userService, jwtSecret)
 
 	// Initialize authority service and handlers
-	authorityService := service.NewAuthorityService(complaintRepo, authorityRepo, emailShadowService, pilotMetricsService)
+	authorityService := service.NewAuthorityService(complaintRepo, authorityRepo, emailShadowService, pilotMetricsService, escalationService, absenceService)
 	authorityHandler := handler.NewAuthorityHandler(authorityService)
 	authorityAuthHandler := handler.NewAuthorityAuthHandler(authorityService)
 	authorityAuthMiddleware := middleware.NewAuthorityAuthMiddleware(authorityService, jwtSecret)
@@ -79,8 +91,20 @@ func SetupRoutes(
 	// GET /api/v1/complaints/{id}/timeline - Get complaint status timeline (REQUIRES AUTH)
 	complaints.Handle("/{id}/timeline", authMiddleware.RequireAuth(http.HandlerFunc(complaintHandler.GetStatusTimeline))).Methods("GET")
 
-	// POST /api/v1/complaints/{id}/voice - Upload voice note (citizen owner only; one per complaint; overwrite allowed)
-	complaints.Handle("/{id}/voice", authMiddleware.RequireAuth(http.HandlerFunc(complaintHandler.UploadVoice))).Methods("POST")
+	// POST /api/v1/complaints/{id}/voice - Add a voice clip (citizen owner only; WAV or WebM/Opus; up to 5 clips per complaint)
+	complaints.Handle("/{id}/voice", authMiddleware.RequireAuth(http.HandlerFunc(voiceNoteHandler.UploadVoice))).Methods("POST")
+
+	// POST /api/v1/complaints/{id}/attachments - Upload photos (multipart; citizen owner only). Creates evidence hash per image.
+	complaints.Handle("/{id}/attachments", authMiddleware.RequireAuth(http.HandlerFunc(attachmentHandler.UploadAttachments))).Methods("POST")
+
+	// POST /api/v1/complaints/{id}/resolution - Citizen confirms or disputes a resolved complaint (owner only)
+	complaints.Handle("/{id}/resolution", authMiddleware.RequireAuth(http.HandlerFunc(complaintHandler.RespondToResolution))).Methods("POST")
+
+	// POST /api/v1/complaints/{id}/clarification - Citizen answers the officer's question (owner only; complaint awaiting_citizen)
+	complaints.Handle("/{id}/clarification", authMiddleware.RequireAuth(http.HandlerFunc(complaintHandler.AnswerClarification))).Methods("POST")
+
+	// POST /api/v1/complaints/{id}/escalate - Citizen requests escalation after an SLA breach (owner only; once per level)
+	complaints.Handle("/{id}/escalate", authMiddleware.RequireAuth(http.HandlerFunc(escalationHandler.RequestEscalation))).Methods("POST")
 
 	// POST /api/v1/complaints/{id}/verify - Verify a complaint (rule-based). Admin only; no public status write.
 	complaints.Handle("/{id}/verify", middleware.RequireAdminAuth(http.HandlerFunc(verificationHandler.VerifyComplaint))).Methods("POST")
@@ -111,15 +135,39 @@ func SetupRoutes(
 	// GET /api/v1/authority/me - Officer profile (requires authority auth).
 	authority.Handle("/me", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(authorityAuthHandler.Me))).Methods("GET")
 
-	// GET /api/v1/authority/complaints - Get complaints assigned to logged-in authority
+	// GET /api/v1/authority/complaints - Get complaints assigned to logged-in authority (and, marked delegated, those of officers on leave they cover for)
 	authority.Handle("/complaints", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(authorityHandler.GetMyComplaints))).Methods("GET")
 	
 	// POST /api/v1/authority/complaints/{id}/status - Update complaint status
 	authority.Handle("/complaints/{id}/status", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(authorityHandler.UpdateComplaintStatus))).Methods("POST")
 	
+	// POST /api/v1/authority/complaints/{id}/priority - Change priority (SLA due time follows the new priority)
+	authority.Handle("/complaints/{id}/priority", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(authorityHandler.UpdateComplaintPriority))).Methods("POST")
+
+	// POST /api/v1/authority/complaints/{id}/escalate - Escalate to the next level (assigned officer; reason required)
+	authority.Handle("/complaints/{id}/escalate", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(escalationHandler.EscalateByOfficer))).Methods("POST")
+
+	// POST /api/v1/authority/complaints/{id}/transfer - Transfer to another department/location (assigned officer; reason required)
+	authority.Handle("/complaints/{id}/transfer", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(escalationHandler.TransferComplaint))).Methods("POST")
+
 	// POST /api/v1/authority/complaints/{id}/note - Add internal note
 	authority.Handle("/complaints/{id}/note", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(authorityHandler.AddNote))).Methods("POST")
 
+	// POST /api/v1/authority/complaints/{id}/attachments/{attachment_id}/verify - Recompute evidence hash (assigned officer only)
+	authority.Handle("/complaints/{id}/attachments/{attachment_id}/verify", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(evidenceHandler.VerifyForAuthority))).Methods("POST")
+
+	// GET /api/v1/authority/complaints/{id}/voice - Stream citizen voice note (assigned officer or supervisor; Range supported; audited)
+	authority.Handle("/complaints/{id}/voice", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(voiceNoteHandler.StreamForAuthority))).Methods("GET", "HEAD")
+	// GET /api/v1/authority/complaints/{id}/voice/clips - List all voice clips (oldest first); stream one via /voice/clips/{clip_id}
+	authority.Handle("/complaints/{id}/voice/clips", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(voiceNoteHandler.ListForAuthority))).Methods("GET")
+	authority.Handle("/complaints/{id}/voice/clips/{clip_id}", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(voiceNoteHandler.StreamForAuthority))).Methods("GET", "HEAD")
+
+	// POST/GET /api/v1/authority/absences - Record own leave with a delegate officer / list own absences and those covered as delegate
+	authority.Handle("/absences", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(absenceHandler.RecordMyAbsence))).Methods("POST")
+	authority.Handle("/absences", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(absenceHandler.ListMyAbsences))).Methods("GET")
+	// DELETE /api/v1/authority/absences/{absence_id} - Cancel own absence (ends it now if in effect)
+	authority.Handle("/absences/{absence_id}", authorityAuthMiddleware.RequireAuthorityAuth(http.HandlerFunc(absenceHandler.CancelMyAbsence))).Methods("DELETE")
+
 	// Admin routes (env-based token; separate from citizen/authority). No UI; pilot operation only.
 	adminHandler := handler.NewAdminHandler(authorityRepo, complaintRepo)
 	admin := apiV1.PathPrefix("/admin").Subrouter()
@@ -127,11 +175,45 @@ func SetupRoutes(
 	admin.HandleFunc("/authorities", adminHandler.GetAuthorities).Methods("GET")
 	admin.HandleFunc("/authorities", adminHandler.CreateAuthority).Methods("POST")
 	admin.HandleFunc("/authorities/{officer_id}", adminHandler.UpdateAuthority).Methods("PUT")
+	// Officer leave and delegation (audited)
+	admin.HandleFunc("/authorities/{officer_id}/absences", absenceHandler.ListAbsences).Methods("GET")
+	admin.HandleFunc("/authorities/{officer_id}/absences", absenceHandler.RecordAbsence).Methods("POST")
+	admin.HandleFunc("/absences/{absence_id}", absenceHandler.CancelAbsence).Methods("DELETE")
+	admin.HandleFunc("/complaints/{id}/attachments/{attachment_id}/verify", evidenceHandler.VerifyForAdmin).Methods("POST")
+	photoReuseHandler := handler.NewPhotoReuseHandler(photoReuseService)
+	admin.HandleFunc("/photo-reuse", photoReuseHandler.ListFlags).Methods("GET")
+	admin.HandleFunc("/photo-reuse/{flag_id}/review", photoReuseHandler.ReviewFlag).Methods("POST")
+	// Escalation rules: strict validation, conflict detection, If-Match versioning; every change audited (history endpoint)
+	escalationRuleHandler := handler.NewEscalationRuleHandler(escalationRuleService, escalationService)
+	admin.HandleFunc("/escalation-rules", escalationRuleHandler.ListRules).Methods("GET")
+	admin.HandleFunc("/escalation-rules", escalationRuleHandler.CreateRule).Methods("POST")
+	admin.HandleFunc("/escalation-rules/simulate", escalationRuleHandler.SimulateEscalations).Methods("POST")
+	admin.HandleFunc("/escalation-rules/{rule_id}", escalationRuleHandler.GetRule).Methods("GET")
+	admin.HandleFunc("/escalation-rules/{rule_id}", escalationRuleHandler.UpdateRule).Methods("PUT")
+	admin.HandleFunc("/escalation-rules/{rule_id}", escalationRuleHandler.DeactivateRule).Methods("DELETE")
+	admin.HandleFunc("/escalation-rules/{rule_id}/history", escalationRuleHandler.GetRuleHistory).Methods("GET")
+	// Background jobs: shared pause/resume, run now (waits for the run) and job_runs history
+	jobHandler := handler.NewJobHandler(jobManager)
+	admin.HandleFunc("/jobs", jobHandler.ListJobs).Methods("GET")
+	admin.HandleFunc("/jobs/{name}/runs", jobHandler.GetJobRuns).Methods("GET")
+	admin.HandleFunc("/jobs/{name}/pause", jobHandler.PauseJob).Methods("POST")
+	admin.HandleFunc("/jobs/{name}/resume", jobHandler.ResumeJob).Methods("POST")
+	admin.HandleFunc("/jobs/{name}/run", jobHandler.RunJob).Methods("POST")
+
+	// GET /api/v1/lifecycle - Complaint state machine (states, transitions, actors). No auth; static data.
+	lifecycleHandler := handler.NewLifecycleHandler()
+	apiV1.HandleFunc("/lifecycle", lifecycleHandler.GetGraph).Methods("GET")
 
 	// Public read-only case page by complaint_number (shareable; complaint_id never exposed).
-	publicHandler := handler.NewPublicHandler(complaintRepo)
+	publicHandler := handler.NewPublicHandler(complaintRepo, escalationRepo)
 	apiV1.HandleFunc("/public/complaints/by-number/{complaint_number}", publicHandler.GetPublicComplaintByNumber).Methods("GET")
 
+	// Signed file URLs (local storage backend only; S3 signed URLs point at the bucket). No auth; the signature is the credential.
+	if local, ok := blob.(*storage.Local); ok {
+		fileHandler := handler.NewFileHandler(local)
+		apiV1.HandleFunc("/files/{key:.+}", fileHandler.ServeFile).Methods("GET", "HEAD")
+	}
+
 	// Health check endpoint
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -10,7 +10,7 @@ import (
 const tableComplaintStatusHistory = "complaint_status_history"
 
 // EnsureComplaintStatusHistory ensures the complaint_status_history table exists and has required columns
-// (actor_type, actor_id, reason). Creates the table if missing; adds only missing columns if table exists.
+// (actor_type, actor_id, reason, on_behalf_of_officer_id). Creates the table if missing; adds only missing columns if table exists.
 // Does not drop or recreate the table; does not remove existing data.
 func EnsureComplaintStatusHistory(db *sql.DB) {
 	exists, err := tableExists(db, tableComplaintStatusHistory)
@@ -25,6 +25,7 @@ func EnsureComplaintStatusHistory(db *sql.DB) {
 	ensureColumn(db, tableComplaintStatusHistory, "actor_type", "VARCHAR(50) NULL COMMENT 'Audit: who made the change (system, authority, user)'")
 	ensureColumn(db, tableComplaintStatusHistory, "actor_id", "BIGINT NULL COMMENT 'Audit: user_id or officer_id; NULL for system'")
 	ensureColumn(db, tableComplaintStatusHistory, "reason", "TEXT NULL COMMENT 'Audit: reason for change when available'")
+	ensureColumn(db, tableComplaintStatusHistory, "on_behalf_of_officer_id", "BIGINT NULL COMMENT 'Absent officer the acting delegate stood in for'")
 	log.Println("[SCHEMA] Schema check passed")
 }
 
@@ -63,8 +64,8 @@ func createComplaintStatusHistoryTable(db *sql.DB) {
 CREATE TABLE IF NOT EXISTS complaint_status_history (
     history_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     complaint_id BIGINT NOT NULL COMMENT 'Related complaint',
-    old_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated') NULL COMMENT 'Previous status',
-    new_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated') NOT NULL COMMENT 'New status',
+    old_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NULL COMMENT 'Previous status',
+    new_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NOT NULL COMMENT 'New status',
     changed_by_type ENUM('user', 'officer', 'system', 'admin') NOT NULL COMMENT 'Who made the change',
     changed_by_user_id BIGINT NULL COMMENT 'User who changed (if applicable)',
     changed_by_officer_id BIGINT NULL COMMENT 'Officer who changed (if applicable)',
@@ -73,6 +74,7 @@ CREATE TABLE IF NOT EXISTS complaint_status_history (
     notes TEXT NULL COMMENT 'Status change notes/comments',
     actor_type VARCHAR(50) NULL COMMENT 'Audit: who made the change',
     actor_id BIGINT NULL COMMENT 'Audit: user_id or officer_id; NULL for system',
+    on_behalf_of_officer_id BIGINT NULL COMMENT 'Absent officer the acting delegate stood in for',
     reason TEXT NULL COMMENT 'Audit: reason for change when available',
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Change timestamp',
     FOREIGN KEY (complaint_id) REFERENCES complaints(complaint_id) ON DELETE CASCADE,
@@ -15,6 +15,7 @@ const (
 // InitializeDatabase ensures core tables exist. Checks INFORMATION_SCHEMA.TABLES; creates only missing
 // tables in order: users → complaints → complaint_status_history. Then runs EnsureComplaintStatusHistory
 // to add any missing columns. Does not drop or recreate tables; does not remove data.
+// Other tables, and columns added to them later, come from migrations/ (checked by ValidateRequiredColumns).
 func InitializeDatabase(db *sql.DB) {
 	// 1. users
 	if exists, err := tableExists(db, tableUsers); err != nil {
@@ -36,6 +37,9 @@ func InitializeDatabase(db *sql.DB) {
 		log.Println("[SCHEMA] created complaints table")
 	}
 
+	// Optimistic concurrency column on complaints (older tables predate it)
+	ensureColumn(db, tableComplaints, "version", "INT NOT NULL DEFAULT 0 COMMENT 'Optimistic concurrency version'")
+
 	// 3. complaint_status_history (depends on complaints)
 	if exists, err := tableExists(db, tableComplaintStatusHistory); err != nil {
 		log.Fatalf("[SCHEMA] Failed to check if table complaint_status_history exists: %v", err)
@@ -131,11 +135,12 @@ CREATE TABLE IF NOT EXISTS complaints (
     longitude DECIMAL(11, 8) NULL COMMENT 'Specific coordinates',
     assigned_department_id BIGINT NULL COMMENT 'Initially assigned department',
     assigned_officer_id BIGINT NULL COMMENT 'Currently assigned officer',
-    current_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated') NOT NULL DEFAULT 'draft' COMMENT 'Current status',
+    current_status ENUM('draft', 'submitted', 'verified', 'under_review', 'in_progress', 'resolved', 'rejected', 'closed', 'escalated', 'awaiting_citizen') NOT NULL DEFAULT 'draft' COMMENT 'Current status',
     priority ENUM('low', 'medium', 'high', 'urgent') NOT NULL DEFAULT 'medium' COMMENT 'Priority level',
     is_public BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Public visibility flag',
     public_consent_given BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'User consent for public disclosure',
     supporter_count INT NOT NULL DEFAULT 0 COMMENT 'Count of supporting users',
+    version INT NOT NULL DEFAULT 0 COMMENT 'Optimistic concurrency version',
     resolved_at TIMESTAMP NULL COMMENT 'Resolution timestamp',
     closed_at TIMESTAMP NULL COMMENT 'Closure timestamp',
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Complaint creation',
@@ -15,10 +15,40 @@ type RequiredColumn struct {
 
 // DefaultRequiredColumns returns the columns required for escalation and status history to work.
 // If any are missing, the server should not start (avoids runtime failures and timezone/actor_type issues).
+// Tables and columns that only migrations create are listed by migration (one column stands for a new table).
 var DefaultRequiredColumns = []RequiredColumn{
 	{Table: "complaint_status_history", Column: "actor_type"},
 	{Table: "complaint_status_history", Column: "actor_id"},
 	{Table: "complaint_status_history", Column: "reason"},
+	{Table: "complaints", Column: "version"},                               // 0007
+	{Table: "complaint_evidence", Column: "evidence_hash"},                 // 0008
+	{Table: "complaint_attachments", Column: "exif_captured_at"},           // 0009
+	{Table: "complaint_attachments", Column: "exif_latitude"},              // 0009
+	{Table: "complaint_attachments", Column: "exif_longitude"},             // 0009
+	{Table: "complaint_attachments", Column: "exif_camera_model"},          // 0009
+	{Table: "complaint_attachments", Column: "perceptual_hash"},            // 0010
+	{Table: "photo_reuse_flags", Column: "hamming_distance"},               // 0010
+	{Table: "complaint_voice_notes", Column: "file_size"},                  // 0011
+	{Table: "sla_calendars", Column: "working_days"},                       // 0012
+	{Table: "sla_holidays", Column: "holiday_date"},                        // 0012
+	{Table: "escalation_rules", Column: "version"},                         // 0013
+	{Table: "sla_policies", Column: "is_active"},                           // 0014
+	{Table: "sla_policy_targets", Column: "sla_hours"},                     // 0014
+	{Table: "complaint_clarifications", Column: "return_status"},           // 0015
+	{Table: "worker_leases", Column: "expires_at"},                         // 0016
+	{Table: "notifications_log", Column: "claimed_by"},                     // 0016
+	{Table: "notifications_log", Column: "claim_expires_at"},               // 0016
+	{Table: "job_runs", Column: "trigger_type"},                            // 0017
+	{Table: "worker_jobs", Column: "schedule"},                             // 0017
+	{Table: "complaint_escalations", Column: "escalation_type"},            // 0018
+	{Table: "officers", Column: "supervisor_officer_id"},                   // 0019
+	{Table: "officers", Column: "latitude"},                                // 0020
+	{Table: "officer_categories", Column: "category"},                      // 0020
+	{Table: "assignment_cursors", Column: "last_officer_id"},               // 0020
+	{Table: "officer_absences", Column: "delegate_officer_id"},             // 0021
+	{Table: "complaint_status_history", Column: "on_behalf_of_officer_id"}, // 0021
+	{Table: "authority_notes", Column: "on_behalf_of_officer_id"},          // 0021
+	{Table: "complaint_attachments", Column: "exif_time_zoned"},            // 0022
 }
 
 // ValidateRequiredColumns checks that all required columns exist. On failure, logs a fatal error listing missing columns.
@@ -86,6 +86,15 @@ INSERT INTO department_location_mapping (department_id, location_id, is_active,
 (5, 1, TRUE, NOW())  -- Health → Shivpuri
 ON DUPLICATE KEY UPDATE is_active = TRUE;
 
+-- Step 5: Escalation hierarchy (requires migrations 0003 and 0019)
+-- authority_level from the employee_id level; L1 reports to L2, L2 reports to L3 in each department
+-- PILOT DATA
+
+UPDATE officers SET authority_level = 1 WHERE officer_id IN (1, 4, 7, 10, 13);
+UPDATE officers SET authority_level = 2 WHERE officer_id IN (2, 5, 8, 11, 14);
+UPDATE officers SET authority_level = 3 WHERE officer_id IN (3, 6, 9, 12, 15);
+UPDATE officers SET supervisor_officer_id = officer_id + 1 WHERE officer_id IN (1, 2, 4, 5, 7, 8, 10, 11, 13, 14);
+
 -- ============================================================================
 -- VERIFICATION QUERIES (Run after seeding to verify data)
 -- ============================================================================
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"finalneta/repository"
@@ -29,8 +30,8 @@ type AbuseCheckResult struct {
 
 // CheckRateLimit verifies user hasn't exceeded complaint submission rate limit
 // Max 3 complaints per user per 24 hours
-func (s *AbusePreventionService) CheckRateLimit(userID int64) (*AbuseCheckResult, error) {
-	count, err := s.abuseRepo.CountComplaintsByUserInLast24Hours(userID)
+func (s *AbusePreventionService) CheckRateLimit(ctx context.Context, userID int64) (*AbuseCheckResult, error) {
+	count, err := s.abuseRepo.CountComplaintsByUserInLast24Hours(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check rate limit: %w", err)
 	}
@@ -50,12 +51,12 @@ func (s *AbusePreventionService) CheckRateLimit(userID int64) (*AbuseCheckResult
 
 // CheckDuplicate verifies complaint is not a duplicate submission
 // Same user + same issue_summary + same pincode within 30 minutes → reject
-func (s *AbusePreventionService) CheckDuplicate(
+func (s *AbusePreventionService) CheckDuplicate(ctx context.Context,
 	userID int64,
 	issueSummary string,
 	pincode string,
 ) (*AbuseCheckResult, error) {
-	exists, err := s.abuseRepo.HasDuplicateComplaint(userID, issueSummary, pincode, 30*time.Minute)
+	exists, err := s.abuseRepo.HasDuplicateComplaint(ctx, userID, issueSummary, pincode, 30*time.Minute)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check duplicate: %w", err)
 	}
@@ -87,13 +88,13 @@ func GenerateDeviceFingerprint(userID int64, userAgent string, screenSize string
 }
 
 // ValidateComplaintSubmission performs all abuse prevention checks before allowing submission
-func (s *AbusePreventionService) ValidateComplaintSubmission(
+func (s *AbusePreventionService) ValidateComplaintSubmission(ctx context.Context,
 	userID int64,
 	issueSummary string,
 	pincode string,
 ) (*AbuseCheckResult, error) {
 	// Check 1: Rate limiting
-	rateLimitResult, err := s.CheckRateLimit(userID)
+	rateLimitResult, err := s.CheckRateLimit(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +103,7 @@ func (s *AbusePreventionService) ValidateComplaintSubmission(
 	}
 
 	// Check 2: Duplicate detection
-	duplicateResult, err := s.CheckDuplicate(userID, issueSummary, pincode)
+	duplicateResult, err := s.CheckDuplicate(ctx, userID, issueSummary, pincode)
 	if err != nil {
 		return nil, err
 	}
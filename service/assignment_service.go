@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"finalneta/models"
+	"finalneta/repository"
+	"finalneta/utils"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultAssignmentStrategy applies to departments without ASSIGNMENT_STRATEGY_<DEPARTMENT_ID>
+const DefaultAssignmentStrategy = models.AssignmentLeastOpen
+
+// maxAssignmentInputs caps the officers listed in an assignment note
+const maxAssignmentInputs = 10
+
+// AssignmentTarget is where a complaint is being assigned and what strategies may use to pick the officer
+type AssignmentTarget struct {
+	DepartmentID      int64
+	LocationID        int64
+	MinAuthorityLevel int    // 1 for a new complaint; a transfer keeps the complaint's level
+	ExcludeOfficerID  int64  // never picked (the transferring officer); 0 = none
+	Category          string // complaints.category ("" = none)
+	Latitude          *float64
+	Longitude         *float64
+}
+
+// Assigner is one assignment strategy. officers is never empty and holds only eligible officers (active, same
+// department, location and level). ok = false means the strategy has nothing to decide on (no officer handles the
+// category, no coordinates); the service then falls back to least_open and records why in inputs.
+type Assigner interface {
+	Pick(ctx context.Context, target AssignmentTarget, officers []models.AssignableOfficer) (officerID int64, inputs string, ok bool, err error)
+}
+
+// AssignmentService assigns complaints to officers with the strategy configured for the department
+type AssignmentService struct {
+	assignmentRepo       *repository.AssignmentRepository
+	absenceService       *OfficerAbsenceService // optional; nil = officers on leave are assigned as usual
+	defaultStrategy      models.AssignmentStrategy
+	departmentStrategies map[int64]models.AssignmentStrategy
+	assigners            map[models.AssignmentStrategy]Assigner
+}
+
+// NewAssignmentService creates an assignment service. defaultStrategy "" = least_open; departmentStrategies maps
+// department_id to a strategy name. Unknown strategy names are an error.
+func NewAssignmentService(
+	assignmentRepo *repository.AssignmentRepository,
+	absenceService *OfficerAbsenceService,
+	defaultStrategy string,
+	departmentStrategies map[int64]string,
+) (*AssignmentService, error) {
+	s := &AssignmentService{
+		assignmentRepo:       assignmentRepo,
+		absenceService:       absenceService,
+		defaultStrategy:      DefaultAssignmentStrategy,
+		departmentStrategies: make(map[int64]models.AssignmentStrategy),
+	}
+	s.assigners = map[models.AssignmentStrategy]Assigner{
+		models.AssignmentRoundRobin: roundRobinAssigner{repo: assignmentRepo},
+		models.AssignmentLeastOpen:  leastOpenAssigner{},
+		models.AssignmentCategory:   categoryAssigner{},
+		models.AssignmentNearest:    nearestAssigner{},
+	}
+
+	if defaultStrategy != "" {
+		if _, ok := s.assigners[models.AssignmentStrategy(defaultStrategy)]; !ok {
+			return nil, fmt.Errorf("unknown assignment strategy %q (use round_robin, least_open, category or nearest)", defaultStrategy)
+		}
+		s.defaultStrategy = models.AssignmentStrategy(defaultStrategy)
+	}
+	for departmentID, name := range departmentStrategies {
+		if _, ok := s.assigners[models.AssignmentStrategy(name)]; !ok {
+			return nil, fmt.Errorf("unknown assignment strategy %q for department %d (use round_robin, least_open, category or nearest)", name, departmentID)
+		}
+		s.departmentStrategies[departmentID] = models.AssignmentStrategy(name)
+	}
+	return s, nil
+}
+
+// StrategyFor returns the strategy configured for a department
+func (s *AssignmentService) StrategyFor(departmentID int64) models.AssignmentStrategy {
+	if strategy, ok := s.departmentStrategies[departmentID]; ok {
+		return strategy
+	}
+	return s.defaultStrategy
+}
+
+// Assign picks the officer for a complaint at target, or returns nil when the department has no eligible
+// officer at the location (the complaint stays with the department)
+//
+// Flow:
+// 1. Eligible officers: active, in the department and location, at the lowest authority_level at or above target.MinAuthorityLevel
+// 2. The department's strategy picks one; a strategy that cannot decide falls back to least_open
+// 3. An officer on leave hands the complaint to their delegate (unless that is target.ExcludeOfficerID)
+// 4. The returned Assignment names the strategy and its inputs for the status history notes
+func (s *AssignmentService) Assign(ctx context.Context, target AssignmentTarget) (*models.Assignment, error) {
+	minLevel := target.MinAuthorityLevel
+	if minLevel < 1 {
+		minLevel = 1
+	}
+	officers, err := s.assignmentRepo.ListAssignableOfficers(ctx, target.DepartmentID, target.LocationID, minLevel, target.ExcludeOfficerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(officers) == 0 {
+		return nil, nil
+	}
+
+	strategy := s.StrategyFor(target.DepartmentID)
+	officerID, inputs, ok, err := s.assigners[strategy].Pick(ctx, target, officers)
+	if err != nil {
+		return nil, fmt.Errorf("%s assignment failed: %w", strategy, err)
+	}
+	assignment := &models.Assignment{OfficerID: officerID, Strategy: strategy, Inputs: inputs}
+	if !ok {
+		officerID, fallbackInputs, _, _ := leastOpenAssigner{}.Pick(ctx, target, officers)
+		assignment = &models.Assignment{
+			OfficerID:    officerID,
+			Strategy:     models.AssignmentLeastOpen,
+			FallbackFrom: strategy,
+			Inputs:       inputs + "; " + fallbackInputs,
+		}
+	}
+	delegateID, absence, err := s.absenceService.ResolveDelegate(ctx, assignment.OfficerID)
+	if err != nil {
+		return nil, fmt.Errorf("delegate lookup failed: %w", err)
+	}
+	if absence != nil && delegateID != target.ExcludeOfficerID {
+		assignment.DelegatedFrom = assignment.OfficerID
+		assignment.OfficerID = delegateID
+	}
+	log.Printf("[ASSIGNMENT] department_id=%d location_id=%d: %s", target.DepartmentID, target.LocationID, assignment.Note())
+	return assignment, nil
+}
+
+// roundRobinAssigner gives officers complaints in turn (officer_id order), shared by all replicas
+type roundRobinAssigner struct {
+	repo *repository.AssignmentRepository
+}
+
+func (a roundRobinAssigner) Pick(ctx context.Context, target AssignmentTarget, officers []models.AssignableOfficer) (int64, string, bool, error) {
+	ids := make([]int64, len(officers))
+	for i, o := range officers {
+		ids[i] = o.OfficerID
+	}
+	next, previous, err := a.repo.NextRoundRobin(ctx, target.DepartmentID, target.LocationID, ids)
+	if err != nil {
+		return 0, "", false, err
+	}
+	turn := "first turn"
+	if previous != 0 {
+		turn = fmt.Sprintf("previous turn: officer %d", previous)
+	}
+	return next, fmt.Sprintf("%s; rotation: %s", turn, formatOfficerIDs(ids)), true, nil
+}
+
+// leastOpenAssigner picks the officer with the fewest open complaints (lowest officer_id on a tie)
+type leastOpenAssigner struct{}
+
+func (leastOpenAssigner) Pick(ctx context.Context, target AssignmentTarget, officers []models.AssignableOfficer) (int64, string, bool, error) {
+	best := officers[0]
+	for _, o := range officers[1:] {
+		if o.OpenComplaints < best.OpenComplaints {
+			best = o
+		}
+	}
+	return best.OfficerID, "open complaints: " + formatOpenComplaints(officers), true, nil
+}
+
+// categoryAssigner picks among officers handling the complaint's category, fewest open complaints first
+type categoryAssigner struct{}
+
+func (categoryAssigner) Pick(ctx context.Context, target AssignmentTarget, officers []models.AssignableOfficer) (int64, string, bool, error) {
+	if target.Category == "" {
+		return 0, "complaint has no category", false, nil
+	}
+	var matches []models.AssignableOfficer
+	for _, o := range officers {
+		for _, c := range o.Categories {
+			if strings.EqualFold(c, target.Category) {
+				matches = append(matches, o)
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return 0, fmt.Sprintf("no officer handles category %q", target.Category), false, nil
+	}
+	officerID, _, _, _ := leastOpenAssigner{}.Pick(ctx, target, matches)
+	return officerID, fmt.Sprintf("category %q; open complaints: %s", target.Category, formatOpenComplaints(matches)), true, nil
+}
+
+// nearestAssigner picks the officer whose office is nearest to the complaint (great-circle distance)
+type nearestAssigner struct{}
+
+func (nearestAssigner) Pick(ctx context.Context, target AssignmentTarget, officers []models.AssignableOfficer) (int64, string, bool, error) {
+	if target.Latitude == nil || target.Longitude == nil {
+		return 0, "complaint has no coordinates", false, nil
+	}
+	type candidate struct {
+		officerID int64
+		km        float64
+	}
+	var located []candidate
+	for _, o := range officers {
+		if o.Latitude.Valid && o.Longitude.Valid {
+			located = append(located, candidate{o.OfficerID, utils.DistanceMeters(*target.Latitude, *target.Longitude, o.Latitude.Float64, o.Longitude.Float64) / 1000})
+		}
+	}
+	if len(located) == 0 {
+		return 0, "no officer has office coordinates", false, nil
+	}
+	sort.SliceStable(located, func(i, j int) bool { return located[i].km < located[j].km })
+
+	entries := make([]string, len(located))
+	for i, c := range located {
+		entries[i] = fmt.Sprintf("officer %d=%.1f km", c.officerID, c.km)
+	}
+	inputs := fmt.Sprintf("complaint at %.5f,%.5f; distance: %s", *target.Latitude, *target.Longitude, joinCapped(entries))
+	return located[0].officerID, inputs, true, nil
+}
+
+func formatOpenComplaints(officers []models.AssignableOfficer) string {
+	entries := make([]string, len(officers))
+	for i, o := range officers {
+		entries[i] = fmt.Sprintf("officer %d=%d", o.OfficerID, o.OpenComplaints)
+	}
+	return joinCapped(entries)
+}
+
+func formatOfficerIDs(ids []int64) string {
+	entries := make([]string, len(ids))
+	for i, id := range ids {
+		entries[i] = strconv.FormatInt(id, 10)
+	}
+	return joinCapped(entries)
+}
+
+// joinCapped joins at most maxAssignmentInputs entries so a large department does not flood the notes
+func joinCapped(entries []string) string {
+	if len(entries) <= maxAssignmentInputs {
+		return strings.Join(entries, ", ")
+	}
+	return fmt.Sprintf("%s, +%d more", strings.Join(entries[:maxAssignmentInputs], ", "), len(entries)-maxAssignmentInputs)
+}
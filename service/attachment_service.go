@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"finalneta/models"
+	"finalneta/repository"
+	"finalneta/storage"
+	"finalneta/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxAttachmentSize is the largest accepted image (bytes)
+	MaxAttachmentSize = 10 << 20
+	// MaxAttachmentsPerUpload caps files per upload request
+	MaxAttachmentsPerUpload = 5
+	// PhotoReuseMaxDistance is the largest dHash Hamming distance treated as the same photo
+	PhotoReuseMaxDistance = 6
+	// photoReuseMatchLimit caps flags created per uploaded photo
+	photoReuseMatchLimit = 10
+)
+
+// allowedAttachmentTypes maps sniffed MIME types to stored file extensions
+var allowedAttachmentTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// AttachmentUpload is one uploaded file as received (raw bytes, client file name)
+type AttachmentUpload struct {
+	FileName string
+	Data     []byte
+}
+
+// AttachmentService stores citizen photo uploads and their evidence integrity records
+//
+// Rules:
+//  1. Only the complaint owner may upload; closed complaints accept no new attachments
+//  2. File type is sniffed from the bytes (JPEG, PNG, WebP); the client Content-Type is ignored
+//  3. Evidence hash is computed from the raw bytes, server time and GPS at upload (see EVIDENCE_INTEGRITY_IMPLEMENTATION.md)
+//  4. Attachment rows, evidence rows and the audit row commit together; files are removed if the commit fails
+//  5. JPEG EXIF (capture time, GPS, camera model) is stored on the attachment for the verification EXIF rule
+//  6. A perceptual hash (JPEG/PNG) is stored; near-identical photos on other complaints go to the admin
+//     review list (photo_reuse_flags). Uploads are never rejected for reuse.
+type AttachmentService struct {
+	complaintRepo  *repository.ComplaintRepository
+	evidenceRepo   *repository.EvidenceRepository
+	photoReuseRepo *repository.PhotoReuseRepository
+	blob           storage.Blob
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(
+	complaintRepo *repository.ComplaintRepository,
+	evidenceRepo *repository.EvidenceRepository,
+	photoReuseRepo *repository.PhotoReuseRepository,
+	blob storage.Blob,
+) *AttachmentService {
+	return &AttachmentService{
+		complaintRepo:  complaintRepo,
+		evidenceRepo:   evidenceRepo,
+		photoReuseRepo: photoReuseRepo,
+		blob:           blob,
+	}
+}
+
+// downloadURL returns a signed download link for a storage key, or nil when there is none: no backend, a
+// file_path that is not a storage key (external URL from attachment_urls) or a signing failure (logged)
+func downloadURL(ctx context.Context, blob storage.Blob, key string) *string {
+	if blob == nil || storage.ValidateKey(key) != nil {
+		return nil
+	}
+	u, err := blob.SignedURL(ctx, key, storage.DownloadURLExpiry)
+	if err != nil {
+		log.Printf("[storage] Warning: failed to sign download URL for %s: %v", key, err)
+		return nil
+	}
+	return &u
+}
+
+// storedFile is an upload written to storage, pending database insert
+type storedFile struct {
+	upload   AttachmentUpload
+	mimeType string
+	key      string // storage key, also stored as complaint_attachments.file_path
+}
+
+// UploadAttachments stores images for a complaint and creates an evidence record for each.
+// latitude/longitude are the capture GPS; when nil the complaint's own coordinates are used.
+func (s *AttachmentService) UploadAttachments(ctx context.Context,
+	complaintID int64,
+	userID int64,
+	uploads []AttachmentUpload,
+	latitude *float64,
+	longitude *float64,
+	ipAddress string,
+	userAgent string,
+) (*models.AttachmentUploadResponse, error) {
+	if len(uploads) == 0 {
+		return nil, fmt.Errorf("invalid attachment: at least one image is required")
+	}
+	if len(uploads) > MaxAttachmentsPerUpload {
+		return nil, fmt.Errorf("invalid attachment: too many files: at most %d images per upload", MaxAttachmentsPerUpload)
+	}
+	if (latitude == nil) != (longitude == nil) {
+		return nil, fmt.Errorf("invalid attachment: latitude and longitude must be provided together")
+	}
+
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, err
+	}
+	if complaint.UserID != userID {
+		return nil, fmt.Errorf("only the complaint owner can upload attachments")
+	}
+	if complaint.CurrentStatus == models.StatusClosed {
+		return nil, fmt.Errorf("complaint is closed")
+	}
+
+	// Fall back to the complaint's GPS when the upload carries none
+	if latitude == nil && complaint.Latitude.Valid && complaint.Longitude.Valid {
+		latitude = &complaint.Latitude.Float64
+		longitude = &complaint.Longitude.Float64
+	}
+
+	// Validate every file before writing any of them
+	files := make([]storedFile, 0, len(uploads))
+	for _, u := range uploads {
+		mimeType, err := detectAttachmentType(u)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, storedFile{upload: u, mimeType: mimeType})
+	}
+
+	for i := range files {
+		name := fmt.Sprintf("%s.%s", uuid.New().String(), allowedAttachmentTypes[files[i].mimeType])
+		key := path.Join("attachments", fmt.Sprintf("%d", complaintID), name)
+		if err := s.blob.Put(ctx, key, files[i].upload.Data, files[i].mimeType); err != nil {
+			s.removeStoredFiles(files[:i])
+			return nil, fmt.Errorf("failed to write attachment: %w", err)
+		}
+		files[i].key = key
+	}
+
+	// Server time at upload; never taken from the client
+	capturedAt := time.Now().UTC()
+	response := &models.AttachmentUploadResponse{
+		ComplaintID: complaintID,
+		Attachments: make([]models.UploadedAttachment, 0, len(files)),
+	}
+
+	// Attachment rows, evidence rows and audit row commit atomically
+	err = s.complaintRepo.InTx(ctx, func(tx *sql.Tx) error {
+		complaintRepo := s.complaintRepo.WithTx(tx)
+		evidenceRepo := s.evidenceRepo.WithTx(tx)
+		photoReuseRepo := s.photoReuseRepo.WithTx(tx)
+
+		auditItems := make([]map[string]interface{}, 0, len(files))
+		for _, f := range files {
+			attachment := &models.ComplaintAttachment{
+				ComplaintID:      complaintID,
+				FileName:         attachmentFileName(f.upload.FileName, f.key),
+				FilePath:         f.key,
+				FileType:         sql.NullString{String: f.mimeType, Valid: true},
+				FileSize:         sql.NullInt64{Int64: int64(len(f.upload.Data)), Valid: true},
+				UploadedByUserID: sql.NullInt64{Int64: userID, Valid: true},
+				IsPublic:         complaint.IsPublic,
+			}
+			if f.mimeType == "image/jpeg" {
+				applyExif(attachment, f.upload.Data)
+			}
+			if hash, err := utils.PerceptualHash(f.upload.Data); err == nil {
+				attachment.PerceptualHash = sql.NullInt64{Int64: int64(hash), Valid: true}
+			} else if f.mimeType != "image/webp" { // no WebP decoder in the standard library
+				log.Printf("[attachment] complaint_id=%d perceptual hash failed for %q: %v", complaintID, attachment.FileName, err)
+			}
+			if err := complaintRepo.CreateAttachment(ctx, attachment); err != nil {
+				return err
+			}
+			reuseFlags := flagPhotoReuse(ctx, photoReuseRepo, complaint, attachment)
+
+			evidence := newEvidenceRecord(attachment.AttachmentID, complaintID, f.upload.Data, latitude, longitude, capturedAt)
+			if err := evidenceRepo.CreateEvidence(ctx, evidence); err != nil {
+				return err
+			}
+
+			fileType := attachment.FileType.String
+			fileSize := attachment.FileSize.Int64
+			response.Attachments = append(response.Attachments, models.UploadedAttachment{
+				AttachmentInfo: models.AttachmentInfo{
+					AttachmentID: attachment.AttachmentID,
+					FileName:     attachment.FileName,
+					FilePath:     attachment.FilePath,
+					FileType:     &fileType,
+					FileSize:     &fileSize,
+					IsPublic:     attachment.IsPublic,
+					URL:          downloadURL(ctx, s.blob, attachment.FilePath),
+				},
+				EvidenceHash: evidence.EvidenceHash,
+				CapturedAt:   evidence.CapturedAt,
+			})
+			auditItems = append(auditItems, map[string]interface{}{
+				"attachment_id": attachment.AttachmentID,
+				"file_type":     fileType,
+				"file_size":     fileSize,
+				"evidence_hash": evidence.EvidenceHash,
+				"reuse_flags":   reuseFlags,
+			})
+		}
+
+		// Create audit log entry
+		newValuesJSON, _ := json.Marshal(map[string]interface{}{
+			"attachments": auditItems,
+		})
+		auditLog := &models.AuditLog{
+			EntityType:     "complaint",
+			EntityID:       complaintID,
+			Action:         "attachments_uploaded",
+			ActionByType:   models.ActorUser,
+			ActionByUserID: sql.NullInt64{Int64: userID, Valid: true},
+			NewValues:      sql.NullString{String: string(newValuesJSON), Valid: true},
+			IPAddress:      sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+			UserAgent:      sql.NullString{String: userAgent, Valid: userAgent != ""},
+		}
+		if err := complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.removeStoredFiles(files)
+		return nil, err
+	}
+
+	log.Printf("[attachment] complaint_id=%d stored %d attachment(s)", complaintID, len(files))
+	return response, nil
+}
+
+// flagPhotoReuse records near-identical photos on other complaints in the admin review list.
+// Lookup or insert failures are logged and never fail the upload. Returns the number of matches flagged.
+func flagPhotoReuse(ctx context.Context,
+	photoReuseRepo *repository.PhotoReuseRepository,
+	complaint *models.Complaint,
+	attachment *models.ComplaintAttachment,
+) int {
+	if !attachment.PerceptualHash.Valid {
+		return 0
+	}
+	matches, err := photoReuseRepo.FindSimilarAttachments(ctx, attachment.PerceptualHash.Int64, complaint.ComplaintID, PhotoReuseMaxDistance, photoReuseMatchLimit)
+	if err != nil {
+		log.Printf("[attachment] complaint_id=%d photo reuse lookup failed: %v", complaint.ComplaintID, err)
+		return 0
+	}
+
+	flagged := 0
+	for _, m := range matches {
+		flag := &models.PhotoReuseFlag{
+			ComplaintID:         complaint.ComplaintID,
+			AttachmentID:        attachment.AttachmentID,
+			MatchedComplaintID:  m.ComplaintID,
+			MatchedAttachmentID: m.AttachmentID,
+			HammingDistance:     m.HammingDistance,
+			DifferentUser:       m.UserID != complaint.UserID,
+			// Only a known, differing fingerprint counts as another device
+			DifferentDevice: complaint.DeviceFingerprint.Valid && m.DeviceFingerprint.Valid &&
+				complaint.DeviceFingerprint.String != m.DeviceFingerprint.String,
+		}
+		if err := photoReuseRepo.CreateFlag(ctx, flag); err != nil {
+			log.Printf("[attachment] complaint_id=%d photo reuse flag failed: %v", complaint.ComplaintID, err)
+			continue
+		}
+		flagged++
+		log.Printf("[PHOTO_REUSE] complaint_id=%d attachment_id=%d matches complaint_id=%d attachment_id=%d distance=%d different_user=%t",
+			complaint.ComplaintID, attachment.AttachmentID, m.ComplaintID, m.AttachmentID, m.HammingDistance, flag.DifferentUser)
+	}
+	return flagged
+}
+
+// detectAttachmentType sniffs the MIME type from the file bytes and checks size and type
+func detectAttachmentType(u AttachmentUpload) (string, error) {
+	if len(u.Data) == 0 {
+		return "", fmt.Errorf("invalid attachment: file %q is empty", u.FileName)
+	}
+	if len(u.Data) > MaxAttachmentSize {
+		return "", fmt.Errorf("invalid attachment: file %q exceeds the %d MB limit", u.FileName, MaxAttachmentSize>>20)
+	}
+	mimeType := http.DetectContentType(u.Data)
+	if _, ok := allowedAttachmentTypes[mimeType]; !ok {
+		return "", fmt.Errorf("invalid attachment: unsupported file type %s for %q: only JPEG, PNG and WebP images are accepted", mimeType, u.FileName)
+	}
+	return mimeType, nil
+}
+
+// applyExif copies capture time, GPS and camera model from a JPEG's EXIF onto the attachment.
+// Missing or unreadable EXIF is not an error (many apps strip it); the fields stay NULL.
+func applyExif(attachment *models.ComplaintAttachment, data []byte) {
+	exif, err := utils.ReadJPEGExif(data)
+	if err != nil {
+		if !errors.Is(err, utils.ErrNoExif) {
+			log.Printf("[attachment] complaint_id=%d unreadable EXIF in %q: %v", attachment.ComplaintID, attachment.FileName, err)
+		}
+		return
+	}
+	if exif.CaptureTime != nil {
+		attachment.ExifCapturedAt = sql.NullTime{Time: *exif.CaptureTime, Valid: true}
+		attachment.ExifTimeZoned = exif.CaptureTimeZoned
+	}
+	if exif.Latitude != nil && exif.Longitude != nil {
+		attachment.ExifLatitude = sql.NullFloat64{Float64: *exif.Latitude, Valid: true}
+		attachment.ExifLongitude = sql.NullFloat64{Float64: *exif.Longitude, Valid: true}
+	}
+	camera := exif.CameraModel
+	if exif.CameraMake != "" && !strings.HasPrefix(strings.ToLower(camera), strings.ToLower(exif.CameraMake)) {
+		camera = strings.TrimSpace(exif.CameraMake + " " + camera)
+	}
+	if r := []rune(camera); len(r) > 255 { // exif_camera_model is VARCHAR(255)
+		camera = string(r[:255])
+	}
+	if camera != "" {
+		attachment.ExifCameraModel = sql.NullString{String: camera, Valid: true}
+	}
+}
+
+// attachmentFileName returns the client file name without any path, or the stored name
+func attachmentFileName(clientName, key string) string {
+	name := path.Base(strings.ReplaceAll(clientName, "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		return path.Base(key)
+	}
+	if r := []rune(name); len(r) > 255 { // file_name is VARCHAR(255)
+		name = string(r[len(r)-255:])
+	}
+	return name
+}
+
+// removeStoredFiles deletes objects written for an upload that did not commit
+func (s *AttachmentService) removeStoredFiles(files []storedFile) {
+	for _, f := range files {
+		if f.key == "" {
+			continue
+		}
+		if err := s.blob.Delete(context.Background(), f.key); err != nil {
+			log.Printf("[attachment] failed to remove %s: %v", f.key, err)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package service
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"finalneta/lifecycle"
 	"finalneta/models"
 	"finalneta/repository"
 	"fmt"
+	"log"
 	"time"
 )
 
@@ -14,6 +18,8 @@ type AuthorityService struct {
 	authorityRepo      *repository.AuthorityRepository
 	emailShadowService *EmailShadowService // optional; pilot email shadow mode
 	pilotMetricsService *PilotMetricsService // optional; pilot metrics
+	escalationService  *EscalationService  // optional; SLA due-at on complaint lists
+	absenceService     *OfficerAbsenceService // optional; delegates act on absent officers' complaints
 }
 
 // NewAuthorityService creates a new authority service
@@ -22,38 +28,42 @@ func NewAuthorityService(
 	authorityRepo *repository.AuthorityRepository,
 	emailShadowService *EmailShadowService,
 	pilotMetricsService *PilotMetricsService,
+	escalationService *EscalationService,
+	absenceService *OfficerAbsenceService,
 ) *AuthorityService {
 	return &AuthorityService{
 		complaintRepo:      complaintRepo,
 		authorityRepo:      authorityRepo,
 		emailShadowService: emailShadowService,
 		pilotMetricsService: pilotMetricsService,
+		escalationService:  escalationService,
+		absenceService:     absenceService,
 	}
 }
 
 // ValidateCredentials validates email and password for authority login
-func (s *AuthorityService) ValidateCredentials(email, password string) (int64, error) {
-	return s.authorityRepo.ValidateCredentials(email, password)
+func (s *AuthorityService) ValidateCredentials(ctx context.Context, email, password string) (int64, error) {
+	return s.authorityRepo.ValidateCredentials(ctx, email, password)
 }
 
 // ValidateStaticToken validates static token for pilot authentication
-func (s *AuthorityService) ValidateStaticToken(token string) (int64, error) {
-	return s.authorityRepo.ValidateStaticToken(token)
+func (s *AuthorityService) ValidateStaticToken(ctx context.Context, token string) (int64, error) {
+	return s.authorityRepo.ValidateStaticToken(ctx, token)
 }
 
 // VerifyOfficerExists checks if officer exists and is active
-func (s *AuthorityService) VerifyOfficerExists(officerID int64) (bool, error) {
-	return s.authorityRepo.VerifyOfficerExists(officerID)
+func (s *AuthorityService) VerifyOfficerExists(ctx context.Context, officerID int64) (bool, error) {
+	return s.authorityRepo.VerifyOfficerExists(ctx, officerID)
 }
 
 // GetOfficerProfile returns department_id, location_id, authority_level for the officer (for login JWT and /me).
-func (s *AuthorityService) GetOfficerProfile(officerID int64) (departmentID, locationID int64, authorityLevel int, err error) {
-	return s.authorityRepo.GetOfficerProfile(officerID)
+func (s *AuthorityService) GetOfficerProfile(ctx context.Context, officerID int64) (departmentID, locationID int64, authorityLevel int, err error) {
+	return s.authorityRepo.GetOfficerProfile(ctx, officerID)
 }
 
 // GetComplaintsByOfficerID retrieves all complaints assigned to an officer (legacy; prefer paginated).
-func (s *AuthorityService) GetComplaintsByOfficerID(officerID int64) ([]models.ComplaintSummary, error) {
-	complaints, err := s.authorityRepo.GetComplaintsByOfficerID(officerID)
+func (s *AuthorityService) GetComplaintsByOfficerID(ctx context.Context, officerID int64) ([]models.ComplaintSummary, error) {
+	complaints, err := s.authorityRepo.GetComplaintsByOfficerID(ctx, officerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get complaints: %w", err)
 	}
@@ -67,13 +77,16 @@ func (s *AuthorityService) GetComplaintsByOfficerID(officerID int64) ([]models.C
 			Priority:        string(c.Priority),
 			CreatedAt:       c.CreatedAt,
 			SupporterCount:  c.SupporterCount,
+			Version:         c.Version,
 		})
 	}
+	s.attachSLADueAt(ctx, complaints, summaries)
 	return summaries, nil
 }
 
 // GetComplaintsByOfficerIDPaginated returns paginated complaints assigned to officer with optional status filter (read-only).
-func (s *AuthorityService) GetComplaintsByOfficerIDPaginated(officerID int64, statusFilter string, page, pageSize int) ([]models.ComplaintSummary, int64, error) {
+// Open complaints of officers they cover for as delegate are included, marked with DelegatedFromOfficerID.
+func (s *AuthorityService) GetComplaintsByOfficerIDPaginated(ctx context.Context, officerID int64, statusFilter string, page, pageSize int) ([]models.ComplaintSummary, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -84,7 +97,7 @@ func (s *AuthorityService) GetComplaintsByOfficerIDPaginated(officerID int64, st
 		pageSize = 100
 	}
 	offset := (page - 1) * pageSize
-	complaints, total, err := s.authorityRepo.GetComplaintsByOfficerIDPaginated(officerID, statusFilter, pageSize, offset)
+	complaints, total, err := s.authorityRepo.GetComplaintsByOfficerIDPaginated(ctx, officerID, statusFilter, pageSize, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -98,55 +111,73 @@ func (s *AuthorityService) GetComplaintsByOfficerIDPaginated(officerID int64, st
 			Priority:        string(c.Priority),
 			CreatedAt:       c.CreatedAt,
 			SupporterCount:  c.SupporterCount,
+			Version:         c.Version,
 		})
+		if c.AssignedOfficerID.Valid && c.AssignedOfficerID.Int64 != officerID {
+			delegatedFrom := c.AssignedOfficerID.Int64
+			summaries[len(summaries)-1].DelegatedFromOfficerID = &delegatedFrom
+		}
 	}
+	s.attachSLADueAt(ctx, complaints, summaries)
 	return summaries, total, nil
 }
 
+// attachSLADueAt fills SLADueAt on summaries built from complaints (same order).
+// The list is still served if the due times cannot be computed.
+func (s *AuthorityService) attachSLADueAt(ctx context.Context, complaints []models.Complaint, summaries []models.ComplaintSummary) {
+	if s.escalationService == nil {
+		return
+	}
+	dueAt, err := s.escalationService.SLADueAt(ctx, complaints)
+	if err != nil {
+		log.Printf("[AUTHORITY] Warning: failed to compute SLA due times: %v", err)
+		return
+	}
+	for i := range summaries {
+		if due, ok := dueAt[summaries[i].ComplaintID]; ok {
+			summaries[i].SLADueAt = &due
+		}
+	}
+}
+
 // UpdateComplaintStatus updates complaint status with authority validation
 // Enforces valid status transitions: under_review → in_progress → resolved → closed
-func (s *AuthorityService) UpdateComplaintStatus(
+// Moving to awaiting_citizen asks the citizen the reason as a question; the SLA clock stops until the reply.
+// expectedVersion (from If-Match) is optional; when set and stale, returns repository.ErrVersionConflict.
+// The write itself is always guarded by the version read here, so concurrent writers cannot both win.
+// A delegate of the absent assigned officer may update it too; the change is theirs, on behalf of that officer.
+func (s *AuthorityService) UpdateComplaintStatus(ctx context.Context,
 	complaintID int64,
 	officerID int64,
 	req *models.AuthorityUpdateStatusRequest,
+	expectedVersion *int64,
 	ipAddress, userAgent string,
 ) (*models.UpdateStatusResponse, error) {
 	// Step 1: Get complaint and verify assignment
-	complaint, err := s.complaintRepo.GetComplaintByID(complaintID)
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("complaint not found")
 	}
 
-	// Verify complaint is assigned to this officer
-	if !complaint.AssignedOfficerID.Valid || complaint.AssignedOfficerID.Int64 != officerID {
-		return nil, fmt.Errorf("complaint not assigned to this authority")
+	// Verify complaint is assigned to this officer (or they are the assigned officer's delegate)
+	onBehalfOf, err := s.absenceService.ActingFor(ctx, complaint, officerID)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != nil && *expectedVersion != complaint.Version {
+		return nil, repository.ErrVersionConflict
 	}
 
-	// Step 2: Validate status transition (Authority only: submitted→under_review, under_review→in_progress, in_progress→resolved; closed is system-only).
+	// Step 2: Validate status transition against the lifecycle state machine (actor: officer; closed is system-only).
 	newStatus := models.ComplaintStatus(req.NewStatus)
 	oldStatus := complaint.CurrentStatus
-	if newStatus == models.StatusClosed {
-		return nil, fmt.Errorf("invalid status transition: closed is system-only")
-	}
-	validTransitions := map[models.ComplaintStatus][]models.ComplaintStatus{
-		models.StatusSubmitted:   {models.StatusUnderReview},
-		models.StatusUnderReview: {models.StatusInProgress},
-		models.StatusInProgress:  {models.StatusResolved},
-		models.StatusEscalated:   {models.StatusUnderReview, models.StatusInProgress},
-	}
-	allowedStatuses, ok := validTransitions[oldStatus]
-	if !ok {
-		return nil, fmt.Errorf("invalid status transition: cannot change from %s", oldStatus)
-	}
-	validTransition := false
-	for _, allowed := range allowedStatuses {
-		if newStatus == allowed {
-			validTransition = true
-			break
-		}
+	// Escalation reassigns and writes an escalation record: only EscalationService.EscalateByOfficer does that
+	if newStatus == models.StatusEscalated {
+		return nil, fmt.Errorf("invalid status transition from %s to %s: use the escalate endpoint", oldStatus, newStatus)
 	}
-	if !validTransition {
-		return nil, fmt.Errorf("invalid status transition: cannot change from %s to %s", oldStatus, newStatus)
+	transition, err := lifecycle.Validate(oldStatus, newStatus, models.ActorOfficer, req.Reason)
+	if err != nil {
+		return nil, err
 	}
 
 	// Step 3: Update complaint status via status history (REQUIRED; audit: authority, actor_id, reason)
@@ -163,60 +194,88 @@ func (s *AuthorityService) UpdateComplaintStatus(
 		AssignedOfficerID:    complaint.AssignedOfficerID,
 		Notes:                sql.NullString{String: req.Reason, Valid: true},
 	}
-
-	// resolved_at set only when new_status = resolved; closed is system-only (never set here).
-	var resolvedAt *time.Time
-	if newStatus == models.StatusResolved {
-		now := time.Now().UTC()
-		resolvedAt = &now
-	}
-	err = s.complaintRepo.UpdateComplaintStatusWithTimestamps(
-		complaintID,
-		newStatus,
-		resolvedAt,
-		nil, // closed_at only set by system
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update complaint status: %w", err)
+	if onBehalfOf != nil {
+		statusHistory.OnBehalfOfOfficerID = sql.NullInt64{Int64: onBehalfOf.OfficerID, Valid: true}
+		statusHistory.Notes = sql.NullString{String: onBehalfOf.OnBehalfOf(req.Reason), Valid: true}
 	}
 
-	// Create status history entry
-	err = s.complaintRepo.CreateStatusHistory(statusHistory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create status history: %w", err)
+	// Step 4: Log to audit_log
+	auditLog := &models.AuditLog{
+		EntityType:        "complaint",
+		EntityID:          complaintID,
+		Action:            "status_update",
+		ActionByType:      models.ActorOfficer,
+		ActionByOfficerID: sql.NullInt64{Int64: officerID, Valid: true},
+		IPAddress:         sql.NullString{String: ipAddress, Valid: true},
+		UserAgent:         sql.NullString{String: userAgent, Valid: true},
+		Metadata:          delegationMetadata(onBehalfOf),
 	}
 
-	// Check if this is the first authority action (for metrics)
+	// resolved_at / closed_at follow the transition's timestamp effects
+	resolvedAt, closedAt := transition.Timestamps(complaint.ResolvedAt, complaint.ClosedAt, time.Now().UTC())
+
+	// Status update, history row and audit row commit atomically
 	isFirstAuthorityAction := false
-	if s.pilotMetricsService != nil {
-		history, err := s.complaintRepo.GetStatusHistory(complaintID)
-		if err == nil {
-			// Count authority actions (excluding the one we just created)
-			authorityActionCount := 0
-			for _, h := range history {
-				if h.ActorType.Valid && h.ActorType.String == string(models.StatusHistoryActorAuthority) {
-					authorityActionCount++
+	err = s.complaintRepo.InTx(ctx, func(tx *sql.Tx) error {
+		complaintRepo := s.complaintRepo.WithTx(tx)
+
+		if err := complaintRepo.UpdateComplaintStatusWithTimestamps(ctx, complaintID, newStatus, resolvedAt, closedAt, complaint.Version); err != nil {
+			return fmt.Errorf("failed to update complaint status: %w", err)
+		}
+
+		// Create status history entry
+		if err := complaintRepo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+
+		// Question to the citizen (the reason) is kept with the status to return to on reply;
+		// moving on without a reply withdraws it
+		if newStatus == models.StatusAwaitingCitizen {
+			clarification := &models.ComplaintClarification{
+				ComplaintID:      complaintID,
+				AskedByOfficerID: sql.NullInt64{Int64: officerID, Valid: true},
+				Question:         req.Reason,
+				ReturnStatus:     oldStatus,
+			}
+			if err := complaintRepo.CreateClarification(ctx, clarification); err != nil {
+				return err
+			}
+		}
+		if oldStatus == models.StatusAwaitingCitizen {
+			open, err := complaintRepo.GetOpenClarification(ctx, complaintID)
+			if err != nil {
+				return err
+			}
+			if open != nil {
+				if err := complaintRepo.CloseClarification(ctx, open.ClarificationID, models.ClarificationWithdrawn, sql.NullString{}); err != nil {
+					return fmt.Errorf("failed to withdraw clarification: %w", err)
 				}
 			}
-			// If this is the first authority action (count = 1, which is the one we just created)
-			isFirstAuthorityAction = authorityActionCount == 1
 		}
-	}
 
-	// Step 4: Log to audit_log
-	auditLog := &models.AuditLog{
-		EntityType:      "complaint",
-		EntityID:         complaintID,
-		Action:           "status_update",
-		ActionByType:     models.ActorOfficer,
-		ActionByOfficerID: sql.NullInt64{Int64: officerID, Valid: true},
-		IPAddress:        sql.NullString{String: ipAddress, Valid: true},
-		UserAgent:        sql.NullString{String: userAgent, Valid: true},
-	}
+		// Check if this is the first authority action (for metrics)
+		if s.pilotMetricsService != nil {
+			history, err := complaintRepo.GetStatusHistory(ctx, complaintID)
+			if err == nil {
+				// Count authority actions (including the one we just created)
+				authorityActionCount := 0
+				for _, h := range history {
+					if h.ActorType.Valid && h.ActorType.String == string(models.StatusHistoryActorAuthority) {
+						authorityActionCount++
+					}
+				}
+				// If this is the first authority action (count = 1, which is the one we just created)
+				isFirstAuthorityAction = authorityActionCount == 1
+			}
+		}
 
-	err = s.complaintRepo.CreateAuditLog(auditLog)
+		if err := complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		// Log error but don't fail - audit logging should be resilient
+		return nil, err
 	}
 
 	// Emit pilot metrics: first_authority_action
@@ -226,7 +285,7 @@ func (s *AuthorityService) UpdateComplaintStatus(
 			"new_status": string(newStatus),
 			"officer_id": officerID,
 		}
-		s.pilotMetricsService.EmitFirstAuthorityAction(complaintID, complaint.UserID, complaint.CreatedAt, metadata)
+		s.pilotMetricsService.EmitFirstAuthorityAction(ctx, complaintID, complaint.UserID, complaint.CreatedAt, metadata)
 	}
 
 	// Emit pilot metrics: complaint_resolved (when status becomes resolved or closed)
@@ -236,7 +295,7 @@ func (s *AuthorityService) UpdateComplaintStatus(
 			"new_status": string(newStatus),
 			"officer_id": officerID,
 		}
-		s.pilotMetricsService.EmitComplaintResolved(complaintID, complaint.UserID, complaint.CreatedAt, string(newStatus), metadata)
+		s.pilotMetricsService.EmitComplaintResolved(ctx, complaintID, complaint.UserID, complaint.CreatedAt, string(newStatus), metadata)
 	}
 
 	// Pilot: send resolution/closure email to shadow inbox only (async, non-blocking)
@@ -245,7 +304,7 @@ func (s *AuthorityService) UpdateComplaintStatus(
 		if complaint.AssignedDepartmentID.Valid {
 			deptID := complaint.AssignedDepartmentID.Int64
 			deptName := fmt.Sprintf("Department %d", deptID)
-			s.emailShadowService.SendResolutionEmailAsync(
+			s.emailShadowService.SendResolutionEmailAsync(ctx,
 				complaintID,
 				complaint.ComplaintNumber,
 				deptID,
@@ -261,44 +320,143 @@ func (s *AuthorityService) UpdateComplaintStatus(
 		ComplaintNumber: complaint.ComplaintNumber,
 		OldStatus:       string(oldStatus),
 		NewStatus:       string(newStatus),
+		Version:         complaint.Version + 1,
 		Message:         "Status updated successfully",
 	}, nil
 }
 
+// UpdateComplaintPriority changes the priority of a complaint assigned to the officer
+// The SLA due time follows the new priority (SLA policies are per priority) and is returned re-evaluated.
+// expectedVersion (from If-Match) is optional; when set and stale, returns repository.ErrVersionConflict.
+func (s *AuthorityService) UpdateComplaintPriority(ctx context.Context,
+	complaintID int64,
+	officerID int64,
+	req *models.AuthorityUpdatePriorityRequest,
+	expectedVersion *int64,
+	ipAddress, userAgent string,
+) (*models.UpdatePriorityResponse, error) {
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	onBehalfOf, err := s.absenceService.ActingFor(ctx, complaint, officerID)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != nil && *expectedVersion != complaint.Version {
+		return nil, repository.ErrVersionConflict
+	}
+
+	newPriority := models.Priority(req.Priority)
+	switch newPriority {
+	case models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityUrgent:
+	default:
+		return nil, fmt.Errorf("invalid priority %q (allowed: low, medium, high, urgent)", req.Priority)
+	}
+	oldPriority := complaint.Priority
+	if newPriority == oldPriority {
+		return nil, fmt.Errorf("invalid priority %q: complaint already has this priority", req.Priority)
+	}
+
+	oldValues, _ := json.Marshal(map[string]interface{}{"priority": oldPriority})
+	newValues, _ := json.Marshal(map[string]interface{}{"priority": newPriority, "reason": req.Reason})
+	auditLog := &models.AuditLog{
+		EntityType:        "complaint",
+		EntityID:          complaintID,
+		Action:            "priority_change",
+		ActionByType:      models.ActorOfficer,
+		ActionByOfficerID: sql.NullInt64{Int64: officerID, Valid: true},
+		OldValues:         sql.NullString{String: string(oldValues), Valid: true},
+		NewValues:         sql.NullString{String: string(newValues), Valid: true},
+		IPAddress:         sql.NullString{String: ipAddress, Valid: true},
+		UserAgent:         sql.NullString{String: userAgent, Valid: true},
+		Metadata:          delegationMetadata(onBehalfOf),
+	}
+
+	// Priority update and audit row commit atomically
+	err = s.complaintRepo.InTx(ctx, func(tx *sql.Tx) error {
+		complaintRepo := s.complaintRepo.WithTx(tx)
+		if err := complaintRepo.UpdateComplaintPriority(ctx, complaintID, newPriority, complaint.Version); err != nil {
+			return err
+		}
+		if err := complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.UpdatePriorityResponse{
+		ComplaintID:     complaintID,
+		ComplaintNumber: complaint.ComplaintNumber,
+		OldPriority:     string(oldPriority),
+		NewPriority:     string(newPriority),
+		Version:         complaint.Version + 1,
+		Message:         "Priority updated successfully",
+	}
+	if s.escalationService != nil {
+		updated, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+		if err == nil {
+			var dueAt map[int64]time.Time
+			dueAt, err = s.escalationService.SLADueAt(ctx, []models.Complaint{*updated})
+			if due, ok := dueAt[complaintID]; ok {
+				response.SLADueAt = &due
+			}
+		}
+		if err != nil {
+			log.Printf("[AUTHORITY] Warning: failed to compute SLA due time for complaint %d: %v", complaintID, err)
+		}
+	}
+	return response, nil
+}
+
 // AddNote adds an internal note to a complaint
-func (s *AuthorityService) AddNote(
+// A delegate of the absent assigned officer may add one too; it is recorded on behalf of that officer.
+func (s *AuthorityService) AddNote(ctx context.Context,
 	complaintID int64,
 	officerID int64,
 	noteText string,
 ) (*models.AuthorityNoteResponse, error) {
 	// Step 1: Verify complaint exists and is assigned to this officer
-	complaint, err := s.complaintRepo.GetComplaintByID(complaintID)
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("complaint not found")
 	}
 
-	if !complaint.AssignedOfficerID.Valid || complaint.AssignedOfficerID.Int64 != officerID {
-		return nil, fmt.Errorf("complaint not assigned to this authority")
-	}
-
-	// Step 2: Create note
-	noteID, err := s.authorityRepo.CreateNote(complaintID, officerID, noteText)
+	onBehalfOf, err := s.absenceService.ActingFor(ctx, complaint, officerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create note: %w", err)
+		return nil, err
+	}
+	var onBehalfOfOfficerID *int64
+	if onBehalfOf != nil {
+		onBehalfOfOfficerID = &onBehalfOf.OfficerID
 	}
 
-	// Step 3: Log to audit_log
+	// Step 2: Create note and audit_log row in one transaction
 	auditLog := &models.AuditLog{
-		EntityType:      "complaint",
-		EntityID:         complaintID,
-		Action:           "add_note",
-		ActionByType:     models.ActorOfficer,
+		EntityType:        "complaint",
+		EntityID:          complaintID,
+		Action:            "add_note",
+		ActionByType:      models.ActorOfficer,
 		ActionByOfficerID: sql.NullInt64{Int64: officerID, Valid: true},
+		Metadata:          delegationMetadata(onBehalfOf),
 	}
-
-	err = s.complaintRepo.CreateAuditLog(auditLog)
+	var noteID int64
+	err = s.authorityRepo.InTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		noteID, err = s.authorityRepo.WithTx(tx).CreateNote(ctx, complaintID, officerID, onBehalfOfOfficerID, noteText)
+		if err != nil {
+			return fmt.Errorf("failed to create note: %w", err)
+		}
+		if err := s.complaintRepo.WithTx(tx).CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		// Log error but don't fail
+		return nil, err
 	}
 
 	return &models.AuthorityNoteResponse{
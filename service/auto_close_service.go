@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"finalneta/lifecycle"
+	"finalneta/models"
+	"finalneta/repository"
+	"fmt"
+	"log"
+	"time"
+)
+
+// autoCloseBatchSize caps how many complaints are closed per run
+const autoCloseBatchSize = 200
+
+// AutoCloseService closes complaints that waited on the citizen for too long
+//
+// Lifecycle Rules:
+// 1. 'resolved' complaints are closed after the confirmation window (a citizen dispute moves them out of 'resolved')
+// 2. 'awaiting_citizen' complaints are closed when the officer's question stays unanswered past its window (the question is marked expired)
+// 3. Both transitions to closed are system-only; closed_at is set and resolved_at is kept
+// 4. Each closure writes a status history row (actor system), an audit log entry and the complaint_resolved metric
+type AutoCloseService struct {
+	complaintRepo       *repository.ComplaintRepository
+	pilotMetricsService *PilotMetricsService // optional; pilot metrics
+	closeAfter          time.Duration        // resolved → closed
+	awaitingCloseAfter  time.Duration        // awaiting_citizen → closed
+}
+
+// NewAutoCloseService creates a new auto-close service
+// A window <= 0 disables that kind of auto-close; with both disabled ProcessAutoClose is a no-op
+func NewAutoCloseService(
+	complaintRepo *repository.ComplaintRepository,
+	pilotMetricsService *PilotMetricsService,
+	closeAfterDays int,
+	awaitingCitizenCloseAfterDays int,
+) *AutoCloseService {
+	return &AutoCloseService{
+		complaintRepo:       complaintRepo,
+		pilotMetricsService: pilotMetricsService,
+		closeAfter:          time.Duration(closeAfterDays) * 24 * time.Hour,
+		awaitingCloseAfter:  time.Duration(awaitingCitizenCloseAfterDays) * 24 * time.Hour,
+	}
+}
+
+// Enabled reports whether auto-close is configured
+func (s *AutoCloseService) Enabled() bool {
+	return s.closeAfter > 0 || s.awaitingCloseAfter > 0
+}
+
+// ProcessAutoClose closes resolved complaints older than the confirmation window and awaiting_citizen
+// complaints whose question is older than the reply window.
+// Idempotent: a complaint that left the status since the candidate query is skipped. Returns the IDs closed.
+func (s *AutoCloseService) ProcessAutoClose(ctx context.Context) ([]int64, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+
+	var closed []int64
+	if s.closeAfter > 0 {
+		cutoff := time.Now().UTC().Add(-s.closeAfter)
+		ids, err := s.complaintRepo.GetResolvedComplaintIDsBefore(ctx, cutoff, autoCloseBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get auto-close candidates: %w", err)
+		}
+		closed = append(closed, s.closeEach(ctx, ids, models.StatusResolved)...)
+	}
+	if s.awaitingCloseAfter > 0 {
+		cutoff := time.Now().UTC().Add(-s.awaitingCloseAfter)
+		ids, err := s.complaintRepo.GetAwaitingCitizenComplaintIDsBefore(ctx, cutoff, autoCloseBatchSize)
+		if err != nil {
+			return closed, fmt.Errorf("failed to get awaiting_citizen auto-close candidates: %w", err)
+		}
+		closed = append(closed, s.closeEach(ctx, ids, models.StatusAwaitingCitizen)...)
+	}
+
+	return closed, nil
+}
+
+// closeEach closes the given complaints (expected in fromStatus) and returns those actually closed
+func (s *AutoCloseService) closeEach(ctx context.Context, ids []int64, fromStatus models.ComplaintStatus) []int64 {
+	var closed []int64
+	for _, id := range ids {
+		// On shutdown stop between complaints; the rest are closed by the next run
+		if ctx.Err() != nil {
+			break
+		}
+		ok, err := s.closeComplaint(context.WithoutCancel(ctx), id, fromStatus)
+		if err != nil {
+			log.Printf("[AUTO_CLOSE] complaint_id=%d failed: %v", id, err)
+			continue
+		}
+		if ok {
+			closed = append(closed, id)
+		}
+	}
+	return closed
+}
+
+// closeComplaint moves a single resolved or awaiting_citizen complaint to closed
+// Returns false when the complaint left fromStatus since the candidate query
+func (s *AutoCloseService) closeComplaint(ctx context.Context, complaintID int64, fromStatus models.ComplaintStatus) (bool, error) {
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return false, err
+	}
+	// Re-check: the citizen may have confirmed, disputed or replied since the candidate query
+	if complaint.CurrentStatus != fromStatus {
+		return false, nil
+	}
+
+	var clarification *models.ComplaintClarification
+	closeAfter := s.closeAfter
+	reason := "Auto-closed: no citizen response within %d days of resolution"
+	if fromStatus == models.StatusAwaitingCitizen {
+		clarification, err = s.complaintRepo.GetOpenClarification(ctx, complaintID)
+		if err != nil {
+			return false, err
+		}
+		if clarification == nil {
+			return false, nil
+		}
+		closeAfter = s.awaitingCloseAfter
+		reason = "Auto-closed: no citizen reply within %d days of the officer's question"
+	}
+
+	days := int(closeAfter.Hours() / 24)
+	reason = fmt.Sprintf(reason, days)
+	transition, err := lifecycle.Validate(complaint.CurrentStatus, models.StatusClosed, models.ActorSystem, reason)
+	if err != nil {
+		return false, err
+	}
+	resolvedAt, closedAt := transition.Timestamps(complaint.ResolvedAt, complaint.ClosedAt, time.Now().UTC())
+
+	statusHistory := &models.ComplaintStatusHistory{
+		ComplaintID:          complaintID,
+		OldStatus:            sql.NullString{String: string(fromStatus), Valid: true},
+		NewStatus:            models.StatusClosed,
+		ChangedByType:        models.ActorSystem,
+		ActorType:            sql.NullString{String: string(models.StatusHistoryActorSystem), Valid: true},
+		ActorID:              sql.NullInt64{Valid: false}, // system has no actor_id
+		AssignedDepartmentID: complaint.AssignedDepartmentID,
+		AssignedOfficerID:    complaint.AssignedOfficerID,
+		Reason:               sql.NullString{String: reason, Valid: true},
+		Notes:                sql.NullString{String: reason, Valid: true},
+	}
+	// Create audit log entry
+	changesJSON, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"old": string(fromStatus),
+			"new": string(models.StatusClosed),
+		},
+	})
+	metadata := map[string]interface{}{
+		"reason":           "auto_close",
+		"close_after_days": days,
+	}
+	if clarification != nil {
+		metadata["clarification_id"] = clarification.ClarificationID
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	auditLog := &models.AuditLog{
+		EntityType:   "complaint",
+		EntityID:     complaintID,
+		Action:       "auto_closed",
+		ActionByType: models.ActorSystem,
+		Changes:      sql.NullString{String: string(changesJSON), Valid: true},
+		Metadata:     sql.NullString{String: string(metadataJSON), Valid: true},
+	}
+
+	// Status update, history row and audit row commit atomically
+	err = s.complaintRepo.InTx(ctx, func(tx *sql.Tx) error {
+		complaintRepo := s.complaintRepo.WithTx(tx)
+
+		if err := complaintRepo.UpdateComplaintStatusWithTimestamps(ctx, complaintID, models.StatusClosed, resolvedAt, closedAt, complaint.Version); err != nil {
+			return err
+		}
+		if err := complaintRepo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+		if clarification != nil {
+			if err := complaintRepo.CloseClarification(ctx, clarification.ClarificationID, models.ClarificationExpired, sql.NullString{}); err != nil {
+				return fmt.Errorf("failed to expire clarification: %w", err)
+			}
+		}
+		if err := complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		// Changed concurrently (e.g. citizen responded); re-evaluated next run
+		log.Printf("[AUTO_CLOSE] complaint_id=%d skipped: modified concurrently", complaintID)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Emit pilot metrics: complaint_resolved (status closed)
+	if s.pilotMetricsService != nil {
+		metadata := map[string]interface{}{
+			"old_status": string(fromStatus),
+			"new_status": string(models.StatusClosed),
+			"auto_close": true,
+		}
+		s.pilotMetricsService.EmitComplaintResolved(ctx, complaintID, complaint.UserID, complaint.CreatedAt, string(models.StatusClosed), metadata)
+	}
+
+	log.Printf("[AUTO_CLOSE] complaint_id=%d closed", complaintID)
+	return true, nil
+}
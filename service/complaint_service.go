@@ -1,34 +1,51 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"finalneta/lifecycle"
 	"finalneta/models"
 	"finalneta/repository"
+	"finalneta/storage"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 )
 
 // ComplaintService handles business logic for complaints
 type ComplaintService struct {
 	repo              *repository.ComplaintRepository
 	departmentRepo    *repository.DepartmentRepository
+	evidenceRepo      *repository.EvidenceRepository
 	emailShadowService *EmailShadowService // optional; pilot email shadow mode
 	pilotMetricsService *PilotMetricsService // optional; pilot metrics
+	escalationService  *EscalationService  // optional; SLA due-at on the status timeline
+	assignmentService  *AssignmentService  // optional; picks the officer (ASSIGNMENT_STRATEGY)
+	blob               storage.Blob        // optional; signs attachment download URLs
 }
 
 // NewComplaintService creates a new complaint service
 func NewComplaintService(
 	repo *repository.ComplaintRepository,
 	departmentRepo *repository.DepartmentRepository,
+	evidenceRepo *repository.EvidenceRepository,
 	emailShadowService *EmailShadowService,
 	pilotMetricsService *PilotMetricsService,
+	escalationService *EscalationService,
+	assignmentService *AssignmentService,
+	blob storage.Blob,
 ) *ComplaintService {
 	return &ComplaintService{
 		repo:               repo,
 		departmentRepo:     departmentRepo,
+		evidenceRepo:       evidenceRepo,
 		emailShadowService: emailShadowService,
 		pilotMetricsService: pilotMetricsService,
+		escalationService:  escalationService,
+		assignmentService:  assignmentService,
+		blob:               blob,
 	}
 }
 
@@ -39,7 +56,7 @@ func NewComplaintService(
 // 2. Initial status history entry is created
 // 3. All attachments are linked to the complaint
 // 4. Audit log entry is created for creation action
-func (s *ComplaintService) CreateComplaint(
+func (s *ComplaintService) CreateComplaint(ctx context.Context,
 	req *models.CreateComplaintRequest,
 	userID int64,
 	ipAddress, userAgent string,
@@ -140,55 +157,101 @@ func (s *ComplaintService) CreateComplaint(
 		}
 	}
 
-	// Try to find an officer for this department and location (optional)
-	if s.departmentRepo != nil {
-		officerID, err := s.departmentRepo.FindOfficerForDepartment(assignedDeptID, req.LocationID)
-		if err == nil && officerID != nil {
-			complaint.AssignedOfficerID = sql.NullInt64{Int64: *officerID, Valid: true}
+	// Assign an officer in the department and location with the department's strategy (optional);
+	// the strategy and its inputs go into the initial status history notes
+	createdNote := "Complaint created"
+	if s.assignmentService != nil {
+		target := AssignmentTarget{
+			DepartmentID:      assignedDeptID,
+			LocationID:        req.LocationID,
+			MinAuthorityLevel: 1,
+			Latitude:          req.Latitude,
+			Longitude:         req.Longitude,
+		}
+		if req.Category != nil {
+			target.Category = *req.Category
+		}
+		assignment, err := s.assignmentService.Assign(ctx, target)
+		if err != nil {
+			// Complaint stays with the department
+			log.Printf("[complaint] Warning: officer assignment failed for department ID=%d: %v", assignedDeptID, err)
+		} else if assignment != nil {
+			complaint.AssignedOfficerID = sql.NullInt64{Int64: assignment.OfficerID, Valid: true}
+			createdNote += "; " + assignment.Note()
 		}
 	}
 
-	// Create complaint in database
+	// Complaint, initial history, attachments and audit row commit together
 	log.Printf("[complaint] Creating complaint with category=%v, location_id=%d", req.Category, req.LocationID)
-	err = s.repo.CreateComplaint(complaint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create complaint: %w", err)
-	}
-	log.Printf("[complaint] Complaint created with ID=%d, number=%s", complaint.ComplaintID, complaint.ComplaintNumber)
+	err = s.repo.InTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
 
-	// Create initial status history entry (submission audit: user, actor_id, reason)
-	statusHistory := &models.ComplaintStatusHistory{
-		ComplaintID:          complaint.ComplaintID,
-		OldStatus:            sql.NullString{Valid: false}, // No old status for creation
-		NewStatus:            initialStatus,
-		ChangedByType:        models.ActorUser,
-		ChangedByUserID:      sql.NullInt64{Int64: userID, Valid: true},
-		ActorType:            sql.NullString{String: string(models.StatusHistoryActorUser), Valid: true},
-		ActorID:              sql.NullInt64{Int64: userID, Valid: true},
-		Reason:               sql.NullString{String: "Complaint created", Valid: true},
-		Notes:                sql.NullString{String: "Complaint created", Valid: true},
-	}
-	err = s.repo.CreateStatusHistory(statusHistory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create initial status history: %w", err)
-	}
+		if err := repo.CreateComplaint(ctx, complaint); err != nil {
+			return fmt.Errorf("failed to create complaint: %w", err)
+		}
 
-	// Create attachments if provided
-	for _, url := range req.AttachmentURLs {
-		attachment := &models.ComplaintAttachment{
-			ComplaintID:      complaint.ComplaintID,
-			FileName:         extractFileName(url),
-			FilePath:         url,
-			IsPublic:         req.PublicConsentGiven,
-			UploadedByUserID: sql.NullInt64{Int64: userID, Valid: true},
+		// Create initial status history entry (submission audit: user, actor_id, reason)
+		statusHistory := &models.ComplaintStatusHistory{
+			ComplaintID:          complaint.ComplaintID,
+			OldStatus:            sql.NullString{Valid: false}, // No old status for creation
+			NewStatus:            initialStatus,
+			ChangedByType:        models.ActorUser,
+			ChangedByUserID:      sql.NullInt64{Int64: userID, Valid: true},
+			ActorType:            sql.NullString{String: string(models.StatusHistoryActorUser), Valid: true},
+			ActorID:              sql.NullInt64{Int64: userID, Valid: true},
+			AssignedDepartmentID: complaint.AssignedDepartmentID,
+			AssignedOfficerID:    complaint.AssignedOfficerID,
+			Reason:               sql.NullString{String: "Complaint created", Valid: true},
+			Notes:                sql.NullString{String: createdNote, Valid: true},
 		}
-		err = s.repo.CreateAttachment(attachment)
-		if err != nil {
-			// Log error but don't fail the entire operation
-			// In production, consider using a transaction or retry mechanism
-			continue
+		if err := repo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create initial status history: %w", err)
+		}
+
+		// Create attachments if provided
+		for _, url := range req.AttachmentURLs {
+			attachment := &models.ComplaintAttachment{
+				ComplaintID:      complaint.ComplaintID,
+				FileName:         extractFileName(url),
+				FilePath:         url,
+				IsPublic:         req.PublicConsentGiven,
+				UploadedByUserID: sql.NullInt64{Int64: userID, Valid: true},
+			}
+			if err := repo.CreateAttachment(ctx, attachment); err != nil {
+				// Log error but don't fail the entire operation
+				log.Printf("[complaint] Warning: failed to create attachment for complaint ID=%d: %v", complaint.ComplaintID, err)
+				continue
+			}
+		}
+
+		// Create audit log entry
+		auditData := map[string]interface{}{
+			"complaint_id":     complaint.ComplaintID,
+			"complaint_number": complaintNumber,
+			"title":            req.Title,
+			"status":           string(initialStatus),
+		}
+		newValuesJSON, _ := json.Marshal(auditData)
+
+		auditLog := &models.AuditLog{
+			EntityType:     "complaint",
+			EntityID:       complaint.ComplaintID,
+			Action:         "create",
+			ActionByType:   models.ActorUser,
+			ActionByUserID: sql.NullInt64{Int64: userID, Valid: true},
+			NewValues:      sql.NullString{String: string(newValuesJSON), Valid: true},
+			IPAddress:      sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+			UserAgent:      sql.NullString{String: userAgent, Valid: userAgent != ""},
 		}
+		if err := repo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("[complaint] Complaint created with ID=%d, number=%s", complaint.ComplaintID, complaint.ComplaintNumber)
 
 	// Pilot: send assignment email to shadow inbox only (async, non-blocking)
 	// Authority abstraction: department_id only, not officer-based
@@ -199,7 +262,7 @@ func (s *ComplaintService) CreateComplaint(
 		// Get real department name from repository
 		var deptName string
 		if s.departmentRepo != nil {
-			name, err := s.departmentRepo.GetDepartmentName(deptID)
+			name, err := s.departmentRepo.GetDepartmentName(ctx, deptID)
 			if err != nil {
 				log.Printf("[complaint] Warning: Failed to get department name for ID=%d: %v", deptID, err)
 				deptName = fmt.Sprintf("Department %d", deptID) // Fallback
@@ -214,7 +277,7 @@ func (s *ComplaintService) CreateComplaint(
 			complaint.ComplaintID, deptID, deptName)
 		
 		if s.emailShadowService != nil {
-			s.emailShadowService.SendAssignmentEmailAsync(complaint.ComplaintID, complaint.ComplaintNumber, deptID, deptName)
+			s.emailShadowService.SendAssignmentEmailAsync(ctx, complaint.ComplaintID, complaint.ComplaintNumber, deptID, deptName)
 			log.Printf("[complaint] Assignment email queued for complaint ID=%d", complaint.ComplaintID)
 		} else {
 			log.Printf("[complaint] ERROR: emailShadowService is nil - email NOT sent for complaint ID=%d", complaint.ComplaintID)
@@ -235,32 +298,7 @@ func (s *ComplaintService) CreateComplaint(
 		if complaint.AssignedDepartmentID.Valid {
 			metadata["assigned_department_id"] = complaint.AssignedDepartmentID.Int64
 		}
-		s.pilotMetricsService.EmitComplaintCreated(complaint.ComplaintID, userID, metadata)
-	}
-
-	// Create audit log entry
-	auditData := map[string]interface{}{
-		"complaint_id":     complaint.ComplaintID,
-		"complaint_number": complaintNumber,
-		"title":            req.Title,
-		"status":           string(initialStatus),
-	}
-	newValuesJSON, _ := json.Marshal(auditData)
-
-	auditLog := &models.AuditLog{
-		EntityType:     "complaint",
-		EntityID:       complaint.ComplaintID,
-		Action:         "create",
-		ActionByType:   models.ActorUser,
-		ActionByUserID: sql.NullInt64{Int64: userID, Valid: true},
-		NewValues:      sql.NullString{String: string(newValuesJSON), Valid: true},
-		IPAddress:      sql.NullString{String: ipAddress, Valid: ipAddress != ""},
-		UserAgent:      sql.NullString{String: userAgent, Valid: userAgent != ""},
-	}
-	err = s.repo.CreateAuditLog(auditLog)
-	if err != nil {
-		// Log error but don't fail the operation
-		// Audit logging should be resilient
+		s.pilotMetricsService.EmitComplaintCreated(ctx, complaint.ComplaintID, userID, metadata)
 	}
 
 	// Build response with assigned department ID for admin visibility
@@ -282,8 +320,8 @@ func (s *ComplaintService) CreateComplaint(
 }
 
 // GetUserComplaints retrieves all complaints for a specific user
-func (s *ComplaintService) GetUserComplaints(userID int64) ([]models.ComplaintSummary, error) {
-	complaints, err := s.repo.GetComplaintsByUserID(userID)
+func (s *ComplaintService) GetUserComplaints(ctx context.Context, userID int64) ([]models.ComplaintSummary, error) {
+	complaints, err := s.repo.GetComplaintsByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user complaints: %w", err)
 	}
@@ -307,8 +345,8 @@ func (s *ComplaintService) GetUserComplaints(userID int64) ([]models.ComplaintSu
 }
 
 // GetComplaintByID retrieves a complaint with full details (citizen view)
-func (s *ComplaintService) GetComplaintByID(complaintID int64, requestingUserID int64) (*models.ComplaintDetailResponse, error) {
-	complaint, err := s.repo.GetComplaintByID(complaintID)
+func (s *ComplaintService) GetComplaintByID(ctx context.Context, complaintID int64, requestingUserID int64) (*models.ComplaintDetailResponse, error) {
+	complaint, err := s.repo.GetComplaintByID(ctx, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get complaint: %w", err)
 	}
@@ -319,7 +357,7 @@ func (s *ComplaintService) GetComplaintByID(complaintID int64, requestingUserID
 	}
 
 	// Get attachments
-	attachments, err := s.repo.GetAttachmentsByComplaintID(complaintID)
+	attachments, err := s.repo.GetAttachmentsByComplaintID(ctx, complaintID)
 	if err != nil {
 		// Log error but continue
 		attachments = []models.ComplaintAttachment{}
@@ -372,6 +410,7 @@ func (s *ComplaintService) GetComplaintByID(complaintID int64, requestingUserID
 				FileName:     att.FileName,
 				FilePath:     att.FilePath,
 				IsPublic:     att.IsPublic,
+				URL:          downloadURL(ctx, s.blob, att.FilePath),
 			}
 			if att.FileType.Valid {
 				attInfo.FileType = &att.FileType.String
@@ -387,9 +426,9 @@ func (s *ComplaintService) GetComplaintByID(complaintID int64, requestingUserID
 }
 
 // GetStatusTimeline retrieves the complete status timeline for a complaint
-func (s *ComplaintService) GetStatusTimeline(complaintID int64, requestingUserID int64) (*models.StatusTimelineResponse, error) {
+func (s *ComplaintService) GetStatusTimeline(ctx context.Context, complaintID int64, requestingUserID int64) (*models.StatusTimelineResponse, error) {
 	// Verify complaint exists and user has access
-	complaint, err := s.repo.GetComplaintByID(complaintID)
+	complaint, err := s.repo.GetComplaintByID(ctx, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get complaint: %w", err)
 	}
@@ -400,7 +439,7 @@ func (s *ComplaintService) GetStatusTimeline(complaintID int64, requestingUserID
 	}
 
 	// Get status history
-	history, err := s.repo.GetStatusHistory(complaintID)
+	history, err := s.repo.GetStatusHistory(ctx, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get status history: %w", err)
 	}
@@ -424,6 +463,9 @@ func (s *ComplaintService) GetStatusTimeline(complaintID int64, requestingUserID
 		if h.ChangedByOfficerID.Valid {
 			entry.ChangedByOfficerID = &h.ChangedByOfficerID.Int64
 		}
+		if h.OnBehalfOfOfficerID.Valid {
+			entry.OnBehalfOfOfficerID = &h.OnBehalfOfOfficerID.Int64
+		}
 		if h.AssignedDepartmentID.Valid {
 			entry.AssignedDepartmentID = &h.AssignedDepartmentID.Int64
 		}
@@ -437,11 +479,23 @@ func (s *ComplaintService) GetStatusTimeline(complaintID int64, requestingUserID
 		timeline = append(timeline, entry)
 	}
 
-	return &models.StatusTimelineResponse{
+	response := &models.StatusTimelineResponse{
 		ComplaintID:     complaintID,
 		ComplaintNumber: complaint.ComplaintNumber,
 		Timeline:        timeline,
-	}, nil
+	}
+
+	// SLA due time for the current priority and escalation level (timeline is still served without it)
+	if s.escalationService != nil {
+		dueAt, err := s.escalationService.SLADueAt(ctx, []models.Complaint{*complaint})
+		if err != nil {
+			log.Printf("[complaint] Warning: failed to compute SLA due time for complaint %d: %v", complaintID, err)
+		} else if due, ok := dueAt[complaintID]; ok {
+			response.SLADueAt = &due
+		}
+	}
+
+	return response, nil
 }
 
 // UpdateComplaintStatus updates the status of a complaint (internal use only)
@@ -453,7 +507,7 @@ func (s *ComplaintService) GetStatusTimeline(complaintID int64, requestingUserID
 // 4. resolved_at is set when status becomes 'resolved'
 // 5. closed_at is set when status becomes 'closed'
 // 6. Assignment changes are tracked in status history
-func (s *ComplaintService) UpdateComplaintStatus(
+func (s *ComplaintService) UpdateComplaintStatus(ctx context.Context,
 	complaintID int64,
 	req *models.UpdateStatusRequest,
 	actorType models.ActorType,
@@ -462,7 +516,7 @@ func (s *ComplaintService) UpdateComplaintStatus(
 	ipAddress, userAgent string,
 ) (*models.UpdateStatusResponse, error) {
 	// Get current complaint state
-	complaint, err := s.repo.GetComplaintByID(complaintID)
+	complaint, err := s.repo.GetComplaintByID(ctx, complaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get complaint: %w", err)
 	}
@@ -470,10 +524,14 @@ func (s *ComplaintService) UpdateComplaintStatus(
 	oldStatus := complaint.CurrentStatus
 	newStatus := models.ComplaintStatus(req.NewStatus)
 
-	// Validate status transition
-	// Note: In production, implement a state machine with allowed transitions
-	if !isValidStatusTransition(oldStatus, newStatus) {
-		return nil, fmt.Errorf("invalid status transition from %s to %s", oldStatus, newStatus)
+	// Validate status transition against the lifecycle state machine
+	reason := ""
+	if req.Notes != nil {
+		reason = *req.Notes
+	}
+	transition, err := lifecycle.Validate(oldStatus, newStatus, actorType, reason)
+	if err != nil {
+		return nil, err
 	}
 
 	// Prepare assignment updates
@@ -492,12 +550,6 @@ func (s *ComplaintService) UpdateComplaintStatus(
 		assignedOfficerID = &complaint.AssignedOfficerID.Int64
 	}
 
-	// Update complaint status
-	err = s.repo.UpdateComplaintStatus(complaintID, newStatus, assignedDeptID, assignedOfficerID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update complaint status: %w", err)
-	}
-
 	// Create status history entry (REQUIRED for every status change; audit: actor_type, actor_id, reason)
 	statusHistory := &models.ComplaintStatusHistory{
 		ComplaintID:   complaintID,
@@ -542,11 +594,6 @@ func (s *ComplaintService) UpdateComplaintStatus(
 		statusHistory.Reason = sql.NullString{String: *req.Notes, Valid: true}
 	}
 
-	err = s.repo.CreateStatusHistory(statusHistory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create status history: %w", err)
-	}
-
 	// Create audit log entry (REQUIRED for every action)
 	oldValues := map[string]interface{}{
 		"status":                string(oldStatus),
@@ -596,10 +643,35 @@ func (s *ComplaintService) UpdateComplaintStatus(
 		auditLog.ActionByOfficerID = sql.NullInt64{Int64: *actorOfficerID, Valid: true}
 	}
 
-	err = s.repo.CreateAuditLog(auditLog)
+	// Status update, history row and audit row commit atomically (version-guarded)
+	newVersion := complaint.Version
+	err = s.repo.InTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		if err := repo.UpdateComplaintStatus(ctx, complaintID, newStatus, assignedDeptID, assignedOfficerID, newVersion); err != nil {
+			return fmt.Errorf("failed to update complaint status: %w", err)
+		}
+		newVersion++
+		// Apply timestamp effects UpdateComplaintStatus does not cover (clearing, overwriting)
+		if transition.ResolvedAt != lifecycle.TimestampKeep || transition.ClosedAt != lifecycle.TimestampKeep {
+			resolvedAt, closedAt := transition.Timestamps(complaint.ResolvedAt, complaint.ClosedAt, time.Now().UTC())
+			if err := repo.UpdateComplaintStatusWithTimestamps(ctx, complaintID, newStatus, resolvedAt, closedAt, newVersion); err != nil {
+				return fmt.Errorf("failed to update complaint timestamps: %w", err)
+			}
+			newVersion++
+		}
+
+		if err := repo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+
+		if err := repo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		// Log error but don't fail the operation
-		// Audit logging should be resilient
+		return nil, err
 	}
 
 	return &models.UpdateStatusResponse{
@@ -607,38 +679,297 @@ func (s *ComplaintService) UpdateComplaintStatus(
 		ComplaintNumber: complaint.ComplaintNumber,
 		OldStatus:       string(oldStatus),
 		NewStatus:       string(newStatus),
+		Version:         newVersion,
 		Message:         "Status updated successfully",
 	}, nil
 }
 
-// isValidStatusTransition validates if a status transition is allowed
-// This is a simplified version - in production, implement a proper state machine
-func isValidStatusTransition(oldStatus, newStatus models.ComplaintStatus) bool {
-	// Define allowed transitions
-	allowedTransitions := map[models.ComplaintStatus][]models.ComplaintStatus{
-		models.StatusDraft:        {models.StatusSubmitted, models.StatusDraft},
-		models.StatusSubmitted:     {models.StatusVerified, models.StatusUnderReview, models.StatusRejected, models.StatusDraft},
-		models.StatusVerified:      {models.StatusUnderReview, models.StatusInProgress, models.StatusRejected},
-		models.StatusUnderReview:   {models.StatusInProgress, models.StatusRejected, models.StatusEscalated},
-		models.StatusInProgress:    {models.StatusResolved, models.StatusRejected, models.StatusEscalated},
-		models.StatusResolved:      {models.StatusClosed},
-		models.StatusRejected:      {models.StatusClosed, models.StatusUnderReview}, // Can be reopened
-		models.StatusEscalated:     {models.StatusUnderReview, models.StatusInProgress},
-		models.StatusClosed:        {}, // Terminal state
+// AnswerClarification records the citizen's reply to an officer's question
+//
+// Lifecycle Rules:
+// 1. Only the complaint owner can reply, and only while the complaint is 'awaiting_citizen'
+// 2. The complaint returns to the status it had when the question was asked
+// 3. The SLA clock resumes where it stopped: the time spent awaiting is excluded, the clock is not restarted
+// 4. The reply is stored with the question and in the status history notes
+func (s *ComplaintService) AnswerClarification(ctx context.Context,
+	complaintID int64,
+	userID int64,
+	req *models.ClarificationAnswerRequest,
+	ipAddress, userAgent string,
+) (*models.UpdateStatusResponse, error) {
+	complaint, err := s.repo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	if complaint.UserID != userID {
+		return nil, fmt.Errorf("complaint not found or access denied")
+	}
+	if complaint.CurrentStatus != models.StatusAwaitingCitizen {
+		return nil, fmt.Errorf("invalid status transition: complaint is %s, not awaiting_citizen", complaint.CurrentStatus)
+	}
+	clarification, err := s.repo.GetOpenClarification(ctx, complaintID)
+	if err != nil {
+		return nil, err
+	}
+	if clarification == nil {
+		return nil, fmt.Errorf("invalid status transition: no open question on this complaint")
+	}
+
+	oldStatus := complaint.CurrentStatus
+	newStatus := clarification.ReturnStatus
+	answer := strings.TrimSpace(req.Answer)
+	transition, err := lifecycle.Validate(oldStatus, newStatus, models.ActorUser, answer)
+	if err != nil {
+		return nil, err
 	}
+	resolvedAt, closedAt := transition.Timestamps(complaint.ResolvedAt, complaint.ClosedAt, time.Now().UTC())
 
-	allowed, exists := allowedTransitions[oldStatus]
-	if !exists {
-		return false
+	note := "Citizen replied: " + answer
+	statusHistory := &models.ComplaintStatusHistory{
+		ComplaintID:          complaintID,
+		OldStatus:            sql.NullString{String: string(oldStatus), Valid: true},
+		NewStatus:            newStatus,
+		ChangedByType:        models.ActorUser,
+		ChangedByUserID:      sql.NullInt64{Int64: userID, Valid: true},
+		ActorType:            sql.NullString{String: string(models.StatusHistoryActorUser), Valid: true},
+		ActorID:              sql.NullInt64{Int64: userID, Valid: true},
+		AssignedDepartmentID: complaint.AssignedDepartmentID,
+		AssignedOfficerID:    complaint.AssignedOfficerID,
+		Reason:               sql.NullString{String: note, Valid: true},
+		Notes:                sql.NullString{String: note, Valid: true},
 	}
 
-	for _, status := range allowed {
-		if status == newStatus {
-			return true
+	changesJSON, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"old": string(oldStatus),
+			"new": string(newStatus),
+		},
+	})
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"clarification_id": clarification.ClarificationID,
+		"awaited_seconds":  int64(time.Since(clarification.AskedAt).Seconds()),
+	})
+	auditLog := &models.AuditLog{
+		EntityType:     "complaint",
+		EntityID:       complaintID,
+		Action:         "clarification_answered",
+		ActionByType:   models.ActorUser,
+		ActionByUserID: sql.NullInt64{Int64: userID, Valid: true},
+		Changes:        sql.NullString{String: string(changesJSON), Valid: true},
+		Metadata:       sql.NullString{String: string(metadataJSON), Valid: true},
+		IPAddress:      sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent:      sql.NullString{String: userAgent, Valid: userAgent != ""},
+	}
+
+	// Status update, history row, answered question and audit row commit atomically
+	err = s.repo.InTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		if err := repo.UpdateComplaintStatusWithTimestamps(ctx, complaintID, newStatus, resolvedAt, closedAt, complaint.Version); err != nil {
+			return fmt.Errorf("failed to update complaint status: %w", err)
+		}
+		if err := repo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+		if err := repo.CloseClarification(ctx, clarification.ClarificationID, models.ClarificationAnswered, sql.NullString{String: answer, Valid: true}); err != nil {
+			return fmt.Errorf("failed to record answer: %w", err)
+		}
+		if err := repo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UpdateStatusResponse{
+		ComplaintID:     complaintID,
+		ComplaintNumber: complaint.ComplaintNumber,
+		OldStatus:       string(oldStatus),
+		NewStatus:       string(newStatus),
+		Version:         complaint.Version + 1,
+		Message:         "Reply recorded; complaint returned to " + string(newStatus),
+	}, nil
+}
+
+// RespondToResolution records the citizen's confirmation or dispute of a resolved complaint
+//
+// Lifecycle Rules:
+// 1. Only the complaint owner can respond, and only while the complaint is 'resolved'
+// 2. confirm: resolved → closed (closed_at set; resolved_at kept)
+// 3. dispute: resolved → under_review with a mandatory reason (resolved_at cleared)
+// 4. The reopen writes a new status history row, which restarts the SLA clock
+//    (escalation measures time since the latest status change)
+// 5. An optional photo is stored as an attachment on the complaint
+func (s *ComplaintService) RespondToResolution(ctx context.Context,
+	complaintID int64,
+	userID int64,
+	req *models.ResolutionResponseRequest,
+	ipAddress, userAgent string,
+) (*models.UpdateStatusResponse, error) {
+	complaint, err := s.repo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	if complaint.UserID != userID {
+		return nil, fmt.Errorf("complaint not found or access denied")
+	}
+	if complaint.CurrentStatus != models.StatusResolved {
+		return nil, fmt.Errorf("invalid status transition: complaint is %s, not resolved", complaint.CurrentStatus)
+	}
+
+	oldStatus := complaint.CurrentStatus
+	var newStatus models.ComplaintStatus
+	var note string
+	var auditAction string
+
+	switch req.Action {
+	case models.ResolutionActionConfirm:
+		newStatus = models.StatusClosed
+		note = "Citizen confirmed resolution"
+		auditAction = "resolution_confirmed"
+	case models.ResolutionActionDispute:
+		newStatus = models.StatusUnderReview
+		note = "Citizen disputed resolution: " + req.Reason
+		auditAction = "resolution_disputed"
+	default:
+		return nil, fmt.Errorf("invalid action: must be confirm or dispute")
+	}
+
+	// A dispute photo is an attachment the citizen already uploaded (stored and hashed as evidence)
+	if req.AttachmentID != nil {
+		if req.Action != models.ResolutionActionDispute {
+			return nil, fmt.Errorf("invalid attachment_id: only a dispute takes a photo")
 		}
+		if err := s.checkDisputePhoto(ctx, complaintID, userID, *req.AttachmentID); err != nil {
+			return nil, err
+		}
+		note += fmt.Sprintf(" (photo: attachment %d)", *req.AttachmentID)
+	}
+
+	// Lifecycle enforces the reason on dispute and the timestamp effects (closed_at set / resolved_at cleared)
+	transition, err := lifecycle.Validate(oldStatus, newStatus, models.ActorUser, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	resolvedAt, closedAt := transition.Timestamps(complaint.ResolvedAt, complaint.ClosedAt, time.Now().UTC())
+
+	// Create status history entry (audit: user, actor_id, reason). Officer assignment is kept so the
+	// same officer sees the reopened complaint.
+	statusHistory := &models.ComplaintStatusHistory{
+		ComplaintID:          complaintID,
+		OldStatus:            sql.NullString{String: string(oldStatus), Valid: true},
+		NewStatus:            newStatus,
+		ChangedByType:        models.ActorUser,
+		ChangedByUserID:      sql.NullInt64{Int64: userID, Valid: true},
+		ActorType:            sql.NullString{String: string(models.StatusHistoryActorUser), Valid: true},
+		ActorID:              sql.NullInt64{Int64: userID, Valid: true},
+		AssignedDepartmentID: complaint.AssignedDepartmentID,
+		AssignedOfficerID:    complaint.AssignedOfficerID,
+		Reason:               sql.NullString{String: note, Valid: true},
+		Notes:                sql.NullString{String: note, Valid: true},
 	}
 
-	return false
+	// Create audit log entry
+	changes := map[string]interface{}{
+		"status": map[string]interface{}{
+			"old": string(oldStatus),
+			"new": string(newStatus),
+		},
+	}
+	changesJSON, _ := json.Marshal(changes)
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"action":        string(req.Action),
+		"reason":        req.Reason,
+		"attachment_id": req.AttachmentID,
+	})
+	auditLog := &models.AuditLog{
+		EntityType:     "complaint",
+		EntityID:       complaintID,
+		Action:         auditAction,
+		ActionByType:   models.ActorUser,
+		ActionByUserID: sql.NullInt64{Int64: userID, Valid: true},
+		Changes:        sql.NullString{String: string(changesJSON), Valid: true},
+		Metadata:       sql.NullString{String: string(metadataJSON), Valid: true},
+		IPAddress:      sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent:      sql.NullString{String: userAgent, Valid: userAgent != ""},
+	}
+
+	// Status update, history row and audit row commit atomically
+	err = s.repo.InTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		if err := repo.UpdateComplaintStatusWithTimestamps(ctx, complaintID, newStatus, resolvedAt, closedAt, complaint.Version); err != nil {
+			return fmt.Errorf("failed to update complaint status: %w", err)
+		}
+		if err := repo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+
+		if err := repo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit pilot metrics: resolution_confirmed / resolution_disputed
+	if s.pilotMetricsService != nil {
+		var officerResolvedAt *time.Time
+		if complaint.ResolvedAt.Valid {
+			officerResolvedAt = &complaint.ResolvedAt.Time
+		}
+		metadata := map[string]interface{}{
+			"old_status": string(oldStatus),
+			"new_status": string(newStatus),
+		}
+		if complaint.AssignedOfficerID.Valid {
+			metadata["officer_id"] = complaint.AssignedOfficerID.Int64
+		}
+		s.pilotMetricsService.EmitResolutionFeedback(ctx, complaintID, userID, req.Action == models.ResolutionActionConfirm, officerResolvedAt, metadata)
+	}
+
+	message := "Resolution confirmed; complaint closed"
+	if newStatus == models.StatusUnderReview {
+		message = "Resolution disputed; complaint reopened"
+	}
+	return &models.UpdateStatusResponse{
+		ComplaintID:     complaintID,
+		ComplaintNumber: complaint.ComplaintNumber,
+		OldStatus:       string(oldStatus),
+		NewStatus:       string(newStatus),
+		Version:         complaint.Version + 1,
+		Message:         message,
+	}, nil
+}
+
+// checkDisputePhoto checks that an attachment was uploaded by the citizen to this complaint through the
+// attachments endpoint, so it has a stored file and an evidence hash (not a bare URL from complaint creation)
+func (s *ComplaintService) checkDisputePhoto(ctx context.Context, complaintID, userID, attachmentID int64) error {
+	invalid := fmt.Errorf("invalid attachment_id: attachment %d is not a photo you uploaded to this complaint", attachmentID)
+
+	attachment, err := s.repo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return invalid
+		}
+		return err
+	}
+	if attachment.ComplaintID != complaintID || !attachment.UploadedByUserID.Valid || attachment.UploadedByUserID.Int64 != userID {
+		return invalid
+	}
+	if s.evidenceRepo != nil {
+		if _, err := s.evidenceRepo.GetEvidenceByAttachmentID(ctx, attachmentID); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return invalid
+			}
+			return err
+		}
+	}
+	return nil
 }
 
 // extractFileName extracts filename from URL (simple implementation)
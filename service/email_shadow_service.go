@@ -44,16 +44,17 @@ func authorityDashboardPath(complaintID int64) string {
 }
 
 // SendAssignmentEmailAsync queues assignment email (log + send to pilot inbox). Non-blocking; never fails the caller.
-func (s *EmailShadowService) SendAssignmentEmailAsync(
+func (s *EmailShadowService) SendAssignmentEmailAsync(ctx context.Context,
 	complaintID int64,
 	complaintNumber string,
 	departmentID int64,
 	departmentName string,
 ) {
-	go s.sendAssignmentEmail(complaintID, complaintNumber, departmentID, departmentName)
+	// The send outlives the request that triggered it
+	go s.sendAssignmentEmail(context.WithoutCancel(ctx), complaintID, complaintNumber, departmentID, departmentName)
 }
 
-func (s *EmailShadowService) sendAssignmentEmail(
+func (s *EmailShadowService) sendAssignmentEmail(ctx context.Context,
 	complaintID int64,
 	complaintNumber string,
 	departmentID int64,
@@ -89,7 +90,7 @@ This is a pilot run. Real authority emails are disabled.`,
 	
 	// CRITICAL: Always log email attempt, even if sending fails
 	log.Printf("[email_shadow] Logging assignment email for complaint ID=%d, department ID=%d", complaintID, departmentID)
-	if err := s.emailLogRepo.Create(logEntry); err != nil {
+	if err := s.emailLogRepo.Create(ctx, logEntry); err != nil {
 		log.Printf("[email_shadow] ERROR: Failed to log assignment email: %v", err)
 		// Continue anyway - try to send even if logging fails
 	} else {
@@ -98,14 +99,14 @@ This is a pilot run. Real authority emails are disabled.`,
 	
 	// Send email to pilot inbox (shadow mode)
 	log.Printf("[email_shadow] Sending assignment email to %s for complaint ID=%d", PilotInboxEmail, complaintID)
-	if sendErr := s.sendToPilotInbox(subject, body); sendErr != nil {
+	if sendErr := s.sendToPilotInbox(ctx, subject, body); sendErr != nil {
 		if logEntry.ID > 0 {
-			_ = s.emailLogRepo.UpdateStatus(logEntry.ID, "failed", sendErr.Error())
+			_ = s.emailLogRepo.UpdateStatus(ctx, logEntry.ID, "failed", sendErr.Error())
 		}
 		log.Printf("[email_shadow] ERROR: Send to pilot inbox failed: %v", sendErr)
 	} else {
 		if logEntry.ID > 0 {
-			_ = s.emailLogRepo.UpdateStatus(logEntry.ID, "sent", "")
+			_ = s.emailLogRepo.UpdateStatus(ctx, logEntry.ID, "sent", "")
 		}
 		log.Printf("[email_shadow] Assignment email sent successfully for complaint ID=%d", complaintID)
 	}
@@ -113,7 +114,7 @@ This is a pilot run. Real authority emails are disabled.`,
 
 // SendEscalationEmailAsync queues escalation email. Non-blocking; never fails the caller.
 // Authority abstraction: department_id + level (L1/L2/L3), not officer-based.
-func (s *EmailShadowService) SendEscalationEmailAsync(
+func (s *EmailShadowService) SendEscalationEmailAsync(ctx context.Context,
 	complaintID int64,
 	complaintNumber string,
 	escalationLevel int,
@@ -121,10 +122,10 @@ func (s *EmailShadowService) SendEscalationEmailAsync(
 	departmentName string,
 	reason string,
 ) {
-	go s.sendEscalationEmail(complaintID, complaintNumber, escalationLevel, toDepartmentID, departmentName, reason)
+	go s.sendEscalationEmail(context.WithoutCancel(ctx), complaintID, complaintNumber, escalationLevel, toDepartmentID, departmentName, reason)
 }
 
-func (s *EmailShadowService) sendEscalationEmail(
+func (s *EmailShadowService) sendEscalationEmail(ctx context.Context,
 	complaintID int64,
 	complaintNumber string,
 	escalationLevel int,
@@ -161,21 +162,21 @@ This is a pilot run. Real authority emails are disabled.`,
 		Body:                body,
 		Status:              "pending",
 	}
-	if err := s.emailLogRepo.Create(logEntry); err != nil {
+	if err := s.emailLogRepo.Create(ctx, logEntry); err != nil {
 		log.Printf("[email_shadow] failed to log escalation email: %v", err)
 		return
 	}
-	if sendErr := s.sendToPilotInbox(subject, body); sendErr != nil {
-		_ = s.emailLogRepo.UpdateStatus(logEntry.ID, "failed", sendErr.Error())
+	if sendErr := s.sendToPilotInbox(ctx, subject, body); sendErr != nil {
+		_ = s.emailLogRepo.UpdateStatus(ctx, logEntry.ID, "failed", sendErr.Error())
 		log.Printf("[email_shadow] send escalation failed: %v", sendErr)
 	} else {
-		_ = s.emailLogRepo.UpdateStatus(logEntry.ID, "sent", "")
+		_ = s.emailLogRepo.UpdateStatus(ctx, logEntry.ID, "sent", "")
 	}
 }
 
 // SendResolutionEmailAsync queues resolution/closure email. Non-blocking; never fails the caller.
 // Authority abstraction: department_id, not officer-based.
-func (s *EmailShadowService) SendResolutionEmailAsync(
+func (s *EmailShadowService) SendResolutionEmailAsync(ctx context.Context,
 	complaintID int64,
 	complaintNumber string,
 	departmentID int64,
@@ -183,10 +184,10 @@ func (s *EmailShadowService) SendResolutionEmailAsync(
 	newStatus string,
 	reason string,
 ) {
-	go s.sendResolutionEmail(complaintID, complaintNumber, departmentID, departmentName, newStatus, reason)
+	go s.sendResolutionEmail(context.WithoutCancel(ctx), complaintID, complaintNumber, departmentID, departmentName, newStatus, reason)
 }
 
-func (s *EmailShadowService) sendResolutionEmail(
+func (s *EmailShadowService) sendResolutionEmail(ctx context.Context,
 	complaintID int64,
 	complaintNumber string,
 	departmentID int64,
@@ -219,22 +220,22 @@ This is a pilot run. Real authority emails are disabled.`,
 		Body:                body,
 		Status:              "pending",
 	}
-	if err := s.emailLogRepo.Create(logEntry); err != nil {
+	if err := s.emailLogRepo.Create(ctx, logEntry); err != nil {
 		log.Printf("[email_shadow] failed to log resolution email: %v", err)
 		return
 	}
-	if sendErr := s.sendToPilotInbox(subject, body); sendErr != nil {
-		_ = s.emailLogRepo.UpdateStatus(logEntry.ID, "failed", sendErr.Error())
+	if sendErr := s.sendToPilotInbox(ctx, subject, body); sendErr != nil {
+		_ = s.emailLogRepo.UpdateStatus(ctx, logEntry.ID, "failed", sendErr.Error())
 		log.Printf("[email_shadow] send resolution failed: %v", sendErr)
 	} else {
-		_ = s.emailLogRepo.UpdateStatus(logEntry.ID, "sent", "")
+		_ = s.emailLogRepo.UpdateStatus(ctx, logEntry.ID, "sent", "")
 	}
 }
 
 var sendMu sync.Mutex
 
 // sendToPilotInbox sends email to pilot inbox only. Returns error so caller can log status in email_logs.
-func (s *EmailShadowService) sendToPilotInbox(subject, body string) error {
+func (s *EmailShadowService) sendToPilotInbox(ctx context.Context, subject, body string) error {
 	sendMu.Lock()
 	defer sendMu.Unlock()
 	n := &models.Notification{
@@ -242,5 +243,5 @@ func (s *EmailShadowService) sendToPilotInbox(subject, body string) error {
 		Subject:   sql.NullString{String: subject, Valid: true},
 		Body:      body,
 	}
-	return s.emailSender.Send(context.Background(), n)
+	return s.emailSender.Send(ctx, n)
 }
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"finalneta/models"
+	"finalneta/repository"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// maxRuleHours caps every hour value in rule conditions (one year of working or wall-clock hours)
+const maxRuleHours = 24 * 366
+
+// EscalationRuleService manages escalation_rules for the admin API
+//
+// Rules:
+//  1. Conditions JSON is decoded strictly (unknown fields rejected) and checked by ValidateEscalationRule
+//  2. An active rule may not share department, location and level with another active rule of the same
+//     kind (escalation or reminder) when their status/priority filters overlap; nested scopes (one side
+//     NULL = any) are allowed but returned as warnings
+//  3. Every change bumps escalation_rules.version and writes the old and new rule to audit_log
+//     (entity_type escalation_rule) in the same transaction
+//  4. Rules are never deleted; DELETE deactivates
+//  5. A referenced SLA policy (time_based.sla_policy_id) must exist and be active
+type EscalationRuleService struct {
+	escalationRepo   *repository.EscalationRepository
+	complaintRepo    *repository.ComplaintRepository // audit_log
+	slaPolicyService *SLAPolicyService
+}
+
+// NewEscalationRuleService creates a new escalation rule service
+func NewEscalationRuleService(
+	escalationRepo *repository.EscalationRepository,
+	complaintRepo *repository.ComplaintRepository,
+	slaPolicyService *SLAPolicyService,
+) *EscalationRuleService {
+	return &EscalationRuleService{
+		escalationRepo:   escalationRepo,
+		complaintRepo:    complaintRepo,
+		slaPolicyService: slaPolicyService,
+	}
+}
+
+// ListRules returns escalation rules (active only unless includeInactive)
+func (s *EscalationRuleService) ListRules(ctx context.Context, includeInactive bool) ([]models.EscalationRule, error) {
+	return s.escalationRepo.ListEscalationRules(ctx, includeInactive)
+}
+
+// GetRule returns one escalation rule
+func (s *EscalationRuleService) GetRule(ctx context.Context, ruleID int64) (*models.EscalationRule, error) {
+	return s.escalationRepo.GetEscalationRule(ctx, ruleID)
+}
+
+// GetRuleHistory returns the audit trail of a rule, one row per version
+func (s *EscalationRuleService) GetRuleHistory(ctx context.Context, ruleID int64) ([]models.AuditLog, error) {
+	if _, err := s.escalationRepo.GetEscalationRule(ctx, ruleID); err != nil {
+		return nil, err
+	}
+	return s.escalationRepo.GetEscalationRuleHistory(ctx, ruleID)
+}
+
+// CreateRule validates and inserts a rule. Returns the stored rule and overlap warnings.
+// Validation errors are prefixed "invalid escalation rule:"; conflicts contain "conflicts with".
+func (s *EscalationRuleService) CreateRule(ctx context.Context,
+	rule *models.EscalationRule,
+	ipAddress, userAgent string,
+) (*models.EscalationRule, []string, error) {
+	conditions, err := ValidateEscalationRule(rule)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkRuleSLAPolicy(ctx, s.slaPolicyService, conditions); err != nil {
+		return nil, nil, err
+	}
+
+	var created *models.EscalationRule
+	var warnings []string
+	err = s.escalationRepo.InTx(ctx, func(tx *sql.Tx) error {
+		escalationRepo := s.escalationRepo.WithTx(tx)
+		if rule.IsActive {
+			var err error
+			if warnings, err = checkRuleConflicts(ctx, escalationRepo, rule, conditions); err != nil {
+				return err
+			}
+		}
+		ruleID, err := escalationRepo.CreateEscalationRule(ctx, rule)
+		if err != nil {
+			return err
+		}
+		if created, err = escalationRepo.GetEscalationRule(ctx, ruleID); err != nil {
+			return err
+		}
+		return s.auditRuleChange(ctx, tx, "create", nil, created, warnings, ipAddress, userAgent)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, warnings, nil
+}
+
+// UpdateRule validates and replaces a rule. expectedVersion (If-Match) is optional; the write is
+// always guarded by the version read here. Returns repository.ErrRuleVersionConflict when stale.
+func (s *EscalationRuleService) UpdateRule(ctx context.Context,
+	ruleID int64,
+	rule *models.EscalationRule,
+	expectedVersion *int64,
+	ipAddress, userAgent string,
+) (*models.EscalationRule, []string, error) {
+	conditions, err := ValidateEscalationRule(rule)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkRuleSLAPolicy(ctx, s.slaPolicyService, conditions); err != nil {
+		return nil, nil, err
+	}
+	rule.RuleID = ruleID
+
+	var updated *models.EscalationRule
+	var warnings []string
+	err = s.escalationRepo.InTx(ctx, func(tx *sql.Tx) error {
+		escalationRepo := s.escalationRepo.WithTx(tx)
+		current, err := escalationRepo.GetEscalationRule(ctx, ruleID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != nil && int64(current.Version) != *expectedVersion {
+			return repository.ErrRuleVersionConflict
+		}
+		if rule.IsActive {
+			if warnings, err = checkRuleConflicts(ctx, escalationRepo, rule, conditions); err != nil {
+				return err
+			}
+		}
+		if err := escalationRepo.UpdateEscalationRule(ctx, rule, current.Version); err != nil {
+			return err
+		}
+		if updated, err = escalationRepo.GetEscalationRule(ctx, ruleID); err != nil {
+			return err
+		}
+		return s.auditRuleChange(ctx, tx, "update", current, updated, warnings, ipAddress, userAgent)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, warnings, nil
+}
+
+// DeactivateRule sets is_active = false (rules are kept for the audit trail). Deactivating an
+// inactive rule is a no-op that returns the rule unchanged.
+func (s *EscalationRuleService) DeactivateRule(ctx context.Context,
+	ruleID int64,
+	expectedVersion *int64,
+	ipAddress, userAgent string,
+) (*models.EscalationRule, error) {
+	var deactivated *models.EscalationRule
+	err := s.escalationRepo.InTx(ctx, func(tx *sql.Tx) error {
+		escalationRepo := s.escalationRepo.WithTx(tx)
+		current, err := escalationRepo.GetEscalationRule(ctx, ruleID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != nil && int64(current.Version) != *expectedVersion {
+			return repository.ErrRuleVersionConflict
+		}
+		if !current.IsActive {
+			deactivated = current
+			return nil
+		}
+		next := *current
+		next.IsActive = false
+		if err := escalationRepo.UpdateEscalationRule(ctx, &next, current.Version); err != nil {
+			return err
+		}
+		if deactivated, err = escalationRepo.GetEscalationRule(ctx, ruleID); err != nil {
+			return err
+		}
+		return s.auditRuleChange(ctx, tx, "deactivate", current, deactivated, nil, ipAddress, userAgent)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deactivated, nil
+}
+
+// auditRuleChange writes one audit_log row per rule version (fails the transaction if it cannot)
+func (s *EscalationRuleService) auditRuleChange(ctx context.Context,
+	tx *sql.Tx,
+	action string,
+	oldRule, newRule *models.EscalationRule,
+	warnings []string,
+	ipAddress, userAgent string,
+) error {
+	auditLog := &models.AuditLog{
+		EntityType:   "escalation_rule",
+		EntityID:     newRule.RuleID,
+		Action:       action,
+		ActionByType: models.ActorAdmin,
+		IPAddress:    sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent:    sql.NullString{String: userAgent, Valid: userAgent != ""},
+	}
+	if oldRule != nil {
+		oldJSON, _ := json.Marshal(EscalationRuleSnapshot(oldRule))
+		auditLog.OldValues = sql.NullString{String: string(oldJSON), Valid: true}
+	}
+	newJSON, _ := json.Marshal(EscalationRuleSnapshot(newRule))
+	auditLog.NewValues = sql.NullString{String: string(newJSON), Valid: true}
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"version":  newRule.Version,
+		"warnings": warnings,
+	})
+	auditLog.Metadata = sql.NullString{String: string(metadataJSON), Valid: true}
+
+	if err := s.complaintRepo.WithTx(tx).CreateAuditLog(ctx, auditLog); err != nil {
+		return fmt.Errorf("failed to record escalation rule version: %w", err)
+	}
+	return nil
+}
+
+// EscalationRuleSnapshot converts a rule to its JSON view (audit snapshots and API responses)
+func EscalationRuleSnapshot(rule *models.EscalationRule) models.EscalationRuleView {
+	view := models.EscalationRuleView{
+		RuleID:           rule.RuleID,
+		FromDepartmentID: nullInt64Ptr(rule.FromDepartmentID),
+		FromLocationID:   nullInt64Ptr(rule.FromLocationID),
+		ToDepartmentID:   nullInt64Ptr(rule.ToDepartmentID),
+		ToLocationID:     nullInt64Ptr(rule.ToLocationID),
+		EscalationLevel:  rule.EscalationLevel,
+		Conditions:       json.RawMessage("null"),
+		IsActive:         rule.IsActive,
+		Version:          rule.Version,
+	}
+	if rule.Conditions.Valid && json.Valid([]byte(rule.Conditions.String)) {
+		view.Conditions = json.RawMessage(rule.Conditions.String)
+	}
+	return view
+}
+
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	id := v.Int64
+	return &id
+}
+
+// ValidateEscalationRule checks a rule before it is stored and returns its parsed conditions.
+// Conditions are decoded strictly: unknown fields, trailing data and impossible values are rejected.
+// Errors are prefixed "invalid escalation rule:".
+func ValidateEscalationRule(rule *models.EscalationRule) (*models.EscalationConditions, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("invalid escalation rule: "+format, args...)
+	}
+
+	if rule.EscalationLevel < 0 || rule.EscalationLevel >= MaxEscalationLevel {
+		return nil, invalid("escalation_level must be between 0 (L1 to L2) and %d (L%d to L%d)",
+			MaxEscalationLevel-1, MaxEscalationLevel, MaxEscalationLevel+1)
+	}
+	for name, id := range map[string]sql.NullInt64{
+		"from_department_id": rule.FromDepartmentID,
+		"from_location_id":   rule.FromLocationID,
+		"to_department_id":   rule.ToDepartmentID,
+		"to_location_id":     rule.ToLocationID,
+	} {
+		if id.Valid && id.Int64 <= 0 {
+			return nil, invalid("%s must be a positive ID or null", name)
+		}
+	}
+
+	if !rule.Conditions.Valid || strings.TrimSpace(rule.Conditions.String) == "" {
+		return nil, invalid("conditions are required")
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(rule.Conditions.String)))
+	decoder.DisallowUnknownFields()
+	var conditions *models.EscalationConditions
+	if err := decoder.Decode(&conditions); err != nil {
+		return nil, invalid("conditions: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, invalid("conditions: unexpected data after JSON object")
+	}
+	if conditions == nil {
+		return nil, invalid("conditions must be a JSON object")
+	}
+
+	for _, status := range conditions.Statuses {
+		if !slices.Contains(escalationCandidateStatuses, models.ComplaintStatus(status)) {
+			return nil, invalid("status %q is never escalated (allowed: verified, under_review, in_progress)", status)
+		}
+	}
+	for _, priority := range conditions.Priorities {
+		switch models.Priority(priority) {
+		case models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityUrgent:
+		default:
+			return nil, invalid("unknown priority %q (allowed: low, medium, high, urgent)", priority)
+		}
+	}
+
+	hasTimeCondition := false
+	if tb := conditions.TimeBased; tb != nil {
+		for name, hours := range map[string]int{
+			"hours_since_last_update":   tb.HoursSinceLastUpdate,
+			"sla_hours":                 tb.SLAHours,
+			"hours_since_status_change": tb.HoursSinceStatusChange,
+			"hours_since_creation":      tb.HoursSinceCreation,
+		} {
+			if hours < 0 || hours > maxRuleHours {
+				return nil, invalid("time_based.%s must be between 0 and %d", name, maxRuleHours)
+			}
+			if hours > 0 {
+				hasTimeCondition = true
+			}
+		}
+		if tb.SLAHours > 0 && tb.HoursSinceStatusChange > 0 {
+			return nil, invalid("set sla_hours or the legacy hours_since_status_change, not both")
+		}
+		if tb.SLAPolicyID < 0 {
+			return nil, invalid("time_based.sla_policy_id must be a positive ID")
+		}
+		if tb.SLAPolicyID > 0 {
+			if tb.SLAHours > 0 || tb.HoursSinceStatusChange > 0 {
+				return nil, invalid("set sla_hours or sla_policy_id, not both")
+			}
+			hasTimeCondition = true
+		}
+	}
+
+	if conditions.IsReminder {
+		if conditions.ReminderIntervalHours == nil || *conditions.ReminderIntervalHours <= 0 || *conditions.ReminderIntervalHours > maxRuleHours {
+			return nil, invalid("reminder rules need reminder_interval_hours between 1 and %d", maxRuleHours)
+		}
+	} else {
+		if conditions.ReminderIntervalHours != nil {
+			return nil, invalid("reminder_interval_hours is only valid with is_reminder")
+		}
+		// Without a time condition the engine would escalate on the first worker run
+		if !hasTimeCondition {
+			return nil, invalid("escalation rules need at least one time_based condition")
+		}
+	}
+
+	return conditions, nil
+}
+
+// checkRuleSLAPolicy checks that the SLA policy a rule references exists and is active
+func checkRuleSLAPolicy(ctx context.Context, policies *SLAPolicyService, conditions *models.EscalationConditions) error {
+	if conditions.TimeBased == nil || conditions.TimeBased.SLAPolicyID == 0 {
+		return nil
+	}
+	if policies == nil {
+		return fmt.Errorf("invalid escalation rule: SLA policies are not available")
+	}
+	return policies.CheckPolicy(ctx, conditions.TimeBased.SLAPolicyID)
+}
+
+// checkRuleConflicts compares an active rule with the other active rules at its level (locked for the
+// transaction). Same scope with overlapping filters is a conflict; nested scopes are warnings.
+func checkRuleConflicts(ctx context.Context,
+	escalationRepo *repository.EscalationRepository,
+	rule *models.EscalationRule,
+	conditions *models.EscalationConditions,
+) ([]string, error) {
+	others, err := escalationRepo.LockActiveEscalationRulesAtLevel(ctx, rule.EscalationLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflictIDs []string
+	var warnings []string
+	for _, other := range others {
+		if other.RuleID == rule.RuleID {
+			continue
+		}
+		otherConditions, err := repository.ParseEscalationConditions(other.Conditions)
+		if err != nil || otherConditions == nil {
+			warnings = append(warnings, fmt.Sprintf("rule %d at the same level has unreadable conditions and is skipped by the engine", other.RuleID))
+			continue
+		}
+		if otherConditions.IsReminder != conditions.IsReminder ||
+			!filtersOverlap(conditions.Statuses, otherConditions.Statuses) ||
+			!filtersOverlap(conditions.Priorities, otherConditions.Priorities) {
+			continue
+		}
+
+		sameScope := rule.FromDepartmentID == other.FromDepartmentID && rule.FromLocationID == other.FromLocationID
+		nestedScope := scopeOverlaps(rule.FromDepartmentID, other.FromDepartmentID) &&
+			scopeOverlaps(rule.FromLocationID, other.FromLocationID)
+		switch {
+		case sameScope:
+			conflictIDs = append(conflictIDs, fmt.Sprintf("%d", other.RuleID))
+		case nestedScope:
+			warnings = append(warnings, fmt.Sprintf("overlaps rule %d (one side applies to any department or location); the engine uses whichever matches first", other.RuleID))
+		}
+	}
+
+	if len(conflictIDs) > 0 {
+		return nil, fmt.Errorf("escalation rule conflicts with active rule(s) %s: same department, location and level with overlapping statuses and priorities",
+			strings.Join(conflictIDs, ", "))
+	}
+	return warnings, nil
+}
+
+// filtersOverlap reports whether two status or priority filters can match the same complaint (empty = any)
+func filtersOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, v := range a {
+		if slices.Contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeOverlaps reports whether two rule scopes can match the same complaint (NULL = any)
+func scopeOverlaps(a, b sql.NullInt64) bool {
+	return !a.Valid || !b.Valid || a.Int64 == b.Int64
+}
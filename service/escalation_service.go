@@ -1,12 +1,18 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"finalneta/lifecycle"
 	"finalneta/models"
 	"finalneta/repository"
+	"finalneta/sla"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -17,11 +23,34 @@ type EscalationService struct {
 	verificationRepo            *repository.VerificationRepository
 	emailShadowService          *EmailShadowService   // optional; pilot email shadow mode
 	pilotMetricsService         *PilotMetricsService // optional; pilot metrics
+	slaCalendarService          *SLACalendarService  // optional; nil = SLA counted in wall-clock time
+	slaPolicyService            *SLAPolicyService    // optional; nil = rules referencing an SLA policy never fall due
+	assignmentService           *AssignmentService   // optional; nil = transfers go to the first officer at the level
+	absenceService              *OfficerAbsenceService // optional; nil = no delegation (escalations reach officers on leave)
 	dryRun                      bool                 // PILOT_DRY_RUN: Enable dry-run/testing mode
 	dryRunSLAOverrideMinutes    int                  // PILOT_DRY_RUN_SLA_OVERRIDE_MINUTES: Override SLA hours with minutes (0 = disabled)
 	testEscalationOverrideMinutes int                 // TEST_ESCALATION_OVERRIDE_MINUTES: Safe test-only SLA override (0 = disabled)
 }
 
+// escalationCandidateStatuses are the statuses the escalation engine acts on
+var escalationCandidateStatuses = []models.ComplaintStatus{
+	models.StatusVerified,
+	models.StatusUnderReview,
+	models.StatusInProgress,
+}
+
+// officerEscalationStatuses are the statuses an officer may escalate or transfer from
+// (an escalated complaint can go further up or sideways)
+var officerEscalationStatuses = []models.ComplaintStatus{
+	models.StatusVerified,
+	models.StatusUnderReview,
+	models.StatusInProgress,
+	models.StatusEscalated,
+}
+
+// MaxEscalationLevel is the highest current escalation level that can still escalate (L3 = level 2)
+const MaxEscalationLevel = 2
+
 // NewEscalationService creates a new escalation service
 func NewEscalationService(
 	complaintRepo *repository.ComplaintRepository,
@@ -29,6 +58,10 @@ func NewEscalationService(
 	verificationRepo *repository.VerificationRepository,
 	emailShadowService *EmailShadowService,
 	pilotMetricsService *PilotMetricsService,
+	slaCalendarService *SLACalendarService,
+	slaPolicyService *SLAPolicyService,
+	assignmentService *AssignmentService,
+	absenceService *OfficerAbsenceService,
 	dryRun bool,
 	dryRunSLAOverrideMinutes int,
 	testEscalationOverrideMinutes int,
@@ -39,6 +72,10 @@ func NewEscalationService(
 		verificationRepo:            verificationRepo,
 		emailShadowService:          emailShadowService,
 		pilotMetricsService:         pilotMetricsService,
+		slaCalendarService:          slaCalendarService,
+		slaPolicyService:            slaPolicyService,
+		assignmentService:           assignmentService,
+		absenceService:              absenceService,
 		dryRun:                      dryRun,
 		dryRunSLAOverrideMinutes:    dryRunSLAOverrideMinutes,
 		testEscalationOverrideMinutes: testEscalationOverrideMinutes,
@@ -55,7 +92,7 @@ func NewEscalationService(
 // 4. Create escalation records if conditions met
 // 5. Update complaint status via status history
 // 6. Log all actions to audit_log
-func (s *EscalationService) ProcessEscalations() ([]models.EscalationResult, error) {
+func (s *EscalationService) ProcessEscalations(ctx context.Context) ([]models.EscalationResult, error) {
 	// DRY RUN MODE: Log mode status
 	if s.dryRun {
 		log.Printf("[DRY RUN] Escalation worker running in DRY RUN mode")
@@ -65,7 +102,7 @@ func (s *EscalationService) ProcessEscalations() ([]models.EscalationResult, err
 	}
 	
 	// Load active escalation rules
-	rules, err := s.escalationRepo.GetActiveEscalationRules()
+	rules, err := s.escalationRepo.GetActiveEscalationRules(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load escalation rules: %w", err)
 	}
@@ -79,12 +116,8 @@ func (s *EscalationService) ProcessEscalations() ([]models.EscalationResult, err
 		return []models.EscalationResult{}, nil
 	}
 
-	candidates, err := s.escalationRepo.GetEscalationCandidates(
-		[]models.ComplaintStatus{
-			models.StatusVerified,
-			models.StatusUnderReview,
-			models.StatusInProgress,
-		},
+	candidates, err := s.escalationRepo.GetEscalationCandidates(ctx,
+		escalationCandidateStatuses,
 		24*time.Hour, // unused; candidate query no longer filters by time
 	)
 	if err != nil {
@@ -107,9 +140,16 @@ func (s *EscalationService) ProcessEscalations() ([]models.EscalationResult, err
 			c.ComplaintID, c.CurrentStatus, deptID, pincode, c.LocationID, mins)
 	}
 
+	run := &escalationRun{now: time.Now().UTC(), writer: liveEscalationWriter{s: s}}
 	var results []models.EscalationResult
-	for _, candidate := range candidates {
-		result, err := s.processComplaintEscalation(candidate, rules)
+	for i, candidate := range candidates {
+		// On shutdown stop between complaints; the rest are picked up by the next cycle
+		if ctx.Err() != nil {
+			log.Printf("[ESCALATION] Cycle interrupted after %d of %d candidates: %v", i, len(candidates), ctx.Err())
+			break
+		}
+		// A complaint that has started is finished, so its writes are never cut off halfway
+		result, err := s.processComplaintEscalation(context.WithoutCancel(ctx), run, candidate, rules)
 		if err != nil {
 			log.Printf("[ESCALATION] Skipping complaint %d: %v", candidate.ComplaintID, err)
 			continue
@@ -122,13 +162,391 @@ func (s *EscalationService) ProcessEscalations() ([]models.EscalationResult, err
 	return results, nil
 }
 
+// maxEscalationNoteLength caps the citizen's note on a requested escalation
+const maxEscalationNoteLength = 500
+
+// RequestEscalation escalates a complaint at its owner's request
+//
+// Flow:
+// 1. Complaint must belong to the citizen and be in a status the engine escalates
+// 2. One citizen request per escalation level
+// 3. A time-based rule for the current level must be due (same rules, SLA and calendar as the engine)
+// 4. Escalate through executeEscalation, recorded as escalated by the user (history, escalation row, audit)
+func (s *EscalationService) RequestEscalation(ctx context.Context,
+	complaintID int64,
+	userID int64,
+	note string,
+	ipAddress, userAgent string,
+) (*models.EscalationResult, error) {
+	note = strings.TrimSpace(note)
+	if len(note) > maxEscalationNoteLength {
+		return nil, fmt.Errorf("invalid request: note must be at most %d characters", maxEscalationNoteLength)
+	}
+
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	if complaint.UserID != userID {
+		return nil, fmt.Errorf("complaint not found or access denied")
+	}
+	if !slices.Contains(escalationCandidateStatuses, complaint.CurrentStatus) {
+		return nil, fmt.Errorf("escalation not available: complaint is %s", complaint.CurrentStatus)
+	}
+	candidate, err := s.escalationRepo.GetEscalationCandidate(ctx, complaintID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate == nil {
+		return nil, fmt.Errorf("escalation not available: complaint is %s", complaint.CurrentStatus)
+	}
+
+	currentLevel, err := s.escalationRepo.GetLastEscalationLevel(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation level: %w", err)
+	}
+	if currentLevel >= MaxEscalationLevel {
+		return nil, fmt.Errorf("escalation not available: highest escalation level reached")
+	}
+	requested, err := s.escalationRepo.HasEscalationBy(ctx, complaintID, currentLevel, models.ActorUser)
+	if err != nil {
+		return nil, err
+	}
+	if requested {
+		return nil, fmt.Errorf("escalation already requested at this level")
+	}
+
+	rules, err := s.escalationRepo.GetActiveEscalationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load escalation rules: %w", err)
+	}
+	calendar, err := s.calendarFor(ctx, candidate.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SLA calendar: %w", err)
+	}
+	if candidate.SLAPauses, err = s.escalationRepo.GetSLAPauses(ctx, complaintID); err != nil {
+		return nil, err
+	}
+
+	run := &escalationRun{
+		now:     time.Now().UTC(),
+		writer:  liveEscalationWriter{s: s},
+		request: &escalationRequest{userID: userID, ipAddress: ipAddress, userAgent: userAgent},
+	}
+	notDue := "no SLA applies at this level"
+	for _, rule := range rules {
+		if rule.EscalationLevel != currentLevel || !s.ruleMatchesComplaint(rule, *candidate) {
+			continue
+		}
+		conditions, err := repository.ParseEscalationConditions(rule.Conditions)
+		// Only a breached SLA entitles the citizen to escalate: reminders and rules without a time condition never do
+		if err != nil || conditions == nil || conditions.IsReminder || conditions.TimeBased == nil {
+			continue
+		}
+		due, reason := s.evaluateEscalationConditions(ctx, *candidate, currentLevel, conditions, calendar, run.now)
+		if !due {
+			notDue = reason
+			continue
+		}
+
+		reason = "Citizen requested escalation after SLA breach"
+		if note != "" {
+			reason += ": " + note
+		}
+		result, err := s.executeEscalation(ctx, run, *candidate, rule, reason)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return nil, fmt.Errorf("escalation not available: no escalation target for this complaint")
+		}
+		log.Printf("[ESCALATION] complaint_id=%d escalated at the request of user_id=%d", complaintID, userID)
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("escalation not available: %s", notDue)
+}
+
+// EscalateByOfficer escalates a complaint assigned to the officer to the next level, without waiting for the SLA
+//
+// Flow:
+// 1. Complaint must be assigned to the officer (or the absent officer they are delegate of) and open
+//    (verified, under_review, in_progress or escalated)
+// 2. Target from the first escalation rule at the current level matching the complaint, else same department and location
+// 3. Escalate through executeEscalation (officer lookup, escalation row, history, audit), recorded as escalated by the officer
+// expectedVersion (from If-Match) is optional; when set and stale, returns repository.ErrVersionConflict.
+func (s *EscalationService) EscalateByOfficer(ctx context.Context,
+	complaintID int64,
+	officerID int64,
+	reason string,
+	expectedVersion *int64,
+	ipAddress, userAgent string,
+) (*models.EscalationResult, error) {
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	onBehalfOf, err := s.absenceService.ActingFor(ctx, complaint, officerID)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != nil && *expectedVersion != complaint.Version {
+		return nil, repository.ErrVersionConflict
+	}
+	if !slices.Contains(officerEscalationStatuses, complaint.CurrentStatus) {
+		return nil, fmt.Errorf("escalation not available: complaint is %s", complaint.CurrentStatus)
+	}
+	candidate, err := s.escalationRepo.GetEscalationCandidate(ctx, complaintID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate == nil {
+		return nil, fmt.Errorf("escalation not available: complaint is %s", complaint.CurrentStatus)
+	}
+
+	currentLevel, err := s.escalationRepo.GetLastEscalationLevel(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation level: %w", err)
+	}
+	if currentLevel >= MaxEscalationLevel {
+		return nil, fmt.Errorf("escalation not available: highest escalation level reached")
+	}
+
+	// The hierarchy is configured by the escalation rules: use the target of the rule the engine would apply
+	// at this level, ignoring its conditions (the officer decides when)
+	rules, err := s.escalationRepo.GetActiveEscalationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load escalation rules: %w", err)
+	}
+	target := models.EscalationRule{EscalationLevel: currentLevel} // same department and location
+	for _, rule := range rules {
+		if rule.EscalationLevel != currentLevel || !s.ruleMatchesComplaint(rule, *candidate) {
+			continue
+		}
+		conditions, err := repository.ParseEscalationConditions(rule.Conditions)
+		if err != nil || (conditions != nil && conditions.IsReminder) {
+			continue
+		}
+		target = rule
+		break
+	}
+
+	run := &escalationRun{
+		now:     time.Now().UTC(),
+		writer:  liveEscalationWriter{s: s},
+		request: &escalationRequest{officerID: officerID, onBehalfOf: onBehalfOf, ipAddress: ipAddress, userAgent: userAgent},
+	}
+	reason = strings.TrimSpace(reason)
+	if onBehalfOf != nil {
+		reason = onBehalfOf.OnBehalfOf(reason)
+	}
+	result, err := s.executeEscalation(ctx, run, *candidate, target, reason)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("escalation not available: no escalation target for this complaint")
+	}
+	result.Version = candidate.Version + 1
+	log.Printf("[ESCALATION] complaint_id=%d escalated by officer_id=%d", complaintID, officerID)
+	return result, nil
+}
+
+// TransferComplaint moves a complaint assigned to the officer to another department and/or location
+//
+// Flow:
+// 1. Complaint must be assigned to the officer (or the absent officer they are delegate of) and open
+//    (verified, under_review, in_progress or escalated)
+// 2. Officer in the target department and location at the complaint's current escalation level, picked by the
+//    department's assignment strategy (never the transferring officer); strategy and inputs go into the history notes
+// 3. Reassignment (status unchanged), status history, transfer row in complaint_escalations and audit commit atomically
+// 4. Pilot metrics: complaint_transferred, never escalation_triggered (a transfer is not an SLA escalation)
+// The history row restarts the SLA clock, so the receiving department gets a full SLA.
+// expectedVersion (from If-Match) is optional; when set and stale, returns repository.ErrVersionConflict.
+func (s *EscalationService) TransferComplaint(ctx context.Context,
+	complaintID int64,
+	officerID int64,
+	req *models.AuthorityTransferRequest,
+	expectedVersion *int64,
+	ipAddress, userAgent string,
+) (*models.TransferResponse, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required for transfer")
+	}
+	if req.ToDepartmentID <= 0 {
+		return nil, fmt.Errorf("invalid transfer: to_department_id is required")
+	}
+
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	onBehalfOf, err := s.absenceService.ActingFor(ctx, complaint, officerID)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != nil && *expectedVersion != complaint.Version {
+		return nil, repository.ErrVersionConflict
+	}
+	if !slices.Contains(officerEscalationStatuses, complaint.CurrentStatus) {
+		return nil, fmt.Errorf("transfer not available: complaint is %s", complaint.CurrentStatus)
+	}
+
+	toLocationID := complaint.LocationID
+	if req.ToLocationID != nil {
+		toLocationID = *req.ToLocationID
+	}
+	currentLevel, err := s.escalationRepo.GetLastEscalationLevel(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation level: %w", err)
+	}
+	// The receiving officer works at the complaint's level (level index 0 = authority_level 1)
+	var toOfficerID *int64
+	var assignment *models.Assignment
+	if s.assignmentService != nil {
+		target := AssignmentTarget{
+			DepartmentID:      req.ToDepartmentID,
+			LocationID:        toLocationID,
+			MinAuthorityLevel: currentLevel + 1,
+			ExcludeOfficerID:  officerID,
+			Category:          complaint.Category.String,
+		}
+		if complaint.Latitude.Valid && complaint.Longitude.Valid {
+			target.Latitude = &complaint.Latitude.Float64
+			target.Longitude = &complaint.Longitude.Float64
+		}
+		assignment, err = s.assignmentService.Assign(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		if assignment != nil {
+			toOfficerID = &assignment.OfficerID
+		}
+	} else {
+		toOfficerID, err = s.escalationRepo.FindOfficerAtLevel(ctx, req.ToDepartmentID, toLocationID, currentLevel+1, officerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if toOfficerID == nil {
+		if complaint.AssignedDepartmentID.Valid && complaint.AssignedDepartmentID.Int64 == req.ToDepartmentID && toLocationID == complaint.LocationID {
+			return nil, fmt.Errorf("transfer not available: department %d at location %d is already handling this complaint", req.ToDepartmentID, toLocationID)
+		}
+		return nil, fmt.Errorf("transfer not available: no active officer in department %d at location %d", req.ToDepartmentID, toLocationID)
+	}
+
+	request := &escalationRequest{officerID: officerID, onBehalfOf: onBehalfOf, ipAddress: ipAddress, userAgent: userAgent}
+	note := fmt.Sprintf("Transferred to department %d (location %d): %s", req.ToDepartmentID, toLocationID, reason)
+	if onBehalfOf != nil {
+		note = onBehalfOf.OnBehalfOf(note)
+	}
+	historyNote := note
+	if assignment != nil {
+		historyNote += "; " + assignment.Note()
+	}
+	statusHistory := &models.ComplaintStatusHistory{
+		ComplaintID:          complaintID,
+		OldStatus:            sql.NullString{String: string(complaint.CurrentStatus), Valid: true},
+		NewStatus:            complaint.CurrentStatus,
+		Reason:               sql.NullString{String: note, Valid: true},
+		Notes:                sql.NullString{String: historyNote, Valid: true},
+		AssignedDepartmentID: sql.NullInt64{Int64: req.ToDepartmentID, Valid: true},
+		AssignedOfficerID:    sql.NullInt64{Int64: *toOfficerID, Valid: true},
+	}
+	request.stampHistory(statusHistory)
+	transfer := &models.ComplaintEscalation{
+		ComplaintID:          complaintID,
+		FromDepartmentID:     complaint.AssignedDepartmentID,
+		FromOfficerID:        complaint.AssignedOfficerID,
+		ToDepartmentID:       req.ToDepartmentID,
+		ToOfficerID:          sql.NullInt64{Int64: *toOfficerID, Valid: true},
+		EscalationLevel:      currentLevel, // a transfer keeps the level
+		EscalationType:       models.EscalationTypeTransfer,
+		Reason:               sql.NullString{String: reason, Valid: true},
+		EscalatedByType:      models.ActorOfficer,
+		EscalatedByOfficerID: sql.NullInt64{Int64: officerID, Valid: true},
+	}
+
+	// Reassignment, history row, transfer record and audit row commit atomically
+	err = s.complaintRepo.InTx(ctx, func(tx *sql.Tx) error {
+		complaintRepo := s.complaintRepo.WithTx(tx)
+		escalationRepo := s.escalationRepo.WithTx(tx)
+
+		if err := complaintRepo.UpdateComplaintAssignment(ctx,
+			complaintID,
+			req.ToDepartmentID,
+			toLocationID,
+			*toOfficerID,
+			complaint.Version,
+		); err != nil {
+			return fmt.Errorf("failed to reassign complaint: %w", err)
+		}
+		if err := complaintRepo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+		transfer.StatusHistoryID = sql.NullInt64{Int64: statusHistory.HistoryID, Valid: true}
+		if err := escalationRepo.CreateEscalation(ctx, transfer); err != nil {
+			return fmt.Errorf("failed to create transfer: %w", err)
+		}
+
+		auditData := map[string]interface{}{
+			"escalation_id":    transfer.EscalationID,
+			"escalation_level": currentLevel,
+			"from_department":  complaint.AssignedDepartmentID,
+			"from_officer":     complaint.AssignedOfficerID,
+			"to_department":    req.ToDepartmentID,
+			"to_location":      toLocationID,
+			"to_officer":       *toOfficerID,
+			"reason":           reason,
+		}
+		if err := s.logEscalationAction(ctx, complaintRepo, complaintID, "transfer", auditData, request); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[ESCALATION] complaint_id=%d transferred by officer_id=%d to department=%d location=%d officer=%d",
+		complaintID, officerID, req.ToDepartmentID, toLocationID, *toOfficerID)
+
+	if s.pilotMetricsService != nil {
+		metadata := map[string]interface{}{
+			"escalation_level": currentLevel,
+			"from_department":  complaint.AssignedDepartmentID.Int64,
+			"to_department":    req.ToDepartmentID,
+			"to_location":      toLocationID,
+			"officer_id":       officerID,
+		}
+		s.pilotMetricsService.EmitComplaintTransferred(ctx, complaintID, complaint.UserID, metadata)
+	}
+
+	response := &models.TransferResponse{
+		ComplaintID:     complaintID,
+		ComplaintNumber: complaint.ComplaintNumber,
+		EscalationID:    transfer.EscalationID,
+		ToDepartmentID:  req.ToDepartmentID,
+		ToLocationID:    toLocationID,
+		ToOfficerID:     *toOfficerID,
+		Version:         complaint.Version + 1,
+		Message:         "Complaint transferred successfully",
+	}
+	if complaint.AssignedDepartmentID.Valid {
+		response.FromDepartmentID = &complaint.AssignedDepartmentID.Int64
+	}
+	return response, nil
+}
+
 // processComplaintEscalation processes escalation for a single complaint
-func (s *EscalationService) processComplaintEscalation(
+// Decisions go through run.writer (committed by ProcessEscalations, only recorded by SimulateEscalations)
+func (s *EscalationService) processComplaintEscalation(ctx context.Context,
+	run *escalationRun,
 	candidate models.EscalationCandidate,
 	rules []models.EscalationRule,
 ) (*models.EscalationResult, error) {
 	// Get current escalation level (0 = L1, 1 = L2, 2 = L3)
-	currentLevel, err := s.escalationRepo.GetLastEscalationLevel(candidate.ComplaintID)
+	currentLevel, err := s.escalationRepo.GetLastEscalationLevel(ctx, candidate.ComplaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get escalation level: %w", err)
 	}
@@ -136,7 +554,6 @@ func (s *EscalationService) processComplaintEscalation(
 
 	// SAFEGUARD 1: Do NOT escalate beyond L3 (max escalation level)
 	// L3 = currentLevel 2 (0=L1, 1=L2, 2=L3)
-	const MaxEscalationLevel = 2 // L3 is the maximum
 	if currentLevel >= MaxEscalationLevel {
 		log.Printf("[ESCALATION_DEBUG] skip complaint %d: max escalation level reached (currentLevel=%d)", candidate.ComplaintID, currentLevel)
 		return nil, nil
@@ -161,11 +578,21 @@ func (s *EscalationService) processComplaintEscalation(
 	}
 	log.Printf("[ESCALATION_DEBUG] complaint_id=%d applicable_rules=%d", candidate.ComplaintID, len(applicableRules))
 
+	calendar, err := s.calendarFor(ctx, candidate.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SLA calendar: %w", err)
+	}
+	if candidate.SLAPauses, err = s.escalationRepo.GetSLAPauses(ctx, candidate.ComplaintID); err != nil {
+		return nil, err
+	}
+
 	// Evaluate conditions for each applicable rule
 	for _, rule := range applicableRules {
 		conditions, err := repository.ParseEscalationConditions(rule.Conditions)
 		if err != nil {
-			continue // Skip rule if conditions can't be parsed
+			// Skip rule if conditions can't be parsed (rules saved through the admin API are validated)
+			log.Printf("[ESCALATION] Warning: skipping rule %d for complaint %d: %v", rule.RuleID, candidate.ComplaintID, err)
+			continue
 		}
 
 		if conditions == nil {
@@ -174,7 +601,7 @@ func (s *EscalationService) processComplaintEscalation(
 
 		// Check if this is a reminder (not escalation)
 		if conditions.IsReminder {
-			reminderResult, err := s.processReminder(candidate, rule, conditions)
+			reminderResult, err := s.processReminder(ctx, run, candidate, rule, conditions, calendar)
 			if err != nil {
 				continue
 			}
@@ -191,14 +618,14 @@ func (s *EscalationService) processComplaintEscalation(
 		}
 
 		// Evaluate escalation conditions
-		shouldEscalate, reason := s.evaluateEscalationConditions(candidate, conditions)
+		shouldEscalate, reason := s.evaluateEscalationConditions(ctx, candidate, rule.EscalationLevel, conditions, calendar, run.now)
 		if !shouldEscalate {
 			log.Printf("[ESCALATION_DEBUG] skip complaint %d: SLA/conditions not satisfied - %s", candidate.ComplaintID, reason)
 			continue
 		}
 
 		// Check idempotency - don't escalate if already escalated at this level recently
-		alreadyEscalated, err := s.escalationRepo.HasExistingEscalation(
+		alreadyEscalated, err := s.escalationRepo.HasExistingEscalation(ctx,
 			candidate.ComplaintID,
 			rule.EscalationLevel,
 			1, // Within 1 hour
@@ -211,7 +638,7 @@ func (s *EscalationService) processComplaintEscalation(
 		}
 
 		// Perform escalation
-		return s.executeEscalation(candidate, rule, reason)
+		return s.executeEscalation(ctx, run, candidate, rule, reason)
 	}
 
 	return nil, nil // No escalation needed
@@ -241,12 +668,14 @@ func (s *EscalationService) ruleMatchesComplaint(
 }
 
 // evaluateEscalationConditions evaluates if escalation conditions are met
-func (s *EscalationService) evaluateEscalationConditions(
+// Time-based conditions count working time on the district's SLA calendar (test/dry-run minute overrides stay wall-clock)
+func (s *EscalationService) evaluateEscalationConditions(ctx context.Context,
 	candidate models.EscalationCandidate,
+	level int,
 	conditions *models.EscalationConditions,
+	calendar *sla.Calendar,
+	now time.Time,
 ) (bool, string) {
-	now := time.Now().UTC()
-
 	// Check status conditions
 	if len(conditions.Statuses) > 0 {
 		statusMatch := false
@@ -275,9 +704,10 @@ func (s *EscalationService) evaluateEscalationConditions(
 		}
 	}
 
-	// Check time-based conditions
+	// Check time-based conditions (time awaiting the citizen's reply does not count)
 	if conditions.TimeBased != nil {
 		timeBased := conditions.TimeBased
+		pauses := slaPauses(candidate)
 
 		// Check hours since last update
 		if timeBased.HoursSinceLastUpdate > 0 {
@@ -288,16 +718,16 @@ func (s *EscalationService) evaluateEscalationConditions(
 				lastUpdate = candidate.CreatedAt
 			}
 
-			hoursSinceUpdate := now.Sub(lastUpdate).Hours()
+			hoursSinceUpdate := calendar.WorkingTimeExcluding(lastUpdate, now, pauses).Hours()
 			if hoursSinceUpdate < float64(timeBased.HoursSinceLastUpdate) {
-				return false, fmt.Sprintf("Not enough time since last update: %.1f hours", hoursSinceUpdate)
+				return false, fmt.Sprintf("Not enough time since last update: %.1f working hours", hoursSinceUpdate)
 			}
 		}
 
-		// Check SLA hours (preferred) or legacy hours_since_status_change
-		slaHours := timeBased.SLAHours
-		if slaHours == 0 && timeBased.HoursSinceStatusChange > 0 {
-			slaHours = timeBased.HoursSinceStatusChange // Backward compatibility
+		// Check SLA hours (preferred), SLA policy or legacy hours_since_status_change
+		slaHours, slaSource, err := s.ruleSLAHours(ctx, candidate, level, timeBased)
+		if err != nil {
+			return false, err.Error()
 		}
 		if slaHours > 0 {
 			// Effective SLA in MINUTES for comparison
@@ -311,7 +741,12 @@ func (s *EscalationService) evaluateEscalationConditions(
 				effectiveSlaMinutes = float64(slaHours) * 60
 			}
 
-			minutesSinceStatusChange := now.Sub(candidate.LastStatusChangeAt).Minutes()
+			var minutesSinceStatusChange float64
+			if s.slaOverrideActive() {
+				minutesSinceStatusChange = sla.AlwaysOpen().WorkingTimeExcluding(candidate.LastStatusChangeAt, now, pauses).Minutes()
+			} else {
+				minutesSinceStatusChange = calendar.WorkingTimeExcluding(candidate.LastStatusChangeAt, now, pauses).Minutes()
+			}
 			if minutesSinceStatusChange < effectiveSlaMinutes {
 				if s.testEscalationOverrideMinutes > 0 {
 					return false, fmt.Sprintf("SLA not breached: %.1f minutes elapsed (test override: %d minutes)", minutesSinceStatusChange, s.testEscalationOverrideMinutes)
@@ -319,15 +754,17 @@ func (s *EscalationService) evaluateEscalationConditions(
 				if s.dryRun && s.dryRunSLAOverrideMinutes > 0 {
 					return false, fmt.Sprintf("[DRY RUN] SLA not breached: %.1f minutes elapsed (SLA override: %d minutes)", minutesSinceStatusChange, s.dryRunSLAOverrideMinutes)
 				}
-				return false, fmt.Sprintf("SLA not breached: %.1f hours elapsed (SLA: %d hours)", minutesSinceStatusChange/60, slaHours)
+				return false, fmt.Sprintf("SLA not breached: %.1f working hours elapsed (SLA: %d hours%s, due %s)",
+					minutesSinceStatusChange/60, slaHours, slaSource,
+					calendar.AddWorkingTimeExcluding(candidate.LastStatusChangeAt, time.Duration(slaHours)*time.Hour, pauses).Format(time.RFC3339))
 			}
 		}
 
 		// Check hours since creation
 		if timeBased.HoursSinceCreation > 0 {
-			hoursSinceCreation := now.Sub(candidate.CreatedAt).Hours()
+			hoursSinceCreation := calendar.WorkingTimeExcluding(candidate.CreatedAt, now, pauses).Hours()
 			if hoursSinceCreation < float64(timeBased.HoursSinceCreation) {
-				return false, fmt.Sprintf("Not enough time since creation: %.1f hours", hoursSinceCreation)
+				return false, fmt.Sprintf("Not enough time since creation: %.1f working hours", hoursSinceCreation)
 			}
 		}
 	}
@@ -335,9 +772,207 @@ func (s *EscalationService) evaluateEscalationConditions(
 	return true, "All conditions met"
 }
 
+// SLADueAt returns, per complaint, when the escalation rule for its current level will be due
+// (working time on the district's SLA calendar). Complaints the engine would not escalate
+// (other statuses, max level, no time-based rule) are absent from the map.
+func (s *EscalationService) SLADueAt(ctx context.Context, complaints []models.Complaint) (map[int64]time.Time, error) {
+	dueAt := make(map[int64]time.Time)
+	if len(complaints) == 0 {
+		return dueAt, nil
+	}
+
+	rules, err := s.escalationRepo.GetActiveEscalationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load escalation rules: %w", err)
+	}
+
+	for _, c := range complaints {
+		escalatable := false
+		for _, status := range escalationCandidateStatuses {
+			if c.CurrentStatus == status {
+				escalatable = true
+				break
+			}
+		}
+		if !escalatable {
+			continue
+		}
+
+		currentLevel, err := s.escalationRepo.GetLastEscalationLevel(ctx, c.ComplaintID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get escalation level: %w", err)
+		}
+		if currentLevel >= MaxEscalationLevel {
+			continue
+		}
+		lastChange, err := s.escalationRepo.GetLastStatusChangeAt(ctx, c.ComplaintID)
+		if err != nil {
+			return nil, err
+		}
+		pauses, err := s.escalationRepo.GetSLAPauses(ctx, c.ComplaintID)
+		if err != nil {
+			return nil, err
+		}
+		calendar, err := s.calendarFor(ctx, c.LocationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SLA calendar: %w", err)
+		}
+
+		candidate := models.EscalationCandidate{
+			ComplaintID:          c.ComplaintID,
+			ComplaintNumber:      c.ComplaintNumber,
+			CurrentStatus:        c.CurrentStatus,
+			Priority:             c.Priority,
+			Category:             c.Category,
+			AssignedDepartmentID: c.AssignedDepartmentID,
+			AssignedOfficerID:    c.AssignedOfficerID,
+			LocationID:           c.LocationID,
+			Pincode:              c.Pincode,
+			CreatedAt:            c.CreatedAt,
+			UpdatedAt:            c.UpdatedAt,
+			LastStatusChangeAt:   lastChange,
+			SLAPauses:            pauses,
+			Version:              c.Version,
+		}
+
+		// Earliest due time over the rules the engine would evaluate for this complaint
+		for _, rule := range rules {
+			if rule.EscalationLevel != currentLevel || !s.ruleMatchesComplaint(rule, candidate) {
+				continue
+			}
+			conditions, err := repository.ParseEscalationConditions(rule.Conditions)
+			if err != nil || conditions == nil || conditions.IsReminder {
+				continue
+			}
+			due, ok := s.ruleDueAt(ctx, candidate, currentLevel, conditions, calendar)
+			if !ok {
+				continue
+			}
+			if current, seen := dueAt[c.ComplaintID]; !seen || due.Before(current) {
+				dueAt[c.ComplaintID] = due
+			}
+		}
+	}
+
+	return dueAt, nil
+}
+
+// ruleDueAt returns when all time-based conditions of a rule are met (the latest of them).
+// Mirrors evaluateEscalationConditions; false if the rule does not apply or has no time condition.
+func (s *EscalationService) ruleDueAt(ctx context.Context,
+	candidate models.EscalationCandidate,
+	level int,
+	conditions *models.EscalationConditions,
+	calendar *sla.Calendar,
+) (time.Time, bool) {
+	if len(conditions.Statuses) > 0 && !slices.Contains(conditions.Statuses, string(candidate.CurrentStatus)) {
+		return time.Time{}, false
+	}
+	if len(conditions.Priorities) > 0 && !slices.Contains(conditions.Priorities, string(candidate.Priority)) {
+		return time.Time{}, false
+	}
+	timeBased := conditions.TimeBased
+	if timeBased == nil {
+		return time.Time{}, false
+	}
+
+	pauses := slaPauses(candidate)
+	var due time.Time
+	later := func(t time.Time) {
+		if t.After(due) {
+			due = t
+		}
+	}
+
+	if timeBased.HoursSinceLastUpdate > 0 {
+		lastUpdate := candidate.CreatedAt
+		if candidate.UpdatedAt.Valid {
+			lastUpdate = candidate.UpdatedAt.Time
+		}
+		later(calendar.AddWorkingTimeExcluding(lastUpdate, time.Duration(timeBased.HoursSinceLastUpdate)*time.Hour, pauses))
+	}
+
+	slaHours, _, err := s.ruleSLAHours(ctx, candidate, level, timeBased)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if slaHours > 0 {
+		switch {
+		case s.testEscalationOverrideMinutes > 0:
+			later(sla.AlwaysOpen().AddWorkingTimeExcluding(candidate.LastStatusChangeAt, time.Duration(s.testEscalationOverrideMinutes)*time.Minute, pauses))
+		case s.dryRun && s.dryRunSLAOverrideMinutes > 0:
+			later(sla.AlwaysOpen().AddWorkingTimeExcluding(candidate.LastStatusChangeAt, time.Duration(s.dryRunSLAOverrideMinutes)*time.Minute, pauses))
+		default:
+			later(calendar.AddWorkingTimeExcluding(candidate.LastStatusChangeAt, time.Duration(slaHours)*time.Hour, pauses))
+		}
+	}
+
+	if timeBased.HoursSinceCreation > 0 {
+		later(calendar.AddWorkingTimeExcluding(candidate.CreatedAt, time.Duration(timeBased.HoursSinceCreation)*time.Hour, pauses))
+	}
+
+	return due, !due.IsZero()
+}
+
+// ruleSLAHours returns the SLA hours of a time condition for a complaint at an escalation level and where they
+// come from: sla_hours (or the legacy hours_since_status_change), or the referenced SLA policy's target for the
+// complaint's current priority and category. 0 = no SLA condition. An error means the rule cannot fall due
+// (policy missing or inactive, or no target for this priority).
+func (s *EscalationService) ruleSLAHours(ctx context.Context,
+	candidate models.EscalationCandidate,
+	level int,
+	timeBased *models.TimeBasedCondition,
+) (int, string, error) {
+	if timeBased.SLAPolicyID == 0 {
+		if timeBased.SLAHours == 0 && timeBased.HoursSinceStatusChange > 0 {
+			return timeBased.HoursSinceStatusChange, "", nil // Backward compatibility
+		}
+		return timeBased.SLAHours, "", nil
+	}
+
+	if s.slaPolicyService == nil {
+		return 0, "", fmt.Errorf("SLA policy %d not available (no SLA policy service)", timeBased.SLAPolicyID)
+	}
+	policy, err := s.slaPolicyService.Policy(ctx, timeBased.SLAPolicyID)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to load SLA policy %d: %w", timeBased.SLAPolicyID, err)
+	}
+	if policy == nil {
+		return 0, "", fmt.Errorf("SLA policy %d does not exist or is inactive", timeBased.SLAPolicyID)
+	}
+	hours, ok := policy.Hours(string(candidate.Priority), level, candidate.Category.String)
+	if !ok {
+		return 0, "", fmt.Errorf("SLA policy %q has no target for priority %s at L%d", policy.Name, candidate.Priority, level+1)
+	}
+	return hours, fmt.Sprintf(" from policy %q", policy.Name), nil
+}
+
+// slaPauses returns the candidate's awaiting_citizen periods for the SLA calendar
+func slaPauses(candidate models.EscalationCandidate) []sla.Pause {
+	pauses := make([]sla.Pause, 0, len(candidate.SLAPauses))
+	for _, p := range candidate.SLAPauses {
+		pauses = append(pauses, sla.Pause{Start: p.Start, End: p.End})
+	}
+	return pauses
+}
+
+// slaOverrideActive reports whether SLA hours are replaced by wall-clock minutes (test override or dry run)
+func (s *EscalationService) slaOverrideActive() bool {
+	return s.testEscalationOverrideMinutes > 0 || (s.dryRun && s.dryRunSLAOverrideMinutes > 0)
+}
+
+// calendarFor returns the SLA calendar of a location (wall-clock when no calendar service is configured)
+func (s *EscalationService) calendarFor(ctx context.Context, locationID int64) (*sla.Calendar, error) {
+	if s.slaCalendarService == nil {
+		return sla.AlwaysOpen(), nil
+	}
+	return s.slaCalendarService.CalendarForLocation(ctx, locationID)
+}
+
 // executeEscalation performs the actual escalation
 // Escalation reassigns authority (department), not personnel (officers)
-func (s *EscalationService) executeEscalation(
+func (s *EscalationService) executeEscalation(ctx context.Context,
+	run *escalationRun,
 	candidate models.EscalationCandidate,
 	rule models.EscalationRule,
 	reason string,
@@ -361,42 +996,63 @@ func (s *EscalationService) executeEscalation(
 		toLocationID = rule.ToLocationID.Int64
 	}
 
-	// Authority lookup: department_id + location_id + current escalation level
-	targetLevel := rule.EscalationLevel + 1
-	log.Printf("[ESCALATION_DEBUG] FindAuthorityByDepartmentPincodeLevel inputs: department_id=%d location_id=%d current_level=%d (target L%d)",
-		targetDepartmentID, toLocationID, rule.EscalationLevel, targetLevel)
+	// Escalation must be a valid transition from the candidate's current status for the engine
+	// (system) or whoever requested it (citizen, officer)
+	actor := models.ActorSystem
+	if run.request != nil {
+		actor = run.request.actor()
+	}
+	if _, err := lifecycle.Validate(candidate.CurrentStatus, models.StatusEscalated, actor, reason); err != nil {
+		return nil, err
+	}
 
-	authorityID, err := s.escalationRepo.FindAuthorityByDepartmentPincodeLevel(
+	// Authority lookup: the assigned officer's reporting line, else the next authority level in the target
+	// department and location (level index 0 = L1 escalates to authority_level 2)
+	targetAuthorityLevel := rule.EscalationLevel + 2
+	fromOfficerID := int64(0)
+	if candidate.AssignedOfficerID.Valid {
+		fromOfficerID = candidate.AssignedOfficerID.Int64
+	}
+	log.Printf("[ESCALATION_DEBUG] FindEscalationOfficer inputs: department_id=%d location_id=%d current_level=%d (target L%d) from_officer_id=%d",
+		targetDepartmentID, toLocationID, rule.EscalationLevel, targetAuthorityLevel, fromOfficerID)
+
+	toOfficerID, err := s.escalationRepo.FindEscalationOfficer(ctx,
 		targetDepartmentID,
 		toLocationID,
-		rule.EscalationLevel,
+		targetAuthorityLevel,
+		fromOfficerID,
 	)
 	if err != nil {
 		log.Printf("[ESCALATION_DEBUG] skip complaint %d: authority lookup failed - %v", candidate.ComplaintID, err)
 		return nil, nil
 	}
-
-	var toOfficerID *int64
-	if authorityID != nil {
-		toOfficerID = authorityID
-		log.Printf("[ESCALATION_DEBUG] authority lookup returned officer_id=%d", *authorityID)
-	} else {
-		log.Printf("[ESCALATION_DEBUG] authority lookup returned 0 rows (no officer for dept=%d location=%d level=%d)", targetDepartmentID, toLocationID, rule.EscalationLevel)
-		// [PILOT] Escalate anyway when no authority found (level increment, department unchanged, officer unassigned).
-		log.Printf("[ESCALATION] WARNING [PILOT] No authority found for complaint %d - escalating anyway (level increment, department unchanged, officer unassigned)", candidate.ComplaintID)
-		toOfficerID = nil
+	if toOfficerID == nil {
+		// Never escalate to nobody: the complaint stays with its officer and an alert goes out; the engine retries next cycle
+		missing := &escalationPlan{
+			candidate:            candidate,
+			rule:                 rule,
+			reason:               reason,
+			targetDepartmentID:   targetDepartmentID,
+			targetLocationID:     toLocationID,
+			targetAuthorityLevel: targetAuthorityLevel,
+			request:              run.request,
+		}
+		if err := run.writer.targetMissing(ctx, missing); err != nil {
+			log.Printf("[ESCALATION] Warning: escalation_target_missing alert failed for complaint %d: %v", candidate.ComplaintID, err)
+		}
+		return nil, nil
 	}
+	log.Printf("[ESCALATION_DEBUG] authority lookup returned officer_id=%d", *toOfficerID)
 
-	// Update complaint status to "escalated" via status history
-	// Escalation reassigns to target department (authority), not individual officer
-	err = s.complaintRepo.UpdateComplaintStatus(
-		candidate.ComplaintID,
-		models.StatusEscalated,
-		&targetDepartmentID,
-		toOfficerID,
-	)
+	// An officer on leave hands new work to their delegate (unless that is who the complaint is coming from)
+	var delegatedFrom *models.OfficerAbsence
+	delegateID, absence, err := s.absenceService.ResolveDelegate(ctx, *toOfficerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update complaint status: %w", err)
+		log.Printf("[ESCALATION] Warning: delegate lookup failed for officer %d, escalating to them: %v", *toOfficerID, err)
+	} else if absence != nil && delegateID != fromOfficerID {
+		log.Printf("[ESCALATION_DEBUG] officer_id=%d on leave (absence %d), delegated to officer_id=%d", *toOfficerID, absence.AbsenceID, delegateID)
+		delegatedFrom = absence
+		toOfficerID = &delegateID
 	}
 
 	// Create status history entry (REQUIRED - escalation audit: system, no actor_id, reason)
@@ -404,6 +1060,9 @@ func (s *EscalationService) executeEscalation(
 	if s.dryRun {
 		reasonNote = fmt.Sprintf("[DRY RUN] Escalated to level %d: %s", rule.EscalationLevel, reason)
 	}
+	if delegatedFrom != nil {
+		reasonNote += fmt.Sprintf("; officer %d on leave, delegated to officer %d", delegatedFrom.OfficerID, delegatedFrom.DelegateOfficerID)
+	}
 	statusHistory := &models.ComplaintStatusHistory{
 		ComplaintID:   candidate.ComplaintID,
 		OldStatus:     sql.NullString{String: string(candidate.CurrentStatus), Valid: true},
@@ -414,27 +1073,27 @@ func (s *EscalationService) executeEscalation(
 		Reason:        sql.NullString{String: reasonNote, Valid: true},
 		Notes:         sql.NullString{String: reasonNote, Valid: true},
 	}
+	if run.request != nil {
+		// Requested by a citizen or officer: the history row names them
+		run.request.stampHistory(statusHistory)
+	}
 
 	// Set new assignment (authority reassignment)
 	statusHistory.AssignedDepartmentID = sql.NullInt64{Int64: targetDepartmentID, Valid: true}
-	if toOfficerID != nil {
-		statusHistory.AssignedOfficerID = sql.NullInt64{Int64: *toOfficerID, Valid: true}
-	}
-
-	err = s.complaintRepo.CreateStatusHistory(statusHistory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create status history: %w", err)
-	}
+	statusHistory.AssignedOfficerID = sql.NullInt64{Int64: *toOfficerID, Valid: true}
 
-	// Create escalation record (with link to status history)
+	// Escalation record (linked to status history once it is written)
 	// Escalation level stored is the CURRENT level before escalation (rule.EscalationLevel)
 	escalation := &models.ComplaintEscalation{
-		ComplaintID:        candidate.ComplaintID,
-		ToDepartmentID:     targetDepartmentID,
-		EscalationLevel:    rule.EscalationLevel, // Current level before escalation
-		EscalatedByType:    models.ActorSystem,
-		Reason:             sql.NullString{String: reason, Valid: true},
-		StatusHistoryID:    sql.NullInt64{Int64: statusHistory.HistoryID, Valid: true},
+		ComplaintID:     candidate.ComplaintID,
+		ToDepartmentID:  targetDepartmentID,
+		EscalationLevel: rule.EscalationLevel, // Current level before escalation
+		EscalatedByType: actor,
+		Reason:          sql.NullString{String: reason, Valid: true},
+	}
+	if run.request != nil {
+		escalation.EscalatedByUserID = sql.NullInt64{Int64: run.request.userID, Valid: run.request.userID != 0}
+		escalation.EscalatedByOfficerID = sql.NullInt64{Int64: run.request.officerID, Valid: run.request.officerID != 0}
 	}
 
 	if candidate.AssignedDepartmentID.Valid {
@@ -443,91 +1102,405 @@ func (s *EscalationService) executeEscalation(
 	if candidate.AssignedOfficerID.Valid {
 		escalation.FromOfficerID = candidate.AssignedOfficerID
 	}
-	if toOfficerID != nil {
-		escalation.ToOfficerID = sql.NullInt64{Int64: *toOfficerID, Valid: true}
+	escalation.ToOfficerID = sql.NullInt64{Int64: *toOfficerID, Valid: true}
+	plan := &escalationPlan{
+		candidate:            candidate,
+		rule:                 rule,
+		reason:               reason,
+		targetDepartmentID:   targetDepartmentID,
+		targetLocationID:     toLocationID,
+		targetAuthorityLevel: targetAuthorityLevel,
+		toOfficerID:          toOfficerID,
+		delegatedFrom:        delegatedFrom,
+		newLevel:             rule.EscalationLevel + 1,
+		statusHistory:        statusHistory,
+		escalation:           escalation,
+		request:              run.request,
+	}
+	err = run.writer.escalate(ctx, plan)
+	if errors.Is(err, repository.ErrVersionConflict) && run.request == nil {
+		// Lost the race (officer or another worker changed the complaint since it was read): skip, retry next cycle
+		log.Printf("[ESCALATION] skip complaint %d: modified concurrently (version %d), will retry next cycle", candidate.ComplaintID, candidate.Version)
+		return nil, nil
 	}
-
-	err = s.escalationRepo.CreateEscalation(escalation)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create escalation: %w", err)
+		return nil, err
+	}
+
+	result := &models.EscalationResult{
+		ComplaintID: candidate.ComplaintID,
+		Escalated:   true,
+		NewStatus:   stringPtr(string(models.StatusEscalated)),
+		Reason:      reason,
+		ProcessedAt: run.now,
 	}
-	newLevel := rule.EscalationLevel + 1
-	if err = s.complaintRepo.UpdateComplaintEscalationLevel(candidate.ComplaintID, newLevel); err != nil {
-		// Log but don't fail - column may not exist in all envs
-		log.Printf("[ESCALATION] Warning: could not update complaints.current_escalation_level: %v", err)
+	if escalation.EscalationID != 0 {
+		result.EscalationID = &escalation.EscalationID
 	}
-	log.Printf("[ESCALATION] ESCALATION FIRED complaint_id=%d new_escalation_level=%d (from %d) escalation_id=%d", candidate.ComplaintID, newLevel, rule.EscalationLevel, escalation.EscalationID)
+	return result, nil
+}
 
-	// Log to audit_log (REQUIRED)
-	auditData := map[string]interface{}{
-		"escalation_id":     escalation.EscalationID,
-		"escalation_level":  rule.EscalationLevel, // Current level before escalation
-		"from_department":  candidate.AssignedDepartmentID,
-		"to_department":     targetDepartmentID,
-		"reason":           reason,
+// escalationRun is one pass of the engine: the clock conditions are evaluated against and where decisions go
+type escalationRun struct {
+	now     time.Time
+	writer  escalationWriter
+	request *escalationRequest // set when a citizen or officer asked for this escalation (not the engine)
+}
+
+// escalationRequest is the person behind an escalation or transfer not started by the engine:
+// a citizen after an SLA breach (RequestEscalation) or an officer (EscalateByOfficer, TransferComplaint)
+type escalationRequest struct {
+	userID     int64                  // citizen; 0 for an officer
+	officerID  int64                  // officer; 0 for a citizen
+	onBehalfOf *models.OfficerAbsence // officer acting as delegate of the absent assigned officer; nil otherwise
+	ipAddress  string
+	userAgent  string
+}
+
+// actor returns who asked for the escalation
+func (r *escalationRequest) actor() models.ActorType {
+	if r.officerID != 0 {
+		return models.ActorOfficer
 	}
-	if s.dryRun {
-		auditData["dry_run"] = true
-		auditData["dry_run_sla_override_minutes"] = s.dryRunSLAOverrideMinutes
-		log.Printf("[DRY RUN] Escalation executed for complaint %d: L%d -> L%d", candidate.ComplaintID, rule.EscalationLevel, rule.EscalationLevel+1)
+	return models.ActorUser
+}
+
+// stampHistory names the requester as the actor of a status history row
+func (r *escalationRequest) stampHistory(h *models.ComplaintStatusHistory) {
+	if r.officerID != 0 {
+		officerID := sql.NullInt64{Int64: r.officerID, Valid: true}
+		h.ChangedByType = models.ActorOfficer
+		h.ChangedByOfficerID = officerID
+		h.ActorType = sql.NullString{String: string(models.StatusHistoryActorAuthority), Valid: true}
+		h.ActorID = officerID
+		if r.onBehalfOf != nil {
+			h.OnBehalfOfOfficerID = sql.NullInt64{Int64: r.onBehalfOf.OfficerID, Valid: true}
+		}
+		return
 	}
-	err = s.logEscalationAction(
-		candidate.ComplaintID,
-		"escalation",
-		auditData,
-	)
+	citizenID := sql.NullInt64{Int64: r.userID, Valid: true}
+	h.ChangedByType = models.ActorUser
+	h.ChangedByUserID = citizenID
+	h.ActorType = sql.NullString{String: string(models.StatusHistoryActorUser), Valid: true}
+	h.ActorID = citizenID
+}
+
+// escalationPlan is a fully resolved escalation (target, records) ready to be written.
+// For targetMissing only the target fields are set (no officer, no records).
+type escalationPlan struct {
+	candidate            models.EscalationCandidate
+	rule                 models.EscalationRule
+	reason               string
+	targetDepartmentID   int64
+	targetLocationID     int64
+	targetAuthorityLevel int // officers.authority_level looked up (2 = L2, 3 = L3)
+	toOfficerID          *int64
+	delegatedFrom        *models.OfficerAbsence // the officer looked up is on leave; toOfficerID is their delegate
+	newLevel             int
+	statusHistory        *models.ComplaintStatusHistory
+	escalation           *models.ComplaintEscalation
+	request              *escalationRequest // nil = escalated by the engine
+}
+
+// escalationWriter applies the engine's decisions. The engine logic (processComplaintEscalation) is the
+// same for live runs and what-if simulations; only the writer differs.
+type escalationWriter interface {
+	// escalate writes one escalation; repository.ErrVersionConflict if the complaint changed since it was read
+	escalate(ctx context.Context, p *escalationPlan) error
+	// targetMissing raises escalation_target_missing: the escalation is due but no officer can take it
+	targetMissing(ctx context.Context, p *escalationPlan) error
+	// remind records one reminder
+	remind(ctx context.Context, candidate models.EscalationCandidate, rule models.EscalationRule, reason string) error
+}
+
+// liveEscalationWriter commits decisions: status, history, escalation row and audit in one transaction,
+// then metrics and the shadow email
+type liveEscalationWriter struct {
+	s *EscalationService
+}
+
+func (w liveEscalationWriter) escalate(ctx context.Context, p *escalationPlan) error {
+	s := w.s
+	// Status update, history row, escalation record, level and audit row commit atomically
+	err := s.complaintRepo.InTx(ctx, func(tx *sql.Tx) error {
+		complaintRepo := s.complaintRepo.WithTx(tx)
+		escalationRepo := s.escalationRepo.WithTx(tx)
+
+		// Update complaint status to "escalated" via status history
+		// Escalation reassigns to target department (authority), not individual officer
+		if err := complaintRepo.UpdateComplaintStatus(ctx,
+			p.candidate.ComplaintID,
+			models.StatusEscalated,
+			&p.targetDepartmentID,
+			p.toOfficerID,
+			p.candidate.Version,
+		); err != nil {
+			return fmt.Errorf("failed to update complaint status: %w", err)
+		}
+
+		if err := complaintRepo.CreateStatusHistory(ctx, p.statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+
+		p.escalation.StatusHistoryID = sql.NullInt64{Int64: p.statusHistory.HistoryID, Valid: true}
+		if err := escalationRepo.CreateEscalation(ctx, p.escalation); err != nil {
+			return fmt.Errorf("failed to create escalation: %w", err)
+		}
+
+		if err := complaintRepo.UpdateComplaintEscalationLevel(ctx, p.candidate.ComplaintID, p.newLevel); err != nil {
+			// Log but don't fail - column may not exist in all envs
+			log.Printf("[ESCALATION] Warning: could not update complaints.current_escalation_level: %v", err)
+		}
+
+		// Log to audit_log (REQUIRED)
+		auditData := map[string]interface{}{
+			"escalation_id":    p.escalation.EscalationID,
+			"escalation_level": p.rule.EscalationLevel, // Current level before escalation
+			"from_department":  p.candidate.AssignedDepartmentID,
+			"to_department":    p.targetDepartmentID,
+			"reason":           p.reason,
+		}
+		if p.delegatedFrom != nil {
+			auditData["delegated_from_officer_id"] = p.delegatedFrom.OfficerID
+			auditData["absence_id"] = p.delegatedFrom.AbsenceID
+		}
+		if s.dryRun {
+			auditData["dry_run"] = true
+			auditData["dry_run_sla_override_minutes"] = s.dryRunSLAOverrideMinutes
+		}
+		if err := s.logEscalationAction(ctx, complaintRepo, p.candidate.ComplaintID, "escalation", auditData, p.request); err != nil {
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		// Log error but don't fail - audit logging should be resilient
+		return err
+	}
+	log.Printf("[ESCALATION] ESCALATION FIRED complaint_id=%d new_escalation_level=%d (from %d) escalation_id=%d", p.candidate.ComplaintID, p.newLevel, p.rule.EscalationLevel, p.escalation.EscalationID)
+	if s.dryRun {
+		log.Printf("[DRY RUN] Escalation executed for complaint %d: L%d -> L%d", p.candidate.ComplaintID, p.rule.EscalationLevel, p.rule.EscalationLevel+1)
 	}
 
 	// Emit pilot metrics: escalation_triggered
 	if s.pilotMetricsService != nil {
 		// Get user_id from complaint
-		complaint, err := s.complaintRepo.GetComplaintByID(candidate.ComplaintID)
+		complaint, err := s.complaintRepo.GetComplaintByID(ctx, p.candidate.ComplaintID)
 		userID := int64(0)
 		if err == nil {
 			userID = complaint.UserID
 		}
 		metadata := map[string]interface{}{
-			"escalation_level": rule.EscalationLevel,
-			"target_level":     rule.EscalationLevel + 1,
-			"from_department":  targetDepartmentID,
-			"to_department":     targetDepartmentID,
-			"reason":           reason,
+			"escalation_level": p.rule.EscalationLevel,
+			"target_level":     p.rule.EscalationLevel + 1,
+			"from_department":  p.targetDepartmentID,
+			"to_department":     p.targetDepartmentID,
+			"reason":           p.reason,
+			"escalated_by":     string(p.escalation.EscalatedByType),
 		}
-		s.pilotMetricsService.EmitEscalationTriggered(candidate.ComplaintID, userID, rule.EscalationLevel, metadata)
+		s.pilotMetricsService.EmitEscalationTriggered(ctx, p.candidate.ComplaintID, userID, p.rule.EscalationLevel, metadata)
 	}
 
 	// Pilot: send escalation email to shadow inbox only (async, non-blocking)
 	// Authority abstraction: department_id + level, not officer-based
 	if s.emailShadowService != nil {
-		deptID := targetDepartmentID
+		deptID := p.targetDepartmentID
 		deptName := fmt.Sprintf("Department %d", deptID)
-		s.emailShadowService.SendEscalationEmailAsync(
-			candidate.ComplaintID,
-			candidate.ComplaintNumber,
-			rule.EscalationLevel+1, // Target level (L2=1, L3=2)
+		s.emailShadowService.SendEscalationEmailAsync(ctx,
+			p.candidate.ComplaintID,
+			p.candidate.ComplaintNumber,
+			p.rule.EscalationLevel+1, // Target level (L2=1, L3=2)
 			deptID,
 			deptName,
-			reason,
+			p.reason,
 		)
 	}
 
-	return &models.EscalationResult{
-		ComplaintID:  candidate.ComplaintID,
-		Escalated:    true,
-		EscalationID: &escalation.EscalationID,
-		NewStatus:    stringPtr(string(models.StatusEscalated)),
-		Reason:       reason,
-		ProcessedAt:  time.Now().UTC(),
-	}, nil
+
+	return nil
+}
+
+// targetMissingAlertInterval limits escalation_target_missing to one alert per complaint per interval
+// (the engine finds the same gap every cycle until an officer is configured)
+const targetMissingAlertInterval = 24 * time.Hour
+
+func (w liveEscalationWriter) targetMissing(ctx context.Context, p *escalationPlan) error {
+	s := w.s
+	log.Printf("[ALERT] escalation_target_missing complaint_id=%d: no active officer at L%d or above in department_id=%d location_id=%d (escalation from L%d not applied)",
+		p.candidate.ComplaintID, p.targetAuthorityLevel, p.targetDepartmentID, p.targetLocationID, p.rule.EscalationLevel+1)
+
+	lastAlert, err := s.escalationRepo.GetLastTargetMissingAlertTime(ctx, p.candidate.ComplaintID)
+	if err != nil {
+		return err
+	}
+	if lastAlert != nil && time.Since(*lastAlert) < targetMissingAlertInterval {
+		return nil
+	}
+
+	metadata := map[string]interface{}{
+		"escalation_level":       p.rule.EscalationLevel,
+		"target_authority_level": p.targetAuthorityLevel,
+		"from_department":        p.candidate.AssignedDepartmentID.Int64,
+		"from_officer":           p.candidate.AssignedOfficerID.Int64,
+		"to_department":          p.targetDepartmentID,
+		"to_location":            p.targetLocationID,
+		"rule_id":                p.rule.RuleID,
+		"reason":                 p.reason,
+	}
+	if err := s.logEscalationAction(ctx, s.complaintRepo, p.candidate.ComplaintID, "escalation_target_missing", metadata, p.request); err != nil {
+		return err
+	}
+	if s.pilotMetricsService != nil {
+		userID := int64(0)
+		if complaint, err := s.complaintRepo.GetComplaintByID(ctx, p.candidate.ComplaintID); err == nil {
+			userID = complaint.UserID
+		}
+		s.pilotMetricsService.EmitEscalationTargetMissing(ctx, p.candidate.ComplaintID, userID, metadata)
+	}
+	return nil
+}
+
+func (w liveEscalationWriter) remind(ctx context.Context, candidate models.EscalationCandidate, rule models.EscalationRule, reason string) error {
+	// Log reminder to audit_log
+	err := w.s.logEscalationAction(ctx,
+		w.s.complaintRepo,
+		candidate.ComplaintID,
+		"reminder",
+		map[string]interface{}{
+			"reminder_reason": reason,
+			"rule_id":        rule.RuleID,
+		},
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log reminder: %w", err)
+	}
+	return nil
+}
+
+// simulationWriter records decisions without writing anything (SimulateEscalations)
+type simulationWriter struct {
+	sim *models.EscalationSimulation
+}
+
+func (w simulationWriter) escalate(ctx context.Context, p *escalationPlan) error {
+	toLevel := p.newLevel
+	toDepartmentID := p.targetDepartmentID
+	w.sim.Actions = append(w.sim.Actions, models.SimulatedEscalationAction{
+		ComplaintID:     p.candidate.ComplaintID,
+		ComplaintNumber: p.candidate.ComplaintNumber,
+		Action:          "escalate",
+		Rule:            simulatedRuleLabel(p.rule),
+		FromLevel:       p.rule.EscalationLevel,
+		ToLevel:         &toLevel,
+		ToDepartmentID:  &toDepartmentID,
+		ToOfficerID:     p.toOfficerID,
+		Reason:          p.reason,
+	})
+	return nil
+}
+
+func (w simulationWriter) targetMissing(ctx context.Context, p *escalationPlan) error {
+	toDepartmentID := p.targetDepartmentID
+	w.sim.Actions = append(w.sim.Actions, models.SimulatedEscalationAction{
+		ComplaintID:     p.candidate.ComplaintID,
+		ComplaintNumber: p.candidate.ComplaintNumber,
+		Action:          "target_missing",
+		Rule:            simulatedRuleLabel(p.rule),
+		FromLevel:       p.rule.EscalationLevel,
+		ToDepartmentID:  &toDepartmentID,
+		Reason:          fmt.Sprintf("no active officer at L%d or above in department %d, location %d", p.targetAuthorityLevel, p.targetDepartmentID, p.targetLocationID),
+	})
+	return nil
+}
+
+func (w simulationWriter) remind(ctx context.Context, candidate models.EscalationCandidate, rule models.EscalationRule, reason string) error {
+	w.sim.Actions = append(w.sim.Actions, models.SimulatedEscalationAction{
+		ComplaintID:     candidate.ComplaintID,
+		ComplaintNumber: candidate.ComplaintNumber,
+		Action:          "reminder",
+		Rule:            simulatedRuleLabel(rule),
+		FromLevel:       rule.EscalationLevel,
+		Reason:          reason,
+	})
+	return nil
+}
+
+// simulatedRuleLabel names a rule in simulation output. Proposed rules carry RuleID -(index+1).
+func simulatedRuleLabel(rule models.EscalationRule) string {
+	if rule.RuleID < 0 {
+		return fmt.Sprintf("proposed[%d]", -rule.RuleID-1)
+	}
+	return fmt.Sprintf("rule %d", rule.RuleID)
+}
+
+// SimulateEscalations runs the escalation engine against the current candidates with a simulated clock
+// and writes nothing: it reports which complaints would get reminders or escalate, and to whom
+//
+// Rules:
+// 1. proposed nil = the current active rules; otherwise the proposed set replaces them (inactive entries ignored)
+// 2. Proposed rules are validated like the admin API (ValidateEscalationRule, SLA policy exists)
+// 3. Candidates, escalation levels and officer lookups are read live; only "now" is simulated
+// 4. Idempotency checks (recent escalation/reminder) use the database clock, as in a live run
+func (s *EscalationService) SimulateEscalations(ctx context.Context, proposed []models.EscalationRule, now time.Time) (*models.EscalationSimulation, error) {
+	sim := &models.EscalationSimulation{
+		SimulatedNow: now.UTC(),
+		RuleSet:      "current",
+		Actions:      []models.SimulatedEscalationAction{},
+		Skipped:      []models.SimulationSkip{},
+	}
+
+	var rules []models.EscalationRule
+	if proposed == nil {
+		active, err := s.escalationRepo.GetActiveEscalationRules(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load escalation rules: %w", err)
+		}
+		rules = active
+	} else {
+		sim.RuleSet = "proposed"
+		for i := range proposed {
+			rule := proposed[i]
+			conditions, err := ValidateEscalationRule(&rule)
+			if err == nil {
+				err = checkRuleSLAPolicy(ctx, s.slaPolicyService, conditions)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("rules[%d]: %w", i, err)
+			}
+			if !rule.IsActive {
+				continue
+			}
+			rule.RuleID = -int64(i + 1)
+			rules = append(rules, rule)
+		}
+	}
+	sim.Rules = len(rules)
+	if len(rules) == 0 {
+		return sim, nil
+	}
+
+	candidates, err := s.escalationRepo.GetEscalationCandidates(ctx, escalationCandidateStatuses, 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation candidates: %w", err)
+	}
+	sim.Candidates = len(candidates)
+
+	run := &escalationRun{now: sim.SimulatedNow, writer: simulationWriter{sim: sim}}
+	for _, candidate := range candidates {
+		if _, err := s.processComplaintEscalation(ctx, run, candidate, rules); err != nil {
+			sim.Skipped = append(sim.Skipped, models.SimulationSkip{ComplaintID: candidate.ComplaintID, Error: err.Error()})
+		}
+	}
+	return sim, nil
 }
 
 // processReminder processes a reminder (not escalation)
-func (s *EscalationService) processReminder(
+func (s *EscalationService) processReminder(ctx context.Context,
+	run *escalationRun,
 	candidate models.EscalationCandidate,
 	rule models.EscalationRule,
 	conditions *models.EscalationConditions,
+	calendar *sla.Calendar,
 ) (*models.ReminderResult, error) {
 	// Check if reminder interval has passed
 	if conditions.ReminderIntervalHours == nil || *conditions.ReminderIntervalHours == 0 {
@@ -535,28 +1508,28 @@ func (s *EscalationService) processReminder(
 	}
 
 	// Get last reminder time
-	lastReminder, err := s.escalationRepo.GetLastReminderTime(candidate.ComplaintID)
+	lastReminder, err := s.escalationRepo.GetLastReminderTime(ctx, candidate.ComplaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get last reminder time: %w", err)
 	}
 
-	now := time.Now().UTC()
+	now := run.now
 	shouldSendReminder := false
 	reason := ""
 
 	if lastReminder == nil {
 		// Never sent reminder, check if conditions are met
-		shouldEscalate, reason := s.evaluateEscalationConditions(candidate, conditions)
+		shouldEscalate, conditionReason := s.evaluateEscalationConditions(ctx, candidate, rule.EscalationLevel, conditions, calendar, now)
 		if shouldEscalate {
 			shouldSendReminder = true
-			reason = "First reminder: " + reason
+			reason = "First reminder: " + conditionReason
 		}
 	} else {
 		// Check if reminder interval has passed
-		hoursSinceLastReminder := now.Sub(*lastReminder).Hours()
+		hoursSinceLastReminder := calendar.WorkingTimeExcluding(*lastReminder, now, slaPauses(candidate)).Hours()
 		if hoursSinceLastReminder >= float64(*conditions.ReminderIntervalHours) {
 			shouldSendReminder = true
-			reason = fmt.Sprintf("Reminder sent (last reminder %.1f hours ago)", hoursSinceLastReminder)
+			reason = fmt.Sprintf("Reminder sent (last reminder %.1f working hours ago)", hoursSinceLastReminder)
 		}
 	}
 
@@ -564,17 +1537,8 @@ func (s *EscalationService) processReminder(
 		return nil, nil
 	}
 
-	// Log reminder to audit_log
-	err = s.logEscalationAction(
-		candidate.ComplaintID,
-		"reminder",
-		map[string]interface{}{
-			"reminder_reason": reason,
-			"rule_id":        rule.RuleID,
-		},
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to log reminder: %w", err)
+	if err := run.writer.remind(ctx, candidate, rule, reason); err != nil {
+		return nil, err
 	}
 
 	return &models.ReminderResult{
@@ -586,11 +1550,17 @@ func (s *EscalationService) processReminder(
 }
 
 // logEscalationAction logs escalation/reminder actions to audit_log
-func (s *EscalationService) logEscalationAction(
+// complaintRepo may be transaction-scoped (WithTx) so the row commits with the escalation
+func (s *EscalationService) logEscalationAction(ctx context.Context,
+	complaintRepo *repository.ComplaintRepository,
 	complaintID int64,
 	action string,
 	metadata map[string]interface{},
+	request *escalationRequest, // nil = the engine
 ) error {
+	if request != nil && request.onBehalfOf != nil {
+		metadata["on_behalf_of_officer_id"] = request.onBehalfOf.OfficerID
+	}
 	metadataJSON, err := json.Marshal(metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
@@ -603,8 +1573,15 @@ func (s *EscalationService) logEscalationAction(
 		ActionByType: models.ActorSystem,
 		Metadata:     sql.NullString{String: string(metadataJSON), Valid: true},
 	}
+	if request != nil {
+		auditLog.ActionByType = request.actor()
+		auditLog.ActionByUserID = sql.NullInt64{Int64: request.userID, Valid: request.userID != 0}
+		auditLog.ActionByOfficerID = sql.NullInt64{Int64: request.officerID, Valid: request.officerID != 0}
+		auditLog.IPAddress = sql.NullString{String: request.ipAddress, Valid: request.ipAddress != ""}
+		auditLog.UserAgent = sql.NullString{String: request.userAgent, Valid: request.userAgent != ""}
+	}
 
-	err = s.complaintRepo.CreateAuditLog(auditLog)
+	err = complaintRepo.CreateAuditLog(ctx, auditLog)
 	if err != nil {
 		return fmt.Errorf("failed to create audit log: %w", err)
 	}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"finalneta/models"
+	"finalneta/repository"
+)
+
+// complaintRowDriver is a database/sql driver backed by a single complaints row.
+// It applies "UPDATE complaints SET col = ?, ... WHERE complaint_id = ? AND version = ?" statements
+// and answers the escalation candidate query, which is enough to follow a complaint through a transfer.
+type complaintRowDriver struct {
+	row map[string]driver.Value
+}
+
+var (
+	setColumn         = regexp.MustCompile(`(\w+) = \?`)
+	candidateColumns  = []string{"complaint_id", "complaint_number", "current_status", "priority", "category", "assigned_department_id", "assigned_officer_id", "location_id", "pincode", "created_at", "updated_at", "version", "last_status_change_at"}
+	errNotImplemented = errors.New("complaintRowDriver: statement not supported")
+)
+
+func (d *complaintRowDriver) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d *complaintRowDriver) Driver() driver.Driver                        { return d }
+func (d *complaintRowDriver) Open(string) (driver.Conn, error)             { return d, nil }
+func (d *complaintRowDriver) Prepare(string) (driver.Stmt, error) {
+	return nil, errNotImplemented
+}
+func (d *complaintRowDriver) Close() error              { return nil }
+func (d *complaintRowDriver) Begin() (driver.Tx, error) { return nil, errNotImplemented }
+
+func (d *complaintRowDriver) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	set, where, ok := strings.Cut(query, "WHERE")
+	if !strings.Contains(set, "UPDATE complaints") || !ok {
+		return nil, errNotImplemented
+	}
+	columns := setColumn.FindAllStringSubmatch(set, -1)
+	if len(args) != len(columns)+2 || !strings.Contains(where, "complaint_id = ? AND version = ?") {
+		return nil, errNotImplemented
+	}
+	if args[len(columns)].Value != d.row["complaint_id"] || args[len(columns)+1].Value != d.row["version"] {
+		return driver.RowsAffected(0), nil
+	}
+	for i, c := range columns {
+		d.row[c[1]] = args[i].Value
+	}
+	d.row["version"] = d.row["version"].(int64) + 1
+	return driver.RowsAffected(1), nil
+}
+
+func (d *complaintRowDriver) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "FROM complaints c") {
+		return nil, errNotImplemented
+	}
+	values := make([]driver.Value, len(candidateColumns))
+	for i, c := range candidateColumns {
+		values[i] = d.row[c]
+	}
+	return &complaintRows{values: values}, nil
+}
+
+type complaintRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *complaintRows) Columns() []string { return candidateColumns }
+func (r *complaintRows) Close() error      { return nil }
+func (r *complaintRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestTransferredComplaintMatchesRulesForNewLocation(t *testing.T) {
+	created := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	rowDriver := &complaintRowDriver{row: map[string]driver.Value{
+		"complaint_id":           int64(7),
+		"complaint_number":       "COMP-20261012-7",
+		"current_status":         string(models.StatusUnderReview),
+		"priority":               "medium",
+		"assigned_department_id": int64(1),
+		"assigned_officer_id":    int64(3),
+		"location_id":            int64(10),
+		"created_at":             created,
+		"version":                int64(4),
+		"last_status_change_at":  created,
+	}}
+	db := sql.OpenDB(rowDriver)
+	defer db.Close()
+	ctx := context.Background()
+	complaintRepo := repository.NewComplaintRepository(db)
+	escalationRepo := repository.NewEscalationRepository(db)
+
+	if err := complaintRepo.UpdateComplaintAssignment(ctx, 7, 2, 20, 9, 4); err != nil {
+		t.Fatalf("UpdateComplaintAssignment: %v", err)
+	}
+	if err := complaintRepo.UpdateComplaintAssignment(ctx, 7, 2, 20, 9, 4); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("UpdateComplaintAssignment at a stale version: err = %v, want ErrVersionConflict", err)
+	}
+
+	candidate, err := escalationRepo.GetEscalationCandidate(ctx, 7)
+	if err != nil || candidate == nil {
+		t.Fatalf("GetEscalationCandidate = %v, %v", candidate, err)
+	}
+	if candidate.LocationID != 20 || candidate.AssignedDepartmentID.Int64 != 2 || candidate.AssignedOfficerID.Int64 != 9 {
+		t.Fatalf("candidate after transfer: location %d, department %d, officer %d; want 20, 2, 9",
+			candidate.LocationID, candidate.AssignedDepartmentID.Int64, candidate.AssignedOfficerID.Int64)
+	}
+
+	rule := func(departmentID, locationID int64) models.EscalationRule {
+		return models.EscalationRule{
+			FromDepartmentID: sql.NullInt64{Int64: departmentID, Valid: true},
+			FromLocationID:   sql.NullInt64{Int64: locationID, Valid: true},
+		}
+	}
+	s := &EscalationService{}
+	tests := []struct {
+		name string
+		rule models.EscalationRule
+		want bool
+	}{
+		{"new department and location", rule(2, 20), true},
+		{"new location, any department", models.EscalationRule{FromLocationID: sql.NullInt64{Int64: 20, Valid: true}}, true},
+		{"old department and location", rule(1, 10), false},
+		{"new department at the old location", rule(2, 10), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.ruleMatchesComplaint(tt.rule, *candidate); got != tt.want {
+				t.Errorf("ruleMatchesComplaint = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,23 +1,43 @@
 package service
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"finalneta/models"
 	"finalneta/repository"
+	"finalneta/storage"
 	"finalneta/utils"
 	"fmt"
+	"log"
+	"math"
 	"time"
 )
 
+// evidenceSweepBatchSize is how many evidence rows the integrity sweep loads per query
+const evidenceSweepBatchSize = 200
+
 // EvidenceService handles evidence integrity verification logic
 type EvidenceService struct {
-	evidenceRepo *repository.EvidenceRepository
+	evidenceRepo   *repository.EvidenceRepository
+	complaintRepo  *repository.ComplaintRepository
+	absenceService *OfficerAbsenceService // optional; nil = only the assigned officer verifies
+	blob           storage.Blob           // attachment file_path is the storage key
 }
 
 // NewEvidenceService creates a new evidence service
-func NewEvidenceService(evidenceRepo *repository.EvidenceRepository) *EvidenceService {
+func NewEvidenceService(
+	evidenceRepo *repository.EvidenceRepository,
+	complaintRepo *repository.ComplaintRepository,
+	absenceService *OfficerAbsenceService,
+	blob storage.Blob,
+) *EvidenceService {
 	return &EvidenceService{
-		evidenceRepo: evidenceRepo,
+		evidenceRepo:   evidenceRepo,
+		complaintRepo:  complaintRepo,
+		absenceService: absenceService,
+		blob:           blob,
 	}
 }
 
@@ -28,7 +48,7 @@ func NewEvidenceService(evidenceRepo *repository.EvidenceRepository) *EvidenceSe
 // complaint_evidence is WRITE-ONCE: no updates to evidence_hash, latitude, longitude, or
 // captured_at after insert. This service does not expose any update method; immutability
 // is enforced at the service layer.
-func (s *EvidenceService) CreateEvidenceRecord(
+func (s *EvidenceService) CreateEvidenceRecord(ctx context.Context,
 	attachmentID int64,
 	complaintID int64,
 	imageBytes []byte,
@@ -36,50 +56,266 @@ func (s *EvidenceService) CreateEvidenceRecord(
 	longitude *float64,
 	capturedAt time.Time,
 ) (*models.ComplaintEvidence, error) {
+	evidence := newEvidenceRecord(attachmentID, complaintID, imageBytes, latitude, longitude, capturedAt)
+
+	// Store in database
+	err := s.evidenceRepo.CreateEvidence(ctx, evidence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create evidence record: %w", err)
+	}
+
+	return evidence, nil
+}
+
+// newEvidenceRecord builds an evidence row and its hash without storing it.
+// captured_at is truncated to whole seconds and lat/lng rounded to 8 decimals before hashing,
+// matching the precision of the complaint_evidence columns, so the hash can be recomputed
+// from the stored row and the original file.
+func newEvidenceRecord(
+	attachmentID int64,
+	complaintID int64,
+	imageBytes []byte,
+	latitude *float64,
+	longitude *float64,
+	capturedAt time.Time,
+) *models.ComplaintEvidence {
+	capturedAt = capturedAt.UTC().Truncate(time.Second)
+
 	// Default latitude/longitude if not provided
 	lat := 0.0
 	lon := 0.0
 	if latitude != nil {
-		lat = *latitude
+		lat = roundCoordinate(*latitude)
 	}
 	if longitude != nil {
-		lon = *longitude
+		lon = roundCoordinate(*longitude)
 	}
 
 	// Generate evidence hash (server-side, immutable)
 	evidenceHash := utils.GenerateEvidenceHash(imageBytes, lat, lon, capturedAt)
 
-	// Create evidence record
 	evidence := &models.ComplaintEvidence{
 		AttachmentID: attachmentID,
-		ComplaintID:   complaintID,
-		EvidenceHash:  evidenceHash,
-		CapturedAt:    capturedAt, // Server timestamp
+		ComplaintID:  complaintID,
+		EvidenceHash: evidenceHash,
+		CapturedAt:   capturedAt, // Server timestamp
 	}
 
 	// Set latitude/longitude if provided
 	if latitude != nil {
-		evidence.Latitude = sql.NullFloat64{Float64: *latitude, Valid: true}
+		evidence.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
 	}
 	if longitude != nil {
-		evidence.Longitude = sql.NullFloat64{Float64: *longitude, Valid: true}
+		evidence.Longitude = sql.NullFloat64{Float64: lon, Valid: true}
 	}
 
-	// Store in database
-	err := s.evidenceRepo.CreateEvidence(evidence)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create evidence record: %w", err)
-	}
+	return evidence
+}
 
-	return evidence, nil
+// roundCoordinate rounds to 8 decimal places (DECIMAL(x, 8) in complaint_evidence)
+func roundCoordinate(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
 }
 
 // GetEvidenceByAttachmentID retrieves evidence record for an attachment
-func (s *EvidenceService) GetEvidenceByAttachmentID(attachmentID int64) (*models.ComplaintEvidence, error) {
-	return s.evidenceRepo.GetEvidenceByAttachmentID(attachmentID)
+func (s *EvidenceService) GetEvidenceByAttachmentID(ctx context.Context, attachmentID int64) (*models.ComplaintEvidence, error) {
+	return s.evidenceRepo.GetEvidenceByAttachmentID(ctx, attachmentID)
 }
 
 // GetEvidenceByComplaintID retrieves all evidence records for a complaint
-func (s *EvidenceService) GetEvidenceByComplaintID(complaintID int64) ([]models.ComplaintEvidence, error) {
-	return s.evidenceRepo.GetEvidenceByComplaintID(complaintID)
+func (s *EvidenceService) GetEvidenceByComplaintID(ctx context.Context, complaintID int64) ([]models.ComplaintEvidence, error) {
+	return s.evidenceRepo.GetEvidenceByComplaintID(ctx, complaintID)
+}
+
+// VerifyAttachment recomputes the evidence hash of one attachment from the stored file and the
+// recorded lat/lng/captured_at, and reports match or mismatch. Officers may only verify complaints
+// assigned to them. Every check is written to audit_log (action evidence_verified).
+func (s *EvidenceService) VerifyAttachment(ctx context.Context,
+	complaintID int64,
+	attachmentID int64,
+	actorType models.ActorType,
+	officerID *int64,
+) (*models.EvidenceVerificationResult, error) {
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, err
+	}
+	// The assigned officer, or their delegate while they are on leave
+	var onBehalfOf *models.OfficerAbsence
+	if actorType == models.ActorOfficer {
+		if officerID == nil {
+			return nil, fmt.Errorf("complaint not assigned to this authority")
+		}
+		onBehalfOf, err = s.absenceService.ActingFor(ctx, complaint, *officerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attachment, err := s.complaintRepo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.ComplaintID != complaintID {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	evidence, err := s.evidenceRepo.GetEvidenceByAttachmentID(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.verifyEvidence(ctx, evidence, attachment)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog := evidenceAuditLog(result, "evidence_verified", actorType, onBehalfOf)
+	if officerID != nil {
+		auditLog.ActionByOfficerID = sql.NullInt64{Int64: *officerID, Valid: true}
+	}
+	if err := s.complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+		// Log error but don't fail the operation
+		// Audit logging should be resilient
+		log.Printf("[evidence] Warning: failed to create audit log for attachment ID=%d: %v", attachmentID, err)
+	}
+
+	return result, nil
+}
+
+// SweepEvidence re-verifies every complaint_evidence row. A row whose status changed since its last
+// integrity audit is written to audit_log (actor system): evidence_integrity_failed for a mismatch or
+// missing file, evidence_integrity_restored when a failed row matches again. A failure already on record
+// is not logged again. Returns the number of rows checked and the number of failures.
+func (s *EvidenceService) SweepEvidence(ctx context.Context) (checked int, failed int, err error) {
+	var afterID int64
+	for {
+		batch, err := s.evidenceRepo.ListEvidenceAfter(ctx, afterID, evidenceSweepBatchSize)
+		if err != nil {
+			return checked, failed, fmt.Errorf("failed to list evidence: %w", err)
+		}
+		if len(batch) == 0 {
+			return checked, failed, nil
+		}
+
+		for i := range batch {
+			if ctx.Err() != nil {
+				return checked, failed, fmt.Errorf("sweep interrupted: %w", ctx.Err())
+			}
+			evidence := &batch[i]
+			afterID = evidence.EvidenceID
+
+			attachment, err := s.complaintRepo.GetAttachmentByID(ctx, evidence.AttachmentID)
+			if err != nil {
+				log.Printf("[EVIDENCE_SWEEP] evidence_id=%d attachment lookup failed: %v", evidence.EvidenceID, err)
+				continue
+			}
+
+			result, err := s.verifyEvidence(ctx, evidence, attachment)
+			if err != nil {
+				log.Printf("[EVIDENCE_SWEEP] evidence_id=%d skipped: %v", evidence.EvidenceID, err)
+				continue
+			}
+			checked++
+			if result.Status != models.EvidenceMatch {
+				failed++
+			}
+
+			last, err := s.evidenceRepo.GetLastIntegrityStatus(ctx, evidence.ComplaintID, evidence.EvidenceID)
+			if err != nil {
+				log.Printf("[EVIDENCE_SWEEP] evidence_id=%d integrity history lookup failed: %v", evidence.EvidenceID, err)
+				continue
+			}
+			action := sweepAuditAction(last, result.Status)
+			if action == "" {
+				continue
+			}
+			log.Printf("[EVIDENCE_SWEEP] complaint_id=%d attachment_id=%d status=%s (was %q)", result.ComplaintID, result.AttachmentID, result.Status, last)
+			if err := s.complaintRepo.CreateAuditLog(ctx, evidenceAuditLog(result, action, models.ActorSystem, nil)); err != nil {
+				log.Printf("[EVIDENCE_SWEEP] audit log failed for attachment_id=%d: %v", result.AttachmentID, err)
+			}
+		}
+	}
+}
+
+// sweepAuditAction is the audit action for a sweep result given the last recorded integrity status
+// ("" = never recorded), or "" when nothing changed
+func sweepAuditAction(last, current models.EvidenceCheckStatus) string {
+	switch {
+	case current == models.EvidenceMatch && last != "" && last != models.EvidenceMatch:
+		return "evidence_integrity_restored"
+	case current != models.EvidenceMatch && current != last:
+		return "evidence_integrity_failed"
+	default:
+		return ""
+	}
+}
+
+// verifyEvidence reads the stored file and recomputes the hash with the recorded metadata.
+// Returns an error only when storage is unreachable, so outages are not reported as tampering.
+func (s *EvidenceService) verifyEvidence(ctx context.Context, evidence *models.ComplaintEvidence, attachment *models.ComplaintAttachment) (*models.EvidenceVerificationResult, error) {
+	result := &models.EvidenceVerificationResult{
+		EvidenceID:   evidence.EvidenceID,
+		AttachmentID: evidence.AttachmentID,
+		ComplaintID:  evidence.ComplaintID,
+		StoredHash:   evidence.EvidenceHash,
+		CapturedAt:   evidence.CapturedAt,
+		CheckedAt:    time.Now().UTC(),
+	}
+	if evidence.Latitude.Valid {
+		result.Latitude = &evidence.Latitude.Float64
+	}
+	if evidence.Longitude.Valid {
+		result.Longitude = &evidence.Longitude.Float64
+	}
+
+	// Legacy rows may hold a URL instead of a storage key; those count as missing
+	if storage.ValidateKey(attachment.FilePath) != nil {
+		result.Status = models.EvidenceFileMissing
+		return result, nil
+	}
+	imageBytes, err := storage.ReadAll(ctx, s.blob, attachment.FilePath)
+	if errors.Is(err, storage.ErrNotFound) {
+		result.Status = models.EvidenceFileMissing
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment from storage: %w", err)
+	}
+
+	// NULL lat/lng were hashed as 0 (see newEvidenceRecord)
+	result.ComputedHash = utils.GenerateEvidenceHash(
+		imageBytes,
+		evidence.Latitude.Float64,
+		evidence.Longitude.Float64,
+		evidence.CapturedAt.UTC(),
+	)
+	if result.ComputedHash == evidence.EvidenceHash {
+		result.Status = models.EvidenceMatch
+	} else {
+		result.Status = models.EvidenceMismatch
+	}
+	return result, nil
+}
+
+// evidenceAuditLog builds the audit_log row for a verification result (entity: the complaint)
+func evidenceAuditLog(result *models.EvidenceVerificationResult, action string, actorType models.ActorType, onBehalfOf *models.OfficerAbsence) *models.AuditLog {
+	metadata := map[string]interface{}{
+		"evidence_id":   result.EvidenceID,
+		"attachment_id": result.AttachmentID,
+		"status":        string(result.Status),
+		"stored_hash":   result.StoredHash,
+		"computed_hash": result.ComputedHash,
+		"checked_at":    result.CheckedAt,
+	}
+	if onBehalfOf != nil {
+		metadata["on_behalf_of_officer_id"] = onBehalfOf.OfficerID
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	return &models.AuditLog{
+		EntityType:   "complaint",
+		EntityID:     result.ComplaintID,
+		Action:       action,
+		ActionByType: actorType,
+		Metadata:     sql.NullString{String: string(metadataJSON), Valid: true},
+	}
 }
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"finalneta/models"
+)
+
+func TestSweepAuditAction(t *testing.T) {
+	const (
+		match    = models.EvidenceMatch
+		mismatch = models.EvidenceMismatch
+		missing  = models.EvidenceFileMissing
+	)
+	tests := []struct {
+		name          string
+		last, current models.EvidenceCheckStatus
+		want          string
+	}{
+		{"match, never recorded", "", match, ""},
+		{"match, last check matched", match, match, ""},
+		{"first failure", "", mismatch, "evidence_integrity_failed"},
+		{"failure after a match", match, missing, "evidence_integrity_failed"},
+		{"same failure again", mismatch, mismatch, ""},
+		{"same missing file again", missing, missing, ""},
+		{"failure changed", mismatch, missing, "evidence_integrity_failed"},
+		{"restored", mismatch, match, "evidence_integrity_restored"},
+		{"file back", missing, match, "evidence_integrity_restored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sweepAuditAction(tt.last, tt.current); got != tt.want {
+				t.Errorf("sweepAuditAction(%q, %q) = %q, want %q", tt.last, tt.current, got, tt.want)
+			}
+		})
+	}
+}
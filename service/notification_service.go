@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"finalneta/models"
 	"finalneta/notification"
@@ -50,7 +52,7 @@ func NewNotificationService(
 // QueueNotification queues a notification for async sending
 // This method is non-blocking and returns immediately
 // The notification will be processed by the background worker
-func (s *NotificationService) QueueNotification(req *models.NotificationRequest) (*models.NotificationResult, error) {
+func (s *NotificationService) QueueNotification(ctx context.Context, req *models.NotificationRequest) (*models.NotificationResult, error) {
 	// Set defaults
 	maxRetries := s.config.DefaultMaxRetries
 	if req.MaxRetries != nil {
@@ -97,13 +99,13 @@ func (s *NotificationService) QueueNotification(req *models.NotificationRequest)
 	}
 
 	// Save to database (this is the "queue")
-	err := s.repo.CreateNotification(notification)
+	err := s.repo.CreateNotification(ctx, notification)
 	if err != nil {
 		return nil, fmt.Errorf("failed to queue notification: %w", err)
 	}
 
 	// Log to audit_log (REQUIRED)
-	err = s.logNotificationTriggered(notification)
+	err = s.logNotificationTriggered(ctx, notification)
 	if err != nil {
 		// Log error but don't fail - audit logging should be resilient
 		// Notification is already queued, so we continue
@@ -131,7 +133,7 @@ func (s *NotificationService) ProcessNotification(ctx context.Context, notificat
 	if err != nil {
 		// Validation failed - mark as failed
 		errorMsg := fmt.Sprintf("validation failed: %v", err)
-		return s.handleNotificationFailure(notification, errorMsg)
+		return s.handleNotificationFailure(ctx, notification, errorMsg)
 	}
 
 	// Attempt to send
@@ -139,18 +141,18 @@ func (s *NotificationService) ProcessNotification(ctx context.Context, notificat
 	err = sender.Send(ctx, notification)
 	
 	// Log attempt (always log, regardless of success/failure)
-	logErr := s.logNotificationAttempt(notification, attemptNumber, err)
+	logErr := s.logNotificationAttempt(ctx, notification, attemptNumber, err)
 	if logErr != nil {
 		// Log error but don't fail - attempt logging should be resilient
 	}
 
 	if err != nil {
 		// Sending failed - handle retry or mark as failed
-		return s.handleNotificationFailure(notification, err.Error())
+		return s.handleNotificationFailure(ctx, notification, err.Error())
 	}
 
 	// Success - update status
-	err = s.repo.UpdateNotificationStatus(
+	err = s.repo.UpdateNotificationStatus(ctx,
 		notification.NotificationID,
 		models.NotificationStatusSent,
 		nil,
@@ -163,14 +165,14 @@ func (s *NotificationService) ProcessNotification(ctx context.Context, notificat
 }
 
 // handleNotificationFailure handles notification failure with retry logic
-func (s *NotificationService) handleNotificationFailure(
+func (s *NotificationService) handleNotificationFailure(ctx context.Context,
 	notification *models.Notification,
 	errorMessage string,
 ) error {
 	// Check if we should retry
 	if notification.RetryCount >= notification.MaxRetries {
 		// Max retries exceeded - mark as failed
-		err := s.repo.UpdateNotificationStatus(
+		err := s.repo.UpdateNotificationStatus(ctx,
 			notification.NotificationID,
 			models.NotificationStatusFailed,
 			&errorMessage,
@@ -185,7 +187,7 @@ func (s *NotificationService) handleNotificationFailure(
 	nextRetryAt := s.calculateNextRetryTime(notification.RetryCount)
 	
 	// Schedule retry
-	err := s.repo.ScheduleRetry(
+	err := s.repo.ScheduleRetry(ctx,
 		notification.NotificationID,
 		nextRetryAt,
 		errorMessage,
@@ -212,7 +214,7 @@ func (s *NotificationService) calculateNextRetryTime(retryCount int) time.Time {
 }
 
 // logNotificationAttempt logs a notification attempt to notification_attempts_log
-func (s *NotificationService) logNotificationAttempt(
+func (s *NotificationService) logNotificationAttempt(ctx context.Context,
 	notification *models.Notification,
 	attemptNumber int,
 	sendError error,
@@ -231,7 +233,7 @@ func (s *NotificationService) logNotificationAttempt(
 	// In production, you might want to include response data from the sender
 	// For now, we'll leave it null
 
-	err := s.repo.CreateNotificationAttemptLog(log)
+	err := s.repo.CreateNotificationAttemptLog(ctx, log)
 	if err != nil {
 		return fmt.Errorf("failed to log notification attempt: %w", err)
 	}
@@ -240,7 +242,7 @@ func (s *NotificationService) logNotificationAttempt(
 }
 
 // logNotificationTriggered logs notification trigger to audit_log
-func (s *NotificationService) logNotificationTriggered(notification *models.Notification) error {
+func (s *NotificationService) logNotificationTriggered(ctx context.Context, notification *models.Notification) error {
 	metadata := map[string]interface{}{
 		"notification_id": notification.NotificationID,
 		"channel":         notification.Channel,
@@ -266,7 +268,7 @@ func (s *NotificationService) logNotificationTriggered(notification *models.Noti
 		Metadata:     sql.NullString{String: string(metadataJSON), Valid: true},
 	}
 
-	err = s.complaintRepo.CreateAuditLog(auditLog)
+	err = s.complaintRepo.CreateAuditLog(ctx, auditLog)
 	if err != nil {
 		return fmt.Errorf("failed to create audit log: %w", err)
 	}
@@ -274,7 +276,23 @@ func (s *NotificationService) logNotificationTriggered(notification *models.Noti
 	return nil
 }
 
-// GetPendingNotifications retrieves pending notifications (used by worker)
-func (s *NotificationService) GetPendingNotifications(limit int) ([]models.Notification, error) {
-	return s.repo.GetPendingNotifications(limit)
+// ClaimPendingNotifications claims a batch of notifications ready to be sent (used by worker).
+// Each call uses a fresh claim token, so workers in several server instances never send the same notification.
+func (s *NotificationService) ClaimPendingNotifications(ctx context.Context, limit int) ([]models.Notification, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate claim token: %w", err)
+	}
+	return s.repo.ClaimPendingNotifications(ctx, limit, hex.EncodeToString(token), s.config.ClaimTimeout)
+}
+
+// ReleaseNotifications hands claimed notifications the worker will not send back to the queue,
+// so another instance (or this one after a restart) sends them without waiting for the claim to expire
+func (s *NotificationService) ReleaseNotifications(ctx context.Context, notifications []models.Notification) error {
+	for _, notification := range notifications {
+		if err := s.repo.ReleaseNotificationClaim(ctx, notification.NotificationID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
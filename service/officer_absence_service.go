@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"finalneta/models"
+	"finalneta/repository"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// OfficerAbsenceService records officer leave with a delegate and routes work to the delegate while it is in effect.
+// Nil-safe where other services call it (ResolveDelegate, ActingFor): a nil service means no delegation.
+type OfficerAbsenceService struct {
+	absenceRepo   *repository.OfficerAbsenceRepository
+	authorityRepo *repository.AuthorityRepository
+	complaintRepo *repository.ComplaintRepository // audit_log only
+}
+
+// NewOfficerAbsenceService creates a new officer absence service
+func NewOfficerAbsenceService(
+	absenceRepo *repository.OfficerAbsenceRepository,
+	authorityRepo *repository.AuthorityRepository,
+	complaintRepo *repository.ComplaintRepository,
+) *OfficerAbsenceService {
+	return &OfficerAbsenceService{
+		absenceRepo:   absenceRepo,
+		authorityRepo: authorityRepo,
+		complaintRepo: complaintRepo,
+	}
+}
+
+// RecordAbsence records a leave window for the officer with a delegate
+//
+// Rules:
+//  1. ends_at is required and in the future; starts_at defaults to now and must be before ends_at
+//  2. The delegate is another active officer at the same authority level or above (escalations keep going up)
+//  3. No overlap with another absence of the officer, an absence of the delegate, or a window in which the
+//     officer covers for someone else (so work never lands on an absent delegate)
+//
+// createdBy is models.ActorOfficer (their own leave) or models.ActorAdmin. Audited as absence_recorded.
+func (s *OfficerAbsenceService) RecordAbsence(ctx context.Context,
+	officerID int64,
+	req *models.CreateOfficerAbsenceRequest,
+	createdBy models.ActorType,
+	ipAddress, userAgent string,
+) (*models.OfficerAbsenceResponse, error) {
+	now := time.Now().UTC()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = req.StartsAt.UTC()
+	}
+	endsAt := req.EndsAt.UTC()
+	if req.EndsAt.IsZero() {
+		return nil, fmt.Errorf("invalid absence: ends_at is required")
+	}
+	if !endsAt.After(startsAt) {
+		return nil, fmt.Errorf("invalid absence: ends_at must be after starts_at")
+	}
+	if !endsAt.After(now) {
+		return nil, fmt.Errorf("invalid absence: ends_at must be in the future")
+	}
+	if req.DelegateOfficerID <= 0 {
+		return nil, fmt.Errorf("invalid delegate: delegate_officer_id is required")
+	}
+	if req.DelegateOfficerID == officerID {
+		return nil, fmt.Errorf("invalid delegate: an officer cannot delegate to themselves")
+	}
+
+	_, _, _, officerLevel, _, _, err := s.authorityRepo.GetOfficerByID(ctx, officerID)
+	if err != nil {
+		return nil, fmt.Errorf("officer not found")
+	}
+	_, _, _, delegateLevel, _, delegateActive, err := s.authorityRepo.GetOfficerByID(ctx, req.DelegateOfficerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delegate: officer %d not found", req.DelegateOfficerID)
+	}
+	if !delegateActive {
+		return nil, fmt.Errorf("invalid delegate: officer %d is inactive", req.DelegateOfficerID)
+	}
+	if delegateLevel < officerLevel {
+		return nil, fmt.Errorf("invalid delegate: officer %d is L%d, must be L%d or above", req.DelegateOfficerID, delegateLevel, officerLevel)
+	}
+
+	if other, err := s.absenceRepo.FindOverlappingAbsence(ctx, officerID, startsAt, endsAt, false); err != nil {
+		return nil, err
+	} else if other != nil {
+		return nil, fmt.Errorf("absence conflict: officer %d is already away in this window (absence %d)", officerID, other.AbsenceID)
+	}
+	if other, err := s.absenceRepo.FindOverlappingAbsence(ctx, officerID, startsAt, endsAt, true); err != nil {
+		return nil, err
+	} else if other != nil {
+		return nil, fmt.Errorf("absence conflict: officer %d covers for officer %d in this window (absence %d)", officerID, other.OfficerID, other.AbsenceID)
+	}
+	if other, err := s.absenceRepo.FindOverlappingAbsence(ctx, req.DelegateOfficerID, startsAt, endsAt, false); err != nil {
+		return nil, err
+	} else if other != nil {
+		return nil, fmt.Errorf("invalid delegate: officer %d is away in this window (absence %d)", req.DelegateOfficerID, other.AbsenceID)
+	}
+
+	absence := &models.OfficerAbsence{
+		OfficerID:         officerID,
+		DelegateOfficerID: req.DelegateOfficerID,
+		StartsAt:          startsAt,
+		EndsAt:            endsAt,
+		CreatedByType:     createdBy,
+	}
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		absence.Reason = sql.NullString{String: reason, Valid: true}
+	}
+	if err := s.absenceRepo.CreateAbsence(ctx, absence); err != nil {
+		return nil, err
+	}
+	absence.CreatedAt = now
+	log.Printf("[ABSENCE] officer_id=%d away %s to %s, delegate officer_id=%d (absence %d)",
+		officerID, startsAt.Format(time.RFC3339), endsAt.Format(time.RFC3339), req.DelegateOfficerID, absence.AbsenceID)
+
+	s.audit(ctx, absence, "absence_recorded", createdBy, ipAddress, userAgent)
+	return absenceResponse(absence, now), nil
+}
+
+// ListAbsences returns the officer's absences and the ones they cover as delegate, latest first
+func (s *OfficerAbsenceService) ListAbsences(ctx context.Context, officerID int64) ([]models.OfficerAbsenceResponse, error) {
+	absences, err := s.absenceRepo.ListAbsences(ctx, officerID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	out := make([]models.OfficerAbsenceResponse, 0, len(absences))
+	for i := range absences {
+		out = append(out, *absenceResponse(&absences[i], now))
+	}
+	return out, nil
+}
+
+// CancelAbsence ends an absence early or withdraws a planned one. officerID is the officer cancelling their own
+// absence (0 = admin, any absence). From then on, new work goes to the officer again; complaints assigned to the
+// delegate during the window stay with the delegate. Audited as absence_cancelled.
+func (s *OfficerAbsenceService) CancelAbsence(ctx context.Context,
+	absenceID int64,
+	officerID int64,
+	ipAddress, userAgent string,
+) (*models.OfficerAbsenceResponse, error) {
+	absence, err := s.absenceRepo.GetAbsenceByID(ctx, absenceID)
+	if err != nil {
+		return nil, err
+	}
+	if absence == nil || (officerID != 0 && absence.OfficerID != officerID) {
+		return nil, fmt.Errorf("absence not found")
+	}
+	now := time.Now().UTC()
+	if absence.CancelledAt.Valid {
+		return nil, fmt.Errorf("absence conflict: absence %d is already cancelled", absenceID)
+	}
+	if !now.Before(absence.EndsAt) {
+		return nil, fmt.Errorf("absence conflict: absence %d has already ended", absenceID)
+	}
+	cancelled, err := s.absenceRepo.CancelAbsence(ctx, absenceID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, fmt.Errorf("absence conflict: absence %d is already cancelled", absenceID)
+	}
+	absence.CancelledAt = sql.NullTime{Time: now, Valid: true}
+
+	actor := models.ActorAdmin
+	if officerID != 0 {
+		actor = models.ActorOfficer
+	}
+	s.audit(ctx, absence, "absence_cancelled", actor, ipAddress, userAgent)
+	return absenceResponse(absence, now), nil
+}
+
+// ResolveDelegate returns who takes new work meant for the officer: their delegate while an absence is in effect
+// (and the delegate is active), else the officer. absence is the absence in effect when delegated, else nil.
+func (s *OfficerAbsenceService) ResolveDelegate(ctx context.Context, officerID int64) (int64, *models.OfficerAbsence, error) {
+	if s == nil {
+		return officerID, nil, nil
+	}
+	absence, err := s.absenceRepo.GetActiveAbsence(ctx, officerID, time.Now().UTC())
+	if err != nil || absence == nil {
+		return officerID, nil, err
+	}
+	active, err := s.authorityRepo.VerifyOfficerExists(ctx, absence.DelegateOfficerID)
+	if err != nil {
+		return officerID, nil, err
+	}
+	if !active {
+		log.Printf("[ABSENCE] Warning: officer_id=%d is away but delegate officer_id=%d is inactive (absence %d); not delegating",
+			officerID, absence.DelegateOfficerID, absence.AbsenceID)
+		return officerID, nil, nil
+	}
+	return absence.DelegateOfficerID, absence, nil
+}
+
+// ActingFor checks that the officer may act on the complaint: it is assigned to them, or to an officer whose
+// absence in effect names them as delegate. Returns that absence when acting on behalf of the assigned officer
+// (nil for their own complaint); "complaint not assigned to this authority" otherwise.
+func (s *OfficerAbsenceService) ActingFor(ctx context.Context, complaint *models.Complaint, officerID int64) (*models.OfficerAbsence, error) {
+	if !complaint.AssignedOfficerID.Valid {
+		return nil, fmt.Errorf("complaint not assigned to this authority")
+	}
+	if complaint.AssignedOfficerID.Int64 == officerID {
+		return nil, nil
+	}
+	if s == nil {
+		return nil, fmt.Errorf("complaint not assigned to this authority")
+	}
+	absence, err := s.absenceRepo.GetActiveAbsence(ctx, complaint.AssignedOfficerID.Int64, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if absence == nil || absence.DelegateOfficerID != officerID {
+		return nil, fmt.Errorf("complaint not assigned to this authority")
+	}
+	return absence, nil
+}
+
+// audit writes an absence change to audit_log (entity officer); failures are logged, not returned
+func (s *OfficerAbsenceService) audit(ctx context.Context,
+	absence *models.OfficerAbsence,
+	action string,
+	actor models.ActorType,
+	ipAddress, userAgent string,
+) {
+	values := map[string]interface{}{
+		"absence_id":          absence.AbsenceID,
+		"delegate_officer_id": absence.DelegateOfficerID,
+		"starts_at":           absence.StartsAt,
+		"ends_at":             absence.EndsAt,
+	}
+	if absence.Reason.Valid {
+		values["reason"] = absence.Reason.String
+	}
+	if absence.CancelledAt.Valid {
+		values["cancelled_at"] = absence.CancelledAt.Time
+	}
+	newValues, _ := json.Marshal(values)
+	auditLog := &models.AuditLog{
+		EntityType:   "officer",
+		EntityID:     absence.OfficerID,
+		Action:       action,
+		ActionByType: actor,
+		NewValues:    sql.NullString{String: string(newValues), Valid: true},
+		IPAddress:    sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent:    sql.NullString{String: userAgent, Valid: userAgent != ""},
+	}
+	if actor == models.ActorOfficer {
+		auditLog.ActionByOfficerID = sql.NullInt64{Int64: absence.OfficerID, Valid: true}
+	}
+	if err := s.complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+		// Log error but don't fail - audit logging should be resilient
+		log.Printf("[ABSENCE] Warning: failed to create audit log for absence %d: %v", absence.AbsenceID, err)
+	}
+}
+
+// delegationMetadata is the audit_log metadata of an action a delegate took on behalf of an absent officer
+func delegationMetadata(absence *models.OfficerAbsence) sql.NullString {
+	if absence == nil {
+		return sql.NullString{}
+	}
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"on_behalf_of_officer_id": absence.OfficerID,
+		"absence_id":              absence.AbsenceID,
+	})
+	return sql.NullString{String: string(metadata), Valid: true}
+}
+
+func absenceResponse(a *models.OfficerAbsence, now time.Time) *models.OfficerAbsenceResponse {
+	out := &models.OfficerAbsenceResponse{
+		AbsenceID:         a.AbsenceID,
+		OfficerID:         a.OfficerID,
+		DelegateOfficerID: a.DelegateOfficerID,
+		StartsAt:          a.StartsAt,
+		EndsAt:            a.EndsAt,
+		CreatedByType:     string(a.CreatedByType),
+		Active:            a.ActiveAt(now),
+		CreatedAt:         a.CreatedAt,
+	}
+	if a.Reason.Valid {
+		out.Reason = &a.Reason.String
+	}
+	if a.CancelledAt.Valid {
+		out.CancelledAt = &a.CancelledAt.Time
+	}
+	return out
+}
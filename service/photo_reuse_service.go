@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"finalneta/models"
+	"finalneta/repository"
+	"fmt"
+	"log"
+)
+
+// PhotoReuseService serves the admin review list of recycled photos
+//
+// Flags are created at upload time (AttachmentService). A flag never changes the complaint;
+// the admin decides whether the photo was recycled (confirmed) or a false positive (dismissed).
+type PhotoReuseService struct {
+	photoReuseRepo *repository.PhotoReuseRepository
+	complaintRepo  *repository.ComplaintRepository
+}
+
+// NewPhotoReuseService creates a new photo reuse service
+func NewPhotoReuseService(
+	photoReuseRepo *repository.PhotoReuseRepository,
+	complaintRepo *repository.ComplaintRepository,
+) *PhotoReuseService {
+	return &PhotoReuseService{
+		photoReuseRepo: photoReuseRepo,
+		complaintRepo:  complaintRepo,
+	}
+}
+
+// ListFlags returns a page of flags filtered by status ("" = all)
+func (s *PhotoReuseService) ListFlags(ctx context.Context, status string, page, pageSize int) ([]models.PhotoReuseFlag, int64, error) {
+	switch models.PhotoReuseStatus(status) {
+	case "", models.PhotoReusePending, models.PhotoReuseConfirmed, models.PhotoReuseDismissed:
+	default:
+		return nil, 0, fmt.Errorf("invalid status: must be pending, confirmed or dismissed")
+	}
+	return s.photoReuseRepo.ListFlags(ctx, status, pageSize, (page-1)*pageSize)
+}
+
+// ReviewFlag records an admin decision (confirmed or dismissed) on a pending flag and audits it
+func (s *PhotoReuseService) ReviewFlag(ctx context.Context, flagID int64, decision models.PhotoReuseStatus, note string) (*models.PhotoReuseFlag, error) {
+	if decision != models.PhotoReuseConfirmed && decision != models.PhotoReuseDismissed {
+		return nil, fmt.Errorf("invalid decision: must be confirmed or dismissed")
+	}
+
+	flag, err := s.photoReuseRepo.GetFlag(ctx, flagID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.photoReuseRepo.ReviewFlag(ctx, flagID, decision, note); err != nil {
+		return nil, err
+	}
+
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"flag_id":               flagID,
+		"attachment_id":         flag.AttachmentID,
+		"matched_complaint_id":  flag.MatchedComplaintID,
+		"matched_attachment_id": flag.MatchedAttachmentID,
+		"decision":              string(decision),
+		"note":                  note,
+	})
+	auditLog := &models.AuditLog{
+		EntityType:   "complaint",
+		EntityID:     flag.ComplaintID,
+		Action:       "photo_reuse_reviewed",
+		ActionByType: models.ActorAdmin,
+		Metadata:     sql.NullString{String: string(metadataJSON), Valid: true},
+	}
+	if err := s.complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+		// Log error but don't fail the operation
+		// Audit logging should be resilient
+		log.Printf("[PHOTO_REUSE] Warning: failed to create audit log for flag ID=%d: %v", flagID, err)
+	}
+
+	return s.photoReuseRepo.GetFlag(ctx, flagID)
+}
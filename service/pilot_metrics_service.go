@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"finalneta/models"
 	"finalneta/repository"
 	"log"
@@ -20,7 +21,7 @@ func NewPilotMetricsService(metricsRepo *repository.PilotMetricsRepository) *Pil
 }
 
 // EmitComplaintCreated emits a complaint_created event
-func (s *PilotMetricsService) EmitComplaintCreated(complaintID, userID int64, metadata map[string]interface{}) {
+func (s *PilotMetricsService) EmitComplaintCreated(ctx context.Context, complaintID, userID int64, metadata map[string]interface{}) {
 	if s.metricsRepo == nil {
 		return // Service not initialized
 	}
@@ -30,7 +31,7 @@ func (s *PilotMetricsService) EmitComplaintCreated(complaintID, userID int64, me
 	}
 	metadata["timestamp"] = time.Now().Unix()
 
-	err := s.metricsRepo.CreateEventWithMetadata(
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
 		models.EventComplaintCreated,
 		&complaintID,
 		&userID,
@@ -43,7 +44,7 @@ func (s *PilotMetricsService) EmitComplaintCreated(complaintID, userID int64, me
 
 // EmitFirstAuthorityAction emits a first_authority_action event
 // Calculates time_to_first_authority_action from complaint creation
-func (s *PilotMetricsService) EmitFirstAuthorityAction(complaintID, userID int64, complaintCreatedAt time.Time, metadata map[string]interface{}) {
+func (s *PilotMetricsService) EmitFirstAuthorityAction(ctx context.Context, complaintID, userID int64, complaintCreatedAt time.Time, metadata map[string]interface{}) {
 	if s.metricsRepo == nil {
 		return
 	}
@@ -58,7 +59,7 @@ func (s *PilotMetricsService) EmitFirstAuthorityAction(complaintID, userID int64
 	metadata["time_to_first_action_hours"] = timeToAction.Hours()
 	metadata["timestamp"] = time.Now().Unix()
 
-	err := s.metricsRepo.CreateEventWithMetadata(
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
 		models.EventFirstAuthorityAction,
 		&complaintID,
 		&userID,
@@ -70,7 +71,7 @@ func (s *PilotMetricsService) EmitFirstAuthorityAction(complaintID, userID int64
 }
 
 // EmitEscalationTriggered emits an escalation_triggered event
-func (s *PilotMetricsService) EmitEscalationTriggered(complaintID, userID int64, escalationLevel int, metadata map[string]interface{}) {
+func (s *PilotMetricsService) EmitEscalationTriggered(ctx context.Context, complaintID, userID int64, escalationLevel int, metadata map[string]interface{}) {
 	if s.metricsRepo == nil {
 		return
 	}
@@ -81,7 +82,7 @@ func (s *PilotMetricsService) EmitEscalationTriggered(complaintID, userID int64,
 	metadata["escalation_level"] = escalationLevel
 	metadata["timestamp"] = time.Now().Unix()
 
-	err := s.metricsRepo.CreateEventWithMetadata(
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
 		models.EventEscalationTriggered,
 		&complaintID,
 		&userID,
@@ -92,9 +93,53 @@ func (s *PilotMetricsService) EmitEscalationTriggered(complaintID, userID int64,
 	}
 }
 
+// EmitComplaintTransferred emits a complaint_transferred event (officer transfer; not an escalation)
+func (s *PilotMetricsService) EmitComplaintTransferred(ctx context.Context, complaintID, userID int64, metadata map[string]interface{}) {
+	if s.metricsRepo == nil {
+		return
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["timestamp"] = time.Now().Unix()
+
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
+		models.EventComplaintTransferred,
+		&complaintID,
+		&userID,
+		metadata,
+	)
+	if err != nil {
+		log.Printf("[METRICS] Failed to emit complaint_transferred event: %v", err)
+	}
+}
+
+// EmitEscalationTargetMissing emits an escalation_target_missing event (escalation due, no officer to escalate to)
+func (s *PilotMetricsService) EmitEscalationTargetMissing(ctx context.Context, complaintID, userID int64, metadata map[string]interface{}) {
+	if s.metricsRepo == nil {
+		return
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["timestamp"] = time.Now().Unix()
+
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
+		models.EventEscalationTargetMissing,
+		&complaintID,
+		&userID,
+		metadata,
+	)
+	if err != nil {
+		log.Printf("[METRICS] Failed to emit escalation_target_missing event: %v", err)
+	}
+}
+
 // EmitComplaintResolved emits a complaint_resolved event
 // Calculates time to resolution from complaint creation
-func (s *PilotMetricsService) EmitComplaintResolved(complaintID, userID int64, complaintCreatedAt time.Time, status string, metadata map[string]interface{}) {
+func (s *PilotMetricsService) EmitComplaintResolved(ctx context.Context, complaintID, userID int64, complaintCreatedAt time.Time, status string, metadata map[string]interface{}) {
 	if s.metricsRepo == nil {
 		return
 	}
@@ -110,7 +155,7 @@ func (s *PilotMetricsService) EmitComplaintResolved(complaintID, userID int64, c
 	metadata["status"] = status
 	metadata["timestamp"] = time.Now().Unix()
 
-	err := s.metricsRepo.CreateEventWithMetadata(
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
 		models.EventComplaintResolved,
 		&complaintID,
 		&userID,
@@ -121,8 +166,40 @@ func (s *PilotMetricsService) EmitComplaintResolved(complaintID, userID int64, c
 	}
 }
 
+// EmitResolutionFeedback emits a resolution_confirmed or resolution_disputed event
+// Calculates time from officer resolution to citizen response
+func (s *PilotMetricsService) EmitResolutionFeedback(ctx context.Context, complaintID, userID int64, confirmed bool, resolvedAt *time.Time, metadata map[string]interface{}) {
+	if s.metricsRepo == nil {
+		return
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	if resolvedAt != nil {
+		timeToResponse := time.Since(*resolvedAt)
+		metadata["time_to_citizen_response_seconds"] = int64(timeToResponse.Seconds())
+		metadata["time_to_citizen_response_hours"] = timeToResponse.Hours()
+	}
+	metadata["timestamp"] = time.Now().Unix()
+
+	eventType := models.EventResolutionDisputed
+	if confirmed {
+		eventType = models.EventResolutionConfirmed
+	}
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
+		eventType,
+		&complaintID,
+		&userID,
+		metadata,
+	)
+	if err != nil {
+		log.Printf("[METRICS] Failed to emit %s event: %v", eventType, err)
+	}
+}
+
 // EmitChatAbandoned emits a chat_abandoned event
-func (s *PilotMetricsService) EmitChatAbandoned(userID int64, metadata map[string]interface{}) {
+func (s *PilotMetricsService) EmitChatAbandoned(ctx context.Context, userID int64, metadata map[string]interface{}) {
 	if s.metricsRepo == nil {
 		return
 	}
@@ -132,7 +209,7 @@ func (s *PilotMetricsService) EmitChatAbandoned(userID int64, metadata map[strin
 	}
 	metadata["timestamp"] = time.Now().Unix()
 
-	err := s.metricsRepo.CreateEventWithMetadata(
+	err := s.metricsRepo.CreateEventWithMetadata(ctx,
 		models.EventChatAbandoned,
 		nil, // No complaint_id for abandoned chats
 		&userID,
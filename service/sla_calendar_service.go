@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"finalneta/repository"
+	"finalneta/sla"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// slaCalendarCacheTTL is how long a loaded calendar is reused; edits to sla_calendars / sla_holidays
+// take effect within this time without a restart
+const slaCalendarCacheTTL = 5 * time.Minute
+
+// SLACalendarService builds working-time calendars per district from sla_calendars and sla_holidays
+//
+// Rules:
+// 1. The district's own calendar wins; otherwise the default row (location_id NULL) applies
+// 2. Holidays are the district's plus state-wide ones (location_id NULL)
+// 3. With no calendar configured at all, SLA time is wall-clock time (sla.AlwaysOpen)
+type SLACalendarService struct {
+	repo *repository.SLACalendarRepository
+
+	mu    sync.Mutex
+	cache map[int64]cachedSLACalendar
+}
+
+type cachedSLACalendar struct {
+	calendar *sla.Calendar
+	loadedAt time.Time
+}
+
+// NewSLACalendarService creates a new SLA calendar service
+func NewSLACalendarService(repo *repository.SLACalendarRepository) *SLACalendarService {
+	return &SLACalendarService{
+		repo:  repo,
+		cache: make(map[int64]cachedSLACalendar),
+	}
+}
+
+// CalendarForLocation returns the working-time calendar for a complaint location (cached for slaCalendarCacheTTL)
+func (s *SLACalendarService) CalendarForLocation(ctx context.Context, locationID int64) (*sla.Calendar, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[locationID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < slaCalendarCacheTTL {
+		return cached.calendar, nil
+	}
+
+	calendar, err := s.loadCalendar(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[locationID] = cachedSLACalendar{calendar: calendar, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return calendar, nil
+}
+
+// loadCalendar reads and validates the calendar and holidays for a location
+func (s *SLACalendarService) loadCalendar(ctx context.Context, locationID int64) (*sla.Calendar, error) {
+	cfg, err := s.repo.GetCalendarForLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return sla.AlwaysOpen(), nil
+	}
+
+	tz, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("SLA calendar %d: invalid timezone %q: %w", cfg.CalendarID, cfg.Timezone, err)
+	}
+	start, err := sla.ParseClock(cfg.WorkStart)
+	if err != nil {
+		return nil, fmt.Errorf("SLA calendar %d: %w", cfg.CalendarID, err)
+	}
+	end, err := sla.ParseClock(cfg.WorkEnd)
+	if err != nil {
+		return nil, fmt.Errorf("SLA calendar %d: %w", cfg.CalendarID, err)
+	}
+	days, err := sla.ParseWorkDays(cfg.WorkingDays)
+	if err != nil {
+		return nil, fmt.Errorf("SLA calendar %d: %w", cfg.CalendarID, err)
+	}
+
+	holidays, err := s.repo.GetHolidaysForLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	holidayNames := make(map[string]string, len(holidays))
+	for _, h := range holidays {
+		holidayNames[h.HolidayDate] = h.Name
+	}
+
+	calendar := &sla.Calendar{
+		Location:  tz,
+		WorkStart: start,
+		WorkEnd:   end,
+		WorkDays:  days,
+		Holidays:  holidayNames,
+	}
+	if err := calendar.Validate(); err != nil {
+		return nil, fmt.Errorf("SLA calendar %d: %w", cfg.CalendarID, err)
+	}
+	return calendar, nil
+}
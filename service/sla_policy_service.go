@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"finalneta/repository"
+	"finalneta/sla"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SLAPolicyService loads SLA policies (priority x level x category matrices) referenced by escalation rules
+//
+// Rules:
+//  1. A rule's time_based.sla_policy_id replaces sla_hours; hours come from the complaint's current priority,
+//     so a priority change moves the due time on the next evaluation
+//  2. A category-specific target wins over the any-category target
+//  3. Inactive or missing policies resolve to nil: rules referencing them never fall due
+//  4. Policies are cached for slaCalendarCacheTTL, like calendars
+type SLAPolicyService struct {
+	repo *repository.SLAPolicyRepository
+
+	mu    sync.Mutex
+	cache map[int64]cachedSLAPolicy
+}
+
+type cachedSLAPolicy struct {
+	policy   *sla.Policy
+	loadedAt time.Time
+}
+
+// NewSLAPolicyService creates a new SLA policy service
+func NewSLAPolicyService(repo *repository.SLAPolicyRepository) *SLAPolicyService {
+	return &SLAPolicyService{
+		repo:  repo,
+		cache: make(map[int64]cachedSLAPolicy),
+	}
+}
+
+// Policy returns an active policy with its targets (nil if missing or inactive; cached)
+func (s *SLAPolicyService) Policy(ctx context.Context, policyID int64) (*sla.Policy, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[policyID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < slaCalendarCacheTTL {
+		return cached.policy, nil
+	}
+
+	policy, err := s.loadPolicy(ctx, policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[policyID] = cachedSLAPolicy{policy: policy, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return policy, nil
+}
+
+// CheckPolicy returns a validation error if the policy does not exist or is inactive (not cached,
+// so a policy created a moment ago can be referenced immediately)
+func (s *SLAPolicyService) CheckPolicy(ctx context.Context, policyID int64) error {
+	_, ok, err := s.repo.GetActivePolicyName(ctx, policyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid escalation rule: time_based.sla_policy_id %d does not exist or is inactive", policyID)
+	}
+	return nil
+}
+
+func (s *SLAPolicyService) loadPolicy(ctx context.Context, policyID int64) (*sla.Policy, error) {
+	name, ok, err := s.repo.GetActivePolicyName(ctx, policyID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	targets, err := s.repo.GetPolicyTargets(ctx, policyID)
+	if err != nil {
+		return nil, err
+	}
+	policy := &sla.Policy{ID: policyID, Name: name}
+	for _, t := range targets {
+		policy.Targets = append(policy.Targets, sla.PolicyTarget{
+			Priority:        string(t.Priority),
+			EscalationLevel: t.EscalationLevel,
+			Category:        t.Category,
+			Hours:           t.SLAHours,
+		})
+	}
+	return policy, nil
+}
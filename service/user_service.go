@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"finalneta/repository"
 	"fmt"
 )
@@ -17,9 +18,9 @@ func NewUserService(userRepo *repository.UserRepository) *UserService {
 
 // GetOrCreateUserByPhone gets existing user or creates new one
 // Returns user_id and whether user was just created
-func (s *UserService) GetOrCreateUserByPhone(phoneNumber string) (int64, bool, error) {
+func (s *UserService) GetOrCreateUserByPhone(ctx context.Context, phoneNumber string) (int64, bool, error) {
 	// Check if user exists
-	user, err := s.userRepo.GetUserByPhone(phoneNumber)
+	user, err := s.userRepo.GetUserByPhone(ctx, phoneNumber)
 	if err != nil {
 		return 0, false, fmt.Errorf("failed to check user existence: %w", err)
 	}
@@ -30,7 +31,7 @@ func (s *UserService) GetOrCreateUserByPhone(phoneNumber string) (int64, bool, e
 	}
 
 	// User doesn't exist, create new user
-	userID, err := s.userRepo.CreateUser(phoneNumber)
+	userID, err := s.userRepo.CreateUser(ctx, phoneNumber)
 	if err != nil {
 		return 0, false, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -39,8 +40,8 @@ func (s *UserService) GetOrCreateUserByPhone(phoneNumber string) (int64, bool, e
 }
 
 // VerifyUserExists checks if user exists in database
-func (s *UserService) VerifyUserExists(userID int64) (bool, error) {
-	user, err := s.userRepo.GetUserByID(userID)
+func (s *UserService) VerifyUserExists(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return false, fmt.Errorf("failed to verify user existence: %w", err)
 	}
@@ -49,8 +50,8 @@ func (s *UserService) VerifyUserExists(userID int64) (bool, error) {
 }
 
 // VerifyUserPhoneVerified checks if user's phone is verified
-func (s *UserService) VerifyUserPhoneVerified(userID int64) (bool, error) {
-	user, err := s.userRepo.GetUserByID(userID)
+func (s *UserService) VerifyUserPhoneVerified(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return false, fmt.Errorf("failed to check phone verification: %w", err)
 	}
@@ -63,6 +64,6 @@ func (s *UserService) VerifyUserPhoneVerified(userID int64) (bool, error) {
 }
 
 // MarkPhoneVerified marks user's phone as verified
-func (s *UserService) MarkPhoneVerified(userID int64) error {
-	return s.userRepo.VerifyUserPhone(userID)
+func (s *UserService) MarkPhoneVerified(ctx context.Context, userID int64) error {
+	return s.userRepo.VerifyUserPhone(ctx, userID)
 }
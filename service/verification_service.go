@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"finalneta/lifecycle"
 	"finalneta/models"
 	"finalneta/repository"
+	"finalneta/utils"
 	"fmt"
 	"time"
 )
@@ -46,12 +49,12 @@ func NewVerificationService(
 // 5. All verification decisions must be logged in audit_log
 //
 // Returns VerificationResult with reason code and message
-func (s *VerificationService) VerifyComplaint(
+func (s *VerificationService) VerifyComplaint(ctx context.Context,
 	req *models.VerificationRequest,
 	ipAddress, userAgent string,
 ) (*models.VerificationResult, error) {
 	// Get complaint details
-	complaint, err := s.complaintRepo.GetComplaintByID(req.ComplaintID)
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, req.ComplaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get complaint: %w", err)
 	}
@@ -67,12 +70,12 @@ func (s *VerificationService) VerifyComplaint(
 	}
 
 	// Rule 1: Check for live capture attachment
-	hasLiveCapture, err := s.verificationRepo.HasLiveCaptureAttachment(req.ComplaintID)
+	hasLiveCapture, err := s.verificationRepo.HasLiveCaptureAttachment(ctx, req.ComplaintID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check live capture: %w", err)
 	}
 	if !hasLiveCapture {
-		return s.createVerificationResult(
+		return s.createVerificationResult(ctx,
 			req.ComplaintID,
 			false,
 			models.ReasonCodeNoLiveCapture,
@@ -82,10 +85,24 @@ func (s *VerificationService) VerifyComplaint(
 		)
 	}
 
+	// Rule 1b: EXIF consistency (photos whose EXIF places them elsewhere or long before submission)
+	if reasonCode, reasonMessage, err := s.checkExifConsistency(ctx, complaint); err != nil {
+		return nil, err
+	} else if reasonCode != "" {
+		return s.createVerificationResult(ctx,
+			req.ComplaintID,
+			false,
+			reasonCode,
+			reasonMessage,
+			ipAddress,
+			userAgent,
+		)
+	}
+
 	// Rule 2: Check GPS accuracy (if provided)
 	if req.GPSAccuracy != nil {
 		if *req.GPSAccuracy > s.config.GPSAccuracyThreshold {
-			return s.createVerificationResult(
+			return s.createVerificationResult(ctx,
 				req.ComplaintID,
 				false,
 				models.ReasonCodeGPSAccuracyExceeded,
@@ -97,12 +114,12 @@ func (s *VerificationService) VerifyComplaint(
 	}
 
 	// Rule 3: Check if user phone is verified
-	isPhoneVerified, err := s.verificationRepo.IsUserPhoneVerified(complaint.UserID)
+	isPhoneVerified, err := s.verificationRepo.IsUserPhoneVerified(ctx, complaint.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check phone verification: %w", err)
 	}
 	if !isPhoneVerified {
-		return s.createVerificationResult(
+		return s.createVerificationResult(ctx,
 			req.ComplaintID,
 			false,
 			models.ReasonCodePhoneNotVerified,
@@ -123,7 +140,7 @@ func (s *VerificationService) VerifyComplaint(
 		hasCoordinates = true
 	} else {
 		// Try to get coordinates from repository
-		lat, lon, valid, err := s.verificationRepo.GetComplaintCoordinates(req.ComplaintID)
+		lat, lon, valid, err := s.verificationRepo.GetComplaintCoordinates(ctx, req.ComplaintID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get coordinates: %w", err)
 		}
@@ -141,7 +158,7 @@ func (s *VerificationService) VerifyComplaint(
 
 	// Check for duplicates only if coordinates are available
 	if hasCoordinates {
-		duplicates, err := s.verificationRepo.FindDuplicateComplaints(
+		duplicates, err := s.verificationRepo.FindDuplicateComplaints(ctx,
 			req.ComplaintID,
 			category,
 			latitude,
@@ -163,32 +180,9 @@ func (s *VerificationService) VerifyComplaint(
 				}
 			}
 
-			// Increment supporter count on the original complaint
-			err = s.verificationRepo.IncrementSupporterCount(oldestDuplicate.ComplaintID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to increment supporter count: %w", err)
-			}
-
-			// Add current user as supporter to the original complaint
+			// Supporter count, supporter row and audit entry commit together
 			duplicateNotes := fmt.Sprintf("Merged from complaint #%d", req.ComplaintID)
-			err = s.verificationRepo.AddSupporter(
-				oldestDuplicate.ComplaintID,
-				complaint.UserID,
-				true, // is_duplicate = true
-				duplicateNotes,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to add supporter: %w", err)
-			}
-
-			// Get updated supporter count
-			originalComplaint, err := s.complaintRepo.GetComplaintByID(oldestDuplicate.ComplaintID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get original complaint: %w", err)
-			}
-
-			// Log duplicate detection in audit log
-			err = s.logVerificationDecision(
+			auditLog := verificationAuditLog(
 				req.ComplaintID,
 				false,
 				models.ReasonCodeDuplicateFound,
@@ -200,8 +194,38 @@ func (s *VerificationService) VerifyComplaint(
 				ipAddress,
 				userAgent,
 			)
+			err = s.verificationRepo.InTx(ctx, func(tx *sql.Tx) error {
+				verificationRepo := s.verificationRepo.WithTx(tx)
+
+				// Increment supporter count on the original complaint
+				if err := verificationRepo.IncrementSupporterCount(ctx, oldestDuplicate.ComplaintID); err != nil {
+					return fmt.Errorf("failed to increment supporter count: %w", err)
+				}
+
+				// Add current user as supporter to the original complaint
+				if err := verificationRepo.AddSupporter(ctx,
+					oldestDuplicate.ComplaintID,
+					complaint.UserID,
+					true, // is_duplicate = true
+					duplicateNotes,
+				); err != nil {
+					return fmt.Errorf("failed to add supporter: %w", err)
+				}
+
+				// Log duplicate detection in audit log
+				if err := s.complaintRepo.WithTx(tx).CreateAuditLog(ctx, auditLog); err != nil {
+					return fmt.Errorf("failed to create audit log: %w", err)
+				}
+				return nil
+			})
 			if err != nil {
-				// Log error but don't fail - audit logging should be resilient
+				return nil, err
+			}
+
+			// Get updated supporter count
+			originalComplaint, err := s.complaintRepo.GetComplaintByID(ctx, oldestDuplicate.ComplaintID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get original complaint: %w", err)
 			}
 
 			// Return result indicating duplicate was found and merged
@@ -217,17 +241,9 @@ func (s *VerificationService) VerifyComplaint(
 	}
 
 	// All rules passed - verify the complaint
-	// Update status to "verified" through status history
-	err = s.complaintRepo.UpdateComplaintStatus(
-		req.ComplaintID,
-		models.StatusVerified,
-		nil, // Keep existing department assignment
-		nil, // Keep existing officer assignment
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update status to verified: %w", err)
+	if _, err := lifecycle.Validate(complaint.CurrentStatus, models.StatusVerified, models.ActorSystem, "Complaint verified automatically"); err != nil {
+		return nil, err
 	}
-
 	// Create status history entry (REQUIRED for status change; audit: system, no actor_id, reason)
 	statusHistory := &models.ComplaintStatusHistory{
 		ComplaintID:   req.ComplaintID,
@@ -239,13 +255,9 @@ func (s *VerificationService) VerifyComplaint(
 		Reason:        sql.NullString{String: "Complaint verified automatically", Valid: true},
 		Notes:         sql.NullString{String: "Complaint verified automatically", Valid: true},
 	}
-	err = s.complaintRepo.CreateStatusHistory(statusHistory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create status history: %w", err)
-	}
 
-	// Log successful verification in audit log
-	err = s.logVerificationDecision(
+	// Successful verification audit entry
+	auditLog := verificationAuditLog(
 		req.ComplaintID,
 		true,
 		models.ReasonCodeVerified,
@@ -254,6 +266,7 @@ func (s *VerificationService) VerifyComplaint(
 			"gps_accuracy": req.GPSAccuracy,
 			"rules_passed": []string{
 				"live_capture_attachment",
+				"exif_consistency",
 				"gps_accuracy",
 				"phone_verified",
 				"no_duplicates",
@@ -262,8 +275,33 @@ func (s *VerificationService) VerifyComplaint(
 		ipAddress,
 		userAgent,
 	)
+
+	// Status update, history row and audit row commit atomically
+	err = s.complaintRepo.InTx(ctx, func(tx *sql.Tx) error {
+		complaintRepo := s.complaintRepo.WithTx(tx)
+
+		// Update status to "verified" through status history
+		if err := complaintRepo.UpdateComplaintStatus(ctx,
+			req.ComplaintID,
+			models.StatusVerified,
+			nil, // Keep existing department assignment
+			nil, // Keep existing officer assignment
+			complaint.Version,
+		); err != nil {
+			return fmt.Errorf("failed to update status to verified: %w", err)
+		}
+
+		if err := complaintRepo.CreateStatusHistory(ctx, statusHistory); err != nil {
+			return fmt.Errorf("failed to create status history: %w", err)
+		}
+
+		if err := complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		// Log error but don't fail - audit logging should be resilient
+		return nil, err
 	}
 
 	return &models.VerificationResult{
@@ -274,8 +312,72 @@ func (s *VerificationService) VerifyComplaint(
 	}, nil
 }
 
+// checkExifConsistency compares each attachment's EXIF with the complaint.
+// Returns a failure reason code and message, or "" when all photos are consistent.
+// Photos without EXIF GPS / capture time are not judged on that field.
+func (s *VerificationService) checkExifConsistency(ctx context.Context, complaint *models.Complaint) (models.VerificationReasonCode, string, error) {
+	attachments, err := s.complaintRepo.GetAttachmentsByComplaintID(ctx, complaint.ComplaintID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	for _, att := range attachments {
+		if code, msg := exifMismatch(s.config, complaint, att); code != "" {
+			return code, msg, nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// maxUTCOffset is the largest zone offset (UTC+14). A capture time without OffsetTimeOriginal is camera-local
+// read as UTC, so it can be up to this far ahead of the real instant.
+const maxUTCOffset = 14 * time.Hour
+
+// exifMismatch checks one attachment's EXIF against the complaint. Returns an empty code if it is consistent.
+func exifMismatch(config *models.VerificationConfig, complaint *models.Complaint, att models.ComplaintAttachment) (models.VerificationReasonCode, string) {
+	if config.ExifMaxDistanceMeters > 0 && att.ExifLatitude.Valid && att.ExifLongitude.Valid &&
+		complaint.Latitude.Valid && complaint.Longitude.Valid {
+		distance := utils.DistanceMeters(
+			att.ExifLatitude.Float64, att.ExifLongitude.Float64,
+			complaint.Latitude.Float64, complaint.Longitude.Float64,
+		)
+		if distance > config.ExifMaxDistanceMeters {
+			return models.ReasonCodeExifLocationMismatch,
+				fmt.Sprintf("Photo #%d EXIF GPS is %.0f meters from the complaint location (max %.0f meters)", att.AttachmentID, distance, config.ExifMaxDistanceMeters)
+		}
+	}
+
+	if !att.ExifCapturedAt.Valid {
+		return "", ""
+	}
+
+	if config.ExifMaxCaptureAge > 0 {
+		age := complaint.CreatedAt.Sub(att.ExifCapturedAt.Time)
+		if age > config.ExifMaxCaptureAge {
+			return models.ReasonCodeExifCaptureTooOld,
+				fmt.Sprintf("Photo #%d was taken %s before submission (max %s)", att.AttachmentID, age.Round(time.Hour), config.ExifMaxCaptureAge)
+		}
+	}
+
+	// A photo cannot be taken after it was uploaded; allow for camera clock drift and, when the zone is unknown, the offset
+	if config.ExifMaxClockSkew > 0 {
+		margin := config.ExifMaxClockSkew
+		if !att.ExifTimeZoned {
+			margin += maxUTCOffset
+		}
+		ahead := att.ExifCapturedAt.Time.Sub(att.CreatedAt)
+		if ahead > margin {
+			return models.ReasonCodeExifCaptureInFuture,
+				fmt.Sprintf("Photo #%d EXIF capture time is %s after upload (max %s)", att.AttachmentID, ahead.Round(time.Minute), margin)
+		}
+	}
+
+	return "", ""
+}
+
 // createVerificationResult creates a verification result and logs it to audit log
-func (s *VerificationService) createVerificationResult(
+func (s *VerificationService) createVerificationResult(ctx context.Context,
 	complaintID int64,
 	verified bool,
 	reasonCode models.VerificationReasonCode,
@@ -283,7 +385,7 @@ func (s *VerificationService) createVerificationResult(
 	ipAddress, userAgent string,
 ) (*models.VerificationResult, error) {
 	// Log verification decision in audit log
-	err := s.logVerificationDecision(
+	err := s.logVerificationDecision(ctx,
 		complaintID,
 		verified,
 		reasonCode,
@@ -306,8 +408,8 @@ func (s *VerificationService) createVerificationResult(
 	}, nil
 }
 
-// logVerificationDecision logs verification decision to audit_log
-func (s *VerificationService) logVerificationDecision(
+// logVerificationDecision writes the verification audit log entry outside any transaction
+func (s *VerificationService) logVerificationDecision(ctx context.Context,
 	complaintID int64,
 	verified bool,
 	reasonCode models.VerificationReasonCode,
@@ -315,6 +417,22 @@ func (s *VerificationService) logVerificationDecision(
 	metadata map[string]interface{},
 	ipAddress, userAgent string,
 ) error {
+	auditLog := verificationAuditLog(complaintID, verified, reasonCode, reasonMessage, metadata, ipAddress, userAgent)
+	if err := s.complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// verificationAuditLog builds the audit_log entry for a verification decision
+func verificationAuditLog(
+	complaintID int64,
+	verified bool,
+	reasonCode models.VerificationReasonCode,
+	reasonMessage string,
+	metadata map[string]interface{},
+	ipAddress, userAgent string,
+) *models.AuditLog {
 	// Prepare audit log metadata
 	auditMetadata := map[string]interface{}{
 		"verification": map[string]interface{}{
@@ -330,28 +448,18 @@ func (s *VerificationService) logVerificationDecision(
 		auditMetadata[k] = v
 	}
 
-	metadataJSON, err := json.Marshal(auditMetadata)
-	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
-	}
+	metadataJSON, _ := json.Marshal(auditMetadata)
 
 	// Create audit log entry
-	auditLog := &models.AuditLog{
+	return &models.AuditLog{
 		EntityType:   "complaint",
 		EntityID:     complaintID,
 		Action:       "verification",
 		ActionByType: models.ActorSystem,
 		Metadata:     sql.NullString{String: string(metadataJSON), Valid: true},
-		IPAddress:   sql.NullString{String: ipAddress, Valid: ipAddress != ""},
-		UserAgent:   sql.NullString{String: userAgent, Valid: userAgent != ""},
-	}
-
-	err = s.complaintRepo.CreateAuditLog(auditLog)
-	if err != nil {
-		return fmt.Errorf("failed to create audit log: %w", err)
+		IPAddress:    sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent:    sql.NullString{String: userAgent, Valid: userAgent != ""},
 	}
-
-	return nil
 }
 
 // GetVerificationConfig returns the current verification configuration
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"finalneta/models"
+)
+
+func TestExifMismatch(t *testing.T) {
+	config := models.DefaultVerificationConfig()
+	submitted := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	uploaded := submitted.Add(5 * time.Minute)
+	complaint := &models.Complaint{
+		Latitude:  sql.NullFloat64{Float64: 28.6139, Valid: true},
+		Longitude: sql.NullFloat64{Float64: 77.2090, Valid: true},
+		CreatedAt: submitted,
+	}
+
+	photo := func(captured time.Time, zoned bool) models.ComplaintAttachment {
+		return models.ComplaintAttachment{
+			AttachmentID:   1,
+			ExifCapturedAt: sql.NullTime{Time: captured, Valid: true},
+			ExifTimeZoned:  zoned,
+			CreatedAt:      uploaded,
+		}
+	}
+	farAway := photo(submitted, true)
+	farAway.ExifLatitude = sql.NullFloat64{Float64: 19.0760, Valid: true}
+	farAway.ExifLongitude = sql.NullFloat64{Float64: 72.8777, Valid: true}
+
+	tests := []struct {
+		name string
+		att  models.ComplaintAttachment
+		want models.VerificationReasonCode
+	}{
+		{"no EXIF", models.ComplaintAttachment{AttachmentID: 1, CreatedAt: uploaded}, ""},
+		{"taken just before upload", photo(submitted.Add(-time.Hour), true), ""},
+		{"GPS elsewhere", farAway, models.ReasonCodeExifLocationMismatch},
+		{"taken three days earlier", photo(submitted.Add(-72*time.Hour), true), models.ReasonCodeExifCaptureTooOld},
+		{"zoned, clock a few minutes fast", photo(uploaded.Add(10*time.Minute), true), ""},
+		{"zoned, an hour after upload", photo(uploaded.Add(time.Hour), true), models.ReasonCodeExifCaptureInFuture},
+		{"unzoned, IST camera-local time", photo(uploaded.Add(5*time.Hour+30*time.Minute), false), ""},
+		{"unzoned, a day after upload", photo(uploaded.Add(24*time.Hour), false), models.ReasonCodeExifCaptureInFuture},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, msg := exifMismatch(config, complaint, tt.att)
+			if code != tt.want {
+				t.Errorf("exifMismatch = %q (%s), want %q", code, msg, tt.want)
+			}
+		})
+	}
+
+	off := *config
+	off.ExifMaxClockSkew = 0
+	if code, _ := exifMismatch(&off, complaint, photo(uploaded.Add(24*time.Hour), false)); code != "" {
+		t.Errorf("exifMismatch with ExifMaxClockSkew 0 = %q, want no check", code)
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"finalneta/models"
+	"finalneta/repository"
+	"finalneta/storage"
+	"finalneta/utils"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxVoiceClipSize is the largest accepted voice clip (bytes)
+	MaxVoiceClipSize = 10 << 20
+	// MaxVoiceClipDuration is the longest accepted voice clip
+	MaxVoiceClipDuration = 3 * time.Minute
+	// MinVoiceClipDuration rejects accidental taps that record nothing useful
+	MinVoiceClipDuration = time.Second
+	// MaxVoiceClipsPerComplaint caps follow-up clips on one complaint
+	MaxVoiceClipsPerComplaint = 5
+)
+
+// VoiceNoteService handles citizen voice clips and authority access to them
+//
+// Rules:
+//  1. Only the complaint owner uploads; closed complaints accept no new clips
+//  2. Each upload adds a timestamped clip (up to MaxVoiceClipsPerComplaint); earlier clips are kept
+//  3. Container is sniffed and parsed (WAV, WebM/Opus); invalid audio, oversize or too long/short clips are rejected
+//  4. Voice notes are never public; officers read them only for complaints in their scope:
+//     the assigned officer, a supervisor of the assigned officer (same department and
+//     location, higher authority_level - see AuthorityRepository.IsSupervisorOf), or the
+//     delegate of the assigned officer while they are on leave
+//  5. Every authority access is recorded in audit_log (action voice_note_accessed), including partial (Range) reads
+type VoiceNoteService struct {
+	voiceNoteRepo  *repository.VoiceNoteRepository
+	complaintRepo  *repository.ComplaintRepository
+	authorityRepo  *repository.AuthorityRepository
+	absenceService *OfficerAbsenceService // optional; nil = delegates have no access
+	blob           storage.Blob
+}
+
+// NewVoiceNoteService creates a new voice note service
+func NewVoiceNoteService(
+	voiceNoteRepo *repository.VoiceNoteRepository,
+	complaintRepo *repository.ComplaintRepository,
+	authorityRepo *repository.AuthorityRepository,
+	absenceService *OfficerAbsenceService,
+	blob storage.Blob,
+) *VoiceNoteService {
+	return &VoiceNoteService{
+		voiceNoteRepo:  voiceNoteRepo,
+		complaintRepo:  complaintRepo,
+		authorityRepo:  authorityRepo,
+		absenceService: absenceService,
+		blob:           blob,
+	}
+}
+
+// UploadClip validates a voice recording and adds it as a new clip on the complaint.
+// Validation errors are prefixed "invalid voice note:".
+func (s *VoiceNoteService) UploadClip(
+	ctx context.Context,
+	complaintID int64,
+	userID int64,
+	data []byte,
+	ipAddress, userAgent string,
+) (*models.ComplaintVoiceNote, error) {
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, err
+	}
+	if complaint.UserID != userID {
+		return nil, fmt.Errorf("only the complaint owner can upload a voice note")
+	}
+	if complaint.CurrentStatus == models.StatusClosed {
+		return nil, fmt.Errorf("complaint is closed")
+	}
+
+	info, err := validateVoiceClip(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("voices/%d/%s.%s", complaintID, uuid.New().String(), info.Ext)
+	if err := s.blob.Put(ctx, key, data, info.MimeType); err != nil {
+		return nil, fmt.Errorf("failed to store voice note: %w", err)
+	}
+
+	size := int64(len(data))
+	seconds := int((info.Duration + time.Second - 1) / time.Second) // round up: a 0.4s tail still counts
+	note := &models.ComplaintVoiceNote{
+		ComplaintID:     complaintID,
+		FilePath:        key,
+		MimeType:        info.MimeType,
+		FileSize:        &size,
+		DurationSeconds: &seconds,
+	}
+	if err := s.voiceNoteRepo.Create(ctx, note, MaxVoiceClipsPerComplaint); err != nil {
+		if delErr := s.blob.Delete(ctx, key); delErr != nil {
+			log.Printf("[voice] failed to remove %s after failed insert: %v", key, delErr)
+		}
+		return nil, err
+	}
+
+	newValuesJSON, _ := json.Marshal(map[string]interface{}{
+		"voice_note_id":    note.ID,
+		"mime_type":        note.MimeType,
+		"codec":            info.Codec,
+		"file_size":        size,
+		"duration_seconds": seconds,
+	})
+	auditLog := &models.AuditLog{
+		EntityType:     "complaint",
+		EntityID:       complaintID,
+		Action:         "voice_note_uploaded",
+		ActionByType:   models.ActorUser,
+		ActionByUserID: sql.NullInt64{Int64: userID, Valid: true},
+		NewValues:      sql.NullString{String: string(newValuesJSON), Valid: true},
+		IPAddress:      sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent:      sql.NullString{String: userAgent, Valid: userAgent != ""},
+	}
+	if err := s.complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+		// Log error but don't fail the operation
+		// Audit logging should be resilient
+		log.Printf("[voice] Warning: failed to create audit log for complaint ID=%d: %v", complaintID, err)
+	}
+
+	note.URL = downloadURL(ctx, s.blob, note.FilePath)
+	return note, nil
+}
+
+// validateVoiceClip parses the audio headers and applies size and duration limits
+func validateVoiceClip(data []byte) (*utils.AudioInfo, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("invalid voice note: empty voice data")
+	}
+	if len(data) > MaxVoiceClipSize {
+		return nil, fmt.Errorf("invalid voice note: file exceeds %d MB", MaxVoiceClipSize>>20)
+	}
+	info, err := utils.ParseAudio(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid voice note: %w", err)
+	}
+	if info.Duration < MinVoiceClipDuration {
+		return nil, fmt.Errorf("invalid voice note: recording is shorter than %v", MinVoiceClipDuration)
+	}
+	if info.Duration > MaxVoiceClipDuration {
+		return nil, fmt.Errorf("invalid voice note: recording is longer than %v", MaxVoiceClipDuration)
+	}
+	return info, nil
+}
+
+// ListForAuthority returns the complaint's clips, oldest first (officer scope checked)
+func (s *VoiceNoteService) ListForAuthority(ctx context.Context, complaintID, officerID int64) ([]models.ComplaintVoiceNote, error) {
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	if err := s.checkOfficerScope(ctx, complaint, officerID); err != nil {
+		return nil, err
+	}
+	return s.voiceNoteRepo.ListByComplaintID(ctx, complaintID)
+}
+
+// VoiceNoteStream is an open voice note for streaming; the caller closes Body
+type VoiceNoteStream struct {
+	Note *models.ComplaintVoiceNote
+	Body io.ReadSeekCloser
+	Info *storage.ObjectInfo
+}
+
+// OpenForAuthority checks the officer's scope, records the access and opens a clip for streaming.
+// clipID nil opens the latest clip. rangeHeader is only recorded in the audit row; the caller serves the range.
+func (s *VoiceNoteService) OpenForAuthority(
+	ctx context.Context,
+	complaintID int64,
+	clipID *int64,
+	officerID int64,
+	rangeHeader string,
+	ipAddress, userAgent string,
+) (*VoiceNoteStream, error) {
+	complaint, err := s.complaintRepo.GetComplaintByID(ctx, complaintID)
+	if err != nil {
+		return nil, fmt.Errorf("complaint not found")
+	}
+	if err := s.checkOfficerScope(ctx, complaint, officerID); err != nil {
+		return nil, err
+	}
+
+	var note *models.ComplaintVoiceNote
+	if clipID != nil {
+		note, err = s.voiceNoteRepo.GetByID(ctx, complaintID, *clipID)
+	} else {
+		note, err = s.voiceNoteRepo.GetLatestByComplaintID(ctx, complaintID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, fmt.Errorf("voice note not found")
+	}
+
+	body, info, err := storage.Open(ctx, s.blob, note.FilePath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("[voice] complaint_id=%d voice note row exists but %s is missing from storage", complaintID, note.FilePath)
+			return nil, fmt.Errorf("voice note not found")
+		}
+		return nil, fmt.Errorf("failed to open voice note: %w", err)
+	}
+	if note.MimeType != "" {
+		info.ContentType = note.MimeType
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"voice_note_id": note.ID,
+		"range":         rangeHeader,
+	})
+	auditLog := &models.AuditLog{
+		EntityType:        "complaint",
+		EntityID:          complaintID,
+		Action:            "voice_note_accessed",
+		ActionByType:      models.ActorOfficer,
+		ActionByOfficerID: sql.NullInt64{Int64: officerID, Valid: true},
+		Metadata:          sql.NullString{String: string(metadata), Valid: true},
+		IPAddress:         sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent:         sql.NullString{String: userAgent, Valid: userAgent != ""},
+	}
+	if err := s.complaintRepo.CreateAuditLog(ctx, auditLog); err != nil {
+		// Log error but don't fail the operation
+		// Audit logging should be resilient
+		log.Printf("[voice] Warning: failed to create audit log for complaint ID=%d: %v", complaintID, err)
+	}
+
+	return &VoiceNoteStream{Note: note, Body: body, Info: info}, nil
+}
+
+// checkOfficerScope allows the assigned officer, their supervisors and their delegate while they are on leave
+func (s *VoiceNoteService) checkOfficerScope(ctx context.Context, complaint *models.Complaint, officerID int64) error {
+	if !complaint.AssignedOfficerID.Valid {
+		return fmt.Errorf("complaint not assigned to this authority")
+	}
+	assignedID := complaint.AssignedOfficerID.Int64
+	if assignedID == officerID {
+		return nil
+	}
+	isSupervisor, err := s.authorityRepo.IsSupervisorOf(ctx, officerID, assignedID)
+	if err != nil {
+		return err
+	}
+	if isSupervisor {
+		return nil
+	}
+	_, err = s.absenceService.ActingFor(ctx, complaint, officerID)
+	return err
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"finalneta/repository"
+	"log"
+	"time"
+)
+
+// WorkerLeaseService lets one server instance at a time run a background job.
+// Every replica starts the same workers; before each run a worker takes the job's lease and skips
+// the run if another instance holds it. A crashed holder's lease expires after its TTL.
+type WorkerLeaseService struct {
+	repo       *repository.WorkerLeaseRepository
+	instanceID string
+}
+
+// NewWorkerLeaseService creates a new worker lease service; instanceID identifies this server process
+func NewWorkerLeaseService(repo *repository.WorkerLeaseRepository, instanceID string) *WorkerLeaseService {
+	return &WorkerLeaseService{repo: repo, instanceID: instanceID}
+}
+
+// InstanceID returns the identity this process holds leases under
+func (s *WorkerLeaseService) InstanceID() string {
+	return s.instanceID
+}
+
+// TryAcquire takes (or renews) the named lease for ttl. Returns false when another instance holds it.
+func (s *WorkerLeaseService) TryAcquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return s.repo.TryAcquireLease(ctx, name, s.instanceID, ttl)
+}
+
+// Release gives the named lease back early. Failures are logged: the lease still expires on its own.
+func (s *WorkerLeaseService) Release(ctx context.Context, name string) {
+	if err := s.repo.ReleaseLease(ctx, name, s.instanceID); err != nil {
+		log.Printf("[LEASE] Warning: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package sla counts SLA time in working hours.
+//
+// A Calendar holds the office hours, working weekdays and gazetted holidays of one district
+// (configured in sla_calendars / sla_holidays). Escalation conditions measure elapsed time
+// with WorkingTime, and due-at timestamps shown to authorities come from AddWorkingTime, so a
+// complaint filed on Friday evening does not breach its SLA over the weekend.
+package sla
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones resolve on hosts without a zoneinfo database (slim containers)
+)
+
+// maxCalendarDays bounds the day-by-day walks (a calendar where nothing is ever a working day
+// would otherwise never finish)
+const maxCalendarDays = 3660
+
+// Calendar is the working-time calendar of one district. All times are interpreted in Location.
+type Calendar struct {
+	Location  *time.Location
+	WorkStart time.Duration     // office opens, offset from local midnight
+	WorkEnd   time.Duration     // office closes, offset from local midnight
+	WorkDays  [7]bool           // indexed by time.Weekday
+	Holidays  map[string]string // local date (2006-01-02) -> holiday name
+}
+
+// AlwaysOpen is a 24x7 UTC calendar with no holidays: working time equals wall-clock time.
+// Used when no calendar is configured.
+func AlwaysOpen() *Calendar {
+	return &Calendar{
+		Location:  time.UTC,
+		WorkStart: 0,
+		WorkEnd:   24 * time.Hour,
+		WorkDays:  [7]bool{true, true, true, true, true, true, true},
+	}
+}
+
+// Validate checks that the calendar has a time zone, a non-empty working day and at least one working weekday
+func (c *Calendar) Validate() error {
+	if c.Location == nil {
+		return fmt.Errorf("calendar has no time zone")
+	}
+	if c.WorkStart < 0 || c.WorkEnd > 24*time.Hour || c.WorkEnd <= c.WorkStart {
+		return fmt.Errorf("invalid working hours %v-%v", c.WorkStart, c.WorkEnd)
+	}
+	for _, open := range c.WorkDays {
+		if open {
+			return nil
+		}
+	}
+	return fmt.Errorf("calendar has no working days")
+}
+
+// IsWorkingDay reports whether the local date of t is a working weekday and not a holiday
+func (c *Calendar) IsWorkingDay(t time.Time) bool {
+	local := t.In(c.Location)
+	if !c.WorkDays[local.Weekday()] {
+		return false
+	}
+	_, holiday := c.Holidays[local.Format("2006-01-02")]
+	return !holiday
+}
+
+// WorkingTime returns the working time between from and to (0 if to is not after from)
+func (c *Calendar) WorkingTime(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	var total time.Duration
+	day := startOfDay(from.In(c.Location))
+	for i := 0; i < maxCalendarDays && day.Before(to); i++ {
+		if c.IsWorkingDay(day) {
+			openAt, closeAt := c.window(day)
+			if from.After(openAt) {
+				openAt = from
+			}
+			if to.Before(closeAt) {
+				closeAt = to
+			}
+			if closeAt.After(openAt) {
+				total += closeAt.Sub(openAt)
+			}
+		}
+		day = nextDay(day)
+	}
+	return total
+}
+
+// AddWorkingTime returns the instant at which d of working time has elapsed after from (in UTC).
+// A deadline that falls exactly at closing time stays on that day rather than moving to the next opening.
+func (c *Calendar) AddWorkingTime(from time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return from.UTC()
+	}
+	day := startOfDay(from.In(c.Location))
+	for i := 0; i < maxCalendarDays; i++ {
+		if c.IsWorkingDay(day) {
+			openAt, closeAt := c.window(day)
+			if from.After(openAt) {
+				openAt = from
+			}
+			if closeAt.After(openAt) {
+				avail := closeAt.Sub(openAt)
+				if d <= avail {
+					return openAt.Add(d).UTC()
+				}
+				d -= avail
+			}
+		}
+		day = nextDay(day)
+	}
+	// No working day within maxCalendarDays (misconfigured calendar): fall back to wall-clock time
+	return from.Add(d).UTC()
+}
+
+// window returns the office hours of the local day starting at day
+func (c *Calendar) window(day time.Time) (time.Time, time.Time) {
+	return atOffset(day, c.WorkStart), atOffset(day, c.WorkEnd)
+}
+
+// atOffset returns the wall-clock time offset from local midnight. DST-safe: the offset goes into the
+// date fields (time.Date normalizes it), so on a DST change day 09:00 is still 09:00 local time.
+func atOffset(day time.Time, offset time.Duration) time.Time {
+	if offset >= 24*time.Hour {
+		return nextDay(day)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(offset), day.Location())
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func nextDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location())
+}
+
+// weekdayNames maps the sla_calendars.working_days SET members to time.Weekday
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWorkDays parses a comma-separated weekday list such as "mon,tue,wed,thu,fri"
+func ParseWorkDays(s string) ([7]bool, error) {
+	var days [7]bool
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		wd, ok := weekdayNames[name]
+		if !ok {
+			return days, fmt.Errorf("unknown weekday %q", name)
+		}
+		days[wd] = true
+	}
+	return days, nil
+}
+
+// ParseClock parses a time of day ("HH:MM" or "HH:MM:SS", "24:00" allowed) into an offset from midnight
+func ParseClock(s string) (time.Duration, error) {
+	var h, m, sec int
+	n, _ := fmt.Sscanf(strings.TrimSpace(s), "%d:%d:%d", &h, &m, &sec)
+	if n < 2 || h < 0 || m < 0 || m > 59 || sec < 0 || sec > 59 {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second
+	if d > 24*time.Hour {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,223 @@
+package sla
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+// officeCalendar is Mon-Fri 10:00-18:00 IST with Tuesday 20 October 2026 a holiday
+func officeCalendar(t *testing.T) *Calendar {
+	return &Calendar{
+		Location:  mustLoad(t, "Asia/Kolkata"),
+		WorkStart: 10 * time.Hour,
+		WorkEnd:   18 * time.Hour,
+		WorkDays:  [7]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true},
+		Holidays:  map[string]string{"2026-10-20": "Holiday"},
+	}
+}
+
+// local builds a wall-clock time in loc
+func local(loc *time.Location, month time.Month, day, hour, min int) time.Time {
+	return time.Date(2026, month, day, hour, min, 0, 0, loc)
+}
+
+func TestWorkingTime(t *testing.T) {
+	c := officeCalendar(t)
+	ist := c.Location
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     time.Duration
+	}{
+		{"within one day", local(ist, 10, 19, 10, 30), local(ist, 10, 19, 12, 0), 90 * time.Minute},
+		{"clipped to office hours", local(ist, 10, 19, 7, 0), local(ist, 10, 19, 21, 0), 8 * time.Hour},
+		{"friday evening over the weekend", local(ist, 10, 16, 17, 0), local(ist, 10, 19, 11, 0), 2 * time.Hour},
+		{"weekend only", local(ist, 10, 17, 9, 0), local(ist, 10, 18, 20, 0), 0},
+		{"after closing to before opening", local(ist, 10, 16, 18, 0), local(ist, 10, 19, 10, 0), 0},
+		{"across a holiday", local(ist, 10, 19, 17, 0), local(ist, 10, 21, 11, 0), 2 * time.Hour},
+		{"on a holiday", local(ist, 10, 20, 10, 0), local(ist, 10, 20, 18, 0), 0},
+		{"full working week", local(ist, 10, 19, 0, 0), local(ist, 10, 26, 0, 0), 4 * 8 * time.Hour},
+		{"other zone input", time.Date(2026, 10, 19, 4, 30, 0, 0, time.UTC), time.Date(2026, 10, 19, 6, 30, 0, 0, time.UTC), 2 * time.Hour},
+		{"to before from", local(ist, 10, 19, 12, 0), local(ist, 10, 19, 11, 0), 0},
+		{"equal", local(ist, 10, 19, 12, 0), local(ist, 10, 19, 12, 0), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.WorkingTime(tt.from, tt.to); got != tt.want {
+				t.Errorf("WorkingTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddWorkingTime(t *testing.T) {
+	c := officeCalendar(t)
+	ist := c.Location
+	tests := []struct {
+		name string
+		from time.Time
+		d    time.Duration
+		want time.Time
+	}{
+		{"same day", local(ist, 10, 19, 11, 0), 2 * time.Hour, local(ist, 10, 19, 13, 0)},
+		{"friday evening over the weekend", local(ist, 10, 16, 17, 0), 2 * time.Hour, local(ist, 10, 19, 11, 0)},
+		{"exactly at closing time stays that day", local(ist, 10, 16, 17, 0), time.Hour, local(ist, 10, 16, 18, 0)},
+		{"full day ends at closing time", local(ist, 10, 16, 10, 0), 8 * time.Hour, local(ist, 10, 16, 18, 0)},
+		{"one minute past closing", local(ist, 10, 16, 17, 0), 61 * time.Minute, local(ist, 10, 19, 10, 1)},
+		{"from after closing", local(ist, 10, 16, 19, 0), 30 * time.Minute, local(ist, 10, 19, 10, 30)},
+		{"from before opening", local(ist, 10, 19, 6, 0), 30 * time.Minute, local(ist, 10, 19, 10, 30)},
+		{"from the weekend", local(ist, 10, 17, 12, 0), time.Hour, local(ist, 10, 19, 11, 0)},
+		{"skips a holiday", local(ist, 10, 19, 17, 0), 2 * time.Hour, local(ist, 10, 21, 11, 0)},
+		{"from a holiday", local(ist, 10, 20, 12, 0), time.Hour, local(ist, 10, 21, 11, 0)},
+		{"several days over weekend and holiday", local(ist, 10, 16, 14, 0), 20 * time.Hour, local(ist, 10, 21, 18, 0)},
+		{"zero", local(ist, 10, 17, 3, 0), 0, local(ist, 10, 17, 3, 0)},
+		{"negative", local(ist, 10, 17, 3, 0), -time.Hour, local(ist, 10, 17, 3, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.AddWorkingTime(tt.from, tt.d)
+			if !got.Equal(tt.want) {
+				t.Errorf("AddWorkingTime = %v, want %v", got.In(ist), tt.want)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("AddWorkingTime returned %v, want UTC", got.Location())
+			}
+		})
+	}
+}
+
+func TestAddWorkingTimeInvertsWorkingTime(t *testing.T) {
+	c := officeCalendar(t)
+	start := local(c.Location, 10, 15, 0, 0)
+	for from := start; from.Before(start.Add(7 * 24 * time.Hour)); from = from.Add(97 * time.Minute) {
+		for _, d := range []time.Duration{time.Minute, time.Hour, 8 * time.Hour, 13*time.Hour + 7*time.Minute, 72 * time.Hour} {
+			due := c.AddWorkingTime(from, d)
+			if got := c.WorkingTime(from, due); got != d {
+				t.Fatalf("from %v + %v = %v, but WorkingTime back = %v", from, d, due.In(c.Location), got)
+			}
+		}
+	}
+}
+
+func TestCalendarDST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	allDay := &Calendar{Location: ny, WorkStart: 0, WorkEnd: 24 * time.Hour, WorkDays: [7]bool{true, true, true, true, true, true, true}}
+	office := &Calendar{Location: ny, WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour, WorkDays: [7]bool{true, true, true, true, true, true, true}}
+
+	// 2026-11-01 01:00-02:00 happens twice (EDT -> EST); 2026-03-08 02:00-03:00 is skipped (EST -> EDT)
+	if got := allDay.WorkingTime(local(ny, 10, 31, 0, 0), local(ny, 11, 2, 0, 0)); got != 49*time.Hour {
+		t.Errorf("24h calendar over fall back = %v, want 49h", got)
+	}
+	if got := allDay.WorkingTime(local(ny, 3, 7, 0, 0), local(ny, 3, 9, 0, 0)); got != 47*time.Hour {
+		t.Errorf("24h calendar over spring forward = %v, want 47h", got)
+	}
+	if got := allDay.AddWorkingTime(local(ny, 10, 31, 0, 0), 48*time.Hour); !got.Equal(local(ny, 11, 1, 23, 0)) {
+		t.Errorf("24h calendar +48h over fall back = %v, want Sun 23:00 EST", got.In(ny))
+	}
+
+	// Office hours stay 9-17 local on both sides of the change
+	if got := office.WorkingTime(local(ny, 10, 31, 0, 0), local(ny, 11, 2, 0, 0)); got != 16*time.Hour {
+		t.Errorf("office hours over fall back = %v, want 16h", got)
+	}
+	got := office.AddWorkingTime(local(ny, 10, 31, 16, 0), 2*time.Hour)
+	if want := time.Date(2026, 11, 1, 15, 0, 0, 0, time.UTC); !got.Equal(want) { // 10:00 EST
+		t.Errorf("office hours +2h over fall back = %v, want %v", got, want)
+	}
+	got = office.AddWorkingTime(local(ny, 3, 7, 16, 0), 2*time.Hour)
+	if want := time.Date(2026, 3, 8, 14, 0, 0, 0, time.UTC); !got.Equal(want) { // 10:00 EDT
+		t.Errorf("office hours +2h over spring forward = %v, want %v", got, want)
+	}
+}
+
+func TestAlwaysOpenMatchesWallClock(t *testing.T) {
+	c := AlwaysOpen()
+	ist := mustLoad(t, "Asia/Kolkata")
+	ny := mustLoad(t, "America/New_York")
+	froms := []time.Time{
+		time.Date(2026, 10, 16, 17, 0, 0, 0, time.UTC),
+		time.Date(2026, 12, 31, 23, 59, 30, 0, time.UTC),
+		local(ist, 10, 16, 23, 45),
+		local(ny, 10, 31, 22, 0), // wall clock in New York crosses the DST change
+		local(ny, 3, 7, 12, 0),
+	}
+	for _, from := range froms {
+		for _, d := range []time.Duration{time.Second, 90 * time.Minute, 24 * time.Hour, 72*time.Hour + 5*time.Minute, 40 * 24 * time.Hour} {
+			if got := c.WorkingTime(from, from.Add(d)); got != d {
+				t.Errorf("WorkingTime(%v, +%v) = %v", from, d, got)
+			}
+			if got := c.AddWorkingTime(from, d); !got.Equal(from.Add(d)) {
+				t.Errorf("AddWorkingTime(%v, %v) = %v, want %v", from, d, got, from.Add(d))
+			}
+		}
+	}
+}
+
+func TestAddWorkingTimeNoWorkingDays(t *testing.T) {
+	c := &Calendar{Location: time.UTC, WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour}
+	if err := c.Validate(); err == nil {
+		t.Error("Validate: want error for a calendar without working days")
+	}
+	from := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	if got := c.AddWorkingTime(from, time.Hour); !got.Equal(from.Add(time.Hour)) {
+		t.Errorf("AddWorkingTime = %v, want wall-clock fallback %v", got, from.Add(time.Hour))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ok := officeCalendar(t)
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+	if err := AlwaysOpen().Validate(); err != nil {
+		t.Errorf("AlwaysOpen().Validate = %v", err)
+	}
+	for name, c := range map[string]*Calendar{
+		"no location":      {WorkStart: 0, WorkEnd: time.Hour, WorkDays: ok.WorkDays},
+		"end too late":     {Location: time.UTC, WorkStart: 0, WorkEnd: 25 * time.Hour, WorkDays: ok.WorkDays},
+		"start < 0":        {Location: time.UTC, WorkStart: -time.Hour, WorkEnd: time.Hour, WorkDays: ok.WorkDays},
+		"empty day":        {Location: time.UTC, WorkStart: 9 * time.Hour, WorkEnd: 9 * time.Hour, WorkDays: ok.WorkDays},
+		"end before start": {Location: time.UTC, WorkStart: 17 * time.Hour, WorkEnd: 9 * time.Hour, WorkDays: ok.WorkDays},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: Validate = nil, want error", name)
+		}
+	}
+}
+
+func TestParseWorkDaysAndClock(t *testing.T) {
+	days, err := ParseWorkDays(" Mon,tue, WED ,thu,fri,")
+	if err != nil {
+		t.Fatalf("ParseWorkDays: %v", err)
+	}
+	if want := [7]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true}; days != want {
+		t.Errorf("ParseWorkDays = %v, want %v", days, want)
+	}
+	if _, err := ParseWorkDays("mon,funday"); err == nil {
+		t.Error("ParseWorkDays(funday): want error")
+	}
+
+	for in, want := range map[string]time.Duration{
+		"09:30":    9*time.Hour + 30*time.Minute,
+		"17:45:30": 17*time.Hour + 45*time.Minute + 30*time.Second,
+		"00:00":    0,
+		"24:00":    24 * time.Hour,
+	} {
+		if got, err := ParseClock(in); err != nil || got != want {
+			t.Errorf("ParseClock(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "9", "09:60", "24:01", "-1:00", "ab:cd", "10:00:61"} {
+		if _, err := ParseClock(in); err == nil {
+			t.Errorf("ParseClock(%q): want error", in)
+		}
+	}
+}
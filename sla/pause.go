@@ -0,0 +1,58 @@
+package sla
+
+import (
+	"sort"
+	"time"
+)
+
+// Pause is a period in which the SLA clock is stopped (e.g. the complaint awaits the citizen's reply)
+type Pause struct {
+	Start time.Time
+	End   time.Time
+}
+
+// WorkingTimeExcluding returns the working time between from and to minus the working time inside pauses.
+// Pauses must not overlap.
+func (c *Calendar) WorkingTimeExcluding(from, to time.Time, pauses []Pause) time.Duration {
+	total := c.WorkingTime(from, to)
+	for _, p := range pauses {
+		start, end := p.Start, p.End
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		total -= c.WorkingTime(start, end)
+	}
+	if total < 0 {
+		return 0
+	}
+	return total
+}
+
+// AddWorkingTimeExcluding returns the instant at which d of working time outside pauses has elapsed after
+// from (in UTC). Pauses must not overlap.
+func (c *Calendar) AddWorkingTimeExcluding(from time.Time, d time.Duration, pauses []Pause) time.Time {
+	sorted := make([]Pause, len(pauses))
+	copy(sorted, pauses)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	t := from
+	for _, p := range sorted {
+		if !p.End.After(t) {
+			continue
+		}
+		start := p.Start
+		if start.Before(t) {
+			start = t
+		}
+		before := c.WorkingTime(t, start)
+		if d <= before {
+			break // due before this pause starts
+		}
+		d -= before
+		t = p.End
+	}
+	return c.AddWorkingTime(t, d)
+}
@@ -0,0 +1,92 @@
+package sla
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddWorkingTimeExcluding(t *testing.T) {
+	c := officeCalendar(t)
+	c.Holidays = nil
+	ist := c.Location
+	from := local(ist, 10, 19, 10, 0) // Monday opening
+	at := func(day, hour, min int) time.Time { return local(ist, 10, day, hour, min) }
+
+	tests := []struct {
+		name   string
+		d      time.Duration
+		pauses []Pause
+		want   time.Time
+	}{
+		{"no pauses", 8 * time.Hour, nil, at(19, 18, 0)},
+		{"pause before from", 8 * time.Hour, []Pause{{at(16, 12, 0), at(16, 14, 0)}}, at(19, 18, 0)},
+		{"pause ending at from", 8 * time.Hour, []Pause{{at(16, 12, 0), from}}, at(19, 18, 0)},
+		{"pause spanning from", 8 * time.Hour, []Pause{{at(18, 9, 0), at(19, 14, 0)}}, at(20, 14, 0)},
+		{"pause within working time", 8 * time.Hour, []Pause{{at(19, 12, 0), at(19, 13, 0)}}, at(20, 11, 0)},
+		{"pause across the deadline", 8 * time.Hour, []Pause{{at(19, 17, 0), at(20, 12, 0)}}, at(20, 13, 0)},
+		{"pause starting at the deadline", 8 * time.Hour, []Pause{{at(19, 18, 0), at(20, 12, 0)}}, at(19, 18, 0)},
+		{"pause after the deadline", 8 * time.Hour, []Pause{{at(20, 12, 0), at(21, 12, 0)}}, at(19, 18, 0)},
+		{"pause outside office hours", 16 * time.Hour, []Pause{{at(19, 19, 0), at(20, 9, 0)}}, at(20, 18, 0)},
+		{"pause over the weekend", 10 * time.Hour, []Pause{{at(23, 17, 0), at(26, 11, 0)}}, at(20, 12, 0)},
+		{"unsorted pauses", 8 * time.Hour, []Pause{{at(19, 15, 0), at(19, 16, 0)}, {at(19, 11, 0), at(19, 12, 0)}}, at(20, 12, 0)},
+		{"pause ending after closing", 8 * time.Hour, []Pause{{at(19, 16, 0), at(19, 20, 0)}}, at(20, 12, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.AddWorkingTimeExcluding(from, tt.d, tt.pauses)
+			if !got.Equal(tt.want) {
+				t.Errorf("AddWorkingTimeExcluding = %v, want %v", got.In(ist), tt.want)
+			}
+			// The deadline leaves exactly d of unpaused working time
+			if tt.d > 0 {
+				if spent := c.WorkingTimeExcluding(from, got, tt.pauses); spent != tt.d {
+					t.Errorf("WorkingTimeExcluding(from, due) = %v, want %v", spent, tt.d)
+				}
+			}
+		})
+	}
+}
+
+func TestAddWorkingTimeExcludingDoesNotReorderInput(t *testing.T) {
+	c := AlwaysOpen()
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	pauses := []Pause{
+		{from.Add(5 * time.Hour), from.Add(6 * time.Hour)},
+		{from.Add(time.Hour), from.Add(2 * time.Hour)},
+	}
+	if got, want := c.AddWorkingTimeExcluding(from, 10*time.Hour, pauses), from.Add(12*time.Hour); !got.Equal(want) {
+		t.Errorf("AddWorkingTimeExcluding = %v, want %v", got, want)
+	}
+	if !pauses[0].Start.Equal(from.Add(5 * time.Hour)) {
+		t.Error("AddWorkingTimeExcluding sorted the caller's slice")
+	}
+}
+
+func TestWorkingTimeExcluding(t *testing.T) {
+	c := officeCalendar(t)
+	ist := c.Location
+	at := func(day, hour, min int) time.Time { return local(ist, 10, day, hour, min) }
+	from, to := at(19, 10, 0), at(21, 18, 0) // Mon opening to Wed closing; Tue is a holiday
+
+	tests := []struct {
+		name   string
+		pauses []Pause
+		want   time.Duration
+	}{
+		{"no pauses", nil, 16 * time.Hour},
+		{"pause inside", []Pause{{at(19, 12, 0), at(19, 13, 0)}}, 15 * time.Hour},
+		{"pause on the holiday", []Pause{{at(20, 10, 0), at(20, 18, 0)}}, 16 * time.Hour},
+		{"pause before from is clipped", []Pause{{at(16, 10, 0), at(19, 12, 0)}}, 14 * time.Hour},
+		{"pause after to is clipped", []Pause{{at(21, 17, 0), at(23, 12, 0)}}, 15 * time.Hour},
+		{"pause outside the window", []Pause{{at(22, 10, 0), at(22, 12, 0)}}, 16 * time.Hour},
+		{"pause covering everything", []Pause{{at(18, 0, 0), at(22, 0, 0)}}, 0},
+		{"two pauses", []Pause{{at(19, 10, 0), at(19, 11, 0)}, {at(21, 17, 30), at(21, 18, 0)}}, 14*time.Hour + 30*time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.WorkingTimeExcluding(from, to, tt.pauses); got != tt.want {
+				t.Errorf("WorkingTimeExcluding = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
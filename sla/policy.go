@@ -0,0 +1,35 @@
+package sla
+
+// Policy is an SLA matrix: SLA hours per complaint priority and escalation level, optionally per category
+// (configured in sla_policies / sla_policy_targets and referenced by escalation rules)
+type Policy struct {
+	ID      int64
+	Name    string
+	Targets []PolicyTarget
+}
+
+// PolicyTarget is one cell of the matrix
+type PolicyTarget struct {
+	Priority        string
+	EscalationLevel int    // current level before escalation (0 = L1)
+	Category        string // "" = any category
+	Hours           int
+}
+
+// Hours returns the SLA hours for a complaint of the given priority and category at an escalation level.
+// A target for the complaint's category wins over the any-category target; false if neither exists.
+func (p *Policy) Hours(priority string, level int, category string) (int, bool) {
+	hours, found := 0, false
+	for _, t := range p.Targets {
+		if t.Priority != priority || t.EscalationLevel != level {
+			continue
+		}
+		switch {
+		case t.Category != "" && t.Category == category:
+			return t.Hours, true
+		case t.Category == "":
+			hours, found = t.Hours, true
+		}
+	}
+	return hours, found
+}
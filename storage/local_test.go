@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+	l, err := NewLocal(t.TempDir(), "https://api.example.org", "test-secret")
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	return l
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	key := "attachments/42/photo.jpg"
+	data := []byte("not really a jpeg")
+
+	if err := l.Put(ctx, key, data, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	info, err := l.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size != int64(len(data)) || info.ContentType != "image/jpeg" {
+		t.Errorf("Stat = size %d type %q", info.Size, info.ContentType)
+	}
+	got, err := ReadAll(ctx, l, key)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAll = %q, %v; want %q", got, err, data)
+	}
+
+	// Put replaces
+	if err := l.Put(ctx, key, []byte("v2"), "image/jpeg"); err != nil {
+		t.Fatalf("Put again: %v", err)
+	}
+	if got, _ := ReadAll(ctx, l, key); string(got) != "v2" {
+		t.Errorf("after replace = %q, want v2", got)
+	}
+
+	// Open is seekable for Range requests
+	r, _, err := Open(ctx, l, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := r.Seek(1, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	rest, _ := io.ReadAll(r)
+	r.Close()
+	if string(rest) != "2" {
+		t.Errorf("read after seek = %q, want 2", rest)
+	}
+
+	if err := l.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := l.Delete(ctx, key); err != nil {
+		t.Errorf("Delete missing = %v, want nil", err)
+	}
+	if _, err := l.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after delete = %v, want ErrNotFound", err)
+	}
+	if _, _, err := l.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalRejectsInvalidKeys(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	for _, key := range []string{"", "/etc/passwd", "../x", "a/../../x", "a//b", "a\\b", "https://example.org/a.jpg"} {
+		if err := l.Put(ctx, key, []byte("x"), ""); err == nil {
+			t.Errorf("Put(%q): want error", key)
+		}
+		if _, err := l.SignedURL(ctx, key, time.Minute); err == nil {
+			t.Errorf("SignedURL(%q): want error", key)
+		}
+	}
+}
+
+// signedParams splits a local signed URL into key, expires and signature
+func signedParams(t *testing.T, raw string) (string, string, string) {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	if u.Scheme != "https" || u.Host != "api.example.org" || !strings.HasPrefix(u.Path, LocalFilePath) {
+		t.Fatalf("signed url %q not under https://api.example.org%s", raw, LocalFilePath)
+	}
+	return strings.TrimPrefix(u.Path, LocalFilePath), u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	l := newTestLocal(t)
+	raw, err := l.SignedURL(context.Background(), "voices/42/clip 1.webm", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	if !strings.Contains(raw, "/voices/42/clip%201.webm?") {
+		t.Errorf("signed url %q: key not path-escaped", raw)
+	}
+	key, expires, sig := signedParams(t, raw)
+	if key != "voices/42/clip 1.webm" {
+		t.Errorf("key = %q", key)
+	}
+	if err := l.VerifySignature(key, expires, sig); err != nil {
+		t.Errorf("VerifySignature = %v, want nil", err)
+	}
+}
+
+func TestLocalSignedURLExpiry(t *testing.T) {
+	l := newTestLocal(t)
+	raw, err := l.SignedURL(context.Background(), "voices/42/clip.webm", -time.Second)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	key, expires, sig := signedParams(t, raw)
+	if err := l.VerifySignature(key, expires, sig); err == nil || err.Error() != "signature expired" {
+		t.Errorf("VerifySignature expired = %v, want signature expired", err)
+	}
+}
+
+func TestLocalSignedURLTampering(t *testing.T) {
+	l := newTestLocal(t)
+	raw, err := l.SignedURL(context.Background(), "voices/42/clip.webm", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	key, expires, sig := signedParams(t, raw)
+	exp, _ := strconv.ParseInt(expires, 10, 64)
+
+	other, err := NewLocal(t.TempDir(), "https://api.example.org", "other-secret")
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		key, expires, sig string
+		verifier          *Local
+	}{
+		{"other key", "voices/43/clip.webm", expires, sig, l},
+		{"extended expiry", key, strconv.FormatInt(exp+3600, 10), sig, l},
+		{"flipped signature", key, expires, flipHex(sig), l},
+		{"truncated signature", key, expires, sig[:len(sig)-2], l},
+		{"empty signature", key, expires, "", l},
+		{"non-numeric expiry", key, "tomorrow", sig, l},
+		{"other secret", key, expires, sig, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.verifier.VerifySignature(tt.key, tt.expires, tt.sig); err == nil || err.Error() != "invalid signature" {
+				t.Errorf("VerifySignature = %v, want invalid signature", err)
+			}
+		})
+	}
+}
+
+// flipHex changes the last hex digit of s
+func flipHex(s string) string {
+	last := s[len(s)-1]
+	if last == '0' {
+		return s[:len(s)-1] + "1"
+	}
+	return s[:len(s)-1] + "0"
+}
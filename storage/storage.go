@@ -0,0 +1,156 @@
+// Package storage abstracts where uploaded files live.
+//
+// All file writes and reads (attachments, voice notes, future exports) go through a Blob.
+// Keys are slash-separated relative paths such as "attachments/42/<uuid>.jpg"; the same key
+// works on every backend, so rows written before a backend switch stay valid after copying
+// the objects.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist
+var ErrNotFound = errors.New("object not found")
+
+// DownloadURLExpiry is how long the download links returned in API responses (attachments, voice notes) stay valid
+const DownloadURLExpiry = 15 * time.Minute
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Blob is an object store
+type Blob interface {
+	// Put stores data under key, replacing any existing object
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the object for reading; the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes the object; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// Stat returns object metadata without reading the content
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// SignedURL returns a time-limited URL that lets a client download the object without other credentials
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// ReadAll reads a whole object into memory
+func ReadAll(ctx context.Context, b Blob, key string) ([]byte, error) {
+	r, _, err := b.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// ValidateKey rejects keys that are empty, absolute or escape the store root
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("invalid storage key: empty")
+	}
+	if strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key %q: must be a relative slash-separated path", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid storage key %q", key)
+		}
+	}
+	return nil
+}
+
+// Ranger is implemented by backends that can read part of an object (HTTP Range)
+type Ranger interface {
+	// GetRange reads from offset; length < 0 reads to the end of the object
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+}
+
+// Open returns a seekable reader for the object, as needed by http.ServeContent for Range requests.
+// Local files are seekable as-is; Ranger backends issue a ranged read from the current offset on demand,
+// so a seek to the middle of a large file never downloads the start.
+func Open(ctx context.Context, b Blob, key string) (io.ReadSeekCloser, *ObjectInfo, error) {
+	if r, ok := b.(Ranger); ok {
+		info, err := b.Stat(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &rangeReader{ctx: ctx, ranger: r, key: key, size: info.Size}, info, nil
+	}
+	body, info, err := b.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rs, ok := body.(io.ReadSeekCloser); ok {
+		return rs, info, nil
+	}
+	body.Close()
+	return nil, nil, fmt.Errorf("storage backend does not support seeking")
+}
+
+// rangeReader is an io.ReadSeekCloser over a Ranger
+type rangeReader struct {
+	ctx    context.Context
+	ranger Ranger
+	key    string
+	size   int64
+	pos    int64
+	body   io.ReadCloser // open ranged read starting at pos; nil after a seek
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.ranger.GetRange(r.ctx, r.key, r.pos, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = r.pos + offset
+	case io.SeekEnd:
+		pos = r.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("negative position")
+	}
+	if pos != r.pos && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.pos = pos
+	return pos, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
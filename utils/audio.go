@@ -0,0 +1,314 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// AudioInfo describes a voice recording validated by ParseAudio
+type AudioInfo struct {
+	MimeType string // audio/wav or audio/webm
+	Ext      string // wav or webm
+	Codec    string // pcm, float or opus
+	Duration time.Duration
+}
+
+// ParseAudio sniffs the container from the bytes (the client Content-Type is not trusted) and reads
+// the duration from its headers. Supported: WAV (PCM / IEEE float) and WebM with an Opus audio track,
+// which covers browser MediaRecorder output. Anything else, or a malformed file, is an error.
+// Pure Go; the audio itself is never decoded.
+func ParseAudio(data []byte) (*AudioInfo, error) {
+	switch {
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return parseWAV(data)
+	case len(data) >= 4 && binary.BigEndian.Uint32(data[0:4]) == ebmlIDHeader:
+		return parseWebM(data)
+	}
+	return nil, fmt.Errorf("unsupported audio format: expected WAV or WebM/Opus")
+}
+
+// parseWAV walks the RIFF chunks for "fmt " and "data"; duration = data bytes / byte rate
+func parseWAV(data []byte) (*AudioInfo, error) {
+	var (
+		fmtFound   bool
+		format     uint16
+		channels   uint16
+		sampleRate uint32
+		byteRate   uint32
+		blockAlign uint16
+		bits       uint16
+		dataSize   int64 = -1
+	)
+
+	pos := 12
+	for pos+8 <= len(data) && dataSize < 0 {
+		id := string(data[pos : pos+4])
+		size := int64(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		remaining := int64(len(data) - body)
+
+		switch id {
+		case "fmt ":
+			if size < 16 || remaining < 16 {
+				return nil, fmt.Errorf("malformed WAV: fmt chunk too short")
+			}
+			f := data[body : body+16]
+			format = binary.LittleEndian.Uint16(f[0:2])
+			channels = binary.LittleEndian.Uint16(f[2:4])
+			sampleRate = binary.LittleEndian.Uint32(f[4:8])
+			byteRate = binary.LittleEndian.Uint32(f[8:12])
+			blockAlign = binary.LittleEndian.Uint16(f[12:14])
+			bits = binary.LittleEndian.Uint16(f[14:16])
+			fmtFound = true
+		case "data":
+			if !fmtFound {
+				return nil, fmt.Errorf("malformed WAV: data chunk before fmt chunk")
+			}
+			// Streaming writers leave the size at 0 or 0xFFFFFFFF; trust the bytes actually present
+			if size == 0 || size > remaining {
+				size = remaining
+			}
+			dataSize = size
+			continue
+		}
+
+		if size > remaining {
+			return nil, fmt.Errorf("malformed WAV: %q chunk exceeds file size", id)
+		}
+		pos = body + int(size) + int(size&1) // chunks are word-aligned
+	}
+
+	if !fmtFound {
+		return nil, fmt.Errorf("malformed WAV: missing fmt chunk")
+	}
+	if dataSize <= 0 {
+		return nil, fmt.Errorf("malformed WAV: no audio data")
+	}
+
+	codec := ""
+	switch format {
+	case 1, 0xFFFE: // PCM, WAVE_FORMAT_EXTENSIBLE
+		codec = "pcm"
+	case 3:
+		codec = "float"
+	default:
+		return nil, fmt.Errorf("unsupported WAV encoding (format %d): only PCM and IEEE float", format)
+	}
+	if channels < 1 || channels > 8 {
+		return nil, fmt.Errorf("malformed WAV: %d channels", channels)
+	}
+	if sampleRate < 8000 || sampleRate > 192000 {
+		return nil, fmt.Errorf("malformed WAV: sample rate %d Hz", sampleRate)
+	}
+	if bits == 0 || bits%8 != 0 || bits > 32 {
+		return nil, fmt.Errorf("malformed WAV: %d bits per sample", bits)
+	}
+	if blockAlign != channels*bits/8 || byteRate != sampleRate*uint32(blockAlign) {
+		return nil, fmt.Errorf("malformed WAV: inconsistent block align / byte rate")
+	}
+
+	return &AudioInfo{
+		MimeType: "audio/wav",
+		Ext:      "wav",
+		Codec:    codec,
+		Duration: time.Duration(float64(dataSize) / float64(byteRate) * float64(time.Second)),
+	}, nil
+}
+
+// EBML / Matroska element IDs read by parseWebM
+const (
+	ebmlIDHeader         = 0x1A45DFA3
+	ebmlIDDocType        = 0x4282
+	mkvIDSegment         = 0x18538067
+	mkvIDInfo            = 0x1549A966
+	mkvIDTimecodeScale   = 0x2AD7B1
+	mkvIDDuration        = 0x4489
+	mkvIDTracks          = 0x1654AE6B
+	mkvIDTrackEntry      = 0xAE
+	mkvIDTrackType       = 0x83
+	mkvIDCodecID         = 0x86
+	mkvIDCluster         = 0x1F43B675
+	mkvIDClusterTimecode = 0xE7
+	mkvIDBlockGroup      = 0xA0
+	mkvIDBlock           = 0xA1
+	mkvIDSimpleBlock     = 0xA3
+)
+
+// webmMasters are container elements whose children are walked in place. Walking linearly (instead of
+// recursing by size) handles the unknown-size Segment and Cluster elements that MediaRecorder writes.
+var webmMasters = map[uint32]bool{
+	ebmlIDHeader:    true,
+	mkvIDSegment:    true,
+	mkvIDInfo:       true,
+	mkvIDTracks:     true,
+	mkvIDTrackEntry: true,
+	mkvIDCluster:    true,
+	mkvIDBlockGroup: true,
+}
+
+// parseWebM checks the EBML header and tracks, and takes the duration from Info/Duration or,
+// when absent (MediaRecorder never writes it), from the last block timestamp
+func parseWebM(data []byte) (*AudioInfo, error) {
+	var (
+		docType       string
+		timecodeScale uint64 = 1_000_000 // ns per tick (Matroska default)
+		infoDuration  float64
+		hasOpus       bool
+		hasVideo      bool
+		clusterTime   int64
+		lastBlockTime int64 = -1
+	)
+
+	pos := 0
+	for pos < len(data) {
+		id, idLen, err := readEBMLID(data[pos:])
+		var size uint64
+		var sizeLen int
+		var unknown bool
+		if err == nil {
+			size, sizeLen, unknown, err = readEBMLSize(data[pos+idLen:])
+		}
+		if err == nil && size > uint64(len(data)-pos-idLen-sizeLen) && !unknown && !webmMasters[id] {
+			err = fmt.Errorf("element 0x%X exceeds file size", id)
+		}
+		if err != nil {
+			// A recording cut short mid-element still has usable headers and earlier blocks
+			if lastBlockTime >= 0 {
+				break
+			}
+			return nil, fmt.Errorf("malformed WebM: %w", err)
+		}
+		body := pos + idLen + sizeLen
+		if pos == 0 && id != ebmlIDHeader {
+			return nil, fmt.Errorf("malformed WebM: missing EBML header")
+		}
+		if webmMasters[id] {
+			pos = body
+			continue
+		}
+		if unknown {
+			return nil, fmt.Errorf("malformed WebM: unknown size on element 0x%X", id)
+		}
+		payload := data[body : body+int(size)]
+
+		switch id {
+		case ebmlIDDocType:
+			docType = string(payload)
+		case mkvIDTimecodeScale:
+			if v := ebmlUint(payload); v > 0 {
+				timecodeScale = v
+			}
+		case mkvIDDuration:
+			infoDuration = ebmlFloat(payload)
+		case mkvIDTrackType:
+			if ebmlUint(payload) == 1 {
+				hasVideo = true
+			}
+		case mkvIDCodecID:
+			if string(payload) == "A_OPUS" {
+				hasOpus = true
+			}
+		case mkvIDClusterTimecode:
+			clusterTime = int64(ebmlUint(payload))
+		case mkvIDSimpleBlock, mkvIDBlock:
+			// Block header: track number (vint), int16 timecode relative to the cluster, flags
+			_, n, _, err := readEBMLSize(payload)
+			if err != nil || len(payload) < n+3 {
+				return nil, fmt.Errorf("malformed WebM: short block")
+			}
+			t := clusterTime + int64(int16(binary.BigEndian.Uint16(payload[n:n+2])))
+			if t > lastBlockTime {
+				lastBlockTime = t
+			}
+		}
+		pos = body + int(size)
+	}
+
+	if docType != "webm" {
+		return nil, fmt.Errorf("unsupported container: EBML doc type %q", docType)
+	}
+	if hasVideo {
+		return nil, fmt.Errorf("voice note must be audio-only")
+	}
+	if !hasOpus {
+		return nil, fmt.Errorf("unsupported WebM audio codec: only Opus")
+	}
+
+	var duration time.Duration
+	switch {
+	case infoDuration > 0 && !math.IsInf(infoDuration, 0):
+		duration = time.Duration(infoDuration * float64(timecodeScale))
+	case lastBlockTime >= 0:
+		duration = time.Duration(lastBlockTime) * time.Duration(timecodeScale)
+	default:
+		return nil, fmt.Errorf("malformed WebM: no audio frames")
+	}
+
+	return &AudioInfo{
+		MimeType: "audio/webm",
+		Ext:      "webm",
+		Codec:    "opus",
+		Duration: duration,
+	}, nil
+}
+
+// readEBMLID reads an element ID (1-4 bytes, marker bit kept as part of the ID)
+func readEBMLID(b []byte) (uint32, int, error) {
+	if len(b) == 0 {
+		return 0, 0, fmt.Errorf("unexpected end of data")
+	}
+	n := 1
+	for mask := byte(0x80); n <= 4 && b[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	if n > 4 || len(b) < n {
+		return 0, 0, fmt.Errorf("invalid element ID")
+	}
+	var id uint32
+	for i := 0; i < n; i++ {
+		id = id<<8 | uint32(b[i])
+	}
+	return id, n, nil
+}
+
+// readEBMLSize reads a variable-length size (1-8 bytes, marker bit removed); all ones means unknown
+func readEBMLSize(b []byte) (size uint64, n int, unknown bool, err error) {
+	if len(b) == 0 {
+		return 0, 0, false, fmt.Errorf("unexpected end of data")
+	}
+	n = 1
+	for mask := byte(0x80); n <= 8 && b[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	if n > 8 || len(b) < n {
+		return 0, 0, false, fmt.Errorf("invalid element size")
+	}
+	size = uint64(b[0] & (0xFF >> n))
+	for i := 1; i < n; i++ {
+		size = size<<8 | uint64(b[i])
+	}
+	unknown = size == (uint64(1)<<(7*n))-1
+	return size, n, unknown, nil
+}
+
+// ebmlUint decodes a big-endian unsigned integer element (0-8 bytes)
+func ebmlUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// ebmlFloat decodes a 4- or 8-byte float element
+func ebmlFloat(b []byte) float64 {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
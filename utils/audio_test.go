@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wavHeader builds RIFF/WAVE with a fmt chunk; byte rate and block align follow from the format
+func wavHeader(format, channels uint16, sampleRate uint32, bits uint16) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(0)) // RIFF size is not read
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	blockAlign := channels * bits / 8
+	binary.Write(&b, binary.LittleEndian, format)
+	binary.Write(&b, binary.LittleEndian, channels)
+	binary.Write(&b, binary.LittleEndian, sampleRate)
+	binary.Write(&b, binary.LittleEndian, sampleRate*uint32(blockAlign))
+	binary.Write(&b, binary.LittleEndian, blockAlign)
+	binary.Write(&b, binary.LittleEndian, bits)
+	return b.Bytes()
+}
+
+// wavChunk appends a chunk whose size field is declared (which may differ from len(body))
+func wavChunk(id string, declared uint32, body []byte) []byte {
+	b := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[4:], declared)
+	return append(b, body...)
+}
+
+func pcmWAV(dataBytes int) []byte {
+	return append(wavHeader(1, 1, 8000, 16), wavChunk("data", uint32(dataBytes), make([]byte, dataBytes))...)
+}
+
+// ebml encodes one element with a minimal big-endian ID and a 1-, 2- or 4-byte size
+func ebml(id uint32, payload ...[]byte) []byte {
+	var b []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if c := byte(id >> uint(shift)); c != 0 || len(b) > 0 {
+			b = append(b, c)
+		}
+	}
+	body := bytes.Join(payload, nil)
+	switch n := len(body); {
+	case n < 0x7F:
+		b = append(b, 0x80|byte(n))
+	case n < 0x3FFF:
+		b = append(b, 0x40|byte(n>>8), byte(n))
+	default:
+		b = append(b, 0x10|byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(b, body...)
+}
+
+// ebmlUnknown opens a master element of unknown size, as MediaRecorder writes Segment and Cluster
+func ebmlUnknown(id uint32, children ...[]byte) []byte {
+	b := ebml(id)
+	b = append(b[:len(b)-1], 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	return append(b, bytes.Join(children, nil)...)
+}
+
+func ebmlUintBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return bytes.TrimLeft(b, "\x00")
+}
+
+func ebmlFloat64Bytes(f float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(f))
+	return b
+}
+
+func ebmlFloat32Bytes(f float32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, math.Float32bits(f))
+	return b
+}
+
+func webmHeader(docType string) []byte {
+	return ebml(ebmlIDHeader, ebml(ebmlIDDocType, []byte(docType)))
+}
+
+func trackEntry(trackType uint64, codec string) []byte {
+	return ebml(mkvIDTrackEntry, ebml(mkvIDTrackType, ebmlUintBytes(trackType)), ebml(mkvIDCodecID, []byte(codec)))
+}
+
+var opusTracks = ebml(mkvIDTracks, trackEntry(2, "A_OPUS"))
+
+// simpleBlock is a track-1 block at tc ticks after its cluster's timecode, with a few bytes of "audio"
+func simpleBlock(tc int16) []byte {
+	return ebml(mkvIDSimpleBlock, []byte{0x81, byte(uint16(tc) >> 8), byte(tc), 0x80, 0xFC, 0xFF, 0xFE})
+}
+
+// cluster holds blocks every 20 ticks from 0 to last
+func cluster(timecode uint64, last int16, unknownSize bool) []byte {
+	children := [][]byte{ebml(mkvIDClusterTimecode, ebmlUintBytes(timecode))}
+	for tc := int16(0); tc <= last; tc += 20 {
+		children = append(children, simpleBlock(tc))
+	}
+	if unknownSize {
+		return ebmlUnknown(mkvIDCluster, children...)
+	}
+	return ebml(mkvIDCluster, children...)
+}
+
+// mediaRecorderWebM mimics browser output: unknown-size Segment and Clusters, no Info/Duration
+func mediaRecorderWebM() []byte {
+	return append(webmHeader("webm"), ebmlUnknown(mkvIDSegment,
+		ebml(mkvIDInfo, ebml(mkvIDTimecodeScale, ebmlUintBytes(1_000_000))),
+		opusTracks,
+		cluster(0, 980, true),
+		cluster(1000, 500, true),
+	)...)
+}
+
+func TestParseAudioValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		mime  string
+		codec string
+		want  time.Duration
+	}{
+		{"wav pcm 16-bit mono", pcmWAV(16000), "audio/wav", "pcm", time.Second},
+		{"wav float stereo", append(wavHeader(3, 2, 48000, 32), wavChunk("data", 96000, make([]byte, 96000))...), "audio/wav", "float", 250 * time.Millisecond},
+		{"wav extensible", append(wavHeader(0xFFFE, 1, 16000, 16), wavChunk("data", 8000, make([]byte, 8000))...), "audio/wav", "pcm", 250 * time.Millisecond},
+		{"wav streaming size 0", append(wavHeader(1, 1, 8000, 16), wavChunk("data", 0, make([]byte, 8000))...), "audio/wav", "pcm", 500 * time.Millisecond},
+		{"wav streaming size 0xFFFFFFFF", append(wavHeader(1, 1, 8000, 16), wavChunk("data", 0xFFFFFFFF, make([]byte, 8000))...), "audio/wav", "pcm", 500 * time.Millisecond},
+		{"wav truncated data", pcmWAV(16000)[:44+4000], "audio/wav", "pcm", 250 * time.Millisecond},
+		{"wav odd-sized chunk before data", append(append(wavHeader(1, 1, 8000, 16), wavChunk("LIST", 3, []byte{1, 2, 3, 0})...), wavChunk("data", 16000, make([]byte, 16000))...), "audio/wav", "pcm", time.Second},
+		{"wav data then trailing chunk", append(pcmWAV(8000), wavChunk("LIST", 4, []byte{1, 2, 3, 4})...), "audio/wav", "pcm", 500 * time.Millisecond},
+
+		{"webm info duration float64", append(webmHeader("webm"), ebml(mkvIDSegment,
+			ebml(mkvIDInfo, ebml(mkvIDTimecodeScale, ebmlUintBytes(1_000_000)), ebml(mkvIDDuration, ebmlFloat64Bytes(1500))),
+			opusTracks,
+			cluster(0, 100, false),
+		)...), "audio/webm", "opus", 1500 * time.Millisecond},
+		{"webm info duration float32", append(webmHeader("webm"), ebml(mkvIDSegment,
+			ebml(mkvIDInfo, ebml(mkvIDDuration, ebmlFloat32Bytes(2500))),
+			opusTracks,
+		)...), "audio/webm", "opus", 2500 * time.Millisecond},
+		{"webm custom timecode scale", append(webmHeader("webm"), ebml(mkvIDSegment,
+			ebml(mkvIDInfo, ebml(mkvIDTimecodeScale, ebmlUintBytes(1_000)), ebml(mkvIDDuration, ebmlFloat64Bytes(2_000_000))),
+			opusTracks,
+		)...), "audio/webm", "opus", 2 * time.Second},
+		{"webm unknown-size segment and clusters", mediaRecorderWebM(), "audio/webm", "opus", 1500 * time.Millisecond},
+		{"webm infinite duration falls back to blocks", append(webmHeader("webm"), ebmlUnknown(mkvIDSegment,
+			ebml(mkvIDInfo, ebml(mkvIDDuration, ebmlFloat64Bytes(math.Inf(1)))),
+			opusTracks,
+			cluster(0, 740, true),
+		)...), "audio/webm", "opus", 740 * time.Millisecond},
+		{"webm block group", append(webmHeader("webm"), ebml(mkvIDSegment,
+			opusTracks,
+			ebml(mkvIDCluster, ebml(mkvIDClusterTimecode, ebmlUintBytes(3000)),
+				ebml(mkvIDBlockGroup, ebml(mkvIDBlock, []byte{0x81, 0x00, 0x64, 0x00, 0xFC}))),
+		)...), "audio/webm", "opus", 3100 * time.Millisecond},
+		{"webm truncated mid-block keeps earlier blocks", func() []byte {
+			b := mediaRecorderWebM()
+			return b[:len(b)-3]
+		}(), "audio/webm", "opus", 1480 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseAudio(tt.data)
+			if err != nil {
+				t.Fatalf("ParseAudio: %v", err)
+			}
+			if info.MimeType != tt.mime || info.Codec != tt.codec || info.Duration != tt.want {
+				t.Errorf("ParseAudio = %s %s %v, want %s %s %v", info.MimeType, info.Codec, info.Duration, tt.mime, tt.codec, tt.want)
+			}
+			if ext := strings.TrimPrefix(info.MimeType, "audio/"); info.Ext != ext {
+				t.Errorf("Ext = %q, want %q", info.Ext, ext)
+			}
+		})
+	}
+}
+
+func TestParseAudioInvalid(t *testing.T) {
+	wav := pcmWAV(16000)
+	badByteRate := append([]byte(nil), wav...)
+	binary.LittleEndian.PutUint32(badByteRate[28:32], 12345)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string // error substring
+	}{
+		{"empty", nil, "unsupported audio format"},
+		{"garbage", []byte("definitely not audio, just text"), "unsupported audio format"},
+		{"mp3", append([]byte("ID3\x04\x00\x00"), make([]byte, 64)...), "unsupported audio format"},
+		{"riff but not wave", append([]byte("RIFF\x00\x00\x00\x00AVI "), make([]byte, 32)...), "unsupported audio format"},
+
+		{"wav header only", wav[:12], "missing fmt chunk"},
+		{"wav truncated in fmt", wav[:24], "fmt chunk too short"},
+		{"wav without data", wav[:36], "no audio data"},
+		{"wav empty data", append(wavHeader(1, 1, 8000, 16), wavChunk("data", 0, nil)...), "no audio data"},
+		{"wav data before fmt", append([]byte("RIFF\x00\x00\x00\x00WAVE"), wavChunk("data", 4, []byte{0, 0, 0, 0})...), "data chunk before fmt"},
+		{"wav chunk past end", append(wavHeader(1, 1, 8000, 16), wavChunk("LIST", 1000, []byte{1, 2})...), "exceeds file size"},
+		{"wav adpcm", append(wavHeader(2, 1, 8000, 16), wavChunk("data", 100, make([]byte, 100))...), "unsupported WAV encoding"},
+		{"wav zero channels", append(wavHeader(1, 0, 8000, 16), wavChunk("data", 100, make([]byte, 100))...), "channels"},
+		{"wav low sample rate", append(wavHeader(1, 1, 4000, 16), wavChunk("data", 100, make([]byte, 100))...), "sample rate"},
+		{"wav 12-bit", append(wavHeader(1, 1, 8000, 12), wavChunk("data", 100, make([]byte, 100))...), "bits per sample"},
+		{"wav inconsistent byte rate", badByteRate, "inconsistent"},
+
+		{"webm magic only", []byte{0x1A, 0x45, 0xDF, 0xA3}, "malformed WebM"},
+		{"webm magic then zeros", append([]byte{0x1A, 0x45, 0xDF, 0xA3}, make([]byte, 32)...), "malformed WebM"},
+		{"webm truncated header", mediaRecorderWebM()[:10], "malformed WebM"},
+		{"webm matroska doc type", append(webmHeader("matroska"), ebml(mkvIDSegment, opusTracks, cluster(0, 100, false))...), "doc type"},
+		{"webm video track", append(webmHeader("webm"), ebmlUnknown(mkvIDSegment,
+			ebml(mkvIDTracks, trackEntry(1, "V_VP8"), trackEntry(2, "A_OPUS")),
+			cluster(0, 100, true),
+		)...), "audio-only"},
+		{"webm vorbis", append(webmHeader("webm"), ebml(mkvIDSegment, ebml(mkvIDTracks, trackEntry(2, "A_VORBIS")), cluster(0, 100, false))...), "only Opus"},
+		{"webm no frames", append(webmHeader("webm"), ebml(mkvIDSegment, opusTracks)...), "no audio frames"},
+		{"webm unknown size on a leaf", append(webmHeader("webm"), ebmlUnknown(mkvIDCodecID, []byte("A_OPUS"))...), "unknown size"},
+		{"webm leaf past end", append(webmHeader("webm"), 0x86, 0x90, 'A'), "exceeds file size"},
+		{"webm short block", append(webmHeader("webm"), ebml(mkvIDSegment, opusTracks, ebml(mkvIDCluster, ebml(mkvIDSimpleBlock, []byte{0x81, 0x00})))...), "short block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseAudio(tt.data)
+			if err == nil {
+				t.Fatalf("ParseAudio = %+v, want error containing %q", info, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWebMMissingHeader(t *testing.T) {
+	if _, err := parseWebM(ebml(mkvIDSegment, opusTracks)); err == nil || !strings.Contains(err.Error(), "missing EBML header") {
+		t.Errorf("parseWebM without header = %v, want missing EBML header", err)
+	}
+}
+
+// parseNoPanic reports a panic in ParseAudio as a test failure
+func parseNoPanic(t *testing.T, label string, data []byte) {
+	t.Helper()
+	defer func() {
+		if p := recover(); p != nil {
+			t.Fatalf("%s: ParseAudio panicked on %d bytes %x: %v", label, len(data), data, p)
+		}
+	}()
+	ParseAudio(data)
+}
+
+func TestParseAudioNeverPanics(t *testing.T) {
+	fixtures := map[string][]byte{
+		"wav":           pcmWAV(64),
+		"webm":          mediaRecorderWebM(),
+		"webm duration": append(webmHeader("webm"), ebml(mkvIDSegment, ebml(mkvIDInfo, ebml(mkvIDDuration, ebmlFloat64Bytes(1500))), opusTracks)...),
+	}
+	rng := rand.New(rand.NewSource(1))
+	for name, data := range fixtures {
+		// Every truncation
+		for i := 0; i <= len(data); i++ {
+			parseNoPanic(t, name+" truncated", data[:i])
+		}
+		// Random byte corruption
+		for i := 0; i < 2000; i++ {
+			b := append([]byte(nil), data...)
+			for j := 0; j < 1+rng.Intn(4); j++ {
+				b[4+rng.Intn(len(b)-4)] = byte(rng.Intn(256))
+			}
+			parseNoPanic(t, name+" corrupted", b)
+		}
+	}
+	// Random bytes behind each magic
+	for _, magic := range [][]byte{[]byte("RIFF\xff\xff\xff\xffWAVE"), {0x1A, 0x45, 0xDF, 0xA3}} {
+		for i := 0; i < 2000; i++ {
+			b := make([]byte, rng.Intn(128))
+			rng.Read(b)
+			parseNoPanic(t, "random", append(append([]byte(nil), magic...), b...))
+		}
+	}
+}
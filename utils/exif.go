@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrNoExif is returned when a JPEG carries no EXIF (APP1) segment
+var ErrNoExif = errors.New("no EXIF data")
+
+// ExifData holds the EXIF fields used for complaint photo checks.
+// Nil / empty fields were not present (or not readable) in the image.
+type ExifData struct {
+	CaptureTime      *time.Time // DateTimeOriginal, falling back to DateTime
+	CaptureTimeZoned bool       // CaptureTime carried OffsetTimeOriginal; otherwise it is camera-local read as UTC
+	Latitude         *float64   // GPS, decimal degrees (south negative)
+	Longitude        *float64   // GPS, decimal degrees (west negative)
+	CameraMake       string
+	CameraModel      string
+}
+
+// EXIF tags read by ReadJPEGExif
+const (
+	exifTagMake               = 0x010F
+	exifTagModel              = 0x0110
+	exifTagDateTime           = 0x0132
+	exifTagExifIFD            = 0x8769
+	exifTagGPSIFD             = 0x8825
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagOffsetTimeOriginal = 0x9011
+	gpsTagLatitudeRef         = 0x0001
+	gpsTagLatitude            = 0x0002
+	gpsTagLongitudeRef        = 0x0003
+	gpsTagLongitude           = 0x0004
+)
+
+// exifTypeSizes maps TIFF field types to their size in bytes
+var exifTypeSizes = map[uint16]uint64{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	7:  1, // UNDEFINED
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+}
+
+// ReadJPEGExif extracts capture time, GPS and camera make/model from a JPEG's EXIF segment.
+// Pure Go, no external tools; reads only IFD0, the Exif sub-IFD and the GPS sub-IFD.
+//
+// EXIF timestamps are camera-local. When the camera recorded OffsetTimeOriginal it is applied;
+// otherwise the time is read as UTC, so callers should allow a few hours of tolerance.
+// Returns ErrNoExif when the file has no EXIF segment.
+func ReadJPEGExif(data []byte) (*ExifData, error) {
+	tiff, err := findExifSegment(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseExifTIFF(tiff)
+}
+
+// findExifSegment walks JPEG markers up to the image data and returns the TIFF block of the EXIF APP1 segment
+func findExifSegment(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("not a JPEG image")
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("malformed JPEG: expected marker at offset %d", i)
+		}
+		marker := data[i+1]
+		if marker == 0xFF { // fill byte
+			i++
+			continue
+		}
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			i += 2 // markers without a length
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image: no EXIF before the image data
+			return nil, ErrNoExif
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if length < 2 || i+2+length > len(data) {
+			return nil, fmt.Errorf("malformed JPEG: segment length out of range at offset %d", i)
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+		i += 2 + length
+	}
+	return nil, ErrNoExif
+}
+
+// exifEntry is one IFD field
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // raw value bytes (inline or at offset)
+}
+
+// exifReader decodes IFDs from a TIFF block
+type exifReader struct {
+	tiff  []byte
+	order binary.ByteOrder
+}
+
+func parseExifTIFF(tiff []byte) (*ExifData, error) {
+	if len(tiff) < 8 {
+		return nil, fmt.Errorf("malformed EXIF: TIFF header too short")
+	}
+	r := &exifReader{tiff: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("malformed EXIF: unknown byte order")
+	}
+	if r.order.Uint16(tiff[2:4]) != 42 {
+		return nil, fmt.Errorf("malformed EXIF: bad TIFF magic")
+	}
+
+	ifd0, err := r.readIFD(r.order.Uint32(tiff[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ExifData{
+		CameraMake:  r.ascii(ifd0[exifTagMake]),
+		CameraModel: r.ascii(ifd0[exifTagModel]),
+	}
+
+	// Capture time: Exif sub-IFD DateTimeOriginal, else IFD0 DateTime
+	dateTime := r.ascii(ifd0[exifTagDateTime])
+	offset := ""
+	if ptr, ok := r.long(ifd0[exifTagExifIFD]); ok {
+		if sub, err := r.readIFD(ptr); err == nil {
+			if v := r.ascii(sub[exifTagDateTimeOriginal]); v != "" {
+				dateTime = v
+				offset = r.ascii(sub[exifTagOffsetTimeOriginal])
+			}
+		}
+	}
+	if t, zoned, ok := parseExifTime(dateTime, offset); ok {
+		out.CaptureTime = &t
+		out.CaptureTimeZoned = zoned
+	}
+
+	// GPS sub-IFD
+	if ptr, ok := r.long(ifd0[exifTagGPSIFD]); ok {
+		if gps, err := r.readIFD(ptr); err == nil {
+			lat, latOK := r.coordinate(gps[gpsTagLatitude], r.ascii(gps[gpsTagLatitudeRef]), "S", 90)
+			lon, lonOK := r.coordinate(gps[gpsTagLongitude], r.ascii(gps[gpsTagLongitudeRef]), "W", 180)
+			// 0,0 is what many devices write when they had no fix
+			if latOK && lonOK && !(lat == 0 && lon == 0) {
+				out.Latitude = &lat
+				out.Longitude = &lon
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// readIFD reads the entries of the IFD at offset (bounds-checked; unknown types are skipped)
+func (r *exifReader) readIFD(offset uint32) (map[uint16]*exifEntry, error) {
+	start := uint64(offset)
+	if start+2 > uint64(len(r.tiff)) {
+		return nil, fmt.Errorf("malformed EXIF: IFD offset out of range")
+	}
+	n := uint64(r.order.Uint16(r.tiff[start : start+2]))
+	if start+2+n*12 > uint64(len(r.tiff)) {
+		return nil, fmt.Errorf("malformed EXIF: IFD entries out of range")
+	}
+
+	entries := make(map[uint16]*exifEntry, n)
+	for k := uint64(0); k < n; k++ {
+		pos := start + 2 + k*12
+		raw := r.tiff[pos : pos+12]
+		tag := r.order.Uint16(raw[0:2])
+		typ := r.order.Uint16(raw[2:4])
+		count := r.order.Uint32(raw[4:8])
+		size, ok := exifTypeSizes[typ]
+		if !ok {
+			continue
+		}
+		total := size * uint64(count)
+		var value []byte
+		if total <= 4 {
+			value = raw[8 : 8+total]
+		} else {
+			off := uint64(r.order.Uint32(raw[8:12]))
+			if off+total > uint64(len(r.tiff)) {
+				continue
+			}
+			value = r.tiff[off : off+total]
+		}
+		entries[tag] = &exifEntry{typ: typ, count: count, value: value}
+	}
+	return entries, nil
+}
+
+// ascii returns an ASCII field without trailing NULs and spaces
+func (r *exifReader) ascii(e *exifEntry) string {
+	if e == nil || e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+// long returns a LONG (or SHORT) field, e.g. a sub-IFD pointer
+func (r *exifReader) long(e *exifEntry) (uint32, bool) {
+	if e == nil || e.count < 1 {
+		return 0, false
+	}
+	switch e.typ {
+	case 4:
+		return r.order.Uint32(e.value[0:4]), true
+	case 3:
+		return uint32(r.order.Uint16(e.value[0:2])), true
+	}
+	return 0, false
+}
+
+// coordinate converts a degrees/minutes/seconds RATIONAL triple to signed decimal degrees
+func (r *exifReader) coordinate(e *exifEntry, ref, negativeRef string, limit float64) (float64, bool) {
+	if e == nil || e.typ != 5 || e.count != 3 {
+		return 0, false
+	}
+	var parts [3]float64
+	for k := 0; k < 3; k++ {
+		num := r.order.Uint32(e.value[k*8 : k*8+4])
+		den := r.order.Uint32(e.value[k*8+4 : k*8+8])
+		if den == 0 {
+			return 0, false
+		}
+		parts[k] = float64(num) / float64(den)
+	}
+	v := parts[0] + parts[1]/60 + parts[2]/3600
+	if strings.EqualFold(ref, negativeRef) {
+		v = -v
+	}
+	if math.IsNaN(v) || math.Abs(v) > limit {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseExifTime parses "2006:01:02 15:04:05" with an optional "+05:30" offset; result is UTC.
+// zoned reports whether a valid offset was applied.
+func parseExifTime(value, offset string) (t time.Time, zoned bool, ok bool) {
+	if value == "" {
+		return time.Time{}, false, false
+	}
+	loc := time.UTC
+	if offset != "" {
+		if o, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := o.Zone()
+			loc = time.FixedZone(offset, secs)
+			zoned = true
+		}
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", value, loc)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	return t.UTC(), zoned, true
+}
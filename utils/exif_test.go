@@ -0,0 +1,488 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tiffEntry is one IFD field for the test TIFF builder; value holds the raw bytes in the file's byte order
+type tiffEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+}
+
+func asciiTag(tag uint16, s string) tiffEntry {
+	return tiffEntry{tag: tag, typ: 2, count: uint32(len(s) + 1), value: []byte(s + "\x00")}
+}
+
+func longTag(order binary.ByteOrder, tag uint16, v uint32) tiffEntry {
+	b := make([]byte, 4)
+	order.PutUint32(b, v)
+	return tiffEntry{tag: tag, typ: 4, count: 1, value: b}
+}
+
+// rationalTag encodes {numerator, denominator} pairs
+func rationalTag(order binary.ByteOrder, tag uint16, pairs ...[2]uint32) tiffEntry {
+	b := make([]byte, 8*len(pairs))
+	for i, p := range pairs {
+		order.PutUint32(b[i*8:], p[0])
+		order.PutUint32(b[i*8+4:], p[1])
+	}
+	return tiffEntry{tag: tag, typ: 5, count: uint32(len(pairs)), value: b}
+}
+
+// ifdSize is the encoded size of an IFD: count, entries, next-IFD pointer and out-of-line values
+func ifdSize(entries []tiffEntry) int {
+	n := 2 + 12*len(entries) + 4
+	for _, e := range entries {
+		if len(e.value) > 4 {
+			n += len(e.value)
+		}
+	}
+	return n
+}
+
+// encodeIFD encodes entries for an IFD starting at offset base; values over 4 bytes follow the table
+func encodeIFD(order binary.ByteOrder, base uint32, entries []tiffEntry) []byte {
+	table := make([]byte, 2+12*len(entries)+4)
+	order.PutUint16(table, uint16(len(entries)))
+	var data []byte
+	dataOffset := base + uint32(len(table))
+	for i, e := range entries {
+		raw := table[2+12*i:]
+		order.PutUint16(raw[0:], e.tag)
+		order.PutUint16(raw[2:], e.typ)
+		order.PutUint32(raw[4:], e.count)
+		if len(e.value) <= 4 {
+			copy(raw[8:12], e.value)
+		} else {
+			order.PutUint32(raw[8:], dataOffset+uint32(len(data)))
+			data = append(data, e.value...)
+		}
+	}
+	return append(table, data...)
+}
+
+// buildTIFF lays out IFD0 at offset 8 followed by the Exif and GPS sub-IFDs (when given), with
+// IFD0 pointers to them
+func buildTIFF(order binary.ByteOrder, ifd0, exif, gps []tiffEntry) []byte {
+	ifd0 = append([]tiffEntry(nil), ifd0...)
+	if exif != nil {
+		ifd0 = append(ifd0, longTag(order, exifTagExifIFD, 0))
+	}
+	if gps != nil {
+		ifd0 = append(ifd0, longTag(order, exifTagGPSIFD, 0))
+	}
+	exifOffset := uint32(8 + ifdSize(ifd0))
+	gpsOffset := exifOffset
+	if exif != nil {
+		gpsOffset += uint32(ifdSize(exif))
+	}
+	for i, e := range ifd0 {
+		switch e.tag {
+		case exifTagExifIFD:
+			ifd0[i] = longTag(order, e.tag, exifOffset)
+		case exifTagGPSIFD:
+			ifd0[i] = longTag(order, e.tag, gpsOffset)
+		}
+	}
+
+	header := make([]byte, 8)
+	if order == binary.ByteOrder(binary.LittleEndian) {
+		copy(header, "II")
+	} else {
+		copy(header, "MM")
+	}
+	order.PutUint16(header[2:], 42)
+	order.PutUint32(header[4:], 8)
+	tiff := append(header, encodeIFD(order, 8, ifd0)...)
+	if exif != nil {
+		tiff = append(tiff, encodeIFD(order, exifOffset, exif)...)
+	}
+	if gps != nil {
+		tiff = append(tiff, encodeIFD(order, gpsOffset, gps)...)
+	}
+	return tiff
+}
+
+// jpegWithExif wraps a TIFF block in a JPEG: SOI, APP0 (JFIF), APP1 (Exif), SOS
+func jpegWithExif(tiff []byte) []byte {
+	b := []byte{0xFF, 0xD8}
+	b = append(b, jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))...)
+	b = append(b, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))...)
+	return append(b, 0xFF, 0xDA, 0x00, 0x02, 0x12, 0x34, 0xFF, 0xD9)
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	b := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(b[2:], uint16(len(payload)+2))
+	return append(b, payload...)
+}
+
+// bangaloreGPS is 12°58'17.76"N 77°35'40.8"E
+func bangaloreGPS(order binary.ByteOrder, latRef, lonRef string) []tiffEntry {
+	return []tiffEntry{
+		asciiTag(gpsTagLatitudeRef, latRef),
+		rationalTag(order, gpsTagLatitude, [2]uint32{12, 1}, [2]uint32{58, 1}, [2]uint32{1776, 100}),
+		asciiTag(gpsTagLongitudeRef, lonRef),
+		rationalTag(order, gpsTagLongitude, [2]uint32{77, 1}, [2]uint32{35, 1}, [2]uint32{408, 10}),
+	}
+}
+
+const (
+	bangaloreLat = 12 + 58.0/60 + 17.76/3600
+	bangaloreLon = 77 + 35.0/60 + 40.8/3600
+)
+
+func fullTIFF(order binary.ByteOrder) []byte {
+	return buildTIFF(order,
+		[]tiffEntry{asciiTag(exifTagMake, "Google"), asciiTag(exifTagModel, "Pixel 8  "), asciiTag(exifTagDateTime, "2026:10:16 12:00:00")},
+		[]tiffEntry{asciiTag(exifTagDateTimeOriginal, "2026:10:16 09:30:00"), asciiTag(exifTagOffsetTimeOriginal, "+05:30")},
+		bangaloreGPS(order, "N", "E"),
+	)
+}
+
+func approx(p *float64, want float64) bool {
+	return p != nil && math.Abs(*p-want) < 1e-9
+}
+
+func TestReadJPEGExifByteOrders(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		order binary.ByteOrder
+	}{
+		{"little-endian", binary.LittleEndian},
+		{"big-endian", binary.BigEndian},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ReadJPEGExif(jpegWithExif(fullTIFF(tc.order)))
+			if err != nil {
+				t.Fatalf("ReadJPEGExif: %v", err)
+			}
+			if got.CameraMake != "Google" || got.CameraModel != "Pixel 8" {
+				t.Errorf("camera = %q %q, want Google Pixel 8", got.CameraMake, got.CameraModel)
+			}
+			want := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC) // 09:30 at +05:30
+			if got.CaptureTime == nil || !got.CaptureTime.Equal(want) {
+				t.Errorf("CaptureTime = %v, want %v", got.CaptureTime, want)
+			}
+			if !approx(got.Latitude, bangaloreLat) || !approx(got.Longitude, bangaloreLon) {
+				t.Errorf("GPS = %v, %v, want %v, %v", deref(got.Latitude), deref(got.Longitude), bangaloreLat, bangaloreLon)
+			}
+		})
+	}
+}
+
+func deref(p *float64) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func TestReadJPEGExifGPS(t *testing.T) {
+	le := binary.LittleEndian
+	tests := []struct {
+		name     string
+		gps      []tiffEntry
+		lat, lon *float64 // nil = no GPS expected
+	}{
+		{"north east", bangaloreGPS(le, "N", "E"), ptr(bangaloreLat), ptr(bangaloreLon)},
+		{"south west", bangaloreGPS(le, "S", "W"), ptr(-bangaloreLat), ptr(-bangaloreLon)},
+		{"lowercase refs", bangaloreGPS(le, "s", "w"), ptr(-bangaloreLat), ptr(-bangaloreLon)},
+		{"fractional minutes", []tiffEntry{
+			asciiTag(gpsTagLatitudeRef, "N"),
+			rationalTag(le, gpsTagLatitude, [2]uint32{28, 1}, [2]uint32{3681, 100}, [2]uint32{0, 1}),
+			asciiTag(gpsTagLongitudeRef, "E"),
+			rationalTag(le, gpsTagLongitude, [2]uint32{77, 1}, [2]uint32{1254, 100}, [2]uint32{0, 1}),
+		}, ptr(28 + 36.81/60), ptr(77 + 12.54/60)},
+		{"zero denominator", []tiffEntry{
+			rationalTag(le, gpsTagLatitude, [2]uint32{12, 1}, [2]uint32{58, 0}, [2]uint32{0, 1}),
+			rationalTag(le, gpsTagLongitude, [2]uint32{77, 1}, [2]uint32{35, 1}, [2]uint32{0, 1}),
+		}, nil, nil},
+		{"no fix (0,0)", []tiffEntry{
+			rationalTag(le, gpsTagLatitude, [2]uint32{0, 1}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+			rationalTag(le, gpsTagLongitude, [2]uint32{0, 1}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+		}, nil, nil},
+		{"latitude out of range", []tiffEntry{
+			rationalTag(le, gpsTagLatitude, [2]uint32{91, 1}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+			rationalTag(le, gpsTagLongitude, [2]uint32{77, 1}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+		}, nil, nil},
+		{"two components", []tiffEntry{
+			rationalTag(le, gpsTagLatitude, [2]uint32{12, 1}, [2]uint32{58, 1}),
+			rationalTag(le, gpsTagLongitude, [2]uint32{77, 1}, [2]uint32{35, 1}, [2]uint32{0, 1}),
+		}, nil, nil},
+		{"latitude only", bangaloreGPS(le, "N", "E")[:2], nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadJPEGExif(jpegWithExif(buildTIFF(le, []tiffEntry{asciiTag(exifTagMake, "Test")}, nil, tt.gps)))
+			if err != nil {
+				t.Fatalf("ReadJPEGExif: %v", err)
+			}
+			if tt.lat == nil {
+				if got.Latitude != nil || got.Longitude != nil {
+					t.Errorf("GPS = %v, %v, want none", deref(got.Latitude), deref(got.Longitude))
+				}
+				return
+			}
+			if !approx(got.Latitude, *tt.lat) || !approx(got.Longitude, *tt.lon) {
+				t.Errorf("GPS = %v, %v, want %v, %v", deref(got.Latitude), deref(got.Longitude), *tt.lat, *tt.lon)
+			}
+		})
+	}
+}
+
+func ptr(f float64) *float64 { return &f }
+
+func TestReadJPEGExifCaptureTime(t *testing.T) {
+	le := binary.LittleEndian
+	tests := []struct {
+		name  string
+		ifd0  []tiffEntry
+		exif  []tiffEntry
+		want  *time.Time
+		zoned bool
+	}{
+		{"original without offset is UTC", nil, []tiffEntry{asciiTag(exifTagDateTimeOriginal, "2026:10:16 09:30:00")}, tptr(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)), false},
+		{"negative offset", nil, []tiffEntry{asciiTag(exifTagDateTimeOriginal, "2026:10:16 09:30:00"), asciiTag(exifTagOffsetTimeOriginal, "-04:00")}, tptr(time.Date(2026, 10, 16, 13, 30, 0, 0, time.UTC)), true},
+		{"bad offset ignored", nil, []tiffEntry{asciiTag(exifTagDateTimeOriginal, "2026:10:16 09:30:00"), asciiTag(exifTagOffsetTimeOriginal, "IST")}, tptr(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)), false},
+		{"falls back to DateTime", []tiffEntry{asciiTag(exifTagDateTime, "2026:10:15 18:00:00")}, nil, tptr(time.Date(2026, 10, 15, 18, 0, 0, 0, time.UTC)), false},
+		{"original wins over DateTime", []tiffEntry{asciiTag(exifTagDateTime, "2026:10:15 18:00:00")}, []tiffEntry{asciiTag(exifTagDateTimeOriginal, "2026:10:14 07:00:00")}, tptr(time.Date(2026, 10, 14, 7, 0, 0, 0, time.UTC)), false},
+		{"blank time", []tiffEntry{asciiTag(exifTagDateTime, "    :  :     :  :  ")}, nil, nil, false},
+		{"no time", []tiffEntry{asciiTag(exifTagMake, "Test")}, nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadJPEGExif(jpegWithExif(buildTIFF(le, tt.ifd0, tt.exif, nil)))
+			if err != nil {
+				t.Fatalf("ReadJPEGExif: %v", err)
+			}
+			switch {
+			case tt.want == nil && got.CaptureTime != nil:
+				t.Errorf("CaptureTime = %v, want none", got.CaptureTime)
+			case tt.want != nil && (got.CaptureTime == nil || !got.CaptureTime.Equal(*tt.want)):
+				t.Errorf("CaptureTime = %v, want %v", got.CaptureTime, tt.want)
+			}
+			if got.CaptureTimeZoned != tt.zoned {
+				t.Errorf("CaptureTimeZoned = %v, want %v", got.CaptureTimeZoned, tt.zoned)
+			}
+		})
+	}
+}
+
+func tptr(t time.Time) *time.Time { return &t }
+
+func TestReadJPEGExifOffsetsOutOfRange(t *testing.T) {
+	le := binary.LittleEndian
+	base := []tiffEntry{asciiTag(exifTagMake, "Test"), asciiTag(exifTagDateTime, "2026:10:15 18:00:00")}
+
+	t.Run("IFD0 offset past end", func(t *testing.T) {
+		tiff := buildTIFF(le, base, nil, nil)
+		le.PutUint32(tiff[4:], uint32(len(tiff)+100))
+		if _, err := ReadJPEGExif(jpegWithExif(tiff)); err == nil || !strings.Contains(err.Error(), "IFD offset out of range") {
+			t.Errorf("err = %v, want IFD offset out of range", err)
+		}
+	})
+
+	t.Run("IFD0 offset near uint32 max", func(t *testing.T) {
+		tiff := buildTIFF(le, base, nil, nil)
+		le.PutUint32(tiff[4:], 0xFFFFFFFF)
+		if _, err := ReadJPEGExif(jpegWithExif(tiff)); err == nil {
+			t.Error("want error")
+		}
+	})
+
+	t.Run("entry count past end", func(t *testing.T) {
+		tiff := buildTIFF(le, base, nil, nil)
+		le.PutUint16(tiff[8:], 0xFFFF)
+		if _, err := ReadJPEGExif(jpegWithExif(tiff)); err == nil || !strings.Contains(err.Error(), "IFD entries out of range") {
+			t.Errorf("err = %v, want IFD entries out of range", err)
+		}
+	})
+
+	t.Run("value offset past end is skipped", func(t *testing.T) {
+		tiff := buildTIFF(le, base, nil, nil)
+		// Make's value is out of line (5 bytes): entry 0, value offset at 8+2+8
+		le.PutUint32(tiff[8+2+8:], uint32(len(tiff)))
+		got, err := ReadJPEGExif(jpegWithExif(tiff))
+		if err != nil {
+			t.Fatalf("ReadJPEGExif: %v", err)
+		}
+		if got.CameraMake != "" || got.CaptureTime == nil {
+			t.Errorf("got make %q time %v, want no make and the DateTime", got.CameraMake, got.CaptureTime)
+		}
+	})
+
+	t.Run("huge count is skipped", func(t *testing.T) {
+		tiff := buildTIFF(le, base, nil, nil)
+		le.PutUint32(tiff[8+2+4:], 0xFFFFFFFF) // Make count
+		got, err := ReadJPEGExif(jpegWithExif(tiff))
+		if err != nil {
+			t.Fatalf("ReadJPEGExif: %v", err)
+		}
+		if got.CameraMake != "" {
+			t.Errorf("CameraMake = %q, want none", got.CameraMake)
+		}
+	})
+
+	t.Run("sub-IFD pointers past end are ignored", func(t *testing.T) {
+		ifd0 := append(append([]tiffEntry(nil), base...), longTag(le, exifTagExifIFD, 1<<20), longTag(le, exifTagGPSIFD, 0xFFFFFFF0))
+		got, err := ReadJPEGExif(jpegWithExif(buildTIFF(le, ifd0, nil, nil)))
+		if err != nil {
+			t.Fatalf("ReadJPEGExif: %v", err)
+		}
+		want := time.Date(2026, 10, 15, 18, 0, 0, 0, time.UTC)
+		if got.CaptureTime == nil || !got.CaptureTime.Equal(want) || got.Latitude != nil {
+			t.Errorf("got time %v lat %v, want DateTime and no GPS", got.CaptureTime, deref(got.Latitude))
+		}
+	})
+
+	t.Run("sub-IFD pointer with wrong type is ignored", func(t *testing.T) {
+		ifd0 := append(append([]tiffEntry(nil), base...), asciiTag(exifTagGPSIFD, "x"))
+		got, err := ReadJPEGExif(jpegWithExif(buildTIFF(le, ifd0, nil, nil)))
+		if err != nil || got.Latitude != nil {
+			t.Errorf("got %v, %v; want no GPS and no error", deref(got.Latitude), err)
+		}
+	})
+
+	t.Run("unknown field type is skipped", func(t *testing.T) {
+		ifd0 := append([]tiffEntry{{tag: exifTagModel, typ: 99, count: 1 << 30, value: []byte{1, 2, 3, 4}}}, base...)
+		got, err := ReadJPEGExif(jpegWithExif(buildTIFF(le, ifd0, nil, nil)))
+		if err != nil || got.CameraMake != "Test" || got.CameraModel != "" {
+			t.Errorf("got %+v, %v", got, err)
+		}
+	})
+}
+
+func TestReadJPEGExifIFDLoops(t *testing.T) {
+	le := binary.LittleEndian
+	// Exif and GPS pointers back at IFD0 itself
+	ifd0 := []tiffEntry{asciiTag(exifTagMake, "Loop"), asciiTag(exifTagDateTime, "2026:10:15 18:00:00"), longTag(le, exifTagExifIFD, 8), longTag(le, exifTagGPSIFD, 8)}
+	tiff := buildTIFF(le, ifd0, nil, nil)
+	// IFD0's next-IFD pointer at itself as well
+	le.PutUint32(tiff[8+2+12*len(ifd0):], 8)
+
+	done := make(chan struct{})
+	var got *ExifData
+	var err error
+	go func() {
+		defer close(done)
+		got, err = ReadJPEGExif(jpegWithExif(tiff))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReadJPEGExif did not return on an IFD loop")
+	}
+	if err != nil {
+		t.Fatalf("ReadJPEGExif: %v", err)
+	}
+	if got.CameraMake != "Loop" || got.CaptureTime == nil || got.Latitude != nil {
+		t.Errorf("got %+v, want make Loop, the DateTime and no GPS", got)
+	}
+
+	// GPS IFD pointing at the Exif IFD and vice versa
+	gps := bangaloreGPS(le, "N", "E")
+	tiff = buildTIFF(le, []tiffEntry{asciiTag(exifTagMake, "Loop")}, []tiffEntry{longTag(le, exifTagGPSIFD, 8)}, gps)
+	if got, err := ReadJPEGExif(jpegWithExif(tiff)); err != nil || !approx(got.Latitude, bangaloreLat) {
+		t.Errorf("cross-linked IFDs: got %v, %v", deref(got.Latitude), err)
+	}
+}
+
+func TestReadJPEGExifNoExif(t *testing.T) {
+	soi := []byte{0xFF, 0xD8}
+	jfif := jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	dqt := jpegSegment(0xDB, make([]byte, 65))
+	sos := []byte{0xFF, 0xDA, 0x00, 0x02}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"JFIF only", concat(soi, jfif, dqt, sos, []byte{0x12, 0x34, 0xFF, 0xD9})},
+		{"XMP APP1", concat(soi, jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")), sos)},
+		{"EOI right away", concat(soi, []byte{0xFF, 0xD9, 0x00, 0x00})},
+		{"fill bytes before marker", concat(soi, []byte{0xFF, 0xFF, 0xFF}, jfif, sos)},
+		{"APP1 after scan is not read", concat(soi, sos, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), fullTIFF(binary.LittleEndian)...)))},
+		{"ends without scan", concat(soi, jfif)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadJPEGExif(tt.data); !errors.Is(err, ErrNoExif) {
+				t.Errorf("err = %v, want ErrNoExif", err)
+			}
+		})
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func TestReadJPEGExifMalformed(t *testing.T) {
+	le := binary.LittleEndian
+	valid := fullTIFF(le)
+	badOrder := append([]byte("XX"), valid[2:]...)
+	badMagic := append([]byte(nil), valid...)
+	le.PutUint16(badMagic[2:], 43)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "not a JPEG"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), "not a JPEG"},
+		{"segment length past end", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x10, 0x00, 'E', 'x'}, "segment length out of range"},
+		{"segment length below 2", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x01, 0x00, 0x00}, "segment length out of range"},
+		{"garbage instead of marker", []byte{0xFF, 0xD8, 0x12, 0x34, 0x56, 0x78}, "expected marker"},
+		{"TIFF header too short", jpegWithExif([]byte("II*\x00")), "TIFF header too short"},
+		{"unknown byte order", jpegWithExif(badOrder), "unknown byte order"},
+		{"bad TIFF magic", jpegWithExif(badMagic), "bad TIFF magic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadJPEGExif(tt.data)
+			if err == nil || errors.Is(err, ErrNoExif) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadJPEGExifNeverPanics(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := jpegWithExif(fullTIFF(order))
+		for i := 0; i <= len(data); i++ {
+			readNoPanic(t, data[:i])
+		}
+		// Corrupt every byte of the TIFF block in turn
+		for i := 2 + len(jpegSegment(0xE0, make([]byte, 14))) + 10; i < len(data); i++ {
+			for _, v := range []byte{0x00, 0x01, 0x7F, 0xFF} {
+				b := append([]byte(nil), data...)
+				b[i] = v
+				readNoPanic(t, b)
+			}
+		}
+	}
+}
+
+func readNoPanic(t *testing.T, data []byte) {
+	t.Helper()
+	defer func() {
+		if p := recover(); p != nil {
+			t.Fatalf("ReadJPEGExif panicked on %x: %v", data, p)
+		}
+	}()
+	ReadJPEGExif(data)
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register decoder for image.Decode
+	_ "image/png"  // register decoder for image.Decode
+	"math/bits"
+)
+
+// maxHashPixels caps decoded image size for perceptual hashing (guards against decompression bombs)
+const maxHashPixels = 50_000_000
+
+// PerceptualHash computes a 64-bit difference hash (dHash) of JPEG or PNG bytes.
+//
+// The image is reduced to 9x8 grayscale by area averaging; each bit says whether a pixel is
+// brighter than its right neighbour. Re-encoding, resizing and mild edits change only a few
+// bits, so near-identical photos have a small Hamming distance (see HammingDistance).
+// Unlike GenerateEvidenceHash this is a similarity signal, not an integrity check.
+func PerceptualHash(data []byte) (uint64, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxHashPixels {
+		return 0, fmt.Errorf("image dimensions %dx%d not supported for hashing", cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return DifferenceHash(img), nil
+}
+
+// DifferenceHash computes the dHash of a decoded image
+func DifferenceHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	var gray [h][w]float64
+	var count [h][w]float64
+
+	b := img.Bounds()
+	dx, dy := b.Dx(), b.Dy()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		cy := (y - b.Min.Y) * h / dy
+		for x := b.Min.X; x < b.Max.X; x++ {
+			cx := (x - b.Min.X) * w / dx
+			r, g, bl, _ := img.At(x, y).RGBA()
+			// ITU-R 601 luma on 16-bit channels
+			gray[cy][cx] += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+			count[cy][cx]++
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			left := gray[y][x] / max(count[y][x], 1)
+			right := gray[y][x+1] / max(count[y][x+1], 1)
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HammingDistance returns the number of differing bits between two perceptual hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
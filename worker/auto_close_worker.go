@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+	"finalneta/service"
+)
+
+// AutoCloseJobName is the auto-close job
+const AutoCloseJobName = "auto_close"
+
+// NewAutoCloseJob closes resolved complaints left unconfirmed past the citizen confirmation window,
+// and awaiting_citizen complaints whose question went unanswered
+// Exclusive: closures are re-checked per complaint anyway, one instance at a time avoids wasted work
+func NewAutoCloseJob(autoCloseService *service.AutoCloseService, schedule Schedule) *Job {
+	return &Job{
+		Name:       AutoCloseJobName,
+		Schedule:   schedule,
+		RunOnStart: true,
+		Exclusive:  true,
+		Run: func(ctx context.Context) (RunResult, error) {
+			// This method is idempotent - safe to call multiple times
+			closed, err := autoCloseService.ProcessAutoClose(ctx)
+			if err != nil {
+				return RunResult{Counts: map[string]int{"closed": len(closed)}}, err
+			}
+			return RunResult{Counts: map[string]int{"closed": len(closed)}, Detail: closed}, nil
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"context"
+	"finalneta/service"
+)
+
+// EvidenceIntegrityJobName is the evidence integrity sweep job
+const EvidenceIntegrityJobName = "evidence_integrity"
+
+// NewEvidenceIntegrityJob periodically re-verifies every complaint_evidence hash and records
+// tampering findings in audit_log
+// Read-only apart from audit rows; exclusive so a finding is not reported once per instance
+func NewEvidenceIntegrityJob(evidenceService *service.EvidenceService, schedule Schedule) *Job {
+	return &Job{
+		Name:       EvidenceIntegrityJobName,
+		Schedule:   schedule,
+		RunOnStart: true,
+		Exclusive:  true,
+		Run: func(ctx context.Context) (RunResult, error) {
+			checked, failed, err := evidenceService.SweepEvidence(ctx)
+			if err != nil {
+				return RunResult{}, err
+			}
+			return RunResult{Counts: map[string]int{"checked": checked, "failed": failed}}, nil
+		},
+	}
+}
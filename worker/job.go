@@ -0,0 +1,428 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"finalneta/models"
+	"finalneta/repository"
+	"finalneta/service"
+	"fmt"
+	"log"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultJobLeaseTTL bounds how long a crashed instance blocks an exclusive job elsewhere
+// (the lease is renewed while the run lasts, so runs may take longer)
+const defaultJobLeaseTTL = 10 * time.Minute
+
+var (
+	// ErrJobNotFound is returned for a job name that is not registered
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunInProgress is returned when the job is already running here or (exclusive jobs) on another instance
+	ErrJobRunInProgress = errors.New("job run already in progress")
+)
+
+// RunResult is the outcome of one job run
+type RunResult struct {
+	Counts map[string]int // stored in job_runs, e.g. {"escalated": 2, "reminders": 5}
+	Detail interface{}    // returned to admin run-now only (not stored)
+}
+
+// Job is a named background task run by the Manager
+type Job struct {
+	Name       string
+	Schedule   Schedule
+	Jitter     time.Duration // random delay in [0, Jitter) added to every scheduled run
+	RunOnStart bool          // run once when the manager starts, then follow the schedule
+	Exclusive  bool          // one instance at a time: the run holds the worker_leases row named after the job
+	LeaseTTL   time.Duration // exclusive jobs; default 10 minutes, renewed every third of it while the run lasts
+	// Run does the work; ctx is cancelled by Manager.Stop or, for exclusive jobs, when the lease is lost,
+	// so long runs check it between items
+	Run func(ctx context.Context) (RunResult, error)
+}
+
+// jobRunner is the per-job scheduling state on this instance
+type jobRunner struct {
+	job *Job
+
+	mu        sync.Mutex
+	running   bool
+	nextRunAt time.Time
+}
+
+// Manager schedules registered jobs, records every run in job_runs, applies the shared pause state
+// from worker_jobs and recovers panics so one failing run never stops the worker.
+type Manager struct {
+	repo   *repository.JobRepository
+	leases *service.WorkerLeaseService // nil = single instance, exclusive jobs run without a lease
+
+	mu      sync.Mutex
+	jobs    map[string]*jobRunner
+	started bool
+	ctx     context.Context // runs, scheduled or manual; cancelled by Stop
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewManager creates a job manager
+func NewManager(repo *repository.JobRepository, leases *service.WorkerLeaseService) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:   repo,
+		leases: leases,
+		jobs:   make(map[string]*jobRunner),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register adds a job; call before Start
+func (m *Manager) Register(job *Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return fmt.Errorf("job needs a name, a schedule and a run function")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.jobs[job.Name]; exists {
+		return fmt.Errorf("job %s already registered", job.Name)
+	}
+	if job.Exclusive && job.LeaseTTL <= 0 {
+		job.LeaseTTL = defaultJobLeaseTTL
+	}
+	m.jobs[job.Name] = &jobRunner{job: job}
+	return nil
+}
+
+// Start registers every job in worker_jobs and starts its schedule loop
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		log.Println("Job manager is already running")
+		return
+	}
+	m.started = true
+
+	for _, name := range m.namesLocked() {
+		r := m.jobs[name]
+		if err := m.repo.RegisterJob(m.ctx, name, r.job.Schedule.String()); err != nil {
+			// Pause state and history are unavailable until the migration runs; the job still runs
+			log.Printf("[JOB] Warning: %v", err)
+		}
+		log.Printf("[JOB] %s scheduled (%s, jitter %v)", name, r.job.Schedule, r.job.Jitter)
+		m.wg.Add(1)
+		go m.loop(r)
+	}
+}
+
+// Stop cancels the context of every run and waits for the schedule loops and admin runs to return.
+// Jobs check their context between items, so a run in progress finishes the item it is on
+// (an escalation, a notification) and returns early. The manager cannot be restarted.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	started := m.started
+	m.started = false
+	m.cancel() // under mu: RunNow joins wg only before this
+	m.mu.Unlock()
+	if !started {
+		return
+	}
+
+	log.Println("Stopping job manager...")
+	m.wg.Wait()
+	log.Println("Job manager stopped")
+}
+
+// loop runs one job on its schedule until Stop
+func (m *Manager) loop(r *jobRunner) {
+	defer m.wg.Done()
+
+	if r.job.RunOnStart {
+		m.runScheduled(r)
+	}
+	for {
+		next := r.job.Schedule.Next(time.Now())
+		if r.job.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(r.job.Jitter))))
+		}
+		r.mu.Lock()
+		r.nextRunAt = next
+		r.mu.Unlock()
+		if err := m.repo.SetJobNextRun(m.ctx, r.job.Name, next); err != nil {
+			log.Printf("[JOB] Warning: %v", err)
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			m.runScheduled(r)
+		case <-m.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// runScheduled runs the job unless it is paused or already running somewhere
+func (m *Manager) runScheduled(r *jobRunner) {
+	paused, err := m.repo.IsJobPaused(m.ctx, r.job.Name)
+	if err != nil {
+		log.Printf("[JOB] %s skipped: %v", r.job.Name, err)
+		return
+	}
+	if paused {
+		log.Printf("[JOB] %s skipped: paused", r.job.Name)
+		return
+	}
+
+	run, _, err := m.execute(r, models.JobTriggerSchedule)
+	switch {
+	case errors.Is(err, ErrJobRunInProgress):
+		log.Printf("[JOB] %s skipped: a run is already in progress", r.job.Name)
+	case err != nil:
+		log.Printf("[JOB] %s failed: %v", r.job.Name, err)
+	default:
+		log.Printf("[JOB] %s %s in %dms %v", r.job.Name, run.Status, *run.DurationMS, run.Counts)
+	}
+}
+
+// execute runs the job once and records it in job_runs. Returns ErrJobRunInProgress (nothing recorded)
+// when the job is running on this instance or, for exclusive jobs, another instance holds its lease.
+// A run that fails or panics is recorded as failed and returned with its error.
+// The job gets the manager's context (exclusive jobs: cancelled as well when the lease is lost); bookkeeping
+// (job_runs, lease release) is written even after Stop so an interrupted run is still recorded.
+func (m *Manager) execute(r *jobRunner, trigger models.JobTrigger) (*models.JobRun, RunResult, error) {
+	if m.ctx.Err() != nil {
+		return nil, RunResult{}, fmt.Errorf("job manager is stopping: %w", m.ctx.Err())
+	}
+	bookkeeping := context.WithoutCancel(m.ctx)
+
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return nil, RunResult{}, ErrJobRunInProgress
+	}
+	r.running = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}()
+
+	runCtx := m.ctx
+	if r.job.Exclusive && m.leases != nil {
+		acquired, err := m.leases.TryAcquire(m.ctx, r.job.Name, r.job.LeaseTTL)
+		if err != nil {
+			return nil, RunResult{}, fmt.Errorf("failed to acquire %s lease: %w", r.job.Name, err)
+		}
+		if !acquired {
+			return nil, RunResult{}, ErrJobRunInProgress
+		}
+		defer m.leases.Release(bookkeeping, r.job.Name)
+		var stopRenewal func()
+		runCtx, stopRenewal = m.keepLease(r.job)
+		defer stopRenewal() // runs before Release: no renewal can re-take the lease after it is given back
+	}
+
+	instanceID := ""
+	if m.leases != nil {
+		instanceID = m.leases.InstanceID()
+	}
+	run := &models.JobRun{
+		JobName:    r.job.Name,
+		InstanceID: instanceID,
+		Trigger:    trigger,
+		Status:     models.JobRunRunning,
+		StartedAt:  time.Now().UTC(),
+	}
+	if err := m.repo.CreateJobRun(bookkeeping, run); err != nil {
+		// History is best effort: the job still runs
+		log.Printf("[JOB] Warning: %v", err)
+	}
+
+	result, err := runGuarded(runCtx, r.job)
+	if err != nil && m.ctx.Err() == nil && runCtx.Err() != nil {
+		err = fmt.Errorf("%w: %w", context.Cause(runCtx), err)
+	}
+
+	finishedAt := time.Now().UTC()
+	durationMS := finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.FinishedAt = &finishedAt
+	run.DurationMS = &durationMS
+	run.Counts = result.Counts
+	run.Status = models.JobRunSucceeded
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.ErrorMessage = err.Error()
+	}
+	if run.RunID > 0 {
+		if ferr := m.repo.FinishJobRun(bookkeeping, run); ferr != nil {
+			log.Printf("[JOB] Warning: %v", ferr)
+		}
+	}
+	if ferr := m.repo.SetJobLastRun(bookkeeping, r.job.Name, finishedAt, run.Status); ferr != nil {
+		log.Printf("[JOB] Warning: %v", ferr)
+	}
+	return run, result, err
+}
+
+// keepLease renews the job's lease every third of its TTL while the run lasts. The returned context is
+// cancelled when a renewal fails or finds the lease taken: another instance may then start the job, so
+// this run must stop. stop ends the renewals and returns once none is in flight.
+func (m *Manager) keepLease(job *Job) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(m.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(job.LeaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			renewed, err := m.leases.TryAcquire(ctx, job.Name, job.LeaseTTL)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil && !renewed {
+				err = errors.New("held by another instance")
+			}
+			if err != nil {
+				cause := fmt.Errorf("lost %s lease: %w", job.Name, err)
+				log.Printf("[JOB] %s aborted: %v", job.Name, cause)
+				cancel(cause)
+				return
+			}
+		}
+	}()
+	return ctx, func() {
+		cancel(nil)
+		<-done
+	}
+}
+
+// runGuarded calls the job, turning a panic into an error
+func runGuarded(ctx context.Context, job *Job) (result RunResult, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("[JOB] %s panicked: %v\n%s", job.Name, p, debug.Stack())
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// RunNow runs a job immediately on this instance (admin trigger; also allowed while the job is paused).
+// The run uses the manager's context, not the caller's: a dropped admin request does not cut it short.
+func (m *Manager) RunNow(name string) (*models.JobRun, RunResult, error) {
+	r, err := m.runner(name)
+	if err != nil {
+		return nil, RunResult{}, err
+	}
+	m.mu.Lock()
+	if m.ctx.Err() != nil {
+		m.mu.Unlock()
+		return nil, RunResult{}, fmt.Errorf("job manager is stopping: %w", m.ctx.Err())
+	}
+	m.wg.Add(1) // Stop waits for admin runs too
+	m.mu.Unlock()
+	defer m.wg.Done()
+	return m.execute(r, models.JobTriggerManual)
+}
+
+// Pause stops scheduled runs of a job on every instance; a run in progress finishes
+func (m *Manager) Pause(ctx context.Context, name, reason string) error {
+	if _, err := m.runner(name); err != nil {
+		return err
+	}
+	return m.repo.SetJobPaused(ctx, name, true, reason)
+}
+
+// Resume re-enables scheduled runs of a paused job
+func (m *Manager) Resume(ctx context.Context, name string) error {
+	if _, err := m.runner(name); err != nil {
+		return err
+	}
+	return m.repo.SetJobPaused(ctx, name, false, "")
+}
+
+// History returns a job's most recent runs on any instance, newest first
+func (m *Manager) History(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	if _, err := m.runner(name); err != nil {
+		return nil, err
+	}
+	return m.repo.GetJobRuns(ctx, name, limit)
+}
+
+// Jobs lists every registered job with its shared state and latest run
+func (m *Manager) Jobs(ctx context.Context) ([]models.JobStatus, error) {
+	states, err := m.repo.GetJobStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	names := m.namesLocked()
+	m.mu.Unlock()
+
+	out := make([]models.JobStatus, 0, len(names))
+	for _, name := range names {
+		r, _ := m.runner(name)
+		status := models.JobStatus{
+			Name:          name,
+			Schedule:      r.job.Schedule.String(),
+			JitterSeconds: int(r.job.Jitter.Seconds()),
+			Exclusive:     r.job.Exclusive,
+		}
+		r.mu.Lock()
+		status.Running = r.running
+		if !r.nextRunAt.IsZero() {
+			next := r.nextRunAt
+			status.NextRunAt = &next
+		}
+		r.mu.Unlock()
+
+		if state, ok := states[name]; ok {
+			status.Paused = state.Paused
+			status.PauseReason = state.PauseReason.String
+			if state.PausedAt.Valid {
+				status.PausedAt = &state.PausedAt.Time
+			}
+		}
+		runs, err := m.repo.GetJobRuns(ctx, name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			status.LastRun = &runs[0]
+		}
+		out = append(out, status)
+	}
+	return out, nil
+}
+
+func (m *Manager) runner(name string) (*jobRunner, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return r, nil
+}
+
+func (m *Manager) namesLocked() []string {
+	names := make([]string, 0, len(m.jobs))
+	for name := range m.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"context"
+	"finalneta/repository"
+	"time"
+)
+
+// JobRunsPruneJobName is the job that trims job_runs history
+const JobRunsPruneJobName = "job_runs_prune"
+
+// NewJobRunsPruneJob deletes job_runs rows older than retention on the given schedule
+func NewJobRunsPruneJob(repo *repository.JobRepository, retention time.Duration, schedule Schedule) *Job {
+	return &Job{
+		Name:      JobRunsPruneJobName,
+		Schedule:  schedule,
+		Exclusive: true,
+		Run: func(ctx context.Context) (RunResult, error) {
+			deleted, err := repo.DeleteJobRunsBefore(ctx, time.Now().UTC().Add(-retention))
+			if err != nil {
+				return RunResult{}, err
+			}
+			return RunResult{Counts: map[string]int{"deleted": int(deleted)}}, nil
+		},
+	}
+}
@@ -5,120 +5,65 @@ import (
 	"finalneta/models"
 	"finalneta/service"
 	"log"
-	"time"
 )
 
-// NotificationWorker is a background worker that processes notifications asynchronously
-type NotificationWorker struct {
-	notificationService *service.NotificationService
-	interval            time.Duration
-	stopChan            chan struct{}
-	running             bool
-}
-
-// NewNotificationWorker creates a new notification worker
-func NewNotificationWorker(
-	notificationService *service.NotificationService,
-	interval time.Duration,
-) *NotificationWorker {
-	return &NotificationWorker{
-		notificationService: notificationService,
-		interval:            interval,
-		stopChan:            make(chan struct{}),
-		running:             false,
-	}
-}
-
-// Start starts the notification worker
-// The worker runs in a separate goroutine and processes notifications periodically
-func (w *NotificationWorker) Start() {
-	if w.running {
-		log.Println("Notification worker is already running")
-		return
-	}
-
-	w.running = true
-	log.Printf("Notification worker started (interval: %v)", w.interval)
-
-	go w.run()
-}
-
-// Stop stops the notification worker
-func (w *NotificationWorker) Stop() {
-	if !w.running {
-		return
-	}
-
-	log.Println("Stopping notification worker...")
-	close(w.stopChan)
-	w.running = false
-	log.Println("Notification worker stopped")
-}
-
-// run is the main worker loop
-func (w *NotificationWorker) run() {
-	ticker := time.NewTicker(w.interval)
-	defer ticker.Stop()
-
-	// Process immediately on start
-	w.processNotifications()
-
-	for {
-		select {
-		case <-ticker.C:
-			// Periodic processing
-			w.processNotifications()
-		case <-w.stopChan:
-			// Stop signal received
-			return
-		}
-	}
-}
-
-// processNotifications processes pending notifications
-// This method is safe to call multiple times (idempotent)
-func (w *NotificationWorker) processNotifications() {
-	startTime := time.Now()
-	log.Println("Starting notification processing...")
-
-	// Get pending notifications (batch processing)
-	notifications, err := w.notificationService.GetPendingNotifications(100) // Batch size
-	if err != nil {
-		log.Printf("Error getting pending notifications: %v", err)
-		return
-	}
-
-	if len(notifications) == 0 {
-		log.Println("No pending notifications to process")
-		return
-	}
-
-	log.Printf("Processing %d notifications...", len(notifications))
-
-	ctx := context.Background()
-	successCount := 0
-	failedCount := 0
-	retryCount := 0
+// NotificationJobName is the notification sending job
+const NotificationJobName = "notification"
+
+// notificationBatchSize caps how many notifications one run claims
+const notificationBatchSize = 100
+
+// NewNotificationJob sends queued notifications on the given schedule
+// Not exclusive: every instance runs it and claims its own batch, so nothing is sent twice
+func NewNotificationJob(notificationService *service.NotificationService, schedule Schedule) *Job {
+	return &Job{
+		Name:       NotificationJobName,
+		Schedule:   schedule,
+		RunOnStart: true,
+		Run: func(ctx context.Context) (RunResult, error) {
+			// Claim pending notifications (batch processing); other instances skip the claimed rows
+			notifications, err := notificationService.ClaimPendingNotifications(ctx, notificationBatchSize)
+			if err != nil {
+				return RunResult{}, err
+			}
 
-	// Process each notification
-	for _, notification := range notifications {
-		err := w.notificationService.ProcessNotification(ctx, &notification)
-		if err != nil {
-			// Check if it's a retry (not a final failure)
-			if notification.Status == models.NotificationStatusRetrying {
-				retryCount++
-				log.Printf("Notification #%d scheduled for retry: %v", notification.NotificationID, err)
-			} else {
-				failedCount++
-				log.Printf("Notification #%d failed: %v", notification.NotificationID, err)
+			successCount := 0
+			failedCount := 0
+			retryCount := 0
+
+			// Process each notification
+			for i, notification := range notifications {
+				// On shutdown stop between notifications and hand the rest back to the queue
+				if ctx.Err() != nil {
+					log.Printf("Notification batch interrupted: releasing %d unsent notifications", len(notifications)-i)
+					if err := notificationService.ReleaseNotifications(context.WithoutCancel(ctx), notifications[i:]); err != nil {
+						log.Printf("Warning: %v (claims expire on their own)", err)
+					}
+					break
+				}
+				// A send that has started is finished and its outcome recorded
+				err := notificationService.ProcessNotification(context.WithoutCancel(ctx), &notification)
+				if err != nil {
+					// Check if it's a retry (not a final failure)
+					if notification.Status == models.NotificationStatusRetrying {
+						retryCount++
+						log.Printf("Notification #%d scheduled for retry: %v", notification.NotificationID, err)
+					} else {
+						failedCount++
+						log.Printf("Notification #%d failed: %v", notification.NotificationID, err)
+					}
+				} else {
+					successCount++
+					log.Printf("Notification #%d sent successfully", notification.NotificationID)
+				}
 			}
-		} else {
-			successCount++
-			log.Printf("Notification #%d sent successfully", notification.NotificationID)
-		}
-	}
 
-	duration := time.Since(startTime)
-	log.Printf("Notification processing completed in %v: %d sent, %d failed, %d retries",
-		duration, successCount, failedCount, retryCount)
+			return RunResult{Counts: map[string]int{
+				"claimed": len(notifications),
+				"sent":    successCount,
+				"failed":  failedCount,
+				"retries": retryCount,
+			}}, nil
+		},
+	}
 }
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		after string
+		want  []string // successive runs
+	}{
+		{"every minute is strictly after", "* * * * *", "2026-10-16 10:00:00", []string{"2026-10-16 10:01:00", "2026-10-16 10:02:00"}},
+		{"seconds are truncated", "* * * * *", "2026-10-16 10:00:30", []string{"2026-10-16 10:01:00"}},
+		{"step", "*/15 * * * *", "2026-10-16 10:07:00", []string{"2026-10-16 10:15:00", "2026-10-16 10:30:00", "2026-10-16 10:45:00", "2026-10-16 11:00:00"}},
+		{"step from a value", "5/20 * * * *", "2026-10-16 10:00:00", []string{"2026-10-16 10:05:00", "2026-10-16 10:25:00", "2026-10-16 10:45:00", "2026-10-16 11:05:00"}},
+		{"range with step", "0 8-18/4 * * *", "2026-10-16 09:00:00", []string{"2026-10-16 12:00:00", "2026-10-16 16:00:00", "2026-10-17 08:00:00"}},
+		{"list", "5,35 2 * * *", "2026-10-16 02:10:00", []string{"2026-10-16 02:35:00", "2026-10-17 02:05:00"}},
+		{"list of ranges", "0 1-2,22-23 * * *", "2026-10-16 03:00:00", []string{"2026-10-16 22:00:00", "2026-10-16 23:00:00", "2026-10-17 01:00:00"}},
+		{"weekdays skip the weekend", "0 9 * * 1-5", "2026-10-16 10:00:00", []string{"2026-10-19 09:00:00", "2026-10-20 09:00:00"}},
+		{"daily at 2am", "0 2 * * *", "2026-10-16 02:00:00", []string{"2026-10-17 02:00:00"}},
+
+		{"0 is Sunday", "0 0 * * 0", "2026-10-14 12:00:00", []string{"2026-10-18 00:00:00", "2026-10-25 00:00:00"}},
+		{"7 is Sunday", "0 0 * * 7", "2026-10-14 12:00:00", []string{"2026-10-18 00:00:00", "2026-10-25 00:00:00"}},
+		{"range ending in 7", "0 0 * * 5-7", "2026-10-14 12:00:00", []string{"2026-10-16 00:00:00", "2026-10-17 00:00:00", "2026-10-18 00:00:00", "2026-10-23 00:00:00"}},
+
+		{"day-of-month or day-of-week", "0 0 13 * 5", "2026-10-01 12:00:00", []string{"2026-10-02 00:00:00", "2026-10-09 00:00:00", "2026-10-13 00:00:00", "2026-10-16 00:00:00"}},
+		{"day-of-month only", "0 0 13 * *", "2026-10-01 12:00:00", []string{"2026-10-13 00:00:00", "2026-11-13 00:00:00"}},
+		{"day-of-week only", "0 0 * * 5", "2026-10-01 12:00:00", []string{"2026-10-02 00:00:00", "2026-10-09 00:00:00"}},
+		{"day-of-week with explicit full day-of-month", "0 0 1-31 * 5", "2026-10-01 12:00:00", []string{"2026-10-02 00:00:00", "2026-10-03 00:00:00"}},
+
+		{"month rollover", "0 0 1 * *", "2026-10-16 10:00:00", []string{"2026-11-01 00:00:00", "2026-12-01 00:00:00", "2027-01-01 00:00:00"}},
+		{"year rollover", "30 23 * * *", "2026-12-31 23:45:00", []string{"2027-01-01 23:30:00"}},
+		{"31st skips short months", "0 0 31 * *", "2026-04-01 00:00:00", []string{"2026-05-31 00:00:00", "2026-07-31 00:00:00", "2026-08-31 00:00:00"}},
+		{"restricted month", "0 6 1 1,7 *", "2026-07-02 00:00:00", []string{"2027-01-01 06:00:00", "2027-07-01 06:00:00"}},
+		{"leap day", "0 0 29 2 *", "2026-03-01 00:00:00", []string{"2028-02-29 00:00:00", "2032-02-29 00:00:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			next := at(tt.after)
+			for _, want := range tt.want {
+				next = s.Next(next)
+				if !next.Equal(at(want)) {
+					t.Fatalf("Next = %s, want %s", next.Format(time.DateTime), want)
+				}
+			}
+		})
+	}
+}
+
+func TestCronNextNeverMatches(t *testing.T) {
+	for _, expr := range []string{"0 0 31 2 *", "0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		s, err := ParseCron(expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", expr, err)
+		}
+		after := at("2026-10-16 10:00:00")
+		if got, want := s.Next(after), after.AddDate(100, 0, 0); !got.Equal(want) {
+			t.Errorf("%q: Next = %s, want %s (never)", expr, got, want)
+		}
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+1800)
+	s, err := ParseCron("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Next(time.Date(2026, 10, 16, 3, 0, 0, 0, loc))
+	if want := time.Date(2026, 10, 17, 2, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestParseCronRejects(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"-1 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"0-60 * * * *",
+		"30-10 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+		"* * * JAN *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q): want error", expr)
+		}
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"30s", "30s", false},
+		{" 1h ", "1h0m0s", false},
+		{"0  2 * *   *", "0 2 * * *", false},
+		{"0s", "", true},
+		{"-1m", "", true},
+		{"soon", "", true},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSchedule(%q): want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.in, err)
+			continue
+		}
+		if s.String() != tt.want {
+			t.Errorf("ParseSchedule(%q).String() = %q, want %q", tt.in, s.String(), tt.want)
+		}
+	}
+
+	every, _ := ParseSchedule("30s")
+	if got := every.Next(at("2026-10-16 10:00:10")); !got.Equal(at("2026-10-16 10:00:40")) {
+		t.Errorf("Every(30s).Next = %s", got)
+	}
+}